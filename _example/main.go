@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/netip"
 	"os"
 	"time"
 
@@ -39,65 +40,65 @@ func main() {
 
 	// Define test records
 	testRecords := []libdns.Record{
-		{
-			Type:  "A",
-			Name:  "record-a",
-			Value: "127.0.0.1",
-			TTL:   time.Duration(30) * time.Second,
+		libdns.Address{
+			Name: "record-a",
+			IP:   netip.MustParseAddr("127.0.0.1"),
+			TTL:  time.Duration(30) * time.Second,
 		},
-		{
-			Type:  "AAAA",
-			Name:  "record-aaaa",
-			Value: "::1",
-			TTL:   time.Duration(31) * time.Second,
+		libdns.Address{
+			Name: "record-aaaa",
+			IP:   netip.MustParseAddr("::1"),
+			TTL:  time.Duration(31) * time.Second,
 		},
-		{
-			Type:  "CAA",
+		libdns.CAA{
 			Name:  "record-caa",
-			Value: "0 issue 'ca." + zone + "'",
+			Flags: 0,
+			Tag:   "issue",
+			Value: "ca." + zone,
 			TTL:   time.Duration(32) * time.Second,
 		},
-		{
-			Type:  "CNAME",
-			Name:  "record-cname",
-			Value: "www." + zone,
-			TTL:   time.Duration(33) * time.Second,
+		libdns.CNAME{
+			Name:   "record-cname",
+			Target: "www." + zone,
+			TTL:    time.Duration(33) * time.Second,
 		},
-		{
-			Type:  "MX",
-			Name:  "record-mx",
-			Value: "10 mail." + zone,
-			TTL:   time.Duration(34) * time.Second,
+		libdns.MX{
+			Name:       "record-mx",
+			Preference: 10,
+			Target:     "mail." + zone,
+			TTL:        time.Duration(34) * time.Second,
 		},
-		// {
-		// 	Type:  "NS",
-		// 	Name:  "@",
-		// 	Value: "ns1.example.com.",
-		// 	TTL:   time.Duration(35) * time.Second,
+		// libdns.NS{
+		// 	Name:   "@",
+		// 	Target: "ns1.example.com.",
+		// 	TTL:    time.Duration(35) * time.Second,
 		// },
-		{
-			Type:  "PTR",
-			Name:  "record-ptr",
-			Value: "hoge." + zone,
-			TTL:   time.Duration(36) * time.Second,
+		libdns.RR{
+			Type: "PTR",
+			Name: "record-ptr",
+			Data: "hoge." + zone,
+			TTL:  time.Duration(36) * time.Second,
 		},
-		// {
-		// 	Type:  "SOA",
-		// 	Name:  "@",
-		// 	Value: "ns1.example.com. hostmaster." + zone + " 1 7200 900 1209600 86400",
-		// 	TTL:   time.Duration(37) * time.Second,
+		// libdns.RR{
+		// 	Type: "SOA",
+		// 	Name: "@",
+		// 	Data: "ns1.example.com. hostmaster." + zone + " 1 7200 900 1209600 86400",
+		// 	TTL:  time.Duration(37) * time.Second,
 		// },
-		{
-			Type:  "SRV",
-			Name:  "record-srv",
-			Value: "1 10 5269 app." + zone,
-			TTL:   time.Duration(38) * time.Second,
+		libdns.SRV{
+			Service:   "xmpp-server",
+			Transport: "tcp",
+			Name:      "record-srv",
+			Priority:  1,
+			Weight:    10,
+			Port:      5269,
+			Target:    "app." + zone,
+			TTL:       time.Duration(38) * time.Second,
 		},
-		{
-			Type:  "TXT",
-			Name:  "record-txt",
-			Value: "TEST VALUE",
-			TTL:   time.Duration(39) * time.Second,
+		libdns.TXT{
+			Name: "record-txt",
+			Text: "TEST VALUE",
+			TTL:  time.Duration(39) * time.Second,
 		},
 	}
 
@@ -0,0 +1,199 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+// activityLogAPIVersion is the API version of the Microsoft.Insights Activity Log API.
+const activityLogAPIVersion = "2015-04-01"
+
+// RecordSetChange is a write or delete operation on a record set recorded in Azure Activity Log.
+type RecordSetChange struct {
+	// Time is when the operation was recorded.
+	Time time.Time
+
+	// Caller is the identity that performed the operation, such as the user principal name of a user
+	// or the object ID of a service principal.
+	Caller string
+
+	// Operation is the name of the operation, e.g. "Microsoft.Network/dnszones/TXT/write".
+	Operation string
+
+	// Name is the name of the record set, relative to the zone.
+	Name string
+
+	// Type is the type of the record set, e.g. "TXT".
+	Type string
+
+	// Status is the status of the operation, e.g. "Succeeded" or "Failed".
+	Status string
+
+	// Correlation ID is the correlation ID of the operation, which matches the one of the call of the provider that performed it.
+	CorrelationID string
+}
+
+// activityLogValue is a localizable value of an event in Activity Log.
+type activityLogValue struct {
+	Value string `json:"value"`
+}
+
+// activityLogEvent is an event in Activity Log.
+type activityLogEvent struct {
+	Caller         string           `json:"caller"`
+	CorrelationID  string           `json:"correlationId"`
+	EventTimestamp time.Time        `json:"eventTimestamp"`
+	OperationName  activityLogValue `json:"operationName"`
+	ResourceID     string           `json:"resourceId"`
+	Status         activityLogValue `json:"status"`
+}
+
+// activityLogListResult is a page of the events in Activity Log.
+type activityLogListResult struct {
+	Value    []activityLogEvent `json:"value"`
+	NextLink *string            `json:"nextLink"`
+}
+
+// GetRecordSetChanges queries Azure Activity Log for the write and delete operations on the record sets of the zone
+// between start and end, and returns who changed which record set and when, oldest first. A zero end means now.
+// Activity Log retains events for 90 days, and operations in progress are omitted.
+// The identity needs the Microsoft.Insights/eventtypes/values/read action on the subscription, as granted by the Reader role.
+func (p *Provider) GetRecordSetChanges(ctx context.Context, zone string, start time.Time, end time.Time) ([]RecordSetChange, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.GetRecordSetChanges(ctx, zone, start, end)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	changes, err := p.getRecordSetChanges(ctx, zone, start, end)
+	if err != nil {
+		return nil, wrapCorrelationID(enrichAuthorizationError(err, "/subscriptions/"+p.SubscriptionId), correlationID)
+	}
+
+	return changes, nil
+}
+
+// getRecordSetChanges lists the events of the resource group in Activity Log and picks the changes to the record sets of the zone.
+func (p *Provider) getRecordSetChanges(ctx context.Context, zone string, start time.Time, end time.Time) ([]RecordSetChange, error) {
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if !start.Before(end) {
+		return nil, fmt.Errorf("the time range from %v to %v is empty", start, end)
+	}
+
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return nil, err
+	}
+
+	var events []activityLogEvent
+	err := p.retryOnAuthenticationError(func() error {
+		var err error
+		events, err = p.getActivityLogEvents(ctx, start, end)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []RecordSetChange
+	zoneID := p.generateZoneID(zone) + "/"
+	for _, event := range events {
+		change, ok := convertActivityLogEventToRecordSetChange(event, zoneID)
+		if ok {
+			changes = append(changes, change)
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Time.Before(changes[j].Time)
+	})
+
+	return changes, nil
+}
+
+// getActivityLogEvents gets all the events of the resource group in Activity Log between start and end.
+// The events are filtered by the resource group on Azure, since Activity Log does not filter by the prefix of resource IDs.
+func (p *Provider) getActivityLogEvents(ctx context.Context, start time.Time, end time.Time) ([]activityLogEvent, error) {
+	var events []activityLogEvent
+
+	filter := fmt.Sprintf(
+		"eventTimestamp ge '%s' and eventTimestamp le '%s' and resourceGroupName eq '%s'",
+		start.UTC().Format(time.RFC3339),
+		end.UTC().Format(time.RFC3339),
+		p.ResourceGroupName,
+	)
+	query := url.Values{}
+	query.Set("api-version", activityLogAPIVersion)
+	query.Set("$filter", filter)
+	query.Set("$select", "caller,correlationId,eventTimestamp,operationName,resourceId,status")
+	endpoint := runtime.JoinPaths(p.client.armClient.Endpoint(), "subscriptions", p.SubscriptionId, "providers/Microsoft.Insights/eventtypes/management/values") + "?" + query.Encode()
+	for endpoint != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		req.Raw().Header["Accept"] = []string{"application/json"}
+
+		resp, err := p.client.armClient.Pipeline().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+
+		var result activityLogListResult
+		if err := runtime.UnmarshalAsJSON(resp, &result); err != nil {
+			return nil, err
+		}
+		events = append(events, result.Value...)
+
+		endpoint = ""
+		if result.NextLink != nil {
+			endpoint = *result.NextLink
+		}
+	}
+
+	return events, nil
+}
+
+// convertActivityLogEventToRecordSetChange converts an event to a change if it is a finished write or delete operation
+// on a record set under the zone ID, which ends with a slash. Resource IDs are compared case-insensitively,
+// since Activity Log does not preserve their case.
+func convertActivityLogEventToRecordSetChange(event activityLogEvent, zoneID string) (RecordSetChange, bool) {
+	if len(event.ResourceID) <= len(zoneID) || !strings.EqualFold(event.ResourceID[:len(zoneID)], zoneID) {
+		return RecordSetChange{}, false
+	}
+	typeName, name, ok := strings.Cut(event.ResourceID[len(zoneID):], "/")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return RecordSetChange{}, false
+	}
+	operation := event.OperationName.Value
+	if !strings.HasSuffix(strings.ToLower(operation), "/write") && !strings.HasSuffix(strings.ToLower(operation), "/delete") {
+		return RecordSetChange{}, false
+	}
+	if strings.EqualFold(event.Status.Value, "Started") || strings.EqualFold(event.Status.Value, "Accepted") {
+		return RecordSetChange{}, false
+	}
+
+	return RecordSetChange{
+		Time:          event.EventTimestamp,
+		Caller:        event.Caller,
+		Operation:     operation,
+		Name:          name,
+		Type:          strings.ToUpper(typeName),
+		Status:        event.Status.Value,
+		CorrelationID: event.CorrelationID,
+	}, true
+}
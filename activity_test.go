@@ -0,0 +1,93 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/google/go-cmp/cmp"
+)
+
+const fakeZoneID = "/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/dnszones/example.com"
+
+func getFakeProviderWithActivityLog(pages []string, filters *[]string) *Provider {
+	return getFakeProviderWithTransport(func(req *http.Request, next policy.Transporter) (*http.Response, error) {
+		if !strings.HasSuffix(req.URL.Path, "/providers/Microsoft.Insights/eventtypes/management/values") {
+			return next.Do(req)
+		}
+		*filters = append(*filters, req.URL.Query().Get("$filter"))
+		page := pages[0]
+		if req.URL.Query().Get("page") == "2" {
+			page = pages[1]
+		}
+		return newFakeJSONResponse(req, http.StatusOK, page), nil
+	})
+}
+
+func Test_GetRecordSetChanges(t *testing.T) {
+	pages := []string{
+		`{"value":[
+			{"caller":"user@example.com","correlationId":"c2","eventTimestamp":"2024-01-02T00:00:00Z","operationName":{"value":"Microsoft.Network/dnszones/TXT/delete"},"resourceId":"` + strings.ToLower(fakeZoneID) + `/TXT/_acme-challenge","status":{"value":"Succeeded"}},
+			{"caller":"user@example.com","correlationId":"c2","eventTimestamp":"2024-01-02T00:00:00Z","operationName":{"value":"Microsoft.Network/dnszones/TXT/delete"},"resourceId":"` + fakeZoneID + `/TXT/_acme-challenge","status":{"value":"Started"}},
+			{"caller":"user@example.com","correlationId":"c3","eventTimestamp":"2024-01-02T00:00:00Z","operationName":{"value":"Microsoft.Network/dnszones/TXT/read"},"resourceId":"` + fakeZoneID + `/TXT/_acme-challenge","status":{"value":"Succeeded"}}
+		],"nextLink":"https://management.azure.com/subscriptions/fake-subscription-id/providers/Microsoft.Insights/eventtypes/management/values?page=2"}`,
+		`{"value":[
+			{"caller":"00000000-0000-0000-0000-000000000000","correlationId":"c1","eventTimestamp":"2024-01-01T00:00:00Z","operationName":{"value":"Microsoft.Network/dnszones/A/write"},"resourceId":"` + fakeZoneID + `/A/@","status":{"value":"Failed"}},
+			{"caller":"user@example.com","correlationId":"c4","eventTimestamp":"2024-01-01T00:00:00Z","operationName":{"value":"Microsoft.Network/dnszones/write"},"resourceId":"` + fakeZoneID + `","status":{"value":"Succeeded"}},
+			{"caller":"user@example.com","correlationId":"c5","eventTimestamp":"2024-01-01T00:00:00Z","operationName":{"value":"Microsoft.Network/dnszones/A/write"},"resourceId":"` + fakeZoneID + `.net/A/@","status":{"value":"Succeeded"}}
+		]}`,
+	}
+
+	t.Run("range=valid", func(t *testing.T) {
+		var filters []string
+		provider := getFakeProviderWithActivityLog(pages, &filters)
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+		got, err := provider.GetRecordSetChanges(context.TODO(), "example.com.", start, end)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := []RecordSetChange{
+			{
+				Time:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				Caller:        "00000000-0000-0000-0000-000000000000",
+				Operation:     "Microsoft.Network/dnszones/A/write",
+				Name:          "@",
+				Type:          "A",
+				Status:        "Failed",
+				CorrelationID: "c1",
+			},
+			{
+				Time:          time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+				Caller:        "user@example.com",
+				Operation:     "Microsoft.Network/dnszones/TXT/delete",
+				Name:          "_acme-challenge",
+				Type:          "TXT",
+				Status:        "Succeeded",
+				CorrelationID: "c2",
+			},
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		wantFilter := "eventTimestamp ge '2024-01-01T00:00:00Z' and eventTimestamp le '2024-01-03T00:00:00Z' and resourceGroupName eq 'fake-resource-group-name'"
+		if len(filters) != 2 || filters[0] != wantFilter {
+			t.Errorf("got: %v, want: %v", filters, wantFilter)
+		}
+	})
+	t.Run("range=empty", func(t *testing.T) {
+		var filters []string
+		provider := getFakeProviderWithActivityLog(pages, &filters)
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		_, err := provider.GetRecordSetChanges(context.TODO(), "example.com.", start, start)
+		if err == nil || !strings.Contains(err.Error(), "is empty") {
+			t.Errorf("got: %v", err)
+		}
+		if len(filters) != 0 {
+			t.Errorf("the Activity Log is queried")
+		}
+	})
+}
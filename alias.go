@@ -0,0 +1,72 @@
+package azure
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/libdns/libdns"
+)
+
+// Alias is a record set of Azure DNS that is an alias of an Azure resource, such as a public IP address, a Traffic Manager profile,
+// or an Azure Front Door endpoint, rather than having values of its own. It implements libdns.Record, so that alias record sets
+// are listed by GetRecords and written by AppendRecords and SetRecords like the other records.
+// An alias is the only record of its record set, and cannot be combined with other records sharing the same name and type.
+type Alias struct {
+	// Name is the name of the record set, relative to the zone or fully qualified.
+	Name string
+
+	// TTL is the TTL of the record set.
+	TTL time.Duration
+
+	// TargetResourceID is the resource ID of the Azure resource that the record set refers to,
+	// e.g. "/subscriptions/.../resourceGroups/.../providers/Microsoft.Network/publicIPAddresses/...".
+	TargetResourceID string
+
+	// RecordType is the type of the record set. Either "A", "AAAA", or "CNAME".
+	RecordType string
+}
+
+// RR returns the alias as a resource record without a value, since its values are those of the target resource.
+// Deleting it with DeleteRecords deletes the whole record set.
+func (a Alias) RR() libdns.RR {
+	return libdns.RR{
+		Name: a.Name,
+		TTL:  a.TTL,
+		Type: a.RecordType,
+	}
+}
+
+// recordSet converts the alias to an Azure-styled record set referring to the target resource.
+// Azure DNS supports aliases only for the types A, AAAA, and CNAME.
+func (a Alias) recordSet() (armdns.RecordSet, error) {
+	switch a.RecordType {
+	case "A", "AAAA", "CNAME":
+	default:
+		return armdns.RecordSet{}, fmt.Errorf("the type %v cannot be an alias", a.RecordType)
+	}
+	if a.TargetResourceID == "" {
+		return armdns.RecordSet{}, fmt.Errorf("the alias %v %v has no target resource", a.Name, a.RecordType)
+	}
+	return armdns.RecordSet{
+		Properties: &armdns.RecordSetProperties{
+			TTL: to.Ptr[int64](int64(a.TTL / time.Second)),
+			TargetResource: &armdns.SubResource{
+				ID: to.Ptr(a.TargetResourceID),
+			},
+		},
+	}, nil
+}
+
+// isAliasRecordSet reports whether the properties of a record set refer to a target resource.
+func isAliasRecordSet(properties *armdns.RecordSetProperties) bool {
+	return properties != nil && properties.TargetResource != nil && properties.TargetResource.ID != nil
+}
+
+// hasRecordSetValues reports whether the properties of a record set have any values.
+func hasRecordSetValues(properties *armdns.RecordSetProperties) bool {
+	return len(properties.ARecords) > 0 || len(properties.AaaaRecords) > 0 || len(properties.CaaRecords) > 0 ||
+		properties.CnameRecord != nil || len(properties.MxRecords) > 0 || len(properties.NsRecords) > 0 ||
+		len(properties.PtrRecords) > 0 || properties.SoaRecord != nil || len(properties.SrvRecords) > 0 || len(properties.TxtRecords) > 0
+}
@@ -0,0 +1,204 @@
+package azure
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+const fakeTargetResourceID = "/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/publicIPAddresses/fake"
+
+func getAliasRecordSet() *armdns.RecordSet {
+	return &armdns.RecordSet{
+		Name: to.Ptr("record-alias"),
+		Type: to.Ptr("Microsoft.Network/dnszones/A"),
+		Etag: to.Ptr("ETAG_ALIAS"),
+		Properties: &armdns.RecordSetProperties{
+			TTL:            to.Ptr[int64](30),
+			TargetResource: &armdns.SubResource{ID: to.Ptr(fakeTargetResourceID)},
+		},
+	}
+}
+
+func Test_Alias_RR(t *testing.T) {
+	alias := Alias{Name: "www", TTL: time.Duration(30) * time.Second, TargetResourceID: fakeTargetResourceID, RecordType: "A"}
+	want := libdns.RR{Name: "www", TTL: time.Duration(30) * time.Second, Type: "A"}
+	if diff := cmp.Diff(alias.RR(), want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
+
+func Test_FromRecordSet_alias(t *testing.T) {
+	got, err := FromRecordSet(armdns.RecordSet{
+		Name: to.Ptr("www"),
+		Type: to.Ptr("Microsoft.Network/dnszones/CNAME"),
+		Properties: &armdns.RecordSetProperties{
+			TTL:            to.Ptr[int64](30),
+			TargetResource: &armdns.SubResource{ID: to.Ptr(fakeTargetResourceID)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	want := []libdns.Record{
+		Alias{Name: "www", TTL: time.Duration(30) * time.Second, TargetResourceID: fakeTargetResourceID, RecordType: "CNAME"},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
+
+func Test_convertLibdnsRecordToAzureRecordSet_alias(t *testing.T) {
+	tests := []struct {
+		name    string
+		alias   Alias
+		want    armdns.RecordSet
+		wantErr string
+	}{
+		{
+			name:  "type=A",
+			alias: Alias{Name: "www", TTL: time.Duration(30) * time.Second, TargetResourceID: fakeTargetResourceID, RecordType: "A"},
+			want: armdns.RecordSet{
+				Properties: &armdns.RecordSetProperties{
+					TTL:            to.Ptr[int64](30),
+					TargetResource: &armdns.SubResource{ID: to.Ptr(fakeTargetResourceID)},
+				},
+			},
+		},
+		{
+			name:    "type=TXT",
+			alias:   Alias{Name: "www", TargetResourceID: fakeTargetResourceID, RecordType: "TXT"},
+			wantErr: "the type TXT cannot be an alias",
+		},
+		{
+			name:    "target=none",
+			alias:   Alias{Name: "www", RecordType: "A"},
+			wantErr: "has no target resource",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertLibdnsRecordToAzureRecordSet(tt.alias)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_mergeRecordSetProperties_alias(t *testing.T) {
+	alias := func() *armdns.RecordSetProperties {
+		return &armdns.RecordSetProperties{TargetResource: &armdns.SubResource{ID: to.Ptr(fakeTargetResourceID)}}
+	}
+	values := func() *armdns.RecordSetProperties {
+		return &armdns.RecordSetProperties{ARecords: []*armdns.ARecord{{IPv4Address: to.Ptr("127.0.0.1")}}}
+	}
+	tests := []struct {
+		name    string
+		dst     *armdns.RecordSetProperties
+		src     *armdns.RecordSetProperties
+		wantErr bool
+	}{
+		{name: "dst=empty,src=alias", dst: &armdns.RecordSetProperties{}, src: alias(), wantErr: false},
+		{name: "dst=alias,src=alias", dst: alias(), src: alias(), wantErr: true},
+		{name: "dst=alias,src=values", dst: alias(), src: values(), wantErr: true},
+		{name: "dst=values,src=alias", dst: values(), src: alias(), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mergeRecordSetProperties(tt.dst, tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err == nil && stringValue(tt.dst.TargetResource.ID) != fakeTargetResourceID {
+				t.Errorf("the target resource is not merged")
+			}
+		})
+	}
+}
+
+func Test_alias_write(t *testing.T) {
+	t.Run("operation=set,recordset=new", func(t *testing.T) {
+		zone := newFakeZone(getAliasRecordSet())
+		provider := getFakeProviderWithZone(zone)
+		if _, err := provider.updateRecords(context.TODO(), "example.com.", []libdns.Record{
+			Alias{Name: "record-new", TTL: time.Duration(60) * time.Second, TargetResourceID: fakeTargetResourceID, RecordType: "A"},
+		}); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(zone.written) != 1 {
+			t.Fatalf("got: %d record sets, want: 1", len(zone.written))
+		}
+		want := &armdns.RecordSetProperties{
+			TTL:            to.Ptr[int64](60),
+			TargetResource: &armdns.SubResource{ID: to.Ptr(fakeTargetResourceID)},
+		}
+		if diff := cmp.Diff(zone.written[0].Properties, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("operation=set,recordset=alias", func(t *testing.T) {
+		zone := newFakeZone(getAliasRecordSet())
+		provider := getFakeProviderWithZone(zone)
+		target := strings.Replace(fakeTargetResourceID, "/fake", "/other", 1)
+		if _, err := provider.updateRecords(context.TODO(), "example.com.", []libdns.Record{
+			Alias{Name: "record-alias", TTL: time.Duration(30) * time.Second, TargetResourceID: target, RecordType: "A"},
+		}); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(zone.written) != 1 || stringValue(zone.written[0].Properties.TargetResource.ID) != target {
+			t.Errorf("the target resource is not replaced")
+		}
+	})
+	t.Run("operation=set,recordset=alias,record=A", func(t *testing.T) {
+		zone := newFakeZone(getAliasRecordSet())
+		provider := getFakeProviderWithZone(zone)
+		_, err := provider.updateRecords(context.TODO(), "example.com.", []libdns.Record{
+			libdns.Address{Name: "record-alias", TTL: time.Duration(30) * time.Second, IP: netip.MustParseAddr("127.0.0.1")},
+		})
+		if err == nil || !strings.Contains(err.Error(), "is an alias of") {
+			t.Errorf("got: %v", err)
+		}
+	})
+	t.Run("operation=append,recordset=alias,target=same", func(t *testing.T) {
+		zone := newFakeZone(getAliasRecordSet())
+		provider := getFakeProviderWithZone(zone)
+		if _, err := provider.createRecords(context.TODO(), "example.com.", []libdns.Record{
+			Alias{Name: "record-alias", TTL: time.Duration(30) * time.Second, TargetResourceID: fakeTargetResourceID, RecordType: "A"},
+		}); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(zone.written) != 0 {
+			t.Errorf("the alias record set is overwritten")
+		}
+	})
+	t.Run("operation=append,recordset=alias,target=other", func(t *testing.T) {
+		zone := newFakeZone(getAliasRecordSet())
+		provider := getFakeProviderWithZone(zone)
+		_, err := provider.createRecords(context.TODO(), "example.com.", []libdns.Record{
+			Alias{Name: "record-alias", TTL: time.Duration(30) * time.Second, TargetResourceID: strings.Replace(fakeTargetResourceID, "/fake", "/other", 1), RecordType: "A"},
+		})
+		if err == nil {
+			t.Errorf("the alias is appended to an existing alias")
+		}
+		if len(zone.written) != 0 {
+			t.Errorf("the alias record set is overwritten")
+		}
+	})
+}
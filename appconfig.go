@@ -0,0 +1,297 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// defaultAppConfigurationKeyPrefix is the prefix of the keys of the settings if Key Prefix is not set.
+const defaultAppConfigurationKeyPrefix = "libdns/azure/"
+
+// defaultAppConfigurationRefreshInterval is how often the settings are read again if Refresh Interval is not set.
+const defaultAppConfigurationRefreshInterval = 30 * time.Second
+
+// appConfigurationAPIVersion is the version of the data plane API of Azure App Configuration.
+const appConfigurationAPIVersion = "1.0"
+
+// appConfigurationAudiences are the audiences of Azure App Configuration by the suffixes of the endpoints of the stores.
+var appConfigurationAudiences = map[string]string{
+	".azconfig.io":       "https://azconfig.io",
+	".azconfig.azure.us": "https://appconfig.azure.us",
+	".azconfig.azure.cn": "https://appconfig.azure.cn",
+}
+
+// AppConfigurationLoader reads the settings of providers from a store of Azure App Configuration, so that a fleet of
+// instances shares centrally managed settings. Each key-value under Key Prefix sets the setting of the provider with the
+// JSON name of the rest of the key, e.g. "libdns/azure/subscription_id" or "libdns/azure/zone_resource_ids".
+// Values are strings for string settings, Go durations such as "30s" for durations, and JSON for the others.
+type AppConfigurationLoader struct {
+	// Endpoint is the endpoint of the store, e.g. "https://example.azconfig.io". Required.
+	Endpoint string
+
+	// (Optional)
+	// Key Prefix is the prefix of the keys of the settings. Defaults to "libdns/azure/".
+	KeyPrefix string
+
+	// (Optional)
+	// Label is the label of the key-values to read, e.g. "production". Defaults to the key-values without a label.
+	Label string
+
+	// (Optional)
+	// Credential is the credential to read the store with, which needs the App Configuration Data Reader role.
+	// Defaults to DefaultAzureCredential.
+	Credential azcore.TokenCredential
+
+	// (Optional)
+	// Audience is the audience of the tokens for the store. Defaults to the audience of the cloud of Endpoint.
+	Audience string
+
+	// (Optional)
+	// Client Options are the options of the pipeline sending the requests to the store.
+	ClientOptions *policy.ClientOptions
+
+	// (Optional)
+	// Template is the provider whose settings the settings read are applied on top of, e.g. with Logger or
+	// Meter Provider set, which cannot be set from the store.
+	Template *Provider
+
+	// (Optional)
+	// Refresh Interval is how often Watch reads the settings again. Defaults to 30 seconds.
+	RefreshInterval time.Duration
+
+	// (Optional)
+	// Logger is the logger to write logs to. Defaults to slog.Default().
+	Logger *slog.Logger
+
+	pipeline *runtime.Pipeline
+	mutex    sync.Mutex
+}
+
+// appConfigurationKeyValue is a key-value returned by Azure App Configuration.
+type appConfigurationKeyValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	ContentType string `json:"content_type"`
+}
+
+// appConfigurationKeyValues is a page of the key-values returned by Azure App Configuration.
+type appConfigurationKeyValues struct {
+	Items    []appConfigurationKeyValue `json:"items"`
+	NextLink string                     `json:"@nextLink"`
+}
+
+// Load reads the settings from the store and returns a new provider with them.
+func (l *AppConfigurationLoader) Load(ctx context.Context) (*Provider, error) {
+	settings, err := l.readSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return l.newProvider(settings)
+}
+
+// Watch reads the settings from the store, and again every Refresh Interval until the context is done, calling fn with
+// a new provider whenever they have changed, including the first time. Applications swap the provider in use for the new one,
+// e.g. by registering it in a Registry. It fails if the settings cannot be read or applied at first; later failures
+// are logged, and the provider with the settings read last remains in use.
+func (l *AppConfigurationLoader) Watch(ctx context.Context, fn func(*Provider)) error {
+	settings, err := l.readSettings(ctx)
+	if err != nil {
+		return err
+	}
+	provider, err := l.newProvider(settings)
+	if err != nil {
+		return err
+	}
+	fn(provider)
+
+	interval := l.RefreshInterval
+	if interval <= 0 {
+		interval = defaultAppConfigurationRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		refreshed, err := l.readSettings(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				l.getLogger().Warn("failed to refresh the settings from App Configuration; keeping the settings read last", "endpoint", l.Endpoint, "error", err)
+			}
+			continue
+		}
+		if reflect.DeepEqual(refreshed, settings) {
+			continue
+		}
+		provider, err := l.newProvider(refreshed)
+		if err != nil {
+			l.getLogger().Warn("failed to apply the settings from App Configuration; keeping the settings read last", "endpoint", l.Endpoint, "error", err)
+			continue
+		}
+		settings = refreshed
+		l.getLogger().Info("the settings from App Configuration have changed", "endpoint", l.Endpoint)
+		fn(provider)
+	}
+}
+
+// newProvider returns a new provider with the settings of Template, if set, and the settings read from the store.
+func (l *AppConfigurationLoader) newProvider(settings map[string]string) (*Provider, error) {
+	provider := &Provider{}
+	if l.Template != nil {
+		copySettings(provider, l.Template)
+	}
+	for name, value := range settings {
+		if err := setProviderSetting(provider, name, value); err != nil {
+			return nil, err
+		}
+	}
+	return provider, nil
+}
+
+// readSettings reads the key-values under Key Prefix with Label from the store, keyed by the names of the settings.
+func (l *AppConfigurationLoader) readSettings(ctx context.Context) (map[string]string, error) {
+	pipeline, err := l.getPipeline()
+	if err != nil {
+		return nil, err
+	}
+
+	keyPrefix := l.KeyPrefix
+	if keyPrefix == "" {
+		keyPrefix = defaultAppConfigurationKeyPrefix
+	}
+	label := l.Label
+	if label == "" {
+		// The null character selects the key-values without a label
+		label = "\x00"
+	}
+	query := url.Values{}
+	query.Set("key", keyPrefix+"*")
+	query.Set("label", label)
+	query.Set("api-version", appConfigurationAPIVersion)
+	endpoint := strings.TrimSuffix(l.Endpoint, "/")
+	next := endpoint + "/kv?" + query.Encode()
+
+	settings := map[string]string{}
+	for next != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, next)
+		if err != nil {
+			return nil, err
+		}
+		req.Raw().Header.Set("Accept", "application/vnd.microsoft.appconfig.kvset+json, application/problem+json")
+		resp, err := pipeline.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+		var page appConfigurationKeyValues
+		if err := runtime.UnmarshalAsJSON(resp, &page); err != nil {
+			return nil, err
+		}
+		for _, keyValue := range page.Items {
+			name := strings.TrimPrefix(keyValue.Key, keyPrefix)
+			if strings.HasPrefix(keyValue.ContentType, "application/vnd.microsoft.appconfig.keyvaultref+json") {
+				return nil, fmt.Errorf("the setting %v is a Key Vault reference, which cannot be resolved; store the value itself, or set it in Template", name)
+			}
+			settings[name] = keyValue.Value
+		}
+		next = ""
+		if page.NextLink != "" {
+			next = endpoint + page.NextLink
+		}
+	}
+	return settings, nil
+}
+
+// getPipeline returns the pipeline sending the requests to the store, building it on first use.
+func (l *AppConfigurationLoader) getPipeline() (*runtime.Pipeline, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.pipeline != nil {
+		return l.pipeline, nil
+	}
+
+	endpoint, err := url.Parse(l.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, fmt.Errorf("the App Configuration endpoint %v cannot be interpreted", l.Endpoint)
+	}
+	audience := l.Audience
+	if audience == "" {
+		for suffix, suffixAudience := range appConfigurationAudiences {
+			if strings.HasSuffix(strings.ToLower(endpoint.Hostname()), suffix) {
+				audience = suffixAudience
+			}
+		}
+		if audience == "" {
+			return nil, fmt.Errorf("the audience of the App Configuration endpoint %v cannot be determined; set Audience", l.Endpoint)
+		}
+	}
+	credential := l.Credential
+	if credential == nil {
+		credential, err = azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pipeline := runtime.NewPipeline(moduleName, moduleVersion, runtime.PipelineOptions{
+		PerRetry: []policy.Policy{runtime.NewBearerTokenPolicy(credential, []string{audience + "/.default"}, nil)},
+	}, l.ClientOptions)
+	l.pipeline = &pipeline
+	return l.pipeline, nil
+}
+
+func (l *AppConfigurationLoader) getLogger() *slog.Logger {
+	if l.Logger != nil {
+		return l.Logger
+	}
+	return slog.Default()
+}
+
+// setProviderSetting sets the setting of the provider with the JSON name to the value read from App Configuration.
+func setProviderSetting(provider *Provider, name string, value string) error {
+	providerValue := reflect.ValueOf(provider).Elem()
+	providerType := providerValue.Type()
+	for i := 0; i < providerType.NumField(); i++ {
+		jsonName, _, _ := strings.Cut(providerType.Field(i).Tag.Get("json"), ",")
+		if jsonName != name || jsonName == "" || jsonName == "-" {
+			continue
+		}
+		field := providerValue.Field(i)
+		switch {
+		case field.Type() == reflect.TypeOf(time.Duration(0)):
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("the setting %v %v cannot be interpreted: %v", name, value, err)
+			}
+			field.SetInt(int64(duration))
+		case field.Kind() == reflect.String:
+			field.SetString(value)
+		default:
+			if err := json.Unmarshal([]byte(value), field.Addr().Interface()); err != nil {
+				return fmt.Errorf("the setting %v %v cannot be interpreted: %v", name, value, err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("the setting %v is not a setting of the provider", name)
+}
@@ -0,0 +1,168 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// getFakeAppConfigurationLoader returns a loader reading the key-values returned by the function from a fake store,
+// in pages of two key-values.
+func getFakeAppConfigurationLoader(t *testing.T, keyValues func() []appConfigurationKeyValue) *AppConfigurationLoader {
+	transport := transportFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("Authorization") == "" {
+			t.Errorf("the request is not authenticated")
+		}
+		if got := req.URL.Query().Get("key"); got != "libdns/azure/*" {
+			t.Errorf("got key: %v", got)
+		}
+		items := keyValues()
+		page := appConfigurationKeyValues{}
+		start, _ := strconv.Atoi(req.URL.Query().Get("after"))
+		for i := start; i < len(items) && i < start+2; i++ {
+			page.Items = append(page.Items, items[i])
+		}
+		if start+2 < len(items) {
+			page.NextLink = fmt.Sprintf("/kv?key=libdns%%2Fazure%%2F%%2A&label=%%00&after=%d&api-version=1.0", start+2)
+		}
+		body, _ := json.Marshal(page)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(string(body))),
+			Request:    req,
+		}, nil
+	})
+	return &AppConfigurationLoader{
+		Endpoint:      "https://example.azconfig.io",
+		Credential:    &fake.TokenCredential{},
+		ClientOptions: &policy.ClientOptions{Transport: transport},
+	}
+}
+
+func Test_AppConfigurationLoader_Load(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyValues []appConfigurationKeyValue
+		want      *Provider
+		wantErr   string
+	}{
+		{
+			name: "settings",
+			keyValues: []appConfigurationKeyValue{
+				{Key: "libdns/azure/subscription_id", Value: "fake-subscription-id"},
+				{Key: "libdns/azure/provisioning_poll_interval", Value: "5s"},
+				{Key: "libdns/azure/ttl_conflict_policy", Value: "lowest"},
+				{Key: "libdns/azure/zone_resource_ids", Value: `{"example.com.": "/subscriptions/s/resourceGroups/g/providers/Microsoft.Network/dnszones/example.com"}`, ContentType: "application/json"},
+				{Key: "libdns/azure/conflict_retries", Value: "5"},
+			},
+			want: &Provider{
+				SubscriptionId:           "fake-subscription-id",
+				ResourceGroupName:        "template-resource-group-name",
+				ProvisioningPollInterval: 5 * time.Second,
+				TTLConflictPolicy:        TTLConflictPolicyLowest,
+				ZoneResourceIDs:          map[string]string{"example.com.": "/subscriptions/s/resourceGroups/g/providers/Microsoft.Network/dnszones/example.com"},
+				ConflictRetries:          5,
+			},
+		},
+		{
+			name:      "setting=unknown",
+			keyValues: []appConfigurationKeyValue{{Key: "libdns/azure/logger", Value: "stdout"}},
+			wantErr:   "the setting logger is not a setting of the provider",
+		},
+		{
+			name:      "duration=invalid",
+			keyValues: []appConfigurationKeyValue{{Key: "libdns/azure/zone_cache_ttl", Value: "5"}},
+			wantErr:   "the setting zone_cache_ttl 5 cannot be interpreted",
+		},
+		{
+			name: "key vault reference",
+			keyValues: []appConfigurationKeyValue{{
+				Key:         "libdns/azure/client_secret",
+				Value:       `{"uri":"https://example.vault.azure.net/secrets/client-secret"}`,
+				ContentType: "application/vnd.microsoft.appconfig.keyvaultref+json;charset=utf-8",
+			}},
+			wantErr: "the setting client_secret is a Key Vault reference",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader := getFakeAppConfigurationLoader(t, func() []appConfigurationKeyValue {
+				return tt.keyValues
+			})
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			loader.Template = &Provider{ResourceGroupName: "template-resource-group-name", Logger: logger}
+			provider, err := loader.Load(context.TODO())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got: %v, want: %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if provider.Logger != logger {
+				t.Errorf("the settings of the template are not kept")
+			}
+			provider.Logger = nil
+			if diff := cmp.Diff(provider, tt.want, cmpopts.IgnoreUnexported(Provider{})); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+
+	t.Run("endpoint=invalid", func(t *testing.T) {
+		for _, endpoint := range []string{"example.azconfig.io", "http://example.azconfig.io", "https://example.com"} {
+			loader := &AppConfigurationLoader{Endpoint: endpoint, Credential: &fake.TokenCredential{}}
+			if _, err := loader.Load(context.TODO()); err == nil {
+				t.Errorf("expected an error for %v", endpoint)
+			}
+		}
+	})
+}
+
+func Test_AppConfigurationLoader_Watch(t *testing.T) {
+	var mutex sync.Mutex
+	subscriptionID := "first-subscription-id"
+	loader := getFakeAppConfigurationLoader(t, func() []appConfigurationKeyValue {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return []appConfigurationKeyValue{{Key: "libdns/azure/subscription_id", Value: subscriptionID}}
+	})
+	loader.RefreshInterval = 10 * time.Millisecond
+	loader.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []string
+	err := loader.Watch(ctx, func(provider *Provider) {
+		got = append(got, provider.SubscriptionId)
+		if len(got) == 1 {
+			mutex.Lock()
+			subscriptionID = "second-subscription-id"
+			mutex.Unlock()
+		} else {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if diff := cmp.Diff(got, []string{"first-subscription-id", "second-subscription-id"}); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
@@ -0,0 +1,158 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/libdns/libdns"
+)
+
+// BatchMode determines how a call writing many record sets handles a failure to write one of them.
+type BatchMode string
+
+const (
+	// BatchModeFailFast stops at the first record set that fails to be written, leaving the rest unwritten.
+	BatchModeFailFast BatchMode = "fail_fast"
+
+	// BatchModeBestEffort writes all the record sets regardless of failures, and reports the failures together.
+	BatchModeBestEffort BatchMode = "best_effort"
+)
+
+// BatchError is returned in the best-effort batch mode when some of the record sets fail to be written.
+// The records written successfully are returned along with it.
+type BatchError struct {
+	// Failures are the record sets that failed to be written, in the order they were written.
+	Failures []RecordSetFailure
+}
+
+// RecordSetFailure is a record set that failed to be written in the best-effort batch mode.
+type RecordSetFailure struct {
+	// Zone is the name of the zone.
+	Zone string
+
+	// Name is the name of the record set relative to the zone. It is empty if the whole zone failed to be written.
+	Name string
+
+	// Type is the type of the record set. It is empty if the whole zone failed to be written.
+	Type string
+
+	// Err is the error that the write failed with.
+	Err error
+}
+
+// Error returns the error message listing the failures.
+func (e *BatchError) Error() string {
+	var messages []string
+	for _, failure := range e.Failures {
+		if failure.Name == "" {
+			messages = append(messages, fmt.Sprintf("the zone %v: %v", failure.Zone, failure.Err))
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("the record set %v %v: %v", failure.Name, failure.Type, failure.Err))
+	}
+	return fmt.Sprintf("%v record sets failed to be written: %v", len(e.Failures), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the errors of the failures.
+func (e *BatchError) Unwrap() []error {
+	var errs []error
+	for _, failure := range e.Failures {
+		errs = append(errs, failure.Err)
+	}
+	return errs
+}
+
+// isBestEffort reports whether the provider writes all the record sets of a call regardless of failures.
+func (p *Provider) isBestEffort() bool {
+	return p.BatchMode == BatchModeBestEffort
+}
+
+// writeRecordSets writes the groups of records sharing the same name and type, up to the write concurrency of the zone
+// at the same time, collecting the records written in the order of the groups.
+// In the fail-fast batch mode, it stops writing at the first failure and returns the records written, including those of the groups
+// already being written at the time, along with the error of the first group that failed. In the best-effort batch mode,
+// it writes all the groups and returns the records written along with a *BatchError of the groups that failed.
+// Each group is counted against the progress of the bulk write.
+func (p *Provider) writeRecordSets(ctx context.Context, zone string, recordGroups [][]libdns.Record, write func(records []libdns.Record) ([]libdns.Record, error)) ([]libdns.Record, error) {
+	ctx = p.withProgress(ctx, len(recordGroups))
+
+	concurrency := p.zoneSettings(zone).writeConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	type result struct {
+		records []libdns.Record
+		err     error
+	}
+	results := make([]result, len(recordGroups))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var failed atomic.Bool
+	for i, recordGroup := range recordGroups {
+		semaphore <- struct{}{}
+		// In the fail-fast batch mode, the groups after a failure are left unwritten
+		if failed.Load() && !p.isBestEffort() {
+			<-semaphore
+			break
+		}
+		wg.Add(1)
+		go func(i int, recordGroup []libdns.Record) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			records, err := write(recordGroup)
+			advanceProgress(ctx)
+			if err != nil {
+				failed.Store(true)
+			}
+			results[i] = result{records: records, err: err}
+		}(i, recordGroup)
+	}
+	wg.Wait()
+
+	var writtenRecords []libdns.Record
+	var failures []RecordSetFailure
+	var firstErr error
+	for i, recordGroup := range recordGroups {
+		records, err := results[i].records, results[i].err
+		if err != nil {
+			if !p.isBestEffort() {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			rr := recordGroup[0].RR()
+			failures = append(failures, RecordSetFailure{
+				Zone: zone,
+				Name: p.recordSetName(rr.Name, zone),
+				Type: rr.Type,
+				Err:  err,
+			})
+			continue
+		}
+		writtenRecords = append(writtenRecords, records...)
+	}
+
+	if firstErr != nil {
+		return writtenRecords, firstErr
+	}
+	if len(failures) > 0 {
+		return writtenRecords, &BatchError{Failures: failures}
+	}
+	return writtenRecords, nil
+}
+
+// appendFailures appends the failures of the error to write a zone, expanding a *BatchError into its failures.
+func appendFailures(failures []RecordSetFailure, zone string, err error) []RecordSetFailure {
+	var batchError *BatchError
+	if errors.As(err, &batchError) {
+		return append(failures, batchError.Failures...)
+	}
+	return append(failures, RecordSetFailure{Zone: zone, Err: err})
+}
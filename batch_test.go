@@ -0,0 +1,86 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_writeRecordSets(t *testing.T) {
+	records := []libdns.Record{
+		libdns.TXT{Name: "record-a", Text: "NEW VALUE", TTL: time.Minute},
+		// The record set is owned by another owner, so appending to it fails
+		libdns.TXT{Name: "record-txt", Text: "TEST VALUE", TTL: time.Minute},
+		libdns.TXT{Name: "record-b", Text: "NEW VALUE", TTL: time.Minute},
+	}
+	tests := []struct {
+		name         string
+		batchMode    BatchMode
+		wantNames    []string
+		wantWrites   int
+		wantFailures []string
+	}{
+		{name: "mode=default", batchMode: "", wantNames: []string{"record-a"}, wantWrites: 1},
+		{name: "mode=fail_fast", batchMode: BatchModeFailFast, wantNames: []string{"record-a"}, wantWrites: 1},
+		{name: "mode=best_effort", batchMode: BatchModeBestEffort, wantNames: []string{"record-a", "record-b"}, wantWrites: 2, wantFailures: []string{"record-txt TXT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := newFakeZone(getOwnedTXTRecordSet("team-b"))
+			provider := getFakeProviderWithZone(zone)
+			provider.OwnerId = "team-a"
+			provider.BatchMode = tt.batchMode
+			got, err := provider.AppendRecords(context.TODO(), "example.com.", records)
+			if err == nil {
+				t.Fatalf("got: nil error")
+			}
+			var gotNames []string
+			for _, record := range got {
+				gotNames = append(gotNames, record.RR().Name)
+			}
+			if diff := cmp.Diff(gotNames, tt.wantNames); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+			if len(zone.written) != tt.wantWrites {
+				t.Errorf("got: %d writes, want: %d", len(zone.written), tt.wantWrites)
+			}
+			var batchError *BatchError
+			if !errors.As(err, &batchError) {
+				if tt.wantFailures != nil {
+					t.Errorf("got: %v", err)
+				}
+				return
+			}
+			var gotFailures []string
+			for _, failure := range batchError.Failures {
+				gotFailures = append(gotFailures, failure.Name+" "+failure.Type)
+			}
+			if diff := cmp.Diff(gotFailures, tt.wantFailures); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_appendFailures(t *testing.T) {
+	errFailed := errors.New("failed")
+	failures := appendFailures(nil, "example.com.", &BatchError{Failures: []RecordSetFailure{
+		{Zone: "example.com.", Name: "www", Type: "A", Err: errFailed},
+	}})
+	failures = appendFailures(failures, "sub.example.com.", errFailed)
+	want := []RecordSetFailure{
+		{Zone: "example.com.", Name: "www", Type: "A", Err: errFailed},
+		{Zone: "sub.example.com.", Err: errFailed},
+	}
+	if diff := cmp.Diff(failures, want, cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+	wantMessage := "2 record sets failed to be written: the record set www A: failed; the zone sub.example.com.: failed"
+	if got := (&BatchError{Failures: failures}).Error(); got != wantMessage {
+		t.Errorf("got: %v, want: %v", got, wantMessage)
+	}
+}
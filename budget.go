@@ -0,0 +1,54 @@
+package azure
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultMutationBudgetWindow is the default window in which the mutations of a zone are counted against the budget.
+const defaultMutationBudgetWindow = time.Hour
+
+// mutationBudget tracks the times of the recent mutations of each zone.
+type mutationBudget struct {
+	mutations map[string][]time.Time
+	mutex     sync.Mutex
+}
+
+// spendMutationBudget counts a mutation of the zone against Mutation Budget,
+// throwing a *BudgetExceededError without counting it if the budget of the zone has been used up.
+func (p *Provider) spendMutationBudget(zone string) error {
+	if p.MutationBudget <= 0 {
+		return nil
+	}
+	window := p.MutationBudgetWindow
+	if window <= 0 {
+		window = defaultMutationBudgetWindow
+	}
+
+	p.mutationBudget.mutex.Lock()
+	defer p.mutationBudget.mutex.Unlock()
+
+	if p.mutationBudget.mutations == nil {
+		p.mutationBudget.mutations = map[string][]time.Time{}
+	}
+	name := normalizeZoneName(zone)
+	now := time.Now()
+
+	// Forget the mutations that have left the window
+	mutations := p.mutationBudget.mutations[name]
+	for len(mutations) > 0 && now.Sub(mutations[0]) >= window {
+		mutations = mutations[1:]
+	}
+	if len(mutations) >= p.MutationBudget {
+		p.mutationBudget.mutations[name] = mutations
+		return &BudgetExceededError{
+			Zone:       name,
+			Budget:     p.MutationBudget,
+			Window:     window,
+			RetryAfter: mutations[0].Add(window).Sub(now),
+		}
+	}
+	p.mutationBudget.mutations[name] = append(mutations, now)
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func Test_spendMutationBudget(t *testing.T) {
+	tests := []struct {
+		name      string
+		budget    int
+		window    time.Duration
+		wantSpent int
+	}{
+		{name: "budget=0", budget: 0, wantSpent: 5},
+		{name: "budget=3", budget: 3, wantSpent: 3},
+		{name: "budget=3,window=1ns", budget: 3, window: time.Nanosecond, wantSpent: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := Provider{MutationBudget: tt.budget, MutationBudgetWindow: tt.window}
+			spent := 0
+			for i := 0; i < 5; i++ {
+				err := provider.spendMutationBudget("example.com.")
+				if err == nil {
+					spent++
+				}
+				var budgetExceededError *BudgetExceededError
+				if err != nil && !errors.As(err, &budgetExceededError) {
+					t.Fatalf("%s", err)
+				}
+				time.Sleep(time.Millisecond)
+			}
+			if spent != tt.wantSpent {
+				t.Errorf("got: %d, want: %d", spent, tt.wantSpent)
+			}
+		})
+	}
+	t.Run("zones", func(t *testing.T) {
+		provider := Provider{MutationBudget: 1}
+		if err := provider.spendMutationBudget("example.com."); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if err := provider.spendMutationBudget("sub.example.com."); err != nil {
+			t.Fatalf("%s", err)
+		}
+		err := provider.spendMutationBudget("Example.com")
+		var budgetExceededError *BudgetExceededError
+		if !errors.As(err, &budgetExceededError) {
+			t.Fatalf("got: %v", err)
+		}
+		if budgetExceededError.Zone != "example.com." || budgetExceededError.Window != time.Hour || budgetExceededError.RetryAfter <= 0 {
+			t.Errorf("got: %+v", budgetExceededError)
+		}
+	})
+}
+
+func Test_spendMutationBudget_beforeWriting(t *testing.T) {
+	zone := newFakeZone(getOwnedTXTRecordSet(""))
+	provider := getFakeProviderWithZone(zone)
+	provider.MutationBudget = 1
+	_, err := provider.SetRecords(context.TODO(), "example.com.", []libdns.Record{
+		libdns.TXT{Name: "record-new", Text: "NEW VALUE", TTL: time.Minute},
+		libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Minute},
+	})
+	var budgetExceededError *BudgetExceededError
+	if !errors.As(err, &budgetExceededError) {
+		t.Errorf("got: %v", err)
+	}
+	if len(zone.written) != 1 {
+		t.Errorf("got: %d writes, want: 1", len(zone.written))
+	}
+}
@@ -0,0 +1,18 @@
+// Command certmanager is a cert-manager webhook that solves ACME DNS-01 challenges with Azure DNS through libdns/azure,
+// for clusters that need the authentication modes of the provider that the built-in AzureDNS solver of cert-manager lacks.
+package main
+
+import (
+	"os"
+
+	"github.com/cert-manager/cert-manager/pkg/acme/webhook/cmd"
+)
+
+func main() {
+	groupName := os.Getenv("GROUP_NAME")
+	if groupName == "" {
+		panic("GROUP_NAME must be specified")
+	}
+
+	cmd.RunWebhookServer(groupName, &solver{})
+}
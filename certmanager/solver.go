@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	whapi "github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/libdns/azure"
+	"github.com/libdns/libdns"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+// challengeTTL is the TTL of the TXT records presented for the challenges.
+const challengeTTL = 60 * time.Second
+
+// challengeTimeout is how long to wait for Azure DNS to present or clean up a challenge.
+const challengeTimeout = 2 * time.Minute
+
+// challengeProvider is the part of the provider that solves the challenges.
+type challengeProvider interface {
+	libdns.RecordAppender
+	libdns.RecordDeleter
+}
+
+// solver implements the webhook solver of cert-manager for Azure DNS.
+type solver struct {
+	client kubernetes.Interface
+
+	// newProvider builds the provider to solve the challenges with, or returns the provider itself if nil.
+	newProvider func(provider *azure.Provider) challengeProvider
+
+	providers map[string]*cachedProvider
+	mutex     sync.Mutex
+}
+
+// config is the configuration of the solver in the webhook section of an Issuer or a ClusterIssuer.
+// Only the location of the zones and the identity to authenticate as are accepted, since the Issuers of any namespace
+// may configure the solver, and must not make the webhook read files, reach endpoints, or take settings of their choice.
+// The client secret is read from a Secret rather than given in the configuration.
+type config struct {
+	// Subscription ID is the ID of the subscription in which the DNS zones are located.
+	SubscriptionId string `json:"subscription_id,omitempty"`
+
+	// Resource Group Name is the name of the resource group in which the DNS zones are located.
+	ResourceGroupName string `json:"resource_group_name,omitempty"`
+
+	// Tenant ID is the ID of the tenant of the application authenticating with the client secret.
+	TenantId string `json:"tenant_id,omitempty"`
+
+	// Client ID is the ID of the application authenticating with the client secret.
+	ClientId string `json:"client_id,omitempty"`
+
+	// Managed Identity Client ID selects the user-assigned managed identity of the webhook to authenticate with.
+	ManagedIdentityClientId string `json:"managed_identity_client_id,omitempty"`
+
+	// Managed Identity Resource ID selects the user-assigned managed identity of the webhook by its resource ID instead.
+	ManagedIdentityResourceId string `json:"managed_identity_resource_id,omitempty"`
+
+	// Client Secret Secret Ref refers to the key of the Secret holding the client secret of the application,
+	// in the namespace of the Issuer, or in the cluster resource namespace of cert-manager for a ClusterIssuer.
+	ClientSecretSecretRef *cmmeta.SecretKeySelector `json:"clientSecretSecretRef,omitempty"`
+}
+
+// cachedProvider is a provider cached for the configuration of an Issuer.
+type cachedProvider struct {
+	provider         challengeProvider
+	clientSecretHash [sha256.Size]byte
+	lastUsed         time.Time
+}
+
+// providerCacheTTL is how long a provider is kept after it last solved a challenge, so that the providers
+// of the Issuers deleted or reconfigured since are dropped along with their tokens and client secrets.
+const providerCacheTTL = time.Hour
+
+// Name is the name of the solver referred to by solverName in the webhook section of an Issuer or a ClusterIssuer.
+func (s *solver) Name() string {
+	return "azure-dns"
+}
+
+// Present creates the TXT record with the key of the challenge, adding it to the values of the record set,
+// so that the challenges for a name and its wildcard are solved at the same time.
+func (s *solver) Present(ch *whapi.ChallengeRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), challengeTimeout)
+	defer cancel()
+
+	provider, err := s.getProvider(ctx, ch)
+	if err != nil {
+		return err
+	}
+	_, err = provider.AppendRecords(ctx, ch.ResolvedZone, []libdns.Record{challengeRecord(ch)})
+	return err
+}
+
+// CleanUp deletes the TXT record with the key of the challenge, leaving the other values of the record set as they are.
+func (s *solver) CleanUp(ch *whapi.ChallengeRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), challengeTimeout)
+	defer cancel()
+
+	provider, err := s.getProvider(ctx, ch)
+	if err != nil {
+		return err
+	}
+	_, err = provider.DeleteRecords(ctx, ch.ResolvedZone, []libdns.Record{challengeRecord(ch)})
+	return err
+}
+
+// Initialize creates the client of Kubernetes to read the client secrets from.
+func (s *solver) Initialize(kubeClientConfig *restclient.Config, stopCh <-chan struct{}) error {
+	client, err := kubernetes.NewForConfig(kubeClientConfig)
+	if err != nil {
+		return err
+	}
+	s.client = client
+	return nil
+}
+
+// challengeRecord returns the TXT record with the key of the challenge at the resolved name relative to the resolved zone.
+func challengeRecord(ch *whapi.ChallengeRequest) libdns.Record {
+	return libdns.TXT{
+		Name: libdns.RelativeName(ch.ResolvedFQDN, ch.ResolvedZone),
+		TTL:  challengeTTL,
+		Text: ch.Key,
+	}
+}
+
+// getProvider returns the provider for the configuration of the challenge. Providers are cached by the configuration
+// and its namespace, which refer to the Secret holding the client secret rather than hold it, so that the tokens are reused
+// across the challenges of the same Issuer. The client secret is read again for every challenge, and the provider is
+// rebuilt once the Secret is rotated.
+func (s *solver) getProvider(ctx context.Context, ch *whapi.ChallengeRequest) (challengeProvider, error) {
+	var raw []byte
+	if ch.Config != nil {
+		raw = ch.Config.Raw
+	}
+	key := ch.ResourceNamespace + "/" + string(raw)
+
+	config, err := loadConfig(ch)
+	if err != nil {
+		return nil, err
+	}
+	clientSecret, err := s.readClientSecret(ctx, ch, config)
+	if err != nil {
+		return nil, err
+	}
+	clientSecretHash := sha256.Sum256([]byte(clientSecret))
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	s.evictProviders(now)
+	if cached, ok := s.providers[key]; ok && cached.clientSecretHash == clientSecretHash {
+		cached.lastUsed = now
+		return cached.provider, nil
+	} else if ok {
+		closeProvider(cached.provider)
+	}
+
+	provider := config.newProvider(clientSecret)
+	var challengeProvider challengeProvider = provider
+	if s.newProvider != nil {
+		challengeProvider = s.newProvider(provider)
+	}
+	if s.providers == nil {
+		s.providers = map[string]*cachedProvider{}
+	}
+	s.providers[key] = &cachedProvider{
+		provider:         challengeProvider,
+		clientSecretHash: clientSecretHash,
+		lastUsed:         now,
+	}
+	return challengeProvider, nil
+}
+
+// evictProviders drops the providers that have not solved a challenge for the cache TTL.
+func (s *solver) evictProviders(now time.Time) {
+	for key, cached := range s.providers {
+		if now.Sub(cached.lastUsed) >= providerCacheTTL {
+			closeProvider(cached.provider)
+			delete(s.providers, key)
+		}
+	}
+}
+
+// closeProvider releases the resources held by the provider, if it holds any.
+func closeProvider(provider challengeProvider) {
+	if closer, ok := provider.(interface{ Close() error }); ok {
+		_ = closer.Close()
+	}
+}
+
+// loadConfig decodes the configuration of the challenge, rejecting the fields that are not accepted.
+func loadConfig(ch *whapi.ChallengeRequest) (*config, error) {
+	config := &config{}
+	if ch.Config != nil {
+		decoder := json.NewDecoder(bytes.NewReader(ch.Config.Raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(config); err != nil {
+			return nil, fmt.Errorf("the config cannot be interpreted: %w", err)
+		}
+	}
+	return config, nil
+}
+
+// readClientSecret reads the client secret from the Secret that the configuration refers to, if any.
+// Ambient credentials, i.e. a managed identity or a workload identity of the webhook, are used only if cert-manager allows them.
+func (s *solver) readClientSecret(ctx context.Context, ch *whapi.ChallengeRequest, config *config) (string, error) {
+	ref := config.ClientSecretSecretRef
+	if ref == nil {
+		if !ch.AllowAmbientCredentials {
+			return "", fmt.Errorf("the config has no client secret, and ambient credentials are not allowed for the issuer")
+		}
+		return "", nil
+	}
+
+	if s.client == nil {
+		return "", fmt.Errorf("the secret %v cannot be read before the solver is initialized", ref.Name)
+	}
+	secret, err := s.client.CoreV1().Secrets(ch.ResourceNamespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("the secret %v cannot be read: %w", ref.Name, err)
+	}
+	clientSecret, ok := secret.Data[ref.Key]
+	if !ok || len(clientSecret) == 0 {
+		return "", fmt.Errorf("the secret %v has no key %v", ref.Name, ref.Key)
+	}
+	return string(clientSecret), nil
+}
+
+// newProvider builds the provider for the configuration with the client secret.
+func (c *config) newProvider(clientSecret string) *azure.Provider {
+	return &azure.Provider{
+		SubscriptionId:            c.SubscriptionId,
+		ResourceGroupName:         c.ResourceGroupName,
+		TenantId:                  c.TenantId,
+		ClientId:                  c.ClientId,
+		ClientSecret:              clientSecret,
+		ManagedIdentityClientId:   c.ManagedIdentityClientId,
+		ManagedIdentityResourceId: c.ManagedIdentityResourceId,
+	}
+}
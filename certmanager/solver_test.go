@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	whapi "github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/azure"
+	"github.com/libdns/libdns"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type fakeProvider struct {
+	provider *azure.Provider
+	appended []libdns.Record
+	deleted  []libdns.Record
+}
+
+func (p *fakeProvider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	p.appended = append(p.appended, records...)
+	return records, nil
+}
+
+func (p *fakeProvider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	p.deleted = append(p.deleted, records...)
+	return records, nil
+}
+
+func getFakeSolver(providers *[]*fakeProvider) *solver {
+	return &solver{
+		client: fake.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "azure-dns", Namespace: "cert-manager"},
+			Data:       map[string][]byte{"client-secret": []byte("SECRET")},
+		}),
+		newProvider: func(azureProvider *azure.Provider) challengeProvider {
+			provider := &fakeProvider{provider: azureProvider}
+			*providers = append(*providers, provider)
+			return provider
+		},
+	}
+}
+
+func getChallengeRequest(config string, allowAmbientCredentials bool) *whapi.ChallengeRequest {
+	return &whapi.ChallengeRequest{
+		Key:                     "KEY",
+		ResourceNamespace:       "cert-manager",
+		ResolvedFQDN:            "_acme-challenge.www.example.com.",
+		ResolvedZone:            "example.com.",
+		AllowAmbientCredentials: allowAmbientCredentials,
+		Config:                  &apiextensionsv1.JSON{Raw: []byte(config)},
+	}
+}
+
+func Test_solver(t *testing.T) {
+	var providers []*fakeProvider
+	s := getFakeSolver(&providers)
+	ch := getChallengeRequest(`{"subscription_id": "SUBSCRIPTION", "resource_group_name": "GROUP", "tenant_id": "TENANT", "client_id": "CLIENT", "clientSecretSecretRef": {"name": "azure-dns", "key": "client-secret"}}`, false)
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("%s", err)
+	}
+	if err := s.CleanUp(ch); err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	if len(providers) != 1 {
+		t.Fatalf("got %d providers, want 1 cached", len(providers))
+	}
+	provider := providers[0]
+	got := []string{
+		provider.provider.SubscriptionId,
+		provider.provider.ResourceGroupName,
+		provider.provider.TenantId,
+		provider.provider.ClientId,
+		provider.provider.ClientSecret,
+	}
+	if diff := cmp.Diff(got, []string{"SUBSCRIPTION", "GROUP", "TENANT", "CLIENT", "SECRET"}); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+	want := []libdns.Record{libdns.TXT{Name: "_acme-challenge.www", TTL: 60 * time.Second, Text: "KEY"}}
+	if diff := cmp.Diff(provider.appended, want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+	if diff := cmp.Diff(provider.deleted, want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
+
+func Test_solver_rotation(t *testing.T) {
+	var providers []*fakeProvider
+	s := getFakeSolver(&providers)
+	ch := getChallengeRequest(`{"tenant_id": "TENANT", "client_id": "CLIENT", "clientSecretSecretRef": {"name": "azure-dns", "key": "client-secret"}}`, false)
+	if err := s.Present(ch); err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	secrets := s.client.CoreV1().Secrets("cert-manager")
+	secret, err := secrets.Get(context.TODO(), "azure-dns", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	secret.Data["client-secret"] = []byte("ROTATED")
+	if _, err := secrets.Update(context.TODO(), secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("%s", err)
+	}
+	if err := s.CleanUp(ch); err != nil {
+		t.Fatalf("%s", err)
+	}
+	if len(providers) != 2 || providers[1].provider.ClientSecret != "ROTATED" {
+		t.Fatalf("the provider is not rebuilt with the rotated secret")
+	}
+	if len(s.providers) != 1 {
+		t.Errorf("got %d cached providers, want 1", len(s.providers))
+	}
+
+	for _, cached := range s.providers {
+		cached.lastUsed = time.Now().Add(-providerCacheTTL)
+	}
+	s.evictProviders(time.Now())
+	if len(s.providers) != 0 {
+		t.Errorf("got %d cached providers, want 0", len(s.providers))
+	}
+}
+
+func Test_loadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		ambient bool
+		wantErr bool
+	}{
+		{name: "secret", config: `{"clientSecretSecretRef": {"name": "azure-dns", "key": "client-secret"}}`},
+		{name: "secret,missing", config: `{"clientSecretSecretRef": {"name": "other", "key": "client-secret"}}`, wantErr: true},
+		{name: "secret,missing key", config: `{"clientSecretSecretRef": {"name": "azure-dns", "key": "other"}}`, wantErr: true},
+		{name: "secret,inline", config: `{"client_secret": "SECRET"}`, ambient: true, wantErr: true},
+		{name: "ambient,allowed", config: `{"managed_identity_client_id": "IDENTITY"}`, ambient: true},
+		{name: "ambient,not allowed", config: `{"managed_identity_client_id": "IDENTITY"}`, wantErr: true},
+		{name: "field,not accepted", config: `{"tls_root_ca_files": ["/etc/passwd"]}`, ambient: true, wantErr: true},
+		{name: "invalid", config: `{"subscription_id": 1}`, ambient: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var providers []*fakeProvider
+			s := getFakeSolver(&providers)
+			_, err := s.getProvider(context.TODO(), getChallengeRequest(tt.config, tt.ambient))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got: %v, wantErr: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
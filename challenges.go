@@ -0,0 +1,117 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/libdns/libdns"
+)
+
+const (
+	// acmeChallengeLabel is the label of the names of the TXT records for ACME DNS challenges.
+	acmeChallengeLabel = "_acme-challenge"
+
+	// writtenAtMetadataKey is the key of the metadata stamped on record sets with the time when the provider last wrote them.
+	writtenAtMetadataKey = "libdns_written_at"
+)
+
+// CleanupStaleChallenges deletes the TXT record sets for ACME DNS challenges in the zone, i.e. "_acme-challenge"
+// and the names under it, that the provider last wrote more than olderThan ago, since crashed renewals leave them behind.
+// The time of the last write is taken from the metadata "libdns_written_at" stamped on the challenge record sets
+// the provider writes with Stamp Challenges or Stamp Provenance, so record sets without it, such as those written
+// by other tools, are left as they are.
+// A record set written again after it was listed is not deleted. It returns the records of the record sets deleted.
+func (p *Provider) CleanupStaleChallenges(ctx context.Context, zone string, olderThan time.Duration) ([]libdns.Record, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.CleanupStaleChallenges(ctx, zone, olderThan)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	staleRecordSets, err := p.listStaleChallenges(ctx, zone, time.Now().Add(-olderThan))
+	if err != nil {
+		return nil, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+
+	var deletedRecords []libdns.Record
+	for _, recordSet := range staleRecordSets {
+		name := stringValue(recordSet.Name)
+		_, err := p.DeleteRecordsWithOptions(ctx, zone, []libdns.Record{
+			libdns.RR{Name: name, Type: "TXT"},
+		}, DeleteOptions{
+			ETags: map[string]string{name + "/TXT": stringValue(recordSet.Etag)},
+		})
+		var modifiedError *ModifiedError
+		if errors.As(err, &modifiedError) {
+			continue
+		}
+		if err != nil {
+			return deletedRecords, err
+		}
+		records, err := convertAzureRecordSetsToLibdnsRecords([]*armdns.RecordSet{recordSet})
+		if err != nil {
+			return deletedRecords, wrapCorrelationID(err, correlationID)
+		}
+		deletedRecords = append(deletedRecords, records...)
+	}
+
+	return deletedRecords, nil
+}
+
+// listStaleChallenges lists the TXT record sets for ACME DNS challenges in the zone last written by the provider before the threshold.
+func (p *Provider) listStaleChallenges(ctx context.Context, zone string, threshold time.Time) ([]*armdns.RecordSet, error) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return nil, err
+	}
+
+	var staleRecordSets []*armdns.RecordSet
+	err := p.retryOnAuthenticationError(func() error {
+		staleRecordSets = nil
+		more, nextPage := p.newRecordSetPager(zone, "TXT", armdns.RecordTypeTXT)
+		for more() {
+			recordSets, err := nextPage(ctx)
+			if err != nil {
+				return err
+			}
+			for _, recordSet := range recordSets {
+				if !isChallengeRecordSet(stringValue(recordSet.Name), "TXT") {
+					continue
+				}
+				writtenAt, ok := getRecordSetWrittenAt(recordSet)
+				if ok && writtenAt.Before(threshold) {
+					staleRecordSets = append(staleRecordSets, recordSet)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return staleRecordSets, nil
+}
+
+// isChallengeRecordSet reports whether the record set of the name relative to the zone and the type is for ACME DNS challenges.
+func isChallengeRecordSet(name string, typeName string) bool {
+	name = strings.ToLower(name)
+	return strings.EqualFold(typeName, "TXT") && (name == acmeChallengeLabel || strings.HasPrefix(name, acmeChallengeLabel+"."))
+}
+
+// stampWrittenAt stamps the current time on the properties of a record set to be written.
+func stampWrittenAt(properties *armdns.RecordSetProperties) {
+	setMetadata(properties, writtenAtMetadataKey, time.Now().UTC().Format(time.RFC3339))
+}
+
+// getRecordSetWrittenAt returns the time stamped on the record set when the provider last wrote it, if any.
+func getRecordSetWrittenAt(recordSet *armdns.RecordSet) (time.Time, bool) {
+	writtenAt, err := time.Parse(time.RFC3339, getMetadata(recordSet, writtenAtMetadataKey))
+	return writtenAt, err == nil
+}
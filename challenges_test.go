@@ -0,0 +1,94 @@
+package azure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func getChallengeTXTRecordSet(name string, etag string, metadata map[string]*string) *armdns.RecordSet {
+	return &armdns.RecordSet{
+		Name: to.Ptr(name),
+		Type: to.Ptr("Microsoft.Network/dnszones/TXT"),
+		Etag: to.Ptr(etag),
+		Properties: &armdns.RecordSetProperties{
+			TTL:        to.Ptr[int64](30),
+			TxtRecords: []*armdns.TxtRecord{{Value: []*string{to.Ptr("TOKEN")}}},
+			Metadata:   metadata,
+		},
+	}
+}
+
+func Test_CleanupStaleChallenges(t *testing.T) {
+	stale := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	fresh := time.Now().UTC().Format(time.RFC3339)
+	zone := newFakeZone(
+		getChallengeTXTRecordSet("_acme-challenge", "ETAG_STALE", map[string]*string{"Libdns_written_at": to.Ptr(stale)}),
+		getChallengeTXTRecordSet("_acme-challenge.www", "ETAG_FRESH", map[string]*string{"libdns_written_at": to.Ptr(fresh)}),
+		getChallengeTXTRecordSet("_acme-challenge.api", "ETAG_UNSTAMPED", nil),
+		getChallengeTXTRecordSet("_acme-challenge.mail", "ETAG_STALE_MAIL", map[string]*string{"libdns_written_at": to.Ptr(stale)}),
+		getChallengeTXTRecordSet("record-txt", "ETAG_TXT", map[string]*string{"libdns_written_at": to.Ptr(stale)}),
+	)
+	fakeRecordSetsServer := zone.server()
+	newListByTypePager := fakeRecordSetsServer.NewListByTypePager
+	fakeRecordSetsServer.NewListByTypePager = func(resourceGroupName string, zoneName string, recordType armdns.RecordType, options *armdns.RecordSetsClientListByTypeOptions) (resp azfake.PagerResponder[armdns.RecordSetsClientListByTypeResponse]) {
+		resp = newListByTypePager(resourceGroupName, zoneName, recordType, options)
+		// The record set is written again once it has been listed
+		zone.get("_acme-challenge.mail", "TXT").Etag = to.Ptr("ETAG_MODIFIED")
+		return
+	}
+	provider := getFakeProviderWithServer(fakeRecordSetsServer)
+	got, err := provider.CleanupStaleChallenges(context.TODO(), "example.com.", time.Hour)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if diff := cmp.Diff(zone.keys, []string{"_acme-challenge.www/TXT", "_acme-challenge.api/TXT", "_acme-challenge.mail/TXT", "record-txt/TXT"}); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+	want := []libdns.Record{
+		libdns.TXT{Name: "_acme-challenge", TTL: time.Duration(30) * time.Second, Text: "TOKEN"},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
+
+func Test_stampWrittenAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		record    libdns.Record
+		stamp     bool
+		wantStamp bool
+	}{
+		{name: "name=_acme-challenge", record: libdns.TXT{Name: "_acme-challenge", Text: "TOKEN", TTL: time.Minute}, stamp: true, wantStamp: true},
+		{name: "name=_acme-challenge.www", record: libdns.TXT{Name: "_acme-challenge.www.example.com.", Text: "TOKEN", TTL: time.Minute}, stamp: true, wantStamp: true},
+		{name: "name=record-new", record: libdns.TXT{Name: "record-new", Text: "TOKEN", TTL: time.Minute}, stamp: true, wantStamp: false},
+		{name: "name=_acme-challenge,stamp=false", record: libdns.TXT{Name: "_acme-challenge", Text: "TOKEN", TTL: time.Minute}, stamp: false, wantStamp: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := newFakeZone(getOwnedTXTRecordSet(""))
+			provider := getFakeProviderWithZone(zone)
+			provider.StampChallenges = tt.stamp
+			if _, err := provider.createRecords(context.TODO(), "example.com.", []libdns.Record{tt.record}); err != nil {
+				t.Fatalf("%s", err)
+			}
+			if len(zone.written) != 1 {
+				t.Fatalf("got: %d record sets, want: 1", len(zone.written))
+			}
+			writtenAt, ok := getRecordSetWrittenAt(&zone.written[0])
+			if ok != tt.wantStamp {
+				t.Fatalf("got: %v, want: %v", ok, tt.wantStamp)
+			}
+			if ok && time.Since(writtenAt) > time.Minute {
+				t.Errorf("got: %v", writtenAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package azure
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+)
+
+// ZoneToken captures the state of a zone cheaply, so that HasZoneChanged can detect changes to the zone without listing it.
+// It can be serialized as JSON to be kept across processes.
+type ZoneToken struct {
+	// Zone ETag is the ETag of the zone, which changes when the zone itself is updated.
+	ZoneETag string `json:"zone_etag,omitempty"`
+
+	// Number Of Record Sets is the number of record sets in the zone, which changes when record sets are created or deleted.
+	NumberOfRecordSets int64 `json:"number_of_record_sets,omitempty"`
+
+	// SOA Serial is the serial number of the SOA record of the zone.
+	SOASerial int64 `json:"soa_serial,omitempty"`
+
+	// Record Set ETags are the ETags of the sampled record sets keyed by "name/type", e.g. "_acme-challenge/TXT".
+	// The ETag of a record set that does not exist is empty.
+	RecordSetETags map[string]string `json:"record_set_etags,omitempty"`
+}
+
+// GetZoneToken captures the state of the zone to detect changes later with HasZoneChanged.
+// The record sets in sample are sampled to detect changes to their values, which are not reflected in the zone itself.
+// It takes a request for the zone, a request for the SOA record set, and a request for each sampled record set.
+func (p *Provider) GetZoneToken(ctx context.Context, zone string, sample []RecordSetScope) (ZoneToken, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.GetZoneToken(ctx, zone, sample)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	token, err := p.getZoneToken(ctx, zone, sample)
+	if err != nil {
+		return ZoneToken{}, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+
+	return token, nil
+}
+
+// HasZoneChanged reports whether the zone has changed since the token was captured, and returns a new token
+// sampling the same record sets. Changes to the values of record sets that are not sampled are detected only
+// if record sets are created or deleted, or the SOA serial number is updated, at the same time.
+func (p *Provider) HasZoneChanged(ctx context.Context, zone string, since ZoneToken) (bool, ZoneToken, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.HasZoneChanged(ctx, zone, since)
+	}
+
+	var sample []RecordSetScope
+	for key := range since.RecordSetETags {
+		name, typeName, _ := strings.Cut(key, "/")
+		sample = append(sample, RecordSetScope{
+			Name: name,
+			Type: typeName,
+		})
+	}
+
+	token, err := p.GetZoneToken(ctx, zone, sample)
+	if err != nil {
+		return false, ZoneToken{}, err
+	}
+
+	return !token.equal(since), token, nil
+}
+
+// getZoneToken captures the state of the zone with the sampled record sets.
+func (p *Provider) getZoneToken(ctx context.Context, zone string, sample []RecordSetScope) (ZoneToken, error) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return ZoneToken{}, err
+	}
+
+	var token ZoneToken
+	err := p.retryOnAuthenticationError(func() error {
+		token = ZoneToken{
+			RecordSetETags: map[string]string{},
+		}
+
+		zoneResponse, err := p.client.zonesClient.Get(
+			ctx,
+			p.ResourceGroupName,
+			strings.TrimSuffix(zone, "."),
+			&armdns.ZonesClientGetOptions{},
+		)
+		if err != nil {
+			return err
+		}
+		token.ZoneETag = stringValue(zoneResponse.Etag)
+		if zoneResponse.Properties != nil && zoneResponse.Properties.NumberOfRecordSets != nil {
+			token.NumberOfRecordSets = *zoneResponse.Properties.NumberOfRecordSets
+		}
+
+		soaResponse, err := p.client.azureClient.Get(
+			ctx,
+			p.ResourceGroupName,
+			strings.TrimSuffix(zone, "."),
+			"@",
+			armdns.RecordTypeSOA,
+			&armdns.RecordSetsClientGetOptions{},
+		)
+		if err != nil {
+			return err
+		}
+		if soaResponse.Properties != nil && soaResponse.Properties.SoaRecord != nil && soaResponse.Properties.SoaRecord.SerialNumber != nil {
+			token.SOASerial = *soaResponse.Properties.SoaRecord.SerialNumber
+		}
+
+		for _, scope := range sample {
+			recordType, err := convertStringToRecordType(scope.Type)
+			if err != nil {
+				return err
+			}
+			response, err := p.client.azureClient.Get(
+				ctx,
+				p.ResourceGroupName,
+				strings.TrimSuffix(zone, "."),
+				p.recordSetName(scope.Name, zone),
+				recordType,
+				&armdns.RecordSetsClientGetOptions{},
+			)
+			if err != nil && !isNotFoundError(err) {
+				return err
+			}
+			token.RecordSetETags[p.recordSetName(scope.Name, zone)+"/"+scope.Type] = stringValue(response.Etag)
+		}
+		return nil
+	})
+	if err != nil {
+		return ZoneToken{}, err
+	}
+
+	return token, nil
+}
+
+// equal reports whether the tokens capture the same state.
+func (t ZoneToken) equal(other ZoneToken) bool {
+	if t.ZoneETag != other.ZoneETag || t.NumberOfRecordSets != other.NumberOfRecordSets || t.SOASerial != other.SOASerial {
+		return false
+	}
+	if len(t.RecordSetETags) != len(other.RecordSetETags) {
+		return false
+	}
+	for key, etag := range t.RecordSetETags {
+		if otherETag, ok := other.RecordSetETags[key]; !ok || otherETag != etag {
+			return false
+		}
+	}
+	return true
+}
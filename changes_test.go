@@ -0,0 +1,65 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_HasZoneChanged(t *testing.T) {
+	etag := "ETAG_TXT"
+	fakeRecordSetsServer := getFakeRecordSetsServer()
+	get := fakeRecordSetsServer.Get
+	fakeRecordSetsServer.Get = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientGetOptions) (resp azfake.Responder[armdns.RecordSetsClientGetResponse], errResp azfake.ErrorResponder) {
+		if recordType == armdns.RecordTypeTXT {
+			resp.SetResponse(http.StatusOK, armdns.RecordSetsClientGetResponse{
+				RecordSet: armdns.RecordSet{Etag: to.Ptr(etag)},
+			}, nil)
+			return
+		}
+		return get(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, options)
+	}
+	provider := getFakeProviderWithServer(fakeRecordSetsServer)
+
+	token, err := provider.GetZoneToken(context.TODO(), "example.com.", []RecordSetScope{
+		{Name: "record-txt", Type: "TXT"},
+		{Name: "record-missing", Type: "A"},
+	})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	want := ZoneToken{
+		ZoneETag:           "ETAG_ZONE",
+		NumberOfRecordSets: 12,
+		SOASerial:          1,
+		RecordSetETags: map[string]string{
+			"record-txt/TXT":   "ETAG_TXT",
+			"record-missing/A": "",
+		},
+	}
+	if diff := cmp.Diff(token, want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+
+	changed, token, err := provider.HasZoneChanged(context.TODO(), "example.com.", token)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if changed {
+		t.Errorf("got: true, want: false")
+	}
+
+	etag = "ETAG_TXT_UPDATED"
+	changed, _, err = provider.HasZoneChanged(context.TODO(), "example.com.", token)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if !changed {
+		t.Errorf("got: false, want: true")
+	}
+}
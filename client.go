@@ -2,184 +2,1657 @@ package azure
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/privatedns/armprivatedns"
+	"github.com/google/uuid"
 
 	"github.com/libdns/libdns"
 )
 
+const (
+	// moduleName and moduleVersion identify this package in the telemetry of requests sent by the raw Resource Manager client.
+	moduleName    = "github.com/libdns/azure"
+	moduleVersion = "v0.0.0"
+)
+
+// defaultProvisioningPollInterval is the interval to poll the provisioning state of a record set if not specified.
+const defaultProvisioningPollInterval = 2 * time.Second
+
+// defaultConflictRetries is the number of times a write to a record set modified concurrently is retried if not specified.
+const defaultConflictRetries = 3
+
+// defaultMaxIdleConns, defaultMaxIdleConnsPerHost, and defaultIdleConnTimeout are the settings of the connections
+// of the transports built by the provider if not specified, which are those of the default transport of the Azure SDK.
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 10
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// conflictRetryDelay is the maximum delay before retrying a write to a record set modified concurrently.
+const conflictRetryDelay = 100 * time.Millisecond
+
+// recordSetsAPI is the part of the record sets client of Azure DNS used by the provider.
+// It is implemented by *armdns.RecordSetsClient, and by privateRecordSetsClient for private zones.
+type recordSetsAPI interface {
+	Get(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientGetOptions) (armdns.RecordSetsClientGetResponse, error)
+	CreateOrUpdate(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (armdns.RecordSetsClientCreateOrUpdateResponse, error)
+	Delete(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientDeleteOptions) (armdns.RecordSetsClientDeleteResponse, error)
+	NewListByDNSZonePager(resourceGroupName string, zoneName string, options *armdns.RecordSetsClientListByDNSZoneOptions) *runtime.Pager[armdns.RecordSetsClientListByDNSZoneResponse]
+	NewListByTypePager(resourceGroupName string, zoneName string, recordType armdns.RecordType, options *armdns.RecordSetsClientListByTypeOptions) *runtime.Pager[armdns.RecordSetsClientListByTypeResponse]
+}
+
+// zonesAPI is the part of the zones client of Azure DNS used by the provider.
+// It is implemented by *armdns.ZonesClient, and by privateZonesClient for private zones.
+type zonesAPI interface {
+	Get(ctx context.Context, resourceGroupName string, zoneName string, options *armdns.ZonesClientGetOptions) (armdns.ZonesClientGetResponse, error)
+	NewListPager(options *armdns.ZonesClientListOptions) *runtime.Pager[armdns.ZonesClientListResponse]
+	NewListByResourceGroupPager(resourceGroupName string, options *armdns.ZonesClientListByResourceGroupOptions) *runtime.Pager[armdns.ZonesClientListByResourceGroupResponse]
+}
+
 // Client is an abstraction of RecordSetsClient for Azure DNS
 type Client struct {
-	azureClient *armdns.RecordSetsClient
-	mutex       sync.Mutex
+	azureClient   recordSetsAPI
+	zonesClient   zonesAPI
+	armClient     *arm.Client
+	credential    azcore.TokenCredential
+	clientOptions *arm.ClientOptions
+	mutex         sync.Mutex
+
+	// registry is the registry whose shared credential is set, so that the provider is keyed again on rotating its secret.
+	registry *Registry
+
+	tokenCredential azcore.TokenCredential
+	diagnostics     credentialDiagnostics
+	cloud           cloudDetection
+
+	metrics     *providerMetrics
+	metricsOnce sync.Once
+
+	// transports are the HTTP clients built by the provider, whose idle connections are closed when the client is reset.
+	// They are guarded by their own mutex, since a refreshing credential builds them without locking the client.
+	transports      []*http.Client
+	transportsMutex sync.Mutex
+
+	// rebuildMutex keeps the clients from being rebuilt on an authentication error
+	// while the record sets written concurrently by the same call are using them.
+	rebuildMutex sync.RWMutex
+
+	// hookMutex keeps Before Write from being called concurrently by the record sets written concurrently by the same call.
+	hookMutex sync.Mutex
 }
 
 // setupClient invokes authentication and store client to the provider instance.
 func (p *Provider) setupClient() error {
 	if p.client.azureClient == nil {
-		credentials := []azcore.TokenCredential{}
+		if p.SDKLogLevel != "" {
+			if err := setSDKLogListener(p.getLogger(), p.SDKLogLevel); err != nil {
+				return err
+			}
+		}
 
-		// If Tenant ID, Client ID, or Client Secret is specified, attempt to authenticate using a client secret.
-		// If not, attempt to authenticate using managed identity.
-		// Authentication using a client secret is prioritized over using managed identiry to keep backward compatibility.
-		if p.TenantId != "" || p.ClientId != "" || p.ClientSecret != "" {
-			clientCredential, err := azidentity.NewClientSecretCredential(p.TenantId, p.ClientId, p.ClientSecret, nil)
+		tokenCredential, err := p.newTokenCredential()
+		if err != nil {
+			return err
+		}
+		if p.TokenRefreshOffset > bearerTokenRefreshWindow {
+			refreshing := &refreshingCredential{credential: tokenCredential, offset: p.TokenRefreshOffset}
+			// A credential given explicitly cannot be rebuilt, so its cached tokens are renewed only as early as it allows
+			if p.client.credential == nil {
+				refreshing.newCredential = p.newTokenCredential
+			}
+			tokenCredential = refreshing
+		}
+		p.client.tokenCredential = tokenCredential
+		clientOptions, err := p.getClientOptions()
+		if err != nil {
+			return err
+		}
+		if p.private {
+			clientFactory, err := armprivatedns.NewClientFactory(p.SubscriptionId, tokenCredential, clientOptions)
 			if err != nil {
 				return err
 			}
-			credentials = append(credentials, clientCredential)
+			p.client.azureClient = privateRecordSetsClient{client: clientFactory.NewRecordSetsClient()}
+			p.client.zonesClient = privateZonesClient{client: clientFactory.NewPrivateZonesClient()}
 		} else {
-			managedIdentityCredential, err := azidentity.NewManagedIdentityCredential(nil)
+			clientFactory, err := armdns.NewClientFactory(p.SubscriptionId, tokenCredential, clientOptions)
 			if err != nil {
 				return err
 			}
-			credentials = append(credentials, managedIdentityCredential)
+			p.client.azureClient = clientFactory.NewRecordSetsClient()
+			p.client.zonesClient = clientFactory.NewZonesClient()
 		}
+		armClient, err := arm.NewClient(moduleName, moduleVersion, tokenCredential, clientOptions)
+		if err != nil {
+			return err
+		}
+		p.client.armClient = armClient
+	}
+
+	return nil
+}
+
+// newTokenCredential builds the chained credential of the provider, recording which credential in the chain acquires the tokens.
+func (p *Provider) newTokenCredential() (azcore.TokenCredential, error) {
+	credentials := []azcore.TokenCredential{}
+
+	// If a credential is given explicitly, use it as is.
+	// If not, build one from the fields of the provider.
+	if p.client.credential != nil {
+		credentials = append(credentials, p.client.credential)
+	} else {
+		var err error
+		if credentials, err = p.newCredentials(); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, credential := range credentials {
+		credentials[i] = &diagnosingCredential{
+			credential:  credential,
+			diagnostics: &p.client.diagnostics,
+			logger:      p.getLogger(),
+		}
+	}
+	return azidentity.NewChainedTokenCredential(credentials, nil)
+}
+
+// newCredentials builds the chain of credentials from the fields of the provider.
+// With Fallback To Developer Credentials, the managed identity is followed by the credentials from the environment variables
+// and Azure CLI, and is skipped if Azure Instance Metadata Service does not respond to a brief probe, e.g. on developer machines,
+// so that the chain falls through to the other credentials without waiting for the managed identity to time out.
+func (p *Provider) newCredentials() ([]azcore.TokenCredential, error) {
+	if p.StrictCredentials {
+		if err := p.checkStrictCredentials(); err != nil {
+			return nil, err
+		}
+	}
+	credential, err := p.newCredential()
+	if err != nil {
+		return nil, err
+	}
+	if !p.FallbackToDeveloperCredentials || p.StrictCredentials || p.TenantId != "" || p.ClientId != "" || p.ClientSecret != "" {
+		return []azcore.TokenCredential{credential}, nil
+	}
 
-		chainedTokenCredential, err := azidentity.NewChainedTokenCredential(credentials, nil)
+	var credentials []azcore.TokenCredential
+	if !usesIMDS() || probeIMDS(imdsProbeTimeout) {
+		credentials = append(credentials, credential)
+	} else {
+		p.getLogger().Debug("skipped the managed identity since Azure Instance Metadata Service is unavailable")
+	}
+
+	credentialOptions, err := p.getCredentialOptions()
+	if err != nil {
+		return nil, err
+	}
+	// The environment variables may not be set, in which case the credential is skipped
+	if environmentCredential, err := azidentity.NewEnvironmentCredential(&azidentity.EnvironmentCredentialOptions{
+		ClientOptions:            credentialOptions,
+		DisableInstanceDiscovery: p.DisableInstanceDiscovery,
+	}); err == nil {
+		credentials = append(credentials, environmentCredential)
+	}
+	cliCredential, err := azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{
+		AdditionallyAllowedTenants: p.AdditionallyAllowedTenants,
+	})
+	if err != nil {
+		return nil, err
+	}
+	credentials = append(credentials, cliCredential)
+
+	return credentials, nil
+}
+
+// checkStrictCredentials throws an error if any of Tenant ID, Client ID, and Client Secret is missing with Strict Credentials,
+// instead of authenticating using a managed identity or with an incomplete service principal.
+func (p *Provider) checkStrictCredentials() error {
+	var missing []string
+	for _, field := range []struct{ name, value string }{
+		{"TenantId", p.TenantId},
+		{"ClientId", p.ClientId},
+		{"ClientSecret", p.ClientSecret},
+	} {
+		if field.value == "" {
+			missing = append(missing, field.name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return &CredentialError{
+		Reason: "the service principal is not fully configured",
+		Hint:   "set TenantId, ClientId, and ClientSecret, or unset StrictCredentials to authenticate using a managed identity",
+		Err:    fmt.Errorf("%v not set", strings.Join(missing, ", ")),
+	}
+}
+
+// newCredential builds a credential from the fields of the provider.
+// If Tenant ID, Client ID, or Client Secret is specified, attempt to authenticate using a client secret.
+// If not, attempt to authenticate using managed identity.
+// Authentication using a client secret is prioritized over using managed identiry to keep backward compatibility.
+func (p *Provider) newCredential() (azcore.TokenCredential, error) {
+	credentialOptions, err := p.getCredentialOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.TenantId != "" || p.ClientId != "" || p.ClientSecret != "" {
+		return azidentity.NewClientSecretCredential(p.TenantId, p.ClientId, p.ClientSecret, p.getClientSecretCredentialOptions(credentialOptions))
+	}
+	managedIdentityCredentialOptions, err := p.getManagedIdentityCredentialOptions(credentialOptions)
+	if err != nil {
+		return nil, err
+	}
+	return azidentity.NewManagedIdentityCredential(managedIdentityCredentialOptions)
+}
+
+// getClientSecretCredentialOptions builds options for the client secret credential from the provider settings.
+func (p *Provider) getClientSecretCredentialOptions(credentialOptions azcore.ClientOptions) *azidentity.ClientSecretCredentialOptions {
+	return &azidentity.ClientSecretCredentialOptions{
+		ClientOptions:              credentialOptions,
+		AdditionallyAllowedTenants: p.AdditionallyAllowedTenants,
+		DisableInstanceDiscovery:   p.DisableInstanceDiscovery,
+	}
+}
+
+// getManagedIdentityCredentialOptions builds options for the managed identity credential from the provider settings.
+// The system-assigned managed identity is used unless a user-assigned managed identity is selected.
+func (p *Provider) getManagedIdentityCredentialOptions(credentialOptions azcore.ClientOptions) (*azidentity.ManagedIdentityCredentialOptions, error) {
+	managedIdentityCredentialOptions := &azidentity.ManagedIdentityCredentialOptions{
+		ClientOptions: credentialOptions,
+	}
+
+	switch {
+	case p.ManagedIdentityClientId != "" && p.ManagedIdentityResourceId != "":
+		return nil, errors.New("the managed identity cannot be selected by both the client ID and the resource ID")
+	case p.ManagedIdentityClientId != "":
+		managedIdentityCredentialOptions.ID = azidentity.ClientID(p.ManagedIdentityClientId)
+	case p.ManagedIdentityResourceId != "":
+		managedIdentityCredentialOptions.ID = azidentity.ResourceID(p.ManagedIdentityResourceId)
+	}
+
+	return managedIdentityCredentialOptions, nil
+}
+
+// getClientOptions builds options for the Azure Resource Manager clients from the provider settings,
+// starting from Client Options if set and applying the individual settings on top of them.
+func (p *Provider) getClientOptions() (*arm.ClientOptions, error) {
+	if err := p.validateZoneResourceIDs(); err != nil {
+		return nil, err
+	}
+	if err := p.validateZoneOptions(); err != nil {
+		return nil, err
+	}
+
+	clientOptions := arm.ClientOptions{}
+	if p.ClientOptions != nil {
+		clientOptions = *p.ClientOptions
+	}
+	if p.client.clientOptions != nil {
+		clientOptions = *p.client.clientOptions
+	}
+	if clientOptions.Cloud.ActiveDirectoryAuthorityHost == "" {
+		configuration, err := p.getCloud()
+		if err != nil {
+			return nil, err
+		}
+		if configuration != nil {
+			clientOptions.Cloud = *configuration
+		}
+	}
+
+	// Override the endpoint and the audience of Azure Resource Manager, e.g. to reach it through Private Link.
+	if p.ResourceManagerEndpoint != "" || p.ResourceManagerAudience != "" {
+		configuration := clientOptions.Cloud
+		if configuration.ActiveDirectoryAuthorityHost == "" {
+			configuration = cloud.AzurePublic
+		}
+		services := map[cloud.ServiceName]cloud.ServiceConfiguration{}
+		for name, service := range configuration.Services {
+			services[name] = service
+		}
+		resourceManager := services[cloud.ResourceManager]
+		if p.ResourceManagerEndpoint != "" {
+			endpoint, err := url.Parse(p.ResourceManagerEndpoint)
+			if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+				return nil, fmt.Errorf("the resource manager endpoint %v cannot be interpreted", p.ResourceManagerEndpoint)
+			}
+			resourceManager.Endpoint = p.ResourceManagerEndpoint
+		}
+		if p.ResourceManagerAudience != "" {
+			resourceManager.Audience = p.ResourceManagerAudience
+		}
+		services[cloud.ResourceManager] = resourceManager
+		configuration.Services = services
+		clientOptions.Cloud = configuration
+	}
+
+	if clientOptions.Transport == nil {
+		transport, err := p.newTransport(p.ResourceManagerServerName)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.Transport = transport
+	}
+
+	perCallPolicies := append([]policy.Policy{}, clientOptions.PerCallPolicies...)
+	clientOptions.PerCallPolicies = append(perCallPolicies, correlationIDPolicy{}, statsPolicy{stats: &p.stats})
+	perRetryPolicies := append([]policy.Policy{}, clientOptions.PerRetryPolicies...)
+	clientOptions.PerRetryPolicies = append(perRetryPolicies, statsAttemptPolicy{stats: &p.stats})
+
+	if metrics := p.getMetrics(); metrics != nil {
+		clientOptions.PerCallPolicies = append(clientOptions.PerCallPolicies, metricsPolicy{metrics: metrics})
+		perRetryPolicies := append([]policy.Policy{}, clientOptions.PerRetryPolicies...)
+		clientOptions.PerRetryPolicies = append(perRetryPolicies, attemptCountingPolicy{})
+	}
+
+	if p.Debug {
+		perRetryPolicies := append([]policy.Policy{}, clientOptions.PerRetryPolicies...)
+		clientOptions.PerRetryPolicies = append(perRetryPolicies, debugLoggingPolicy{logger: p.getLogger()})
+	}
+
+	return &clientOptions, nil
+}
+
+// getCredentialOptions builds options for the credentials from the provider settings.
+// The cloud configuration and the transport of Client Options, if set, are used to reach Microsoft Entra ID as well.
+func (p *Provider) getCredentialOptions() (azcore.ClientOptions, error) {
+	credentialOptions := azcore.ClientOptions{}
+	if p.ClientOptions != nil {
+		credentialOptions.Cloud = p.ClientOptions.Cloud
+		credentialOptions.Transport = p.ClientOptions.Transport
+	}
+	if credentialOptions.Cloud.ActiveDirectoryAuthorityHost == "" {
+		configuration, err := p.getCloud()
+		if err != nil {
+			return azcore.ClientOptions{}, err
+		}
+		if configuration != nil {
+			credentialOptions.Cloud = *configuration
+		}
+	}
+
+	if credentialOptions.Transport == nil {
+		transport, err := p.newTransport("")
+		if err != nil {
+			return azcore.ClientOptions{}, err
+		}
+		credentialOptions.Transport = transport
+	}
+
+	if p.Debug {
+		credentialOptions.PerRetryPolicies = []policy.Policy{debugLoggingPolicy{logger: p.getLogger()}}
+	}
+
+	return credentialOptions, nil
+}
+
+// imdsProbeURL is the endpoint of Azure Instance Metadata Service probed before using the managed identity.
+var imdsProbeURL = "http://169.254.169.254/metadata/instance?api-version=2021-02-01"
+
+// imdsProbeTimeout is how long to wait for Azure Instance Metadata Service to respond to the probe.
+const imdsProbeTimeout = time.Second
+
+// usesIMDS reports whether the managed identity is provided by Azure Instance Metadata Service,
+// rather than by the local endpoint of a service such as App Service or Azure Arc given by the environment variables.
+func usesIMDS() bool {
+	for _, name := range []string{"IDENTITY_ENDPOINT", "MSI_ENDPOINT"} {
+		if _, ok := os.LookupEnv(name); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// probeIMDS reports whether Azure Instance Metadata Service responds within the timeout.
+// Any response counts, since only the reachability of the endpoint matters.
+func probeIMDS(timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsProbeURL, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Metadata", "true")
+
+	// Azure Instance Metadata Service is reachable only directly
+	client := &http.Client{Transport: &http.Transport{Proxy: nil}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
+
+// isManagedIdentityHost reports whether the host may serve a managed identity endpoint, which is reachable only directly:
+// the link-local address of Azure Instance Metadata Service, or the local host such as the endpoint of the Azure Arc agent
+// at "localhost:40342" and the endpoints of App Service and Azure Container Apps.
+func isManagedIdentityHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsLinkLocalUnicast())
+}
+
+// getLogger returns the logger of the provider, or the default logger if not set.
+func (p *Provider) getLogger() *slog.Logger {
+	if p.Logger != nil {
+		return p.Logger
+	}
+	return slog.Default()
+}
+
+// newTransport builds an HTTP client for the Azure SDK from the provider settings.
+// It returns nil if no customization is required, so that the default transport of the Azure SDK is used.
+func (p *Provider) newTransport(serverName string) (policy.Transporter, error) {
+	if serverName == "" && p.ProxyURL == "" && p.TLSRootCAs == nil && len(p.TLSRootCAFiles) == 0 && p.TLSMinVersion == "" && !p.tunesConnections() {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if err := p.tuneConnections(transport); err != nil {
+		return nil, err
+	}
+
+	// Trust additional root CAs, e.g. when the traffic is inspected by a proxy with a private CA.
+	if p.TLSRootCAs != nil || len(p.TLSRootCAFiles) > 0 {
+		rootCAs, err := p.getRootCAs()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig.RootCAs = rootCAs
+	}
+
+	switch p.TLSMinVersion {
+	case "":
+	case "1.2":
+		transport.TLSClientConfig.MinVersion = tls.VersionTLS12
+	case "1.3":
+		transport.TLSClientConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("the TLS version %v cannot be interpreted", p.TLSMinVersion)
+	}
+
+	// Present and verify the server name that differs from the host to connect, e.g. when connecting to a private endpoint.
+	if serverName != "" {
+		transport.TLSClientConfig.ServerName = serverName
+	}
+
+	// Send requests through the proxy regardless of the environment variables,
+	// except for requests to the managed identity endpoints which are reachable only directly.
+	if p.ProxyURL != "" {
+		proxyURL, err := url.Parse(p.ProxyURL)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("the proxy URL %v cannot be interpreted", p.ProxyURL)
+		}
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if isManagedIdentityHost(req.URL.Hostname()) {
+				return nil, nil
+			}
+			return proxyURL, nil
+		}
+	}
+
+	client := &http.Client{
+		Transport: transport,
+	}
+	p.client.transportsMutex.Lock()
+	p.client.transports = append(p.client.transports, client)
+	p.client.transportsMutex.Unlock()
+
+	return client, nil
+}
+
+// tunesConnections reports whether any of the settings of the connections to the servers is set.
+func (p *Provider) tunesConnections() bool {
+	return p.MaxIdleConnsPerHost != 0 || p.MaxIdleConns != 0 || p.MaxConnsPerHost != 0 || p.IdleConnTimeout != 0 || p.DisableHTTP2
+}
+
+// tuneConnections applies the settings of the connections to the transport, on top of the defaults of the Azure SDK,
+// which keeps more idle connections to each server than the transport of the standard library.
+func (p *Provider) tuneConnections(transport *http.Transport) error {
+	for _, setting := range []struct {
+		name  string
+		value int64
+	}{
+		{"max idle connections per host", int64(p.MaxIdleConnsPerHost)},
+		{"max idle connections", int64(p.MaxIdleConns)},
+		{"max connections per host", int64(p.MaxConnsPerHost)},
+		{"idle connection timeout", int64(p.IdleConnTimeout)},
+	} {
+		if setting.value < 0 {
+			return fmt.Errorf("the %v %v cannot be interpreted", setting.name, setting.value)
+		}
+	}
+
+	transport.MaxIdleConns = defaultMaxIdleConns
+	transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	transport.IdleConnTimeout = defaultIdleConnTimeout
+	if p.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = p.MaxIdleConnsPerHost
+	}
+	if p.MaxIdleConns > 0 {
+		transport.MaxIdleConns = p.MaxIdleConns
+	}
+	if p.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = p.MaxConnsPerHost
+	}
+	if p.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = p.IdleConnTimeout
+	}
+	if p.DisableHTTP2 {
+		// A non-nil empty map disables the upgrade to HTTP/2 over TLS
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return nil
+}
+
+// getRootCAs builds the pool of root CAs from the provider settings.
+// The certificates in the files are added to the given pool, or to the system pool if not given.
+func (p *Provider) getRootCAs() (*x509.CertPool, error) {
+	var rootCAs *x509.CertPool
+	if p.TLSRootCAs != nil {
+		rootCAs = p.TLSRootCAs.Clone()
+	} else {
+		systemCertPool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, err
+		}
+		rootCAs = systemCertPool
+	}
+
+	for _, file := range p.TLSRootCAFiles {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("the root CA file %v contains no certificates", file)
+		}
+	}
+
+	return rootCAs, nil
+}
+
+// correlationIDKey is the context key for the correlation ID.
+type correlationIDKey struct{}
+
+// correlationIDPolicy is a pipeline policy that sends the correlation ID carried by the context with every request.
+type correlationIDPolicy struct{}
+
+// Do sets the correlation ID header before sending the request to the next policy.
+func (correlationIDPolicy) Do(req *policy.Request) (*http.Response, error) {
+	if correlationID, ok := CorrelationIDFromContext(req.Raw().Context()); ok {
+		req.Raw().Header.Set("x-ms-correlation-request-id", correlationID)
+	}
+	return req.Next()
+}
+
+// ensureCorrelationID returns the context carrying a correlation ID, generating a new one if ctx has none.
+func ensureCorrelationID(ctx context.Context) (context.Context, string) {
+	if correlationID, ok := CorrelationIDFromContext(ctx); ok {
+		return ctx, correlationID
+	}
+	correlationID := uuid.NewString()
+	return WithCorrelationID(ctx, correlationID), correlationID
+}
+
+// wrapCorrelationID annotates the error with the correlation ID to trace the failed requests.
+func wrapCorrelationID(err error, correlationID string) error {
+	return fmt.Errorf("%w (correlation ID: %v)", err, correlationID)
+}
+
+// resetClient discards the client so that it is set up again with the current settings on the next call.
+func (p *Provider) resetClient() {
+	p.client.azureClient = nil
+	p.client.zonesClient = nil
+	p.client.armClient = nil
+	p.client.tokenCredential = nil
+	p.releaseTransports()
+}
+
+// releaseTransports closes the idle connections of the HTTP clients built by the provider and forgets them.
+// Requests in flight are not interrupted, and their connections are closed once they become idle.
+func (p *Provider) releaseTransports() {
+	p.client.transportsMutex.Lock()
+	transports := p.client.transports
+	p.client.transports = nil
+	p.client.transportsMutex.Unlock()
+
+	for _, transport := range transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+// retryOnAuthenticationError calls fn, and if it fails due to an authentication error,
+// rebuilds the client and calls fn once again before giving up.
+// This recovers from stale tokens or credentials that have been updated since the client was set up.
+func (p *Provider) retryOnAuthenticationError(fn func() error) error {
+	call := func() error {
+		p.client.rebuildMutex.RLock()
+		defer p.client.rebuildMutex.RUnlock()
+		return fn()
+	}
+
+	err := call()
+	if !p.isRetriableAuthenticationError(err) {
+		return err
+	}
+
+	p.client.rebuildMutex.Lock()
+	p.resetClient()
+	err = p.setupClient()
+	p.client.rebuildMutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return call()
+}
+
+// isRetriableAuthenticationError reports whether the error is an authentication error that rebuilding the client may recover from.
+// With Strict Credentials, a token that cannot be acquired is not retried, since the same credentials fail again.
+func (p *Provider) isRetriableAuthenticationError(err error) bool {
+	if !isAuthenticationError(err) {
+		return false
+	}
+	var authenticationFailedError *azidentity.AuthenticationFailedError
+	return !p.StrictCredentials || !errors.As(err, &authenticationFailedError)
+}
+
+// readWithARMClient calls fn with the raw Resource Manager client without keeping the client of the provider locked
+// during the request, so that a slow read does not block the other calls of the provider. The client is set up with
+// the client locked, and rebuilt once on an authentication error as retryOnAuthenticationError does.
+func (p *Provider) readWithARMClient(fn func(armClient *arm.Client) error) error {
+	armClient, err := p.getARMClient(false)
+	if err != nil {
+		return err
+	}
+	err = fn(armClient)
+	if !p.isRetriableAuthenticationError(err) {
+		return err
+	}
+
+	if armClient, err = p.getARMClient(true); err != nil {
+		return err
+	}
+	return fn(armClient)
+}
+
+// getARMClient returns the raw Resource Manager client, setting it up if necessary, or rebuilding it if rebuild is set.
+func (p *Provider) getARMClient(rebuild bool) (*arm.Client, error) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if rebuild {
+		p.resetClient()
+	}
+	if err := p.setupClient(); err != nil {
+		return nil, err
+	}
+	return p.client.armClient, nil
+}
+
+// retryOnConflict calls fn, and calls it again up to Conflict Retries times while it fails with a precondition failure,
+// which means that the record set was modified by another writer between reading and writing it.
+// Since fn reads the record set again and merges the change into it, concurrent writers do not overwrite each other.
+// The retries are spread by a short random delay, so that the writers racing each other do not collide again.
+func (p *Provider) retryOnConflict(ctx context.Context, fn func() error) error {
+	retries := p.ConflictRetries
+	if retries == 0 {
+		retries = defaultConflictRetries
+	}
+
+	err := fn()
+	for i := 0; i < retries && isPreconditionFailedError(err); i++ {
+		p.getLogger().Debug("retrying a write to a record set modified concurrently", "attempt", i+1)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(rand.Int63n(int64(conflictRetryDelay)))):
+		}
+		err = fn()
+	}
+	return err
+}
+
+// getRecords gets all records in specified zone on Azure DNS.
+// If the type is not empty, only the records of the type are listed, filtered by Azure DNS.
+func (p *Provider) getRecords(ctx context.Context, zone string, typeName string) ([]libdns.Record, error) {
+	var records []libdns.Record
+	err := p.getRecordsFunc(ctx, zone, typeName, func(page []libdns.Record) error {
+		records = append(records, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// getRecordsFunc lists the records in specified zone on Azure DNS page by page, calling fn with the records of each page,
+// and stops at the first error returned by fn. If the type is not empty, only the records of the type are listed.
+// The client is locked only while fetching each page, so fn may call the provider.
+// If the first page fails due to an authentication error, the client is rebuilt and the page is fetched once again.
+func (p *Provider) getRecordsFunc(ctx context.Context, zone string, typeName string, fn func([]libdns.Record) error) error {
+	var recordType armdns.RecordType
+	if typeName != "" {
+		var err error
+		if recordType, err = convertStringToRecordType(strings.ToUpper(typeName)); err != nil {
+			return err
+		}
+	}
+
+	var more func() bool
+	var nextPage func(ctx context.Context) ([]*armdns.RecordSet, error)
+	fetched := false
+	fetchPage := func() ([]*armdns.RecordSet, error) {
+		p.client.mutex.Lock()
+		defer p.client.mutex.Unlock()
+
+		if err := p.setupClient(); err != nil {
+			return nil, err
+		}
+
+		var recordSets []*armdns.RecordSet
+		fetch := func() error {
+			if !fetched {
+				more, nextPage = p.newRecordSetPager(zone, typeName, recordType)
+			}
+			var err error
+			if recordSets, err = nextPage(ctx); err != nil {
+				return err
+			}
+			fetched = true
+			return nil
+		}
+
+		var err error
+		if !fetched {
+			err = p.retryOnAuthenticationError(fetch)
+		} else {
+			err = fetch()
+		}
+		return recordSets, err
+	}
+
+	for !fetched || more() {
+		recordSets, err := fetchPage()
 		if err != nil {
 			return err
 		}
-		clientFactory, err := armdns.NewClientFactory(p.SubscriptionId, chainedTokenCredential, nil)
+		records, err := p.convertListedRecordSets(zone, recordSets)
 		if err != nil {
 			return err
 		}
-		p.client.azureClient = clientFactory.NewRecordSetsClient()
+		if err := fn(records); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newRecordSetPager returns the functions to fetch the record sets in the zone page by page.
+// The record sets in Scoped Record Sets are got one by one as a single page instead of listing the zone.
+// The client must be set up before calling it.
+func (p *Provider) newRecordSetPager(zone string, typeName string, recordType armdns.RecordType) (func() bool, func(ctx context.Context) ([]*armdns.RecordSet, error)) {
+	if len(p.ScopedRecordSets) > 0 {
+		var scopes []RecordSetScope
+		for _, scope := range p.ScopedRecordSets {
+			if typeName == "" || strings.EqualFold(scope.Type, typeName) {
+				scopes = append(scopes, scope)
+			}
+		}
+		done := false
+		more := func() bool {
+			return !done
+		}
+		nextPage := func(ctx context.Context) ([]*armdns.RecordSet, error) {
+			recordSets, err := p.getRecordSets(ctx, zone, scopes)
+			if err != nil {
+				return nil, err
+			}
+			done = true
+			return recordSets, nil
+		}
+		return more, nextPage
+	}
+
+	if typeName != "" {
+		pager := p.client.azureClient.NewListByTypePager(
+			p.ResourceGroupName,
+			strings.TrimSuffix(zone, "."),
+			recordType,
+			&armdns.RecordSetsClientListByTypeOptions{})
+		return pager.More, func(ctx context.Context) ([]*armdns.RecordSet, error) {
+			page, err := pager.NextPage(ctx)
+			return page.Value, err
+		}
+	}
+
+	pager := p.client.azureClient.NewListByDNSZonePager(
+		p.ResourceGroupName,
+		strings.TrimSuffix(zone, "."),
+		&armdns.RecordSetsClientListByDNSZoneOptions{
+			Top:                 nil,
+			Recordsetnamesuffix: nil,
+		})
+	return pager.More, func(ctx context.Context) ([]*armdns.RecordSet, error) {
+		page, err := pager.NextPage(ctx)
+		return page.Value, err
+	}
+}
+
+// getRecordSets gets the record sets in the zone one by one without listing the zone.
+// Record sets that do not exist are skipped.
+func (p *Provider) getRecordSets(ctx context.Context, zone string, scopes []RecordSetScope) ([]*armdns.RecordSet, error) {
+	var recordSets []*armdns.RecordSet
+	for _, scope := range scopes {
+		recordType, err := convertStringToRecordType(scope.Type)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := p.client.azureClient.Get(
+			ctx,
+			p.ResourceGroupName,
+			strings.TrimSuffix(zone, "."),
+			p.recordSetName(scope.Name, zone),
+			recordType,
+			&armdns.RecordSetsClientGetOptions{},
+		)
+		if err != nil {
+			if isNotFoundError(err) {
+				continue
+			}
+			return nil, err
+		}
+		recordSets = append(recordSets, &resp.RecordSet)
+	}
+	return recordSets, nil
+}
+
+// getZoneInfo gets the details of the specified zone on Azure DNS.
+func (p *Provider) getZoneInfo(ctx context.Context, zone string) (ZoneInfo, error) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return ZoneInfo{}, err
+	}
+
+	var response armdns.ZonesClientGetResponse
+	err := p.retryOnAuthenticationError(func() error {
+		var err error
+		response, err = p.client.zonesClient.Get(
+			ctx,
+			p.ResourceGroupName,
+			strings.TrimSuffix(zone, "."),
+			&armdns.ZonesClientGetOptions{},
+		)
+		return err
+	})
+	if err != nil {
+		return ZoneInfo{}, err
+	}
+
+	return convertAzureZoneToZoneInfo(response.Zone), nil
+}
+
+// createRecords creates new records in the specified zone.
+// Records sharing the same name and type are written to a single record set,
+// and their values are appended to the record set if it already exists.
+// Values that already exist are taken as appended without being duplicated.
+func (p *Provider) createRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	records, err := decodeGenericRecords(records)
+	if err != nil {
+		return nil, err
+	}
+	records = p.applyDefaultTTL(zone, records)
+	if err := p.checkWritable(zone, records); err != nil {
+		return nil, err
+	}
+	if err := p.checkLimits(zone, records); err != nil {
+		return nil, err
+	}
+
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return nil, err
+	}
+
+	return p.writeRecordSets(ctx, zone, groupRecordsByRecordSet(records, zone, p.recordSetName), func(recordGroup []libdns.Record) ([]libdns.Record, error) {
+		err := p.retryOnConflict(ctx, func() error {
+			return p.retryOnAuthenticationError(func() error {
+				return p.appendRecordSet(ctx, zone, recordGroup)
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		var createdRecords []libdns.Record
+		for _, record := range recordGroup {
+			createdRecords = append(createdRecords, normalizeRecord(record, zone))
+		}
+		return createdRecords, nil
+	})
+}
+
+// updateRecords creates or updates records, either by updating existing record sets or creating new ones.
+// Records sharing the same name and type are written to a single record set, replacing all of its existing values.
+func (p *Provider) updateRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	records, err := decodeGenericRecords(records)
+	if err != nil {
+		return nil, err
+	}
+	records = p.applyDefaultTTL(zone, records)
+	if err := p.checkWritable(zone, records); err != nil {
+		return nil, err
+	}
+	if err := p.checkLimits(zone, records); err != nil {
+		return nil, err
+	}
+
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return nil, err
+	}
+
+	return p.writeRecordSets(ctx, zone, groupRecordsByRecordSet(records, zone, p.recordSetName), func(recordGroup []libdns.Record) ([]libdns.Record, error) {
+		recordSet, err := convertLibdnsRecordsToAzureRecordSet(recordGroup, p.TTLConflictPolicy)
+		if err != nil {
+			return nil, err
+		}
+		err = p.retryOnConflict(ctx, func() error {
+			return p.retryOnAuthenticationError(func() error {
+				return p.replaceRecordSet(ctx, zone, recordGroup, recordSet)
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		var updatedRecords []libdns.Record
+		for _, record := range recordGroup {
+			updatedRecords = append(updatedRecords, normalizeRecord(record, zone))
+		}
+		return updatedRecords, nil
+	})
+}
+
+// deleteRecords deletes records, handling the records sharing the same name and type together.
+// Records without a value delete the whole record set they belong to, going straight to the deletion without reading it
+// unless ownership is enforced. Otherwise, the record set is read once, only the values matching the records are removed from it,
+// and the record set is deleted if no values remain. Values that do not exist are ignored.
+// With the exact option, the record set is deleted only if its values are exactly the values of the records.
+// Record sets with an ETag in the options are deleted or updated only if they still have the ETag.
+func (p *Provider) deleteRecords(ctx context.Context, zone string, records []libdns.Record, options DeleteOptions) ([]libdns.Record, error) {
+	records, err := decodeGenericRecords(records)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkWritable(zone, records); err != nil {
+		return nil, err
+	}
+
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return nil, err
+	}
+
+	return p.writeRecordSets(ctx, zone, groupRecordsByRecordSet(records, zone, p.recordSetName), func(recordGroup []libdns.Record) ([]libdns.Record, error) {
+		rr := recordGroup[0].RR()
+		var ifMatch *string
+		if etag, ok := options.ETags[p.recordSetName(rr.Name, zone)+"/"+rr.Type]; ok {
+			ifMatch = to.Ptr(etag)
+		}
+
+		var deletedGroup []libdns.Record
+		deleteGroup := func() error {
+			return p.retryOnAuthenticationError(func() error {
+				var err error
+				switch {
+				case options.Exact:
+					deletedGroup, err = p.deleteExactRecordSet(ctx, zone, recordGroup, ifMatch)
+				case hasRecordWithoutValue(recordGroup) && p.enforcesOwnership():
+					deletedGroup, err = p.deleteOwnedRecordSet(ctx, zone, recordGroup, ifMatch)
+				case hasRecordWithoutValue(recordGroup):
+					deletedGroup, err = p.deleteRecordSet(ctx, zone, recordGroup, nil, ifMatch)
+				default:
+					deletedGroup, err = p.deleteRecordSetValues(ctx, zone, recordGroup, ifMatch)
+				}
+				return err
+			})
+		}
+		// A record set expected to have an ETag given by the caller is not read again, since the precondition would fail again
+		var err error
+		if ifMatch != nil {
+			err = deleteGroup()
+		} else {
+			err = p.retryOnConflict(ctx, deleteGroup)
+		}
+		if isPreconditionFailedError(err) {
+			return nil, &ModifiedError{Name: p.recordSetName(rr.Name, zone), Type: rr.Type, Err: err}
+		}
+		return deletedGroup, err
+	})
+}
+
+// deleteRecordSet deletes the record set that the records sharing the same name and type belong to, regardless of its values.
+// If ifMatch is set, the record set is deleted only if it has the ETag. The existing record set is passed to Before Write if read.
+// It returns the records as they are, since the values deleted are not read.
+func (p *Provider) deleteRecordSet(ctx context.Context, zone string, records []libdns.Record, existing *armdns.RecordSet, ifMatch *string) ([]libdns.Record, error) {
+	rr := records[0].RR()
+	if p.softDeletes(ctx, p.recordSetName(rr.Name, zone), rr.Type) {
+		return p.softDeleteRecordSet(ctx, zone, records, existing, ifMatch)
+	}
+	recordType, err := convertStringToRecordType(rr.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.beforeWrite(ctx, WriteOperationDelete, zone, rr.Name, rr.Type, existing, nil); err != nil {
+		return nil, err
+	}
+
+	_, err = p.client.azureClient.Delete(
+		ctx,
+		p.ResourceGroupName,
+		strings.TrimSuffix(zone, "."),
+		p.recordSetName(records[0].RR().Name, zone),
+		recordType,
+		&armdns.RecordSetsClientDeleteOptions{
+			IfMatch: ifMatch,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var deletedRecords []libdns.Record
+	for _, record := range records {
+		deletedRecords = append(deletedRecords, normalizeRecord(record, zone))
+	}
+	return deletedRecords, nil
+}
+
+// deleteOwnedRecordSet deletes the record set that the records sharing the same name and type belong to, regardless of its values,
+// after reading it to check that it is not owned by another owner. A record set that does not exist is ignored.
+func (p *Provider) deleteOwnedRecordSet(ctx context.Context, zone string, records []libdns.Record, ifMatch *string) ([]libdns.Record, error) {
+	existing, _, err := p.getExistingRecordSet(ctx, zone, records[0], ifMatch)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	// Prevent deleting a record set modified after it was read
+	return p.deleteRecordSet(ctx, zone, records, existing, existing.Etag)
+}
+
+// deleteExactRecordSet deletes the record set that the records sharing the same name and type belong to,
+// only if its values are exactly the values of the records regardless of the order, and it has the ETag if ifMatch is set.
+// A record set that does not exist is ignored.
+func (p *Provider) deleteExactRecordSet(ctx context.Context, zone string, records []libdns.Record, ifMatch *string) ([]libdns.Record, error) {
+	rr := records[0].RR()
+	if hasRecordWithoutValue(records) {
+		return nil, fmt.Errorf("the records %v %v must have values to be deleted exactly", rr.Name, rr.Type)
+	}
+
+	existing, existingRecords, err := p.getExistingRecordSet(ctx, zone, records[0], ifMatch)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	if !equalRecordValues(records, existingRecords) {
+		return nil, &ModifiedError{Name: p.recordSetName(rr.Name, zone), Type: rr.Type}
+	}
+
+	// Prevent deleting a record set modified after it was read
+	return p.deleteRecordSet(ctx, zone, records, existing, existing.Etag)
+}
+
+// deleteRecordSetValues removes the values of the records sharing the same name and type from the record set they belong to.
+// The record set is deleted if no values remain, or updated with the remaining values otherwise, keeping its TTL and metadata.
+// If ifMatch is set, the record set is modified only if it has the ETag. It returns the records whose values existed.
+func (p *Provider) deleteRecordSetValues(ctx context.Context, zone string, records []libdns.Record, ifMatch *string) ([]libdns.Record, error) {
+	rr := records[0].RR()
+	existing, existingRecords, err := p.getExistingRecordSet(ctx, zone, records[0], ifMatch)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	var deletedRecords []libdns.Record
+	deleted := map[string]bool{}
+	for _, record := range records {
+		data := recordData(record)
+		for _, existingRecord := range existingRecords {
+			if !deleted[data] && recordData(existingRecord) == data {
+				deleted[data] = true
+				deletedRecords = append(deletedRecords, normalizeRecord(record, zone))
+				break
+			}
+		}
+	}
+	if len(deletedRecords) == 0 {
+		return nil, nil
 	}
 
-	return nil
-}
+	var remainingRecords []libdns.Record
+	for _, existingRecord := range existingRecords {
+		if !deleted[recordData(existingRecord)] {
+			remainingRecords = append(remainingRecords, existingRecord)
+		}
+	}
 
-// getRecords gets all records in specified zone on Azure DNS.
-func (p *Provider) getRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
-	p.client.mutex.Lock()
-	defer p.client.mutex.Unlock()
+	// Prevent deleting or overwriting a record set modified after it was read
+	if len(remainingRecords) == 0 {
+		if _, err := p.deleteRecordSet(ctx, zone, records, existing, existing.Etag); err != nil {
+			return nil, err
+		}
+		return deletedRecords, nil
+	}
 
-	if err := p.setupClient(); err != nil {
+	remaining, err := convertLibdnsRecordsToAzureRecordSet(remainingRecords, TTLConflictPolicyFirst)
+	if err != nil {
+		return nil, err
+	}
+	before := p.snapshotRecordSet(existing)
+	properties := existing.Properties
+	clearRecordSetValues(properties)
+	if err := mergeRecordSetProperties(properties, remaining.Properties); err != nil {
+		return nil, err
+	}
+	after := armdns.RecordSet{Properties: properties}
+	if err := p.beforeWrite(ctx, WriteOperationDelete, zone, rr.Name, rr.Type, before, &after); err != nil {
+		return nil, err
+	}
+	if err := p.createOrUpdateRecordSet(ctx, zone, records[0], after, existing.Etag, nil); err != nil {
 		return nil, err
 	}
 
-	var recordSets []*armdns.RecordSet
+	return deletedRecords, nil
+}
 
-	pager := p.client.azureClient.NewListByDNSZonePager(
+// getExistingRecordSet gets the record set that the record belongs to, with its values as libdns records.
+// It returns nil if the record set does not exist, or an error if ifMatch is set and the record set does not have the ETag,
+// or if the record set is owned by another owner.
+func (p *Provider) getExistingRecordSet(ctx context.Context, zone string, record libdns.Record, ifMatch *string) (*armdns.RecordSet, []libdns.Record, error) {
+	rr := record.RR()
+	recordType, err := convertStringToRecordType(rr.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response, err := p.client.azureClient.Get(
+		ctx,
 		p.ResourceGroupName,
 		strings.TrimSuffix(zone, "."),
-		&armdns.RecordSetsClientListByDNSZoneOptions{
-			Top:                 nil,
-			Recordsetnamesuffix: nil,
-		})
-
-	for pager.More() {
-		page, err := pager.NextPage(ctx)
-		if err != nil {
-			return nil, err
+		p.recordSetName(rr.Name, zone),
+		recordType,
+		&armdns.RecordSetsClientGetOptions{},
+	)
+	if err != nil {
+		if isNotFoundError(err) {
+			return nil, nil, nil
 		}
-		recordSets = append(recordSets, page.Value...)
+		return nil, nil, err
 	}
 
-	records, _ := convertAzureRecordSetsToLibdnsRecords(recordSets)
-	return records, nil
+	if ifMatch != nil && stringValue(response.Etag) != *ifMatch {
+		return nil, nil, &ModifiedError{Name: p.recordSetName(rr.Name, zone), Type: rr.Type}
+	}
+	if err := p.checkOwner(&response.RecordSet, p.recordSetName(rr.Name, zone), rr.Type); err != nil {
+		return nil, nil, err
+	}
+	// A soft-deleted record set does not exist to the provider until it is purged
+	if p.isSoftDeleted(&response.RecordSet) && ctx.Value(purgeKey{}) == nil {
+		return nil, nil, nil
+	}
+
+	existingRecords, err := convertAzureRecordSetsToLibdnsRecords([]*armdns.RecordSet{&response.RecordSet})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &response.RecordSet, existingRecords, nil
 }
 
-// createRecord creates a new record in the specified zone.
-// It throws an error if the record already exists.
-func (p *Provider) createRecord(ctx context.Context, zone string, record libdns.Record) (libdns.Record, error) {
-	return p.createOrUpdateRecord(ctx, zone, record, "*")
+// equalRecordValues reports whether the records have the same values regardless of the order and the TTLs.
+func equalRecordValues(a []libdns.Record, b []libdns.Record) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	values := map[string]int{}
+	for _, record := range a {
+		values[recordData(record)]++
+	}
+	for _, record := range b {
+		values[recordData(record)]--
+	}
+	for _, count := range values {
+		if count != 0 {
+			return false
+		}
+	}
+	return true
 }
 
-// updateRecord creates or updates a record, either by updating existing record or creating new one.
-func (p *Provider) updateRecord(ctx context.Context, zone string, record libdns.Record) (libdns.Record, error) {
-	return p.createOrUpdateRecord(ctx, zone, record, "")
+// uniqueRecords returns the records without the ones whose values duplicate those of the preceding records.
+func uniqueRecords(records []libdns.Record) []libdns.Record {
+	var unique []libdns.Record
+	seen := map[string]bool{}
+	for _, record := range records {
+		data := recordData(record)
+		if !seen[data] {
+			seen[data] = true
+			unique = append(unique, record)
+		}
+	}
+	return unique
 }
 
-// deleteRecord deletes an existing records.
-// Regardless of the value of the record, if the name and type match, the record will be deleted.
-func (p *Provider) deleteRecord(ctx context.Context, zone string, record libdns.Record) (libdns.Record, error) {
-	p.client.mutex.Lock()
-	defer p.client.mutex.Unlock()
+// newRecordValues returns the records whose values are not among the values of the existing records.
+func newRecordValues(records []libdns.Record, existingRecords []libdns.Record) []libdns.Record {
+	existing := map[string]bool{}
+	for _, existingRecord := range existingRecords {
+		existing[recordData(existingRecord)] = true
+	}
+	var remaining []libdns.Record
+	for _, record := range records {
+		if !existing[recordData(record)] {
+			remaining = append(remaining, record)
+		}
+	}
+	return remaining
+}
 
-	if err := p.setupClient(); err != nil {
-		return record, err
+// hasRecordWithoutValue reports whether any of the records has no value, which stands for all the values of the record set.
+func hasRecordWithoutValue(records []libdns.Record) bool {
+	for _, record := range records {
+		if record.RR().Data == "" {
+			return true
+		}
 	}
+	return false
+}
 
-	recordType, err := convertStringToRecordType(record.Type)
+// appendRecordSet appends records sharing the same name and type to the record set.
+// If the record set does not exist, a new one is created.
+// The TTL and metadata of the existing record set are kept as is. Values that already exist, e.g. the same TXT token
+// added by two solvers, are not appended again, and the record set is not written if all of them exist.
+func (p *Provider) appendRecordSet(ctx context.Context, zone string, records []libdns.Record) error {
+	rr := records[0].RR()
+	recordType, err := convertStringToRecordType(rr.Type)
 	if err != nil {
-		return record, err
+		return err
 	}
 
-	_, err = p.client.azureClient.Delete(
+	records = uniqueRecords(records)
+	recordSet, err := convertLibdnsRecordsToAzureRecordSet(records, p.TTLConflictPolicy)
+	if err != nil {
+		return err
+	}
+
+	existing, err := p.client.azureClient.Get(
 		ctx,
 		p.ResourceGroupName,
 		strings.TrimSuffix(zone, "."),
-		generateRecordSetName(record.Name, zone),
+		p.recordSetName(records[0].RR().Name, zone),
 		recordType,
-		&armdns.RecordSetsClientDeleteOptions{
-			IfMatch: nil,
-		},
+		&armdns.RecordSetsClientGetOptions{},
 	)
 	if err != nil {
-		return record, err
+		if !isNotFoundError(err) {
+			return err
+		}
+		p.stampOwner(recordSet.Properties)
+		if err := p.beforeWrite(ctx, WriteOperationAppend, zone, rr.Name, rr.Type, nil, &recordSet); err != nil {
+			return err
+		}
+		// Prevent overwriting a record set created after it was found missing
+		return p.createOrUpdateRecordSet(ctx, zone, records[0], recordSet, nil, to.Ptr("*"))
+	}
+	if err := p.checkOwner(&existing.RecordSet, p.recordSetName(rr.Name, zone), rr.Type); err != nil {
+		return err
+	}
+	// A soft-deleted record set does not exist to the provider, so its values are replaced as if it were created
+	if p.isSoftDeleted(&existing.RecordSet) {
+		return p.restoreRecordSet(ctx, zone, WriteOperationAppend, records, &existing.RecordSet, recordSet)
 	}
 
-	return record, nil
-}
+	existingRecords, err := convertAzureRecordSetsToLibdnsRecords([]*armdns.RecordSet{&existing.RecordSet})
+	if err != nil {
+		return err
+	}
+	newRecords := newRecordValues(records, existingRecords)
+	if len(newRecords) == 0 {
+		return nil
+	}
+	recordSet, err = convertLibdnsRecordsToAzureRecordSet(newRecords, p.TTLConflictPolicy)
+	if err != nil {
+		return err
+	}
 
-// createOrUpdateRecord creates or updates a record.
-// The behavior depends on the value of ifNoneMatch, set to "*" to allow to create a new record but prevent updating an existing record.
-func (p *Provider) createOrUpdateRecord(ctx context.Context, zone string, record libdns.Record, ifNoneMatch string) (libdns.Record, error) {
-	p.client.mutex.Lock()
-	defer p.client.mutex.Unlock()
+	before := p.snapshotRecordSet(&existing.RecordSet)
+	properties := existing.Properties
+	if properties == nil {
+		properties = &armdns.RecordSetProperties{}
+	}
+	if err := mergeRecordSetProperties(properties, recordSet.Properties); err != nil {
+		return err
+	}
+	if err := checkRecordSetLimits(p.recordSetName(rr.Name, zone), rr.Type, properties); err != nil {
+		return err
+	}
+	after := armdns.RecordSet{Properties: properties}
+	if err := p.beforeWrite(ctx, WriteOperationAppend, zone, rr.Name, rr.Type, before, &after); err != nil {
+		return err
+	}
 
-	if err := p.setupClient(); err != nil {
-		return record, err
+	// Prevent overwriting a record set modified after it was read
+	return p.createOrUpdateRecordSet(ctx, zone, records[0], after, existing.Etag, nil)
+}
+
+// replaceRecordSet replaces the values and the TTL of the record set that the records sharing the same name and type belong to.
+// If the record set does not exist, a new one is created. The metadata of the existing record set is kept as is,
+// and a record set that is an alias of an Azure resource is not overwritten, since its alias configuration would be lost,
+// unless it is replaced by an Alias.
+func (p *Provider) replaceRecordSet(ctx context.Context, zone string, records []libdns.Record, recordSet armdns.RecordSet) error {
+	rr := records[0].RR()
+	recordType, err := convertStringToRecordType(rr.Type)
+	if err != nil {
+		return err
 	}
 
-	recordType, err := convertStringToRecordType(record.Type)
+	existing, err := p.client.azureClient.Get(
+		ctx,
+		p.ResourceGroupName,
+		strings.TrimSuffix(zone, "."),
+		p.recordSetName(rr.Name, zone),
+		recordType,
+		&armdns.RecordSetsClientGetOptions{},
+	)
 	if err != nil {
-		return record, err
+		if !isNotFoundError(err) {
+			return err
+		}
+		p.stampOwner(recordSet.Properties)
+		if err := p.beforeWrite(ctx, WriteOperationSet, zone, rr.Name, rr.Type, nil, &recordSet); err != nil {
+			return err
+		}
+		// Prevent overwriting a record set created after it was found missing
+		return p.createOrUpdateRecordSet(ctx, zone, records[0], recordSet, nil, to.Ptr("*"))
+	}
+	if err := p.checkOwner(&existing.RecordSet, p.recordSetName(rr.Name, zone), rr.Type); err != nil {
+		return err
+	}
+
+	before := p.snapshotRecordSet(&existing.RecordSet)
+	properties := existing.Properties
+	if properties == nil {
+		properties = &armdns.RecordSetProperties{}
+	}
+	// An alias record set is overwritten only by an alias, which replaces its target resource
+	if isAliasRecordSet(properties) && !isAliasRecordSet(recordSet.Properties) {
+		return fmt.Errorf("the record set %v %v is an alias of %v and cannot be overwritten", p.recordSetName(rr.Name, zone), rr.Type, *properties.TargetResource.ID)
+	}
+	clearRecordSetValues(properties)
+	clearSoftDelete(properties)
+	if err := mergeRecordSetProperties(properties, recordSet.Properties); err != nil {
+		return err
+	}
+	properties.TTL = recordSet.Properties.TTL
+	after := armdns.RecordSet{Properties: properties}
+	if err := p.beforeWrite(ctx, WriteOperationSet, zone, rr.Name, rr.Type, before, &after); err != nil {
+		return err
 	}
 
-	recordSet, err := convertLibdnsRecordToAzureRecordSet(record)
+	// Prevent overwriting a record set modified after it was read
+	return p.createOrUpdateRecordSet(ctx, zone, records[0], after, existing.Etag, nil)
+}
+
+// createOrUpdateRecordSet creates or updates the record set that the record belongs to.
+// The behavior depends on the value of ifMatch and ifNoneMatch, set ifNoneMatch to "*" to allow to create a new record set but prevent updating an existing record set,
+// or set ifMatch to the ETag of the existing record set to prevent overwriting concurrent changes.
+// All the record sets are stamped with the record set metadata of the zone, and with their provenance if Stamp Provenance is set.
+// Otherwise, the record sets for ACME DNS challenges are stamped with the time of the write if Stamp Challenges is set,
+// so that CleanupStaleChallenges can find stale ones.
+func (p *Provider) createOrUpdateRecordSet(ctx context.Context, zone string, record libdns.Record, recordSet armdns.RecordSet, ifMatch *string, ifNoneMatch *string) error {
+	_, err := p.writeRecordSet(ctx, zone, record, recordSet, ifMatch, ifNoneMatch)
+	return err
+}
+
+// writeRecordSet creates or updates the record set that the record belongs to in the same manner as createOrUpdateRecordSet,
+// returning the record set written, e.g. for its new ETag.
+func (p *Provider) writeRecordSet(ctx context.Context, zone string, record libdns.Record, recordSet armdns.RecordSet, ifMatch *string, ifNoneMatch *string) (armdns.RecordSet, error) {
+	recordType, err := convertStringToRecordType(record.RR().Type)
 	if err != nil {
-		return record, err
+		return armdns.RecordSet{}, err
+	}
+	p.stampRecordSetMetadata(zone, recordSet.Properties)
+	if p.StampProvenance {
+		p.stampProvenance(recordSet.Properties)
+	} else if p.StampChallenges && isChallengeRecordSet(p.recordSetName(record.RR().Name, zone), record.RR().Type) {
+		stampWrittenAt(recordSet.Properties)
 	}
 
-	_, err = p.client.azureClient.CreateOrUpdate(
+	response, err := p.client.azureClient.CreateOrUpdate(
 		ctx,
 		p.ResourceGroupName,
 		strings.TrimSuffix(zone, "."),
-		generateRecordSetName(record.Name, zone),
+		p.recordSetName(record.RR().Name, zone),
 		recordType,
 		recordSet,
 		&armdns.RecordSetsClientCreateOrUpdateOptions{
-			IfMatch:     nil,
-			IfNoneMatch: &ifNoneMatch,
+			IfMatch:     ifMatch,
+			IfNoneMatch: ifNoneMatch,
 		},
 	)
 	if err != nil {
-		return record, err
+		return armdns.RecordSet{}, err
+	}
+
+	if p.WaitForProvisioning {
+		if err := p.waitForProvisioning(ctx, zone, record, response.RecordSet); err != nil {
+			return armdns.RecordSet{}, err
+		}
+	}
+
+	return response.RecordSet, nil
+}
+
+// waitForProvisioning polls the record set that the record belongs to until its provisioning state becomes Succeeded.
+// It throws an error if the provisioning has failed or the context is done before the provisioning completes.
+func (p *Provider) waitForProvisioning(ctx context.Context, zone string, record libdns.Record, recordSet armdns.RecordSet) error {
+	recordType, err := convertStringToRecordType(record.RR().Type)
+	if err != nil {
+		return err
+	}
+
+	interval := p.ProvisioningPollInterval
+	if interval <= 0 {
+		interval = defaultProvisioningPollInterval
+	}
+
+	for {
+		provisioningState := ""
+		if recordSet.Properties != nil && recordSet.Properties.ProvisioningState != nil {
+			provisioningState = *recordSet.Properties.ProvisioningState
+		}
+
+		switch provisioningState {
+		// A record set without the provisioning state is regarded as committed, since there is nothing to wait for.
+		case "Succeeded", "":
+			return nil
+		case "Failed", "Canceled":
+			return fmt.Errorf("the provisioning of the record set %v %v has ended in the state %v", record.RR().Name, record.RR().Type, provisioningState)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		response, err := p.client.azureClient.Get(
+			ctx,
+			p.ResourceGroupName,
+			strings.TrimSuffix(zone, "."),
+			p.recordSetName(record.RR().Name, zone),
+			recordType,
+			&armdns.RecordSetsClientGetOptions{},
+		)
+		if err != nil {
+			return err
+		}
+		recordSet = response.RecordSet
+	}
+}
+
+// groupRecordsByRecordSet groups records by the record set they belong to, keeping the order of appearance.
+// The record sets are named by recordSetName.
+func groupRecordsByRecordSet(records []libdns.Record, zone string, recordSetName func(name string, zone string) string) [][]libdns.Record {
+	var recordGroups [][]libdns.Record
+	indexes := map[string]int{}
+
+	for _, record := range records {
+		rr := record.RR()
+		key := recordSetName(rr.Name, zone) + "/" + rr.Type
+		if i, ok := indexes[key]; ok {
+			recordGroups[i] = append(recordGroups[i], record)
+			continue
+		}
+		indexes[key] = len(recordGroups)
+		recordGroups = append(recordGroups, []libdns.Record{record})
+	}
+
+	return recordGroups
+}
+
+// isAuthenticationError reports whether the error is caused by a failure to authenticate,
+// either while acquiring a token or by ARM rejecting the token.
+func isAuthenticationError(err error) bool {
+	var authenticationFailedError *azidentity.AuthenticationFailedError
+	if errors.As(err, &authenticationFailedError) {
+		return true
+	}
+	var responseError *azcore.ResponseError
+	return errors.As(err, &responseError) && responseError.StatusCode == http.StatusUnauthorized
+}
+
+// isPreconditionFailedError reports whether the error is a response error with the status code 412,
+// which is returned when the ETag of a resource does not match the one given as a precondition.
+func isPreconditionFailedError(err error) bool {
+	var responseError *azcore.ResponseError
+	return errors.As(err, &responseError) && responseError.StatusCode == http.StatusPreconditionFailed
+}
+
+// isNotFoundError reports whether the error is a response error with the status code 404.
+func isNotFoundError(err error) bool {
+	var responseError *azcore.ResponseError
+	return errors.As(err, &responseError) && responseError.StatusCode == http.StatusNotFound
+}
+
+// normalizeRecord returns the record as the type-specific struct of libdns with the name relative to the zone.
+func normalizeRecord(record libdns.Record, zone string) libdns.Record {
+	if alias, ok := record.(Alias); ok {
+		alias.Name = generateRecordSetName(alias.Name, zone)
+		return alias
+	}
+	rr := normalizeTXTRecord(record).RR()
+	return newLibdnsRecord(generateRecordSetName(rr.Name, zone), rr.TTL, rr.Type, rr.Data)
+}
+
+// recordData returns the data of the record in the canonical form of libdns, so that records can be compared regardless of how they are given.
+// The data of an alias is the ID of its target resource.
+func recordData(record libdns.Record) string {
+	if alias, ok := record.(Alias); ok {
+		return alias.TargetResourceID
+	}
+	rr := normalizeTXTRecord(record).RR()
+	if parsed, err := rr.Parse(); err == nil {
+		return parsed.RR().Data
+	}
+	return rr.Data
+}
+
+// recordSetName returns the name of the record set on Azure DNS for the name of a record,
+// generated by Record Set Name if set, or by generateRecordSetName otherwise.
+func (p *Provider) recordSetName(name string, zone string) string {
+	if p.RecordSetName != nil {
+		return p.RecordSetName(name, zone)
 	}
+	return generateRecordSetName(name, zone)
+}
 
-	return record, nil
+// DefaultRecordSetName returns the name of the record set for the name of a record as the provider does by default,
+// relative to the zone with "@" for the apex, so that a custom Record Set Name can fall back to it.
+func DefaultRecordSetName(name string, zone string) string {
+	return generateRecordSetName(name, zone)
 }
 
 // generateRecordSetName generates name for RecordSet object.
+// The name may be relative to the zone or absolute, with or without the trailing dot, in any case.
 func generateRecordSetName(name string, zone string) string {
-	recordSetName := libdns.RelativeName(strings.TrimSuffix(name, ".")+".", zone)
-	if recordSetName == "" {
+	name = strings.TrimSuffix(name, ".")
+	zone = strings.TrimSuffix(zone, ".")
+
+	if name == "" || name == "@" || strings.EqualFold(name, zone) {
 		return "@"
 	}
-	return recordSetName
+	if zone != "" && len(name) > len(zone)+1 && strings.EqualFold(name[len(name)-len(zone)-1:], "."+zone) {
+		return name[:len(name)-len(zone)-1]
+	}
+	return name
 }
 
+// splitRecordValue splits the data of the record into fields, failing if it has fewer fields than required.
+func splitRecordValue(rr libdns.RR, n int) ([]string, error) {
+	values := strings.Fields(rr.Data)
+	if len(values) < n {
+		return nil, fmt.Errorf("the value %v of the record %v %v cannot be interpreted", rr.Data, rr.Name, rr.Type)
+	}
+	return values, nil
+}
+
+// supportedRecordTypes are the record types that can be converted between libdns and Azure DNS.
+var supportedRecordTypes = []string{"A", "AAAA", "CAA", "CNAME", "MX", "NS", "PTR", "SOA", "SRV", "TXT"}
+
 // convertStringToRecordType casts standard type name string to an Azure-styled dedicated type.
 func convertStringToRecordType(typeName string) (armdns.RecordType, error) {
 	switch typeName {
@@ -208,266 +1681,394 @@ func convertStringToRecordType(typeName string) (armdns.RecordType, error) {
 	}
 }
 
+// convertAzureZoneToZoneInfo converts an Azure-styled zone to the zone details.
+func convertAzureZoneToZoneInfo(zone armdns.Zone) ZoneInfo {
+	zoneInfo := ZoneInfo{
+		Name: stringValue(zone.Name) + ".",
+		ID:   stringValue(zone.ID),
+		Tags: map[string]string{},
+	}
+	for key, value := range zone.Tags {
+		zoneInfo.Tags[key] = stringValue(value)
+	}
+	if zone.Properties != nil {
+		for _, nameServer := range zone.Properties.NameServers {
+			zoneInfo.NameServers = append(zoneInfo.NameServers, stringValue(nameServer))
+		}
+		if zone.Properties.NumberOfRecordSets != nil {
+			zoneInfo.NumberOfRecordSets = *zone.Properties.NumberOfRecordSets
+		}
+		if zone.Properties.MaxNumberOfRecordSets != nil {
+			zoneInfo.MaxNumberOfRecordSets = *zone.Properties.MaxNumberOfRecordSets
+		}
+		if zone.Properties.MaxNumberOfRecordsPerRecordSet != nil {
+			zoneInfo.MaxNumberOfRecordsPerRecordSet = *zone.Properties.MaxNumberOfRecordsPerRecordSet
+		}
+		if zone.Properties.ZoneType != nil {
+			zoneInfo.ZoneType = string(*zone.Properties.ZoneType)
+		}
+	}
+	return zoneInfo
+}
+
+// stringValue returns the value that the pointer points to, or an empty string if the pointer is nil.
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// int32Value returns the value that the pointer points to, or zero if the pointer is nil.
+func int32Value(i *int32) int32 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
 // convertAzureRecordSetsToLibdnsRecords converts Azure-styled records to libdns records.
+// The records are of the type-specific structs of libdns, or the opaque RR for the types that libdns does not define.
+// A record set without properties, or without the value of a single-valued type such as CNAME, has no records,
+// while a record of a record set missing any of its fields fails the conversion.
 func convertAzureRecordSetsToLibdnsRecords(recordSets []*armdns.RecordSet) ([]libdns.Record, error) {
 	var records []libdns.Record
 
 	for _, recordSet := range recordSets {
-		switch typeName := strings.TrimPrefix(*recordSet.Type, "Microsoft.Network/dnszones/"); typeName {
+		if recordSet == nil || recordSet.Type == nil {
+			return []libdns.Record{}, fmt.Errorf("the record set without a type cannot be interpreted")
+		}
+		name := stringValue(recordSet.Name)
+		typeName := strings.TrimPrefix(*recordSet.Type, "Microsoft.Network/dnszones/")
+		properties := recordSet.Properties
+		if properties == nil {
+			properties = &armdns.RecordSetProperties{}
+		}
+		var ttl time.Duration
+		if properties.TTL != nil {
+			ttl = time.Duration(*properties.TTL) * time.Second
+		}
+		missingValue := fmt.Errorf("the record set %v %v has a missing value", name, typeName)
+
+		if isAliasRecordSet(properties) {
+			records = append(records, Alias{
+				Name:             name,
+				TTL:              ttl,
+				TargetResourceID: *properties.TargetResource.ID,
+				RecordType:       typeName,
+			})
+			continue
+		}
+		switch typeName {
 		case "A":
-			for _, v := range recordSet.Properties.ARecords {
-				record := libdns.Record{
-					ID:    *recordSet.Etag,
-					Type:  typeName,
-					Name:  *recordSet.Name,
-					Value: *v.IPv4Address,
-					TTL:   time.Duration(*recordSet.Properties.TTL) * time.Second,
+			for _, v := range properties.ARecords {
+				if v == nil || v.IPv4Address == nil {
+					return []libdns.Record{}, missingValue
 				}
-				records = append(records, record)
+				records = append(records, newLibdnsRecord(name, ttl, typeName, *v.IPv4Address))
 			}
 		case "AAAA":
-			for _, v := range recordSet.Properties.AaaaRecords {
-				record := libdns.Record{
-					ID:    *recordSet.Etag,
-					Type:  typeName,
-					Name:  *recordSet.Name,
-					Value: *v.IPv6Address,
-					TTL:   time.Duration(*recordSet.Properties.TTL) * time.Second,
+			for _, v := range properties.AaaaRecords {
+				if v == nil || v.IPv6Address == nil {
+					return []libdns.Record{}, missingValue
 				}
-				records = append(records, record)
+				records = append(records, newLibdnsRecord(name, ttl, typeName, *v.IPv6Address))
 			}
 		case "CAA":
-			for _, v := range recordSet.Properties.CaaRecords {
-				record := libdns.Record{
-					ID:    *recordSet.Etag,
-					Type:  typeName,
-					Name:  *recordSet.Name,
-					Value: strings.Join([]string{fmt.Sprint(*v.Flags), *v.Tag, *v.Value}, " "),
-					TTL:   time.Duration(*recordSet.Properties.TTL) * time.Second,
+			for _, v := range properties.CaaRecords {
+				if v == nil || v.Flags == nil || v.Tag == nil || v.Value == nil {
+					return []libdns.Record{}, missingValue
 				}
-				records = append(records, record)
+				records = append(records, newLibdnsRecord(name, ttl, typeName, fmt.Sprintf("%d %s %q", *v.Flags, *v.Tag, *v.Value)))
 			}
 		case "CNAME":
-			record := libdns.Record{
-				ID:    *recordSet.Etag,
-				Type:  typeName,
-				Name:  *recordSet.Name,
-				Value: *recordSet.Properties.CnameRecord.Cname,
-				TTL:   time.Duration(*recordSet.Properties.TTL) * time.Second,
+			if properties.CnameRecord == nil {
+				continue
+			}
+			if properties.CnameRecord.Cname == nil {
+				return []libdns.Record{}, missingValue
 			}
-			records = append(records, record)
+			records = append(records, newLibdnsRecord(name, ttl, typeName, *properties.CnameRecord.Cname))
 		case "MX":
-			for _, v := range recordSet.Properties.MxRecords {
-				record := libdns.Record{
-					ID:    *recordSet.Etag,
-					Type:  typeName,
-					Name:  *recordSet.Name,
-					Value: strings.Join([]string{fmt.Sprint(*v.Preference), *v.Exchange}, " "),
-					TTL:   time.Duration(*recordSet.Properties.TTL) * time.Second,
+			for _, v := range properties.MxRecords {
+				if v == nil || v.Preference == nil || v.Exchange == nil {
+					return []libdns.Record{}, missingValue
 				}
-				records = append(records, record)
+				records = append(records, newLibdnsRecord(name, ttl, typeName, fmt.Sprintf("%d %s", *v.Preference, *v.Exchange)))
 			}
 		case "NS":
-			for _, v := range recordSet.Properties.NsRecords {
-				record := libdns.Record{
-					ID:    *recordSet.Etag,
-					Type:  typeName,
-					Name:  *recordSet.Name,
-					Value: *v.Nsdname,
-					TTL:   time.Duration(*recordSet.Properties.TTL) * time.Second,
+			for _, v := range properties.NsRecords {
+				if v == nil || v.Nsdname == nil {
+					return []libdns.Record{}, missingValue
 				}
-				records = append(records, record)
+				records = append(records, newLibdnsRecord(name, ttl, typeName, *v.Nsdname))
 			}
 		case "PTR":
-			for _, v := range recordSet.Properties.PtrRecords {
-				record := libdns.Record{
-					ID:    *recordSet.Etag,
-					Type:  typeName,
-					Name:  *recordSet.Name,
-					Value: *v.Ptrdname,
-					TTL:   time.Duration(*recordSet.Properties.TTL) * time.Second,
+			for _, v := range properties.PtrRecords {
+				if v == nil || v.Ptrdname == nil {
+					return []libdns.Record{}, missingValue
 				}
-				records = append(records, record)
+				records = append(records, newLibdnsRecord(name, ttl, typeName, *v.Ptrdname))
 			}
 		case "SOA":
-			record := libdns.Record{
-				ID:   *recordSet.Etag,
-				Type: typeName,
-				Name: *recordSet.Name,
-				Value: strings.Join([]string{
-					*recordSet.Properties.SoaRecord.Host,
-					*recordSet.Properties.SoaRecord.Email,
-					fmt.Sprint(*recordSet.Properties.SoaRecord.SerialNumber),
-					fmt.Sprint(*recordSet.Properties.SoaRecord.RefreshTime),
-					fmt.Sprint(*recordSet.Properties.SoaRecord.RetryTime),
-					fmt.Sprint(*recordSet.Properties.SoaRecord.ExpireTime),
-					fmt.Sprint(*recordSet.Properties.SoaRecord.MinimumTTL)},
-					" "),
-				TTL: time.Duration(*recordSet.Properties.TTL) * time.Second,
+			soa := properties.SoaRecord
+			if soa == nil {
+				continue
 			}
-			records = append(records, record)
+			if soa.Host == nil || soa.Email == nil || soa.SerialNumber == nil || soa.RefreshTime == nil ||
+				soa.RetryTime == nil || soa.ExpireTime == nil || soa.MinimumTTL == nil {
+				return []libdns.Record{}, missingValue
+			}
+			records = append(records, newLibdnsRecord(name, ttl, typeName, strings.Join([]string{
+				*soa.Host,
+				*soa.Email,
+				fmt.Sprint(*soa.SerialNumber),
+				fmt.Sprint(*soa.RefreshTime),
+				fmt.Sprint(*soa.RetryTime),
+				fmt.Sprint(*soa.ExpireTime),
+				fmt.Sprint(*soa.MinimumTTL)},
+				" ")))
 		case "SRV":
-			for _, v := range recordSet.Properties.SrvRecords {
-				record := libdns.Record{
-					ID:    *recordSet.Etag,
-					Type:  typeName,
-					Name:  *recordSet.Name,
-					Value: strings.Join([]string{fmt.Sprint(*v.Priority), fmt.Sprint(*v.Weight), fmt.Sprint(*v.Port), *v.Target}, " "),
-					TTL:   time.Duration(*recordSet.Properties.TTL) * time.Second,
+			for _, v := range properties.SrvRecords {
+				if v == nil || v.Priority == nil || v.Weight == nil || v.Port == nil || v.Target == nil {
+					return []libdns.Record{}, missingValue
 				}
-				records = append(records, record)
+				records = append(records, newLibdnsRecord(name, ttl, typeName, fmt.Sprintf("%d %d %d %s", *v.Priority, *v.Weight, *v.Port, *v.Target)))
 			}
 		case "TXT":
-			for _, v := range recordSet.Properties.TxtRecords {
+			for _, v := range properties.TxtRecords {
+				if v == nil {
+					return []libdns.Record{}, missingValue
+				}
+				// A TXT record consisting of multiple strings is a single record in libdns
+				var text strings.Builder
 				for _, txt := range v.Value {
-					record := libdns.Record{
-						ID:    *recordSet.Etag,
-						Type:  typeName,
-						Name:  *recordSet.Name,
-						Value: *txt,
-						TTL:   time.Duration(*recordSet.Properties.TTL) * time.Second,
+					if txt == nil {
+						return []libdns.Record{}, missingValue
 					}
-					records = append(records, record)
+					text.WriteString(*txt)
 				}
+				records = append(records, newLibdnsRecord(name, ttl, typeName, text.String()))
 			}
 		default:
-			return []libdns.Record{}, fmt.Errorf("the type %v cannot be interpreted", typeName)
+			return []libdns.Record{}, fmt.Errorf("the type %v of the record set %v cannot be interpreted", typeName, name)
 		}
 	}
 
 	return records, nil
 }
 
+// newLibdnsRecord builds a libdns record of the type-specific struct from the fields of a resource record.
+// It falls back to the opaque RR if libdns does not define the type or cannot parse the data.
+func newLibdnsRecord(name string, ttl time.Duration, typeName string, data string) libdns.Record {
+	rr := libdns.RR{
+		Name: name,
+		TTL:  ttl,
+		Type: typeName,
+		Data: data,
+	}
+	record, err := rr.Parse()
+	if err != nil {
+		return rr
+	}
+	return record
+}
+
+// convertLibdnsRecordsToAzureRecordSet converts libdns records sharing the same name and type to a single Azure-styled record set.
+// Since Azure DNS stores TTL per record set, the TTL is resolved according to the policy if the records have different TTLs.
+func convertLibdnsRecordsToAzureRecordSet(records []libdns.Record, policy TTLConflictPolicy) (armdns.RecordSet, error) {
+	var recordSet armdns.RecordSet
+
+	ttl, err := resolveTTL(records, policy)
+	if err != nil {
+		return armdns.RecordSet{}, err
+	}
+
+	for i, record := range records {
+		convertedRecordSet, err := convertLibdnsRecordToAzureRecordSet(record)
+		if err != nil {
+			return armdns.RecordSet{}, err
+		}
+		if i == 0 {
+			recordSet = convertedRecordSet
+			continue
+		}
+		if err := mergeRecordSetProperties(recordSet.Properties, convertedRecordSet.Properties); err != nil {
+			return armdns.RecordSet{}, err
+		}
+	}
+	recordSet.Properties.TTL = to.Ptr[int64](int64(ttl / time.Second))
+
+	return recordSet, nil
+}
+
+// resolveTTL determines the TTL of the record set from the records according to the policy.
+func resolveTTL(records []libdns.Record, policy TTLConflictPolicy) (time.Duration, error) {
+	first := records[0].RR()
+	ttl := first.TTL
+
+	for _, record := range records[1:] {
+		recordTTL := record.RR().TTL
+		if recordTTL == ttl {
+			continue
+		}
+		switch policy {
+		case TTLConflictPolicyError, "":
+			return 0, fmt.Errorf("the records %v %v have conflicting TTLs %v and %v", first.Name, first.Type, ttl, recordTTL)
+		case TTLConflictPolicyFirst:
+		case TTLConflictPolicyLowest:
+			if recordTTL < ttl {
+				ttl = recordTTL
+			}
+		case TTLConflictPolicyHighest:
+			if recordTTL > ttl {
+				ttl = recordTTL
+			}
+		default:
+			return 0, fmt.Errorf("the TTL conflict policy %v cannot be interpreted", policy)
+		}
+	}
+
+	return ttl, nil
+}
+
+// clearRecordSetValues removes all the values and the alias target from the properties, keeping the others such as the TTL and metadata.
+func clearRecordSetValues(properties *armdns.RecordSetProperties) {
+	properties.TargetResource = nil
+	properties.ARecords = nil
+	properties.AaaaRecords = nil
+	properties.CaaRecords = nil
+	properties.CnameRecord = nil
+	properties.MxRecords = nil
+	properties.NsRecords = nil
+	properties.PtrRecords = nil
+	properties.SoaRecord = nil
+	properties.SrvRecords = nil
+	properties.TxtRecords = nil
+}
+
+// mergeRecordSetProperties appends the values in src to dst.
+// It throws an error if both have a value of the type that allows only a single value per record set.
+func mergeRecordSetProperties(dst *armdns.RecordSetProperties, src *armdns.RecordSetProperties) error {
+	if (isAliasRecordSet(dst) && (isAliasRecordSet(src) || hasRecordSetValues(src))) || (isAliasRecordSet(src) && hasRecordSetValues(dst)) {
+		return fmt.Errorf("an alias record set cannot have other values")
+	}
+	if dst.CnameRecord != nil && src.CnameRecord != nil {
+		return fmt.Errorf("the type CNAME cannot have multiple values")
+	}
+	if dst.SoaRecord != nil && src.SoaRecord != nil {
+		return fmt.Errorf("the type SOA cannot have multiple values")
+	}
+
+	dst.ARecords = append(dst.ARecords, src.ARecords...)
+	dst.AaaaRecords = append(dst.AaaaRecords, src.AaaaRecords...)
+	dst.CaaRecords = append(dst.CaaRecords, src.CaaRecords...)
+	dst.MxRecords = append(dst.MxRecords, src.MxRecords...)
+	dst.NsRecords = append(dst.NsRecords, src.NsRecords...)
+	dst.PtrRecords = append(dst.PtrRecords, src.PtrRecords...)
+	dst.SrvRecords = append(dst.SrvRecords, src.SrvRecords...)
+	dst.TxtRecords = append(dst.TxtRecords, src.TxtRecords...)
+	if src.CnameRecord != nil {
+		dst.CnameRecord = src.CnameRecord
+	}
+	if src.SoaRecord != nil {
+		dst.SoaRecord = src.SoaRecord
+	}
+	if isAliasRecordSet(src) {
+		dst.TargetResource = src.TargetResource
+	}
+
+	return nil
+}
+
 // convertLibdnsRecordToAzureRecordSet converts a libdns record to an Azure-styled record.
 func convertLibdnsRecordToAzureRecordSet(record libdns.Record) (armdns.RecordSet, error) {
-	switch record.Type {
-	case "A":
-		recordSet := armdns.RecordSet{
-			Properties: &armdns.RecordSetProperties{
-				TTL: to.Ptr[int64](int64(record.TTL / time.Second)),
-				ARecords: []*armdns.ARecord{{
-					IPv4Address: to.Ptr(record.Value),
-				}},
-			},
-		}
-		return recordSet, nil
-	case "AAAA":
-		recordSet := armdns.RecordSet{
-			Properties: &armdns.RecordSetProperties{
-				TTL: to.Ptr[int64](int64(record.TTL / time.Second)),
-				AaaaRecords: []*armdns.AaaaRecord{{
-					IPv6Address: to.Ptr(record.Value),
-				}},
-			},
-		}
-		return recordSet, nil
-	case "CAA":
-		values := strings.Split(record.Value, " ")
-		flags, _ := strconv.ParseInt(values[0], 10, 32)
-		recordSet := armdns.RecordSet{
-			Properties: &armdns.RecordSetProperties{
-				TTL: to.Ptr[int64](int64(record.TTL / time.Second)),
-				CaaRecords: []*armdns.CaaRecord{{
-					Flags: to.Ptr[int32](int32(flags)),
-					Tag:   to.Ptr(values[1]),
-					Value: to.Ptr(values[2]),
-				}},
-			},
-		}
-		return recordSet, nil
-	case "CNAME":
-		recordSet := armdns.RecordSet{
-			Properties: &armdns.RecordSetProperties{
-				TTL: to.Ptr[int64](int64(record.TTL / time.Second)),
-				CnameRecord: &armdns.CnameRecord{
-					Cname: to.Ptr(record.Value),
-				},
-			},
-		}
-		return recordSet, nil
-	case "MX":
-		values := strings.Split(record.Value, " ")
-		preference, _ := strconv.ParseInt(values[0], 10, 32)
-		recordSet := armdns.RecordSet{
-			Properties: &armdns.RecordSetProperties{
-				TTL: to.Ptr[int64](int64(record.TTL / time.Second)),
-				MxRecords: []*armdns.MxRecord{{
-					Preference: to.Ptr[int32](int32(preference)),
-					Exchange:   to.Ptr(values[1]),
-				}},
-			},
-		}
-		return recordSet, nil
-	case "NS":
-		recordSet := armdns.RecordSet{
-			Properties: &armdns.RecordSetProperties{
-				TTL: to.Ptr[int64](int64(record.TTL / time.Second)),
-				NsRecords: []*armdns.NsRecord{{
-					Nsdname: to.Ptr(record.Value),
-				}},
-			},
-		}
-		return recordSet, nil
-	case "PTR":
-		recordSet := armdns.RecordSet{
-			Properties: &armdns.RecordSetProperties{
-				TTL: to.Ptr[int64](int64(record.TTL / time.Second)),
-				PtrRecords: []*armdns.PtrRecord{{
-					Ptrdname: to.Ptr(record.Value),
-				}},
-			},
-		}
-		return recordSet, nil
-	case "SOA":
-		values := strings.Split(record.Value, " ")
-		serialNumber, _ := strconv.ParseInt(values[2], 10, 64)
-		refreshTime, _ := strconv.ParseInt(values[3], 10, 64)
-		retryTime, _ := strconv.ParseInt(values[4], 10, 64)
-		expireTime, _ := strconv.ParseInt(values[5], 10, 64)
-		minimumTTL, _ := strconv.ParseInt(values[6], 10, 64)
-		recordSet := armdns.RecordSet{
-			Properties: &armdns.RecordSetProperties{
-				TTL: to.Ptr[int64](int64(record.TTL / time.Second)),
-				SoaRecord: &armdns.SoaRecord{
-					Host:         to.Ptr(values[0]),
-					Email:        to.Ptr(values[1]),
-					SerialNumber: to.Ptr[int64](serialNumber),
-					RefreshTime:  to.Ptr[int64](refreshTime),
-					RetryTime:    to.Ptr[int64](retryTime),
-					ExpireTime:   to.Ptr[int64](expireTime),
-					MinimumTTL:   to.Ptr[int64](minimumTTL),
-				},
-			},
-		}
-		return recordSet, nil
-	case "SRV":
-		values := strings.Split(record.Value, " ")
-		priority, _ := strconv.ParseInt(values[0], 10, 32)
-		weight, _ := strconv.ParseInt(values[1], 10, 32)
-		port, _ := strconv.ParseInt(values[2], 10, 32)
-		recordSet := armdns.RecordSet{
-			Properties: &armdns.RecordSetProperties{
-				TTL: to.Ptr[int64](int64(record.TTL / time.Second)),
-				SrvRecords: []*armdns.SrvRecord{{
-					Priority: to.Ptr[int32](int32(priority)),
-					Weight:   to.Ptr[int32](int32(weight)),
-					Port:     to.Ptr[int32](int32(port)),
-					Target:   to.Ptr(values[3]),
-				}},
-			},
-		}
-		return recordSet, nil
-	case "TXT":
-		recordSet := armdns.RecordSet{
-			Properties: &armdns.RecordSetProperties{
-				TTL: to.Ptr[int64](int64(record.TTL / time.Second)),
-				TxtRecords: []*armdns.TxtRecord{{
-					Value: []*string{&record.Value},
-				}},
-			},
-		}
-		return recordSet, nil
+	if alias, ok := record.(Alias); ok {
+		return alias.recordSet()
+	}
+
+	rr := normalizeTXTRecord(record).RR()
+	parsed, err := rr.Parse()
+	if err != nil {
+		return armdns.RecordSet{}, fmt.Errorf("the record %v %v cannot be interpreted: %w", rr.Name, rr.Type, err)
+	}
+
+	properties := &armdns.RecordSetProperties{
+		TTL: to.Ptr[int64](int64(rr.TTL / time.Second)),
+	}
+
+	switch r := parsed.(type) {
+	case libdns.Address:
+		if rr.Type == "A" {
+			properties.ARecords = []*armdns.ARecord{{
+				IPv4Address: to.Ptr(r.IP.String()),
+			}}
+		} else {
+			properties.AaaaRecords = []*armdns.AaaaRecord{{
+				IPv6Address: to.Ptr(r.IP.String()),
+			}}
+		}
+	case libdns.CAA:
+		properties.CaaRecords = []*armdns.CaaRecord{{
+			Flags: to.Ptr[int32](int32(r.Flags)),
+			Tag:   to.Ptr(r.Tag),
+			Value: to.Ptr(r.Value),
+		}}
+	case libdns.CNAME:
+		properties.CnameRecord = &armdns.CnameRecord{
+			Cname: to.Ptr(r.Target),
+		}
+	case libdns.MX:
+		properties.MxRecords = []*armdns.MxRecord{{
+			Preference: to.Ptr[int32](int32(r.Preference)),
+			Exchange:   to.Ptr(r.Target),
+		}}
+	case libdns.NS:
+		properties.NsRecords = []*armdns.NsRecord{{
+			Nsdname: to.Ptr(r.Target),
+		}}
+	case libdns.SRV:
+		properties.SrvRecords = []*armdns.SrvRecord{{
+			Priority: to.Ptr[int32](int32(r.Priority)),
+			Weight:   to.Ptr[int32](int32(r.Weight)),
+			Port:     to.Ptr[int32](int32(r.Port)),
+			Target:   to.Ptr(r.Target),
+		}}
+	case libdns.TXT:
+		properties.TxtRecords = []*armdns.TxtRecord{{
+			Value: splitTXT(r.Text),
+		}}
+	case libdns.RR:
+		switch r.Type {
+		case "PTR":
+			properties.PtrRecords = []*armdns.PtrRecord{{
+				Ptrdname: to.Ptr(r.Data),
+			}}
+		case "SOA":
+			values, err := splitRecordValue(r, 7)
+			if err != nil {
+				return armdns.RecordSet{}, err
+			}
+			serialNumber, _ := strconv.ParseInt(values[2], 10, 64)
+			refreshTime, _ := strconv.ParseInt(values[3], 10, 64)
+			retryTime, _ := strconv.ParseInt(values[4], 10, 64)
+			expireTime, _ := strconv.ParseInt(values[5], 10, 64)
+			minimumTTL, _ := strconv.ParseInt(values[6], 10, 64)
+			properties.SoaRecord = &armdns.SoaRecord{
+				Host:         to.Ptr(values[0]),
+				Email:        to.Ptr(values[1]),
+				SerialNumber: to.Ptr[int64](serialNumber),
+				RefreshTime:  to.Ptr[int64](refreshTime),
+				RetryTime:    to.Ptr[int64](retryTime),
+				ExpireTime:   to.Ptr[int64](expireTime),
+				MinimumTTL:   to.Ptr[int64](minimumTTL),
+			}
+		default:
+			return armdns.RecordSet{}, fmt.Errorf("the type %v cannot be interpreted", rr.Type)
+		}
 	default:
-		return armdns.RecordSet{}, fmt.Errorf("the type %v cannot be interpreted", record.Type)
+		return armdns.RecordSet{}, fmt.Errorf("the type %v cannot be interpreted", rr.Type)
 	}
+
+	return armdns.RecordSet{Properties: properties}, nil
 }
@@ -2,15 +2,36 @@ package azure
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
+	"math/big"
 	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns/fake"
 
@@ -99,6 +120,23 @@ var azureFakeRecords = []armdns.RecordSet{
 			},
 		},
 	},
+	{
+		Name: to.Ptr("record-ns"),
+		Type: to.Ptr("Microsoft.Network/dnszones/NS"),
+		Etag: to.Ptr("ETAG_NS_DELEGATION"),
+		Properties: &armdns.RecordSetProperties{
+			TTL:  to.Ptr[int64](30),
+			Fqdn: to.Ptr("record-ns.example.com."),
+			NsRecords: []*armdns.NsRecord{
+				{
+					Nsdname: to.Ptr("ns1.example.net"),
+				},
+				{
+					Nsdname: to.Ptr("ns2.example.net"),
+				},
+			},
+		},
+	},
 	{
 		Name: to.Ptr("record-ptr"),
 		Type: to.Ptr("Microsoft.Network/dnszones/PTR"),
@@ -129,12 +167,12 @@ var azureFakeRecords = []armdns.RecordSet{
 		},
 	},
 	{
-		Name: to.Ptr("record-srv"),
+		Name: to.Ptr("_xmpp-server._tcp"),
 		Type: to.Ptr("Microsoft.Network/dnszones/SRV"),
 		Etag: to.Ptr("ETAG_SRV"),
 		Properties: &armdns.RecordSetProperties{
 			TTL:  to.Ptr[int64](30),
-			Fqdn: to.Ptr("record-srv.example.com."),
+			Fqdn: to.Ptr("_xmpp-server._tcp.example.com."),
 			SrvRecords: []*armdns.SrvRecord{{
 				Priority: to.Ptr[int32](1),
 				Weight:   to.Ptr[int32](10),
@@ -158,78 +196,83 @@ var azureFakeRecords = []armdns.RecordSet{
 }
 
 var libdnsFakeRecords = []libdns.Record{
-	{
-		ID:    "ETAG_A",
-		Type:  "A",
-		Name:  "record-a",
-		Value: "127.0.0.1",
-		TTL:   time.Duration(30) * time.Second,
+	libdns.Address{
+		Name: "record-a",
+		IP:   netip.MustParseAddr("127.0.0.1"),
+		TTL:  time.Duration(30) * time.Second,
 	},
-	{
-		ID:    "ETAG_AAAA",
-		Type:  "AAAA",
-		Name:  "record-aaaa",
-		Value: "::1",
-		TTL:   time.Duration(30) * time.Second,
+	libdns.Address{
+		Name: "record-aaaa",
+		IP:   netip.MustParseAddr("::1"),
+		TTL:  time.Duration(30) * time.Second,
 	},
-	{
-		ID:    "ETAG_CAA",
-		Type:  "CAA",
+	libdns.CAA{
 		Name:  "record-caa",
-		Value: "0 issue ca.example.com",
+		Flags: 0,
+		Tag:   "issue",
+		Value: "ca.example.com",
 		TTL:   time.Duration(30) * time.Second,
 	},
-	{
-		ID:    "ETAG_CNAME",
-		Type:  "CNAME",
-		Name:  "record-cname",
-		Value: "www.example.com",
-		TTL:   time.Duration(30) * time.Second,
+	libdns.CNAME{
+		Name:   "record-cname",
+		Target: "www.example.com",
+		TTL:    time.Duration(30) * time.Second,
 	},
-	{
-		ID:    "ETAG_MX",
-		Type:  "MX",
-		Name:  "record-mx",
-		Value: "10 mail.example.com",
-		TTL:   time.Duration(30) * time.Second,
+	libdns.MX{
+		Name:       "record-mx",
+		Preference: 10,
+		Target:     "mail.example.com",
+		TTL:        time.Duration(30) * time.Second,
 	},
-	{
-		ID:    "ETAG_NS",
-		Type:  "NS",
-		Name:  "@",
-		Value: "ns1.example.com",
-		TTL:   time.Duration(30) * time.Second,
+	libdns.NS{
+		Name:   "@",
+		Target: "ns1.example.com",
+		TTL:    time.Duration(30) * time.Second,
 	},
-	{
-		ID:    "ETAG_PTR",
-		Type:  "PTR",
-		Name:  "record-ptr",
-		Value: "hoge.example.com",
-		TTL:   time.Duration(30) * time.Second,
+	libdns.NS{
+		Name:   "record-ns",
+		Target: "ns1.example.net",
+		TTL:    time.Duration(30) * time.Second,
 	},
-	{
-		ID:    "ETAG_SOA",
-		Type:  "SOA",
-		Name:  "@",
-		Value: "ns1.example.com hostmaster.example.com 1 7200 900 1209600 86400",
-		TTL:   time.Duration(30) * time.Second,
+	libdns.NS{
+		Name:   "record-ns",
+		Target: "ns2.example.net",
+		TTL:    time.Duration(30) * time.Second,
 	},
-	{
-		ID:    "ETAG_SRV",
-		Type:  "SRV",
-		Name:  "record-srv",
-		Value: "1 10 5269 app.example.com",
-		TTL:   time.Duration(30) * time.Second,
+	libdns.RR{
+		Type: "PTR",
+		Name: "record-ptr",
+		Data: "hoge.example.com",
+		TTL:  time.Duration(30) * time.Second,
 	},
-	{
-		ID:    "ETAG_TXT",
-		Type:  "TXT",
-		Name:  "record-txt",
-		Value: "TEST VALUE",
-		TTL:   time.Duration(30) * time.Second,
+	libdns.RR{
+		Type: "SOA",
+		Name: "@",
+		Data: "ns1.example.com hostmaster.example.com 1 7200 900 1209600 86400",
+		TTL:  time.Duration(30) * time.Second,
+	},
+	libdns.SRV{
+		Service:   "xmpp-server",
+		Transport: "tcp",
+		Name:      "@",
+		Priority:  1,
+		Weight:    10,
+		Port:      5269,
+		Target:    "app.example.com",
+		TTL:       time.Duration(30) * time.Second,
+	},
+	libdns.TXT{
+		Name: "record-txt",
+		Text: "TEST VALUE",
+		TTL:  time.Duration(30) * time.Second,
 	},
 }
 
+// recordComparer compares IP addresses in libdns records, which cmp cannot compare by default.
+var recordComparer = cmp.Comparer(func(a, b netip.Addr) bool {
+	return a == b
+})
+
 func chunkBy[T any](items []T, size int) (chunks [][]T) {
 	for size < len(items) {
 		items, chunks = items[size:], append(chunks, items[0:size:size])
@@ -256,6 +299,22 @@ func getFakeRecordSetsServer() fake.RecordSetsServer {
 			}
 			return
 		},
+		NewListByTypePager: func(resourceGroupName string, zoneName string, recordType armdns.RecordType, options *armdns.RecordSetsClientListByTypeOptions) (resp azfake.PagerResponder[armdns.RecordSetsClientListByTypeResponse]) {
+			values := []*armdns.RecordSet{}
+			for _, v := range azureFakeRecords {
+				if *v.Type == "Microsoft.Network/dnszones/"+string(recordType) {
+					record := v
+					values = append(values, &record)
+				}
+			}
+			page := armdns.RecordSetsClientListByTypeResponse{
+				RecordSetListResult: armdns.RecordSetListResult{
+					Value: values,
+				},
+			}
+			resp.AddPage(http.StatusOK, page, nil)
+			return
+		},
 		CreateOrUpdate: func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
 			parameters.Name = to.Ptr(relativeRecordSetName)
 			parameters.Type = to.Ptr(string(recordType))
@@ -266,6 +325,22 @@ func getFakeRecordSetsServer() fake.RecordSetsServer {
 			resp.SetResponse(http.StatusOK, response, nil)
 			return
 		},
+		Get: func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientGetOptions) (resp azfake.Responder[armdns.RecordSetsClientGetResponse], errResp azfake.ErrorResponder) {
+			for _, v := range azureFakeRecords {
+				if *v.Name == relativeRecordSetName && *v.Type == "Microsoft.Network/dnszones/"+string(recordType) {
+					record := v
+					properties := *v.Properties
+					record.Properties = &properties
+					response := armdns.RecordSetsClientGetResponse{
+						RecordSet: record,
+					}
+					resp.SetResponse(http.StatusOK, response, nil)
+					return
+				}
+			}
+			errResp.SetResponseError(http.StatusNotFound, "NotFound")
+			return
+		},
 		Delete: func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientDeleteOptions) (resp azfake.Responder[armdns.RecordSetsClientDeleteResponse], errResp azfake.ErrorResponder) {
 			response := armdns.RecordSetsClientDeleteResponse{}
 			resp.SetResponse(http.StatusOK, response, nil)
@@ -274,175 +349,2113 @@ func getFakeRecordSetsServer() fake.RecordSetsServer {
 	}
 }
 
+var azureFakeZone = armdns.Zone{
+	ID:       to.Ptr("/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/dnszones/example.com"),
+	Name:     to.Ptr("example.com"),
+	Type:     to.Ptr("Microsoft.Network/dnszones"),
+	Location: to.Ptr("global"),
+	Etag:     to.Ptr("ETAG_ZONE"),
+	Tags: map[string]*string{
+		"env": to.Ptr("test"),
+	},
+	Properties: &armdns.ZoneProperties{
+		MaxNumberOfRecordSets:          to.Ptr[int64](10000),
+		MaxNumberOfRecordsPerRecordSet: to.Ptr[int64](20),
+		NumberOfRecordSets:             to.Ptr[int64](12),
+		NameServers: []*string{
+			to.Ptr("ns1-01.azure-dns.com."),
+			to.Ptr("ns2-01.azure-dns.net."),
+		},
+		ZoneType: to.Ptr(armdns.ZoneTypePublic),
+	},
+}
+
+var azureFakeZones = []armdns.Zone{
+	azureFakeZone,
+	{
+		ID:   to.Ptr("/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/dnszones/sub.example.com"),
+		Name: to.Ptr("sub.example.com"),
+		Tags: map[string]*string{
+			"env": to.Ptr("prod"),
+		},
+		Properties: &armdns.ZoneProperties{
+			ZoneType: to.Ptr(armdns.ZoneTypePublic),
+		},
+	},
+	{
+		ID:   to.Ptr("/subscriptions/fake-subscription-id/resourceGroups/another-resource-group-name/providers/Microsoft.Network/dnszones/myexample.com"),
+		Name: to.Ptr("myexample.com"),
+		Properties: &armdns.ZoneProperties{
+			ZoneType: to.Ptr(armdns.ZoneTypePublic),
+		},
+	},
+}
+
+func getFakeZonesServer() fake.ZonesServer {
+	return fake.ZonesServer{
+		NewListPager: func(options *armdns.ZonesClientListOptions) (resp azfake.PagerResponder[armdns.ZonesClientListResponse]) {
+			for _, fakeZonesChunk := range chunkBy(azureFakeZones, 2) {
+				values := []*armdns.Zone{}
+				for _, v := range fakeZonesChunk {
+					zone := v
+					values = append(values, &zone)
+				}
+				page := armdns.ZonesClientListResponse{
+					ZoneListResult: armdns.ZoneListResult{
+						Value: values,
+					},
+				}
+				resp.AddPage(http.StatusOK, page, nil)
+			}
+			return
+		},
+		NewListByResourceGroupPager: func(resourceGroupName string, options *armdns.ZonesClientListByResourceGroupOptions) (resp azfake.PagerResponder[armdns.ZonesClientListByResourceGroupResponse]) {
+			values := []*armdns.Zone{}
+			for _, v := range azureFakeZones {
+				zone := v
+				if strings.Contains(*zone.ID, "/resourceGroups/"+resourceGroupName+"/") {
+					values = append(values, &zone)
+				}
+			}
+			page := armdns.ZonesClientListByResourceGroupResponse{
+				ZoneListResult: armdns.ZoneListResult{
+					Value: values,
+				},
+			}
+			resp.AddPage(http.StatusOK, page, nil)
+			return
+		},
+		Get: func(ctx context.Context, resourceGroupName string, zoneName string, options *armdns.ZonesClientGetOptions) (resp azfake.Responder[armdns.ZonesClientGetResponse], errResp azfake.ErrorResponder) {
+			if zoneName != *azureFakeZone.Name {
+				errResp.SetResponseError(http.StatusNotFound, "NotFound")
+				return
+			}
+			response := armdns.ZonesClientGetResponse{
+				Zone: azureFakeZone,
+			}
+			resp.SetResponse(http.StatusOK, response, nil)
+			return
+		},
+	}
+}
+
 func getFakeProvider() (provider Provider) {
-	fakeRecordSetsServer := getFakeRecordSetsServer()
-	azureClient, _ := armdns.NewRecordSetsClient("fake-subscription-id", &azfake.TokenCredential{}, &arm.ClientOptions{
+	return getFakeProviderWithServer(getFakeRecordSetsServer())
+}
+
+func getFakeProviderWithServer(fakeRecordSetsServer fake.RecordSetsServer) (provider Provider) {
+	return getFakeProviderWithServerFactory(fake.ServerFactory{
+		RecordSetsServer: fakeRecordSetsServer,
+		ZonesServer:      getFakeZonesServer(),
+	})
+}
+
+func getFakeProviderWithServerFactory(fakeServerFactory fake.ServerFactory) (provider Provider) {
+	clientOptions := &arm.ClientOptions{
 		ClientOptions: azcore.ClientOptions{
-			Transport: fake.NewRecordSetsServerTransport(&fakeRecordSetsServer),
+			Transport: fake.NewServerFactoryTransport(&fakeServerFactory),
 		},
-	})
+	}
+	clientFactory, _ := armdns.NewClientFactory("fake-subscription-id", &azfake.TokenCredential{}, clientOptions)
+	armClient, _ := arm.NewClient(moduleName, moduleVersion, &azfake.TokenCredential{}, clientOptions)
 	provider = Provider{
 		SubscriptionId:    "fake-subscription-id",
 		ResourceGroupName: "fake-resource-group-name",
 		client: Client{
-			azureClient: azureClient,
+			azureClient:   clientFactory.NewRecordSetsClient(),
+			zonesClient:   clientFactory.NewZonesClient(),
+			armClient:     armClient,
+			credential:    &azfake.TokenCredential{},
+			clientOptions: clientOptions,
 		},
 	}
 	return
 }
 
-func Test_getRecords(t *testing.T) {
-	provider := getFakeProvider()
-	records, err := provider.getRecords(context.TODO(), "example.com.")
-	if err != nil {
-		t.Errorf("%s", err)
+// fakeZone is an in-memory fake of the zone example.com., holding its record sets keyed by their name and type, e.g. "record-txt/TXT",
+// in the order they were added. The providers returned by getFakeProviderWithZone read and write them through the fake servers,
+// which honor the preconditions of the writes and deletions, give every record set written a new ETag, and log the calls.
+type fakeZone struct {
+	keys       []string
+	recordSets map[string]*armdns.RecordSet
+
+	// calls are the calls made to the record sets, i.e. "get", "list", "write", and "delete", in order.
+	calls []string
+
+	// written are the record sets written, as they were sent.
+	written []armdns.RecordSet
+
+	// zoneGets and zoneLists count the calls to get the zone and to list the zones in the resource group.
+	zoneGets  int
+	zoneLists int
+
+	version int
+	mutex   sync.Mutex
+}
+
+// newFakeZone returns a fake zone holding the record sets.
+func newFakeZone(recordSets ...*armdns.RecordSet) *fakeZone {
+	zone := &fakeZone{recordSets: map[string]*armdns.RecordSet{}}
+	for _, recordSet := range recordSets {
+		zone.put(fakeRecordSetKey(stringValue(recordSet.Name), strings.TrimPrefix(stringValue(recordSet.Type), "Microsoft.Network/dnszones/")), recordSet)
 	}
-	for _, record := range records {
-		t.Log(record)
+	return zone
+}
+
+// fakeRecordSetKey returns the key of the record set of the name and the type in a fake zone.
+func fakeRecordSetKey(name string, typeName string) string {
+	return name + "/" + typeName
+}
+
+// get returns the record set of the name and the type, or nil if there is none.
+func (z *fakeZone) get(name string, typeName string) *armdns.RecordSet {
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+
+	return z.recordSets[fakeRecordSetKey(name, typeName)]
+}
+
+// put adds or replaces the record set under the key. It must be called with the zone locked, or before the zone is used.
+func (z *fakeZone) put(key string, recordSet *armdns.RecordSet) {
+	if _, ok := z.recordSets[key]; !ok {
+		z.keys = append(z.keys, key)
 	}
-	if len(records) != len(azureFakeRecords) {
-		t.Errorf("got: %d, want: %d", len(records), len(azureFakeRecords))
+	z.recordSets[key] = recordSet
+}
+
+// list returns the record sets of the type, or all of them if the type is empty, in the order they were added.
+// It must be called with the zone locked.
+func (z *fakeZone) list(typeName string) []*armdns.RecordSet {
+	var values []*armdns.RecordSet
+	for _, key := range z.keys {
+		if typeName == "" || strings.HasSuffix(key, "/"+typeName) {
+			recordSet := *z.recordSets[key]
+			values = append(values, &recordSet)
+		}
 	}
+	return values
 }
 
-func Test_createRecord(t *testing.T) {
-	provider := getFakeProvider()
-	record, err := provider.createRecord(context.TODO(), "example.com.", libdnsFakeRecords[0])
-	t.Log(record)
-	if err != nil {
-		t.Errorf("%s", err)
+// checkPreconditions reports whether the record set under the key matches the preconditions of a write or a deletion.
+// It must be called with the zone locked.
+func (z *fakeZone) checkPreconditions(key string, ifMatch *string, ifNoneMatch *string) bool {
+	recordSet, ok := z.recordSets[key]
+	if stringValue(ifNoneMatch) == "*" && ok {
+		return false
 	}
+	return ifMatch == nil || (ok && *ifMatch == stringValue(recordSet.Etag))
 }
 
-func Test_updateRecord(t *testing.T) {
-	provider := getFakeProvider()
-	record, err := provider.updateRecord(context.TODO(), "example.com.", libdnsFakeRecords[0])
-	t.Log(record)
-	if err != nil {
-		t.Errorf("%s", err)
+// server returns a fake server of the record sets of the zone.
+func (z *fakeZone) server() fake.RecordSetsServer {
+	fakeRecordSetsServer := getFakeRecordSetsServer()
+	fakeRecordSetsServer.Get = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientGetOptions) (resp azfake.Responder[armdns.RecordSetsClientGetResponse], errResp azfake.ErrorResponder) {
+		z.mutex.Lock()
+		defer z.mutex.Unlock()
+		z.calls = append(z.calls, "get")
+		recordSet, ok := z.recordSets[fakeRecordSetKey(relativeRecordSetName, string(recordType))]
+		if !ok {
+			errResp.SetResponseError(http.StatusNotFound, "NotFound")
+			return
+		}
+		resp.SetResponse(http.StatusOK, armdns.RecordSetsClientGetResponse{RecordSet: *recordSet}, nil)
+		return
+	}
+	fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+		z.mutex.Lock()
+		defer z.mutex.Unlock()
+		z.calls = append(z.calls, "write")
+		key := fakeRecordSetKey(relativeRecordSetName, string(recordType))
+		if options == nil {
+			options = &armdns.RecordSetsClientCreateOrUpdateOptions{}
+		}
+		if !z.checkPreconditions(key, options.IfMatch, options.IfNoneMatch) {
+			errResp.SetResponseError(http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		z.written = append(z.written, parameters)
+		z.version++
+		parameters.Name = to.Ptr(relativeRecordSetName)
+		parameters.Type = to.Ptr("Microsoft.Network/dnszones/" + string(recordType))
+		parameters.Etag = to.Ptr("ETAG_" + strconv.Itoa(z.version))
+		z.put(key, &parameters)
+		resp.SetResponse(http.StatusOK, armdns.RecordSetsClientCreateOrUpdateResponse{RecordSet: parameters}, nil)
+		return
+	}
+	fakeRecordSetsServer.Delete = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientDeleteOptions) (resp azfake.Responder[armdns.RecordSetsClientDeleteResponse], errResp azfake.ErrorResponder) {
+		z.mutex.Lock()
+		defer z.mutex.Unlock()
+		z.calls = append(z.calls, "delete")
+		key := fakeRecordSetKey(relativeRecordSetName, string(recordType))
+		if options == nil {
+			options = &armdns.RecordSetsClientDeleteOptions{}
+		}
+		if !z.checkPreconditions(key, options.IfMatch, nil) {
+			errResp.SetResponseError(http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		if _, ok := z.recordSets[key]; ok {
+			delete(z.recordSets, key)
+			z.keys = slices.DeleteFunc(z.keys, func(k string) bool { return k == key })
+		}
+		resp.SetResponse(http.StatusOK, armdns.RecordSetsClientDeleteResponse{}, nil)
+		return
+	}
+	fakeRecordSetsServer.NewListByDNSZonePager = func(resourceGroupName string, zoneName string, options *armdns.RecordSetsClientListByDNSZoneOptions) (resp azfake.PagerResponder[armdns.RecordSetsClientListByDNSZoneResponse]) {
+		z.mutex.Lock()
+		defer z.mutex.Unlock()
+		z.calls = append(z.calls, "list")
+		resp.AddPage(http.StatusOK, armdns.RecordSetsClientListByDNSZoneResponse{
+			RecordSetListResult: armdns.RecordSetListResult{Value: z.list("")},
+		}, nil)
+		return
 	}
+	fakeRecordSetsServer.NewListByTypePager = func(resourceGroupName string, zoneName string, recordType armdns.RecordType, options *armdns.RecordSetsClientListByTypeOptions) (resp azfake.PagerResponder[armdns.RecordSetsClientListByTypeResponse]) {
+		z.mutex.Lock()
+		defer z.mutex.Unlock()
+		z.calls = append(z.calls, "list")
+		resp.AddPage(http.StatusOK, armdns.RecordSetsClientListByTypeResponse{
+			RecordSetListResult: armdns.RecordSetListResult{Value: z.list(string(recordType))},
+		}, nil)
+		return
+	}
+	return fakeRecordSetsServer
 }
 
-func Test_deleteRecord(t *testing.T) {
-	provider := getFakeProvider()
-	record, err := provider.deleteRecord(context.TODO(), "example.com.", libdnsFakeRecords[0])
-	t.Log(record)
-	if err != nil {
-		t.Errorf("%s", err)
+// zonesServer returns a fake server of the zones that counts the calls to get the zone and to list the zones.
+func (z *fakeZone) zonesServer() fake.ZonesServer {
+	fakeZonesServer := getFakeZonesServer()
+	get := fakeZonesServer.Get
+	fakeZonesServer.Get = func(ctx context.Context, resourceGroupName string, zoneName string, options *armdns.ZonesClientGetOptions) (resp azfake.Responder[armdns.ZonesClientGetResponse], errResp azfake.ErrorResponder) {
+		z.mutex.Lock()
+		z.zoneGets++
+		z.mutex.Unlock()
+		return get(ctx, resourceGroupName, zoneName, options)
 	}
+	newListByResourceGroupPager := fakeZonesServer.NewListByResourceGroupPager
+	fakeZonesServer.NewListByResourceGroupPager = func(resourceGroupName string, options *armdns.ZonesClientListByResourceGroupOptions) (resp azfake.PagerResponder[armdns.ZonesClientListByResourceGroupResponse]) {
+		z.mutex.Lock()
+		z.zoneLists++
+		z.mutex.Unlock()
+		return newListByResourceGroupPager(resourceGroupName, options)
+	}
+	return fakeZonesServer
 }
 
-func Test_generateRecordSetName(t *testing.T) {
-	t.Run("name=\"\"", func(t *testing.T) {
-		got := generateRecordSetName("", "example.com.")
-		want := "@"
-		if diff := cmp.Diff(got, want); diff != "" {
-			t.Errorf("diff: %s", diff)
-		}
+// getFakeProviderWithZone returns a fake provider managing the record sets of the fake zone.
+func getFakeProviderWithZone(zone *fakeZone) *Provider {
+	provider := getFakeProviderWithServerFactory(fake.ServerFactory{
+		RecordSetsServer: zone.server(),
+		ZonesServer:      zone.zonesServer(),
 	})
-	t.Run("name=@", func(t *testing.T) {
-		got := generateRecordSetName("@", "example.com.")
-		want := "@"
-		if diff := cmp.Diff(got, want); diff != "" {
-			t.Errorf("diff: %s", diff)
-		}
+	return &provider
+}
+
+// getFakeProviderWithTransport returns a fake provider making its requests through transport, along with the providers
+// derived from it, e.g. to respond to the requests to the endpoints other than those of Azure DNS.
+// The transport of the fake servers is given to pass on the requests that transport does not handle.
+func getFakeProviderWithTransport(transport func(req *http.Request, next policy.Transporter) (*http.Response, error)) *Provider {
+	provider := getFakeProvider()
+	next := provider.client.clientOptions.Transport
+	fakeTransport := transportFunc(func(req *http.Request) (*http.Response, error) {
+		return transport(req, next)
 	})
-	t.Run("name=test", func(t *testing.T) {
-		got := generateRecordSetName("test", "example.com.")
-		want := "test"
-		if diff := cmp.Diff(got, want); diff != "" {
-			t.Errorf("diff: %s", diff)
+	provider.client.clientOptions.Transport = fakeTransport
+	provider.ClientOptions = &arm.ClientOptions{ClientOptions: azcore.ClientOptions{Transport: fakeTransport}}
+	provider.resetClient()
+	return &provider
+}
+
+// newFakeJSONResponse returns a response to the request with the status code and the JSON body.
+func newFakeJSONResponse(req *http.Request, statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func Test_resetClient(t *testing.T) {
+	t.Run("secret", func(t *testing.T) {
+		provider := getFakeProvider()
+		provider.SetClientSecret("new-client-secret")
+		if provider.client.azureClient != nil {
+			t.Errorf("the client is not reset")
 		}
-	})
-	t.Run("name=test.example.com", func(t *testing.T) {
-		got := generateRecordSetName("test.example.com", "example.com.")
-		want := "test"
-		if diff := cmp.Diff(got, want); diff != "" {
-			t.Errorf("diff: %s", diff)
+		if provider.ClientSecret != "new-client-secret" {
+			t.Errorf("got: %s, want: %s", provider.ClientSecret, "new-client-secret")
 		}
 	})
-	t.Run("name=test.example.com.", func(t *testing.T) {
-		got := generateRecordSetName("test.example.com.", "example.com.")
-		want := "test"
-		if diff := cmp.Diff(got, want); diff != "" {
-			t.Errorf("diff: %s", diff)
+	t.Run("credential", func(t *testing.T) {
+		provider := getFakeProvider()
+		provider.SetCredential(&azfake.TokenCredential{})
+		if provider.client.azureClient != nil {
+			t.Errorf("the client is not reset")
 		}
-	})
-	t.Run("name=example.com.", func(t *testing.T) {
-		got := generateRecordSetName("example.com.", "example.com.")
-		want := "@"
-		if diff := cmp.Diff(got, want); diff != "" {
-			t.Errorf("diff: %s", diff)
+		if err := provider.setupClient(); err != nil {
+			t.Errorf("%s", err)
+		}
+		if provider.client.azureClient == nil {
+			t.Errorf("the client is not set up")
 		}
 	})
 }
 
-func Test_convertStringToRecordType(t *testing.T) {
-	typeNames := []string{"A", "AAAA", "CAA", "CNAME", "MX", "NS", "PTR", "SOA", "SRV", "TXT"}
-	for _, typeName := range typeNames {
-		t.Run("type="+typeName, func(t *testing.T) {
-			recordType, _ := convertStringToRecordType(typeName)
-			got := fmt.Sprintf("%T:%v", recordType, recordType)
-			want := "armdns.RecordType:" + typeName
-			if diff := cmp.Diff(got, want); diff != "" {
-				t.Errorf("diff: %s", diff)
-			}
-		})
+func Test_Close(t *testing.T) {
+	provider := &Provider{SubscriptionId: "subscription", ResourceGroupName: "resource-group", ProxyURL: "http://proxy.example.com:8080"}
+	provider.SetCredential(&azfake.TokenCredential{})
+	if err := provider.setupClient(); err != nil {
+		t.Fatalf("%s", err)
 	}
-	t.Run("type=ERR", func(t *testing.T) {
-		_, err := convertStringToRecordType("ERR")
-		got := err.Error()
-		want := "the type ERR cannot be interpreted"
-		if diff := cmp.Diff(got, want); diff != "" {
-			t.Errorf("diff: %s", diff)
+	derived := provider.forContext(WithSubscription(context.TODO(), "other-subscription"))
+	if err := derived.setupClient(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	provider.zoneCache.put(ZoneInfo{Name: "example.com"}, time.Now())
+	if len(provider.client.transports) != 1 || len(derived.client.transports) != 1 {
+		t.Fatalf("got: %d and %d transports, want: 1 and 1", len(provider.client.transports), len(derived.client.transports))
+	}
+
+	if err := provider.Close(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	for name, p := range map[string]*Provider{"provider": provider, "derived": derived} {
+		if p.client.azureClient != nil || p.client.tokenCredential != nil {
+			t.Errorf("the client of the %s is not released", name)
 		}
-	})
+		if p.client.transports != nil {
+			t.Errorf("the transports of the %s are not released", name)
+		}
+	}
+	if provider.overrides.providers != nil {
+		t.Errorf("the derived providers are not released")
+	}
+	if provider.zoneCache.zones != nil {
+		t.Errorf("the zone cache is not flushed")
+	}
+
+	if err := provider.setupClient(); err != nil {
+		t.Errorf("%s", err)
+	}
+	if provider.client.azureClient == nil {
+		t.Errorf("the client is not set up again")
+	}
 }
 
-func Test_convertAzureRecordSetsToLibdnsRecords(t *testing.T) {
-	t.Run("type=supported", func(t *testing.T) {
-		azureRecordSets := []*armdns.RecordSet{}
-		for _, v := range azureFakeRecords {
-			record := v
-			azureRecordSets = append(azureRecordSets, &record)
+func Test_ARMClients(t *testing.T) {
+	provider := getFakeProvider()
+	provider.resetClient()
+
+	recordSetsClient, err := provider.ARMRecordSetsClient()
+	if err != nil {
+		t.Errorf("%s", err)
+	}
+	if recordSetsClient == nil || recordSetsClient != provider.client.azureClient {
+		t.Errorf("the record sets client is not set up")
+	}
+	zonesClient, err := provider.ARMZonesClient()
+	if err != nil {
+		t.Errorf("%s", err)
+	}
+	if zonesClient == nil || zonesClient != provider.client.zonesClient {
+		t.Errorf("the zones client is not set up")
+	}
+}
+
+func Test_retryOnAuthenticationError(t *testing.T) {
+	t.Run("error=unauthorized", func(t *testing.T) {
+		calls := 0
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		get := fakeRecordSetsServer.Get
+		fakeRecordSetsServer.Get = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientGetOptions) (resp azfake.Responder[armdns.RecordSetsClientGetResponse], errResp azfake.ErrorResponder) {
+			calls++
+			if calls == 1 {
+				errResp.SetResponseError(http.StatusUnauthorized, "ExpiredAuthenticationToken")
+				return
+			}
+			return get(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, options)
 		}
-		got, _ := convertAzureRecordSetsToLibdnsRecords(azureRecordSets)
-		want := libdnsFakeRecords
-		if diff := cmp.Diff(got, want); diff != "" {
-			t.Errorf("diff: %s", diff)
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		azureClient := provider.client.azureClient
+		_, err := provider.createRecords(context.TODO(), "example.com.", []libdns.Record{libdnsFakeRecords[0]})
+		if err != nil {
+			t.Errorf("the authentication error is not recovered: %s", err)
 		}
-	})
-	t.Run("type=unsupported", func(t *testing.T) {
-		azureRecordSets := []*armdns.RecordSet{{
-			Type: to.Ptr("Microsoft.Network/dnszones/ERR"),
-		}}
-		_, err := convertAzureRecordSetsToLibdnsRecords(azureRecordSets)
-		got := err.Error()
-		want := "the type ERR cannot be interpreted"
-		if diff := cmp.Diff(got, want); diff != "" {
-			t.Errorf("diff: %s", diff)
+		if calls != 2 {
+			t.Errorf("got: %d, want: %d", calls, 2)
+		}
+		if provider.client.azureClient == azureClient {
+			t.Errorf("the client is not rebuilt")
 		}
 	})
-}
-
-func Test_convertLibdnsRecordToAzureRecordSet(t *testing.T) {
-	t.Run("type=supported", func(t *testing.T) {
-		var got []armdns.RecordSet
-		for _, libdnsRecord := range libdnsFakeRecords {
-			convertedRecord, _ := convertLibdnsRecordToAzureRecordSet(libdnsRecord)
-			got = append(got, convertedRecord)
+	t.Run("error=forbidden", func(t *testing.T) {
+		calls := 0
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		fakeRecordSetsServer.Delete = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientDeleteOptions) (resp azfake.Responder[armdns.RecordSetsClientDeleteResponse], errResp azfake.ErrorResponder) {
+			calls++
+			errResp.SetResponseError(http.StatusForbidden, "AuthorizationFailed")
+			return
 		}
-		want := azureFakeRecords
-		opts := []cmp.Option{
-			cmpopts.IgnoreFields(armdns.RecordSet{}, "Name", "Type", "Etag"),
-			cmpopts.IgnoreFields(armdns.RecordSetProperties{}, "Fqdn"),
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		_, err := provider.deleteRecords(context.TODO(), "example.com.", libdnsFakeRecords[:1], DeleteOptions{})
+		if err == nil {
+			t.Errorf("expected an error for the forbidden request")
 		}
-		if diff := cmp.Diff(got, want, opts...); diff != "" {
-			t.Errorf("diff: %s", diff)
+		if calls != 1 {
+			t.Errorf("got: %d, want: %d", calls, 1)
+		}
+	})
+}
+
+func Test_getClientSecretCredentialOptions(t *testing.T) {
+	tests := []struct {
+		name                       string
+		disableInstanceDiscovery   bool
+		additionallyAllowedTenants []string
+		want                       azidentity.ClientSecretCredentialOptions
+	}{
+		{
+			name: "options=default",
+			want: azidentity.ClientSecretCredentialOptions{},
+		},
+		{
+			name:                     "discovery=disabled",
+			disableInstanceDiscovery: true,
+			want:                     azidentity.ClientSecretCredentialOptions{DisableInstanceDiscovery: true},
+		},
+		{
+			name:                       "tenants=additional",
+			additionallyAllowedTenants: []string{"guest-tenant-id"},
+			want:                       azidentity.ClientSecretCredentialOptions{AdditionallyAllowedTenants: []string{"guest-tenant-id"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := Provider{
+				DisableInstanceDiscovery:   tt.disableInstanceDiscovery,
+				AdditionallyAllowedTenants: tt.additionallyAllowedTenants,
+			}
+			got := provider.getClientSecretCredentialOptions(azcore.ClientOptions{})
+			if got.DisableInstanceDiscovery != tt.want.DisableInstanceDiscovery {
+				t.Errorf("got: %v, want: %v", got.DisableInstanceDiscovery, tt.want.DisableInstanceDiscovery)
+			}
+			if diff := cmp.Diff(got.AdditionallyAllowedTenants, tt.want.AdditionallyAllowedTenants); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_getManagedIdentityCredentialOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		clientId   string
+		resourceId string
+		want       azidentity.ManagedIDKind
+		wantErr    bool
+	}{
+		{name: "identity=system"},
+		{name: "identity=client-id", clientId: "fake-client-id", want: azidentity.ClientID("fake-client-id")},
+		{name: "identity=resource-id", resourceId: "fake-resource-id", want: azidentity.ResourceID("fake-resource-id")},
+		{name: "identity=ERR", clientId: "fake-client-id", resourceId: "fake-resource-id", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := Provider{
+				ManagedIdentityClientId:   tt.clientId,
+				ManagedIdentityResourceId: tt.resourceId,
+			}
+			got, err := provider.getManagedIdentityCredentialOptions(azcore.ClientOptions{})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got: nil error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got.ID, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+	environments := []struct {
+		name       string
+		path       string
+		clientId   string
+		resourceId string
+		wantQuery  string
+	}{
+		{name: "environment=app-service", path: "/msi/token", clientId: "fake-client-id", wantQuery: "client_id=fake-client-id"},
+		{name: "environment=container-apps", path: "/msi/token", resourceId: "fake-resource-id", wantQuery: "mi_res_id=fake-resource-id"},
+		{name: "environment=container-apps,system", path: "/msi/token", wantQuery: ""},
+	}
+	for _, tt := range environments {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath, gotHeader, gotQuery string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				gotHeader = r.Header.Get("X-IDENTITY-HEADER")
+				for _, key := range []string{"client_id", "mi_res_id"} {
+					if value := r.URL.Query().Get(key); value != "" {
+						gotQuery = key + "=" + value
+					}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"access_token":"fake-token","expires_on":"%d","resource":"https://management.azure.com","token_type":"Bearer"}`, time.Now().Add(time.Hour).Unix())
+			}))
+			defer server.Close()
+			// App Service, Azure Functions, and Azure Container Apps provide the same environment variables
+			t.Setenv("IDENTITY_ENDPOINT", server.URL+tt.path)
+			t.Setenv("IDENTITY_HEADER", "fake-identity-header")
+
+			provider := Provider{
+				ManagedIdentityClientId:   tt.clientId,
+				ManagedIdentityResourceId: tt.resourceId,
+			}
+			credential, err := provider.newCredential()
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			token, err := credential.GetToken(context.TODO(), policy.TokenRequestOptions{Scopes: []string{"https://management.azure.com/.default"}})
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if token.Token != "fake-token" || gotPath != tt.path || gotHeader != "fake-identity-header" || gotQuery != tt.wantQuery {
+				t.Errorf("got: token %v, path %v, header %v, query %v", token.Token, gotPath, gotHeader, gotQuery)
+			}
+		})
+	}
+}
+
+func Test_newCredentials(t *testing.T) {
+	present := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer present.Close()
+	absent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	absent.Close()
+
+	tests := []struct {
+		name     string
+		fallback bool
+		strict   bool
+		imdsURL  string
+		secret   bool
+		want     []string
+		wantErr  bool
+	}{
+		{name: "fallback=false", imdsURL: absent.URL, want: []string{"*azidentity.ManagedIdentityCredential"}},
+		{name: "fallback=true,imds=present", fallback: true, imdsURL: present.URL, want: []string{"*azidentity.ManagedIdentityCredential", "*azidentity.AzureCLICredential"}},
+		{name: "fallback=true,imds=absent", fallback: true, imdsURL: absent.URL, want: []string{"*azidentity.AzureCLICredential"}},
+		{name: "fallback=true,secret", fallback: true, imdsURL: absent.URL, secret: true, want: []string{"*azidentity.ClientSecretCredential"}},
+		{name: "strict=true,secret", fallback: true, strict: true, imdsURL: present.URL, secret: true, want: []string{"*azidentity.ClientSecretCredential"}},
+		{name: "strict=true,no-secret", fallback: true, strict: true, imdsURL: present.URL, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"IDENTITY_ENDPOINT", "MSI_ENDPOINT"} {
+				t.Setenv(name, "")
+				os.Unsetenv(name)
+			}
+			for _, name := range []string{"AZURE_TENANT_ID", "AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET", "AZURE_CLIENT_CERTIFICATE_PATH", "AZURE_USERNAME"} {
+				t.Setenv(name, "")
+			}
+			defaultIMDSProbeURL := imdsProbeURL
+			imdsProbeURL = tt.imdsURL
+			defer func() { imdsProbeURL = defaultIMDSProbeURL }()
+
+			provider := Provider{FallbackToDeveloperCredentials: tt.fallback, StrictCredentials: tt.strict}
+			if tt.secret {
+				provider.TenantId, provider.ClientId, provider.ClientSecret = "fake-tenant-id", "fake-client-id", "fake-client-secret"
+			}
+			credentials, err := provider.newCredentials()
+			if tt.wantErr {
+				var credentialError *CredentialError
+				if !errors.As(err, &credentialError) {
+					t.Errorf("got: %v, want: CredentialError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			var got []string
+			for _, credential := range credentials {
+				got = append(got, fmt.Sprintf("%T", credential))
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_getClientOptions(t *testing.T) {
+	t.Run("endpoint=default", func(t *testing.T) {
+		provider := Provider{}
+		clientOptions, err := provider.getClientOptions()
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		if clientOptions.Transport != nil || clientOptions.Cloud.Services != nil {
+			t.Errorf("the client options are customized: %v", clientOptions)
+		}
+	})
+	t.Run("endpoint=private", func(t *testing.T) {
+		provider := Provider{
+			ResourceManagerEndpoint:   "https://management.privatelink.example.com/",
+			ResourceManagerServerName: "management.azure.com",
+		}
+		clientOptions, err := provider.getClientOptions()
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		got := clientOptions.Cloud.Services[cloud.ResourceManager]
+		want := cloud.ServiceConfiguration{
+			Endpoint: "https://management.privatelink.example.com/",
+			Audience: cloud.AzurePublic.Services[cloud.ResourceManager].Audience,
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if cloud.AzurePublic.Services[cloud.ResourceManager].Endpoint == want.Endpoint {
+			t.Errorf("the default cloud configuration is modified")
+		}
+		httpClient, ok := clientOptions.Transport.(*http.Client)
+		if !ok {
+			t.Fatalf("got: %T, want: %T", clientOptions.Transport, &http.Client{})
+		}
+		if serverName := httpClient.Transport.(*http.Transport).TLSClientConfig.ServerName; serverName != "management.azure.com" {
+			t.Errorf("got: %s, want: %s", serverName, "management.azure.com")
+		}
+	})
+	t.Run("endpoint=ERR", func(t *testing.T) {
+		provider := Provider{
+			ResourceManagerEndpoint: "ERR",
+		}
+		_, err := provider.getClientOptions()
+		got := err.Error()
+		want := "the resource manager endpoint ERR cannot be interpreted"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("options=explicit", func(t *testing.T) {
+		transport := &http.Client{}
+		provider := Provider{
+			ClientOptions: &arm.ClientOptions{
+				ClientOptions: azcore.ClientOptions{
+					Cloud:     cloud.AzureChina,
+					Transport: transport,
+					Retry:     policy.RetryOptions{MaxRetries: 7},
+				},
+			},
+			ResourceManagerAudience: "https://management.core.chinacloudapi.cn/custom",
+			ProxyURL:                "http://proxy.example.com:8080",
+		}
+		clientOptions, err := provider.getClientOptions()
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := cloud.ServiceConfiguration{
+			Endpoint: cloud.AzureChina.Services[cloud.ResourceManager].Endpoint,
+			Audience: "https://management.core.chinacloudapi.cn/custom",
+		}
+		if diff := cmp.Diff(clientOptions.Cloud.Services[cloud.ResourceManager], want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if clientOptions.Transport != transport || clientOptions.Retry.MaxRetries != 7 {
+			t.Errorf("the client options are not kept: %v", clientOptions)
+		}
+		if len(provider.ClientOptions.PerCallPolicies) != 0 {
+			t.Errorf("the client options are modified")
+		}
+		credentialOptions, err := provider.getCredentialOptions()
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if credentialOptions.Cloud.ActiveDirectoryAuthorityHost != cloud.AzureChina.ActiveDirectoryAuthorityHost || credentialOptions.Transport != transport {
+			t.Errorf("the credential options do not follow the client options: %v", credentialOptions)
+		}
+	})
+}
+
+func Test_newTransport(t *testing.T) {
+	t.Run("proxy=default", func(t *testing.T) {
+		provider := Provider{}
+		transport, err := provider.newTransport("")
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		if transport != nil {
+			t.Errorf("got: %T, want: nil", transport)
+		}
+	})
+	t.Run("proxy=explicit", func(t *testing.T) {
+		provider := Provider{
+			ProxyURL: "http://proxy.example.com:8080",
+		}
+		transport, err := provider.newTransport("")
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		proxy := transport.(*http.Client).Transport.(*http.Transport).Proxy
+		tests := map[string]string{
+			"https://management.azure.com/subscriptions":      "http://proxy.example.com:8080",
+			"https://login.microsoftonline.com/tenant/oauth2": "http://proxy.example.com:8080",
+			"http://169.254.169.254/metadata/identity/oauth2": "",
+			"http://127.0.0.1:40342/metadata/identity/oauth2": "",
+			"http://localhost:40342/metadata/identity/oauth2": "",
+			"http://LOCALHOST:40342/metadata/identity/oauth2": "",
+		}
+		for target, want := range tests {
+			req, _ := http.NewRequest(http.MethodGet, target, nil)
+			proxyURL, err := proxy(req)
+			if err != nil {
+				t.Errorf("%s", err)
+			}
+			got := ""
+			if proxyURL != nil {
+				got = proxyURL.String()
+			}
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Errorf("%s diff: %s", target, diff)
+			}
+		}
+	})
+	t.Run("proxy=ERR", func(t *testing.T) {
+		provider := Provider{
+			ProxyURL: "ERR",
+		}
+		_, err := provider.newTransport("")
+		got := err.Error()
+		want := "the proxy URL ERR cannot be interpreted"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("connections=default", func(t *testing.T) {
+		provider := Provider{
+			ProxyURL: "http://proxy.example.com:8080",
+		}
+		transport, err := provider.newTransport("")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		httpTransport := transport.(*http.Client).Transport.(*http.Transport)
+		got := []any{httpTransport.MaxIdleConns, httpTransport.MaxIdleConnsPerHost, httpTransport.MaxConnsPerHost, httpTransport.IdleConnTimeout, httpTransport.ForceAttemptHTTP2}
+		want := []any{100, 10, 0, 90 * time.Second, true}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("connections=tuned", func(t *testing.T) {
+		provider := Provider{
+			MaxIdleConnsPerHost: 64,
+			MaxIdleConns:        256,
+			MaxConnsPerHost:     128,
+			IdleConnTimeout:     5 * time.Minute,
+			DisableHTTP2:        true,
+		}
+		transport, err := provider.newTransport("")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		httpTransport := transport.(*http.Client).Transport.(*http.Transport)
+		got := []any{httpTransport.MaxIdleConns, httpTransport.MaxIdleConnsPerHost, httpTransport.MaxConnsPerHost, httpTransport.IdleConnTimeout, httpTransport.ForceAttemptHTTP2}
+		want := []any{256, 64, 128, 5 * time.Minute, false}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if httpTransport.TLSNextProto == nil || len(httpTransport.TLSNextProto) != 0 {
+			t.Errorf("HTTP/2 is not disabled")
+		}
+	})
+	t.Run("connections=ERR", func(t *testing.T) {
+		provider := Provider{
+			MaxIdleConnsPerHost: -1,
+		}
+		_, err := provider.newTransport("")
+		got := err.Error()
+		want := "the max idle connections per host -1 cannot be interpreted"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
+
+func Test_getRootCAs(t *testing.T) {
+	certificate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "libdns-azure-test-ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.CreateCertificate(rand.Reader, certificate, certificate, &key.PublicKey, key)
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	t.Run("file=valid", func(t *testing.T) {
+		provider := Provider{
+			TLSRootCAs:     x509.NewCertPool(),
+			TLSRootCAFiles: []string{file},
+		}
+		rootCAs, err := provider.getRootCAs()
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		parsed, _ := x509.ParseCertificate(der)
+		want := x509.NewCertPool()
+		want.AddCert(parsed)
+		if !rootCAs.Equal(want) {
+			t.Errorf("the certificate is not added to the pool")
+		}
+		if provider.TLSRootCAs.Equal(want) {
+			t.Errorf("the given pool is modified")
+		}
+	})
+	t.Run("file=invalid", func(t *testing.T) {
+		invalidFile := filepath.Join(t.TempDir(), "invalid.pem")
+		if err := os.WriteFile(invalidFile, []byte("ERR"), 0600); err != nil {
+			t.Fatalf("%s", err)
+		}
+		provider := Provider{
+			TLSRootCAs:     x509.NewCertPool(),
+			TLSRootCAFiles: []string{invalidFile},
+		}
+		_, err := provider.getRootCAs()
+		got := err.Error()
+		want := "the root CA file " + invalidFile + " contains no certificates"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("version=1.3", func(t *testing.T) {
+		provider := Provider{
+			TLSMinVersion: "1.3",
+		}
+		transport, err := provider.newTransport("")
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		if got := transport.(*http.Client).Transport.(*http.Transport).TLSClientConfig.MinVersion; got != tls.VersionTLS13 {
+			t.Errorf("got: %x, want: %x", got, tls.VersionTLS13)
+		}
+	})
+	t.Run("version=ERR", func(t *testing.T) {
+		provider := Provider{
+			TLSMinVersion: "ERR",
+		}
+		_, err := provider.newTransport("")
+		got := err.Error()
+		want := "the TLS version ERR cannot be interpreted"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
+
+type transportFunc func(req *http.Request) (*http.Response, error)
+
+func (f transportFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func Test_correlationIDPolicy(t *testing.T) {
+	var got []string
+	provider := getFakeProvider()
+	transport := provider.client.clientOptions.Transport
+	provider.client.clientOptions.Transport = transportFunc(func(req *http.Request) (*http.Response, error) {
+		got = append(got, req.Header.Get("x-ms-correlation-request-id"))
+		return transport.Do(req)
+	})
+	provider.resetClient()
+
+	t.Run("id=given", func(t *testing.T) {
+		got = nil
+		ctx := WithCorrelationID(context.TODO(), "given-correlation-id")
+		if _, err := provider.GetRecords(ctx, "example.com."); err != nil {
+			t.Errorf("%s", err)
+		}
+		if len(got) == 0 {
+			t.Errorf("no requests are sent")
+		}
+		for _, correlationID := range got {
+			if correlationID != "given-correlation-id" {
+				t.Errorf("got: %s, want: %s", correlationID, "given-correlation-id")
+			}
+		}
+	})
+	t.Run("id=generated", func(t *testing.T) {
+		got = nil
+		if _, err := provider.SetRecords(context.TODO(), "example.com.", libdnsFakeRecords[:2]); err != nil {
+			t.Errorf("%s", err)
+		}
+		// Each record set is read and then written
+		if len(got) != 4 || got[0] == "" || got[0] != got[1] || got[0] != got[2] || got[0] != got[3] {
+			t.Errorf("the same correlation ID is not sent with all requests: %v", got)
+		}
+	})
+	t.Run("id=error", func(t *testing.T) {
+		_, err := provider.AppendRecords(WithCorrelationID(context.TODO(), "given-correlation-id"), "example.com.", []libdns.Record{
+			libdns.RR{Name: "record-spf", Type: "SPF", Data: "v=spf1 -all"},
+		})
+		if err == nil || !strings.Contains(err.Error(), "given-correlation-id") {
+			t.Errorf("the correlation ID is not included in the error: %v", err)
+		}
+	})
+}
+
+func Test_getZoneInfo(t *testing.T) {
+	t.Run("zone=existing", func(t *testing.T) {
+		provider := getFakeProvider()
+		got, err := provider.getZoneInfo(context.TODO(), "example.com.")
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		want := ZoneInfo{
+			Name:                           "example.com.",
+			ID:                             "/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/dnszones/example.com",
+			NameServers:                    []string{"ns1-01.azure-dns.com.", "ns2-01.azure-dns.net."},
+			NumberOfRecordSets:             12,
+			MaxNumberOfRecordSets:          10000,
+			MaxNumberOfRecordsPerRecordSet: 20,
+			Tags:                           map[string]string{"env": "test"},
+			ZoneType:                       "Public",
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("zone=missing", func(t *testing.T) {
+		provider := getFakeProvider()
+		_, err := provider.getZoneInfo(context.TODO(), "example.net.")
+		if !isNotFoundError(err) {
+			t.Errorf("got: %v, want: not found error", err)
+		}
+	})
+}
+
+func Test_getRecords(t *testing.T) {
+	t.Run("scope=zone", func(t *testing.T) {
+		provider := getFakeProvider()
+		records, err := provider.getRecords(context.TODO(), "example.com.", "")
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		for _, record := range records {
+			t.Log(record)
+		}
+		if len(records) != len(libdnsFakeRecords) {
+			t.Errorf("got: %d, want: %d", len(records), len(libdnsFakeRecords))
+		}
+	})
+	t.Run("scope=type", func(t *testing.T) {
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		// Listing the whole zone is not expected
+		fakeRecordSetsServer.NewListByDNSZonePager = nil
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		for _, typeName := range []string{"NS", "txt"} {
+			records, err := provider.getRecords(context.TODO(), "example.com.", typeName)
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			var want []libdns.Record
+			for _, record := range libdnsFakeRecords {
+				if strings.EqualFold(record.RR().Type, typeName) {
+					want = append(want, record)
+				}
+			}
+			if len(want) == 0 {
+				t.Fatalf("no records of the type %v in the fixture", typeName)
+			}
+			if diff := cmp.Diff(records, want, recordComparer); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		}
+		_, err := provider.getRecords(context.TODO(), "example.com.", "ERR")
+		if err == nil || err.Error() != "the type ERR cannot be interpreted" {
+			t.Errorf("got: %v", err)
+		}
+	})
+	t.Run("scope=record sets", func(t *testing.T) {
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		// Listing the zone is not allowed
+		fakeRecordSetsServer.NewListByDNSZonePager = nil
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		provider.ScopedRecordSets = []RecordSetScope{
+			{Name: "record-txt", Type: "TXT"},
+			{Name: "@", Type: "NS"},
+			{Name: "record-missing", Type: "TXT"},
+		}
+		records, err := provider.getRecords(context.TODO(), "example.com.", "")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		var want []libdns.Record
+		for _, scope := range provider.ScopedRecordSets {
+			for _, record := range libdnsFakeRecords {
+				if record.RR().Name == scope.Name && record.RR().Type == scope.Type {
+					want = append(want, record)
+				}
+			}
+		}
+		if diff := cmp.Diff(records, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		records, err = provider.getRecords(context.TODO(), "example.com.", "TXT")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(records) != 1 || records[0].RR().Name != "record-txt" {
+			t.Errorf("got: %v", records)
+		}
+	})
+}
+
+func Test_GetRecordsFunc(t *testing.T) {
+	errFound := errors.New("found")
+	t.Run("pages=all", func(t *testing.T) {
+		provider := getFakeProvider()
+		want, err := provider.GetRecords(context.TODO(), "example.com.")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		var got []libdns.Record
+		pages := 0
+		err = provider.GetRecordsFunc(context.TODO(), "example.com.", func(records []libdns.Record) error {
+			pages++
+			got = append(got, records...)
+			// The provider is not locked while the page is handled
+			_, err := provider.GetZoneInfo(context.TODO(), "example.com.")
+			return err
+		})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if diff := cmp.Diff(got, want, recordComparer); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if wantPages := len(chunkBy(azureFakeRecords, 3)); pages != wantPages {
+			t.Errorf("got: %d pages, want: %d", pages, wantPages)
+		}
+	})
+	t.Run("pages=aborted", func(t *testing.T) {
+		provider := getFakeProvider()
+		pages := 0
+		err := provider.GetRecordsFunc(context.TODO(), "example.com.", func(records []libdns.Record) error {
+			pages++
+			return errFound
+		})
+		if err != errFound {
+			t.Errorf("got: %v, want: %v", err, errFound)
+		}
+		if pages != 1 {
+			t.Errorf("got: %d pages, want: 1", pages)
+		}
+	})
+}
+
+func Test_createRecords(t *testing.T) {
+	t.Run("recordset=new", func(t *testing.T) {
+		provider := getFakeProvider()
+		records, err := provider.createRecords(context.TODO(), "example.com.", []libdns.Record{libdns.RR{
+			Type: "A",
+			Name: "record-new",
+			Data: "127.0.0.1",
+			TTL:  time.Duration(30) * time.Second,
+		}})
+		t.Log(records)
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+	})
+	t.Run("recordset=existing", func(t *testing.T) {
+		var got []armdns.RecordSet
+		var gotOptions []*armdns.RecordSetsClientCreateOrUpdateOptions
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+		fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			got = append(got, parameters)
+			gotOptions = append(gotOptions, options)
+			return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+		}
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		_, err := provider.createRecords(context.TODO(), "example.com.", []libdns.Record{
+			libdns.RR{
+				Type: "MX",
+				Name: "record-mx",
+				Data: "20 mail2.example.com",
+				TTL:  time.Duration(60) * time.Second,
+			},
+			libdns.RR{
+				Type: "MX",
+				Name: "record-mx.example.com.",
+				Data: "30 mail3.example.com",
+				TTL:  time.Duration(60) * time.Second,
+			},
+		})
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		want := []armdns.RecordSet{{
+			Properties: &armdns.RecordSetProperties{
+				TTL: to.Ptr[int64](30),
+				MxRecords: []*armdns.MxRecord{
+					{Preference: to.Ptr[int32](10), Exchange: to.Ptr("mail.example.com")},
+					{Preference: to.Ptr[int32](20), Exchange: to.Ptr("mail2.example.com")},
+					{Preference: to.Ptr[int32](30), Exchange: to.Ptr("mail3.example.com")},
+				},
+			},
+		}}
+		opts := []cmp.Option{
+			cmpopts.IgnoreFields(armdns.RecordSetProperties{}, "Fqdn"),
+		}
+		if diff := cmp.Diff(got, want, opts...); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if len(gotOptions) != 1 || gotOptions[0].IfMatch == nil || *gotOptions[0].IfMatch != "ETAG_MX" {
+			t.Errorf("the request is not conditioned on the ETag of the existing record set")
+		}
+	})
+	t.Run("recordset=delegation", func(t *testing.T) {
+		var got []armdns.RecordSet
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+		fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			got = append(got, parameters)
+			return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+		}
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		_, err := provider.createRecords(context.TODO(), "example.com.", []libdns.Record{
+			libdns.RR{
+				Type: "NS",
+				Name: "record-ns",
+				Data: "ns3.example.net",
+				TTL:  time.Duration(3600) * time.Second,
+			},
+			libdns.RR{
+				Type: "NS",
+				Name: "record-ns",
+				Data: "ns4.example.net",
+				TTL:  time.Duration(3600) * time.Second,
+			},
+		})
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("got: %d, want: %d", len(got), 1)
+		}
+		want := []*armdns.NsRecord{
+			{Nsdname: to.Ptr("ns1.example.net")},
+			{Nsdname: to.Ptr("ns2.example.net")},
+			{Nsdname: to.Ptr("ns3.example.net")},
+			{Nsdname: to.Ptr("ns4.example.net")},
+		}
+		if diff := cmp.Diff(got[0].Properties.NsRecords, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("recordset=duplicated", func(t *testing.T) {
+		zone := newFakeZone(getOwnedTXTRecordSet(""))
+		provider := getFakeProviderWithZone(zone)
+		got, err := provider.createRecords(context.TODO(), "example.com.", []libdns.Record{
+			libdns.TXT{Name: "record-txt", Text: "TEST VALUE", TTL: time.Duration(30) * time.Second},
+		})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(got) != 1 {
+			t.Errorf("got: %d records, want: 1", len(got))
+		}
+		if diff := cmp.Diff(zone.calls, []string{"get"}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("recordset=partially duplicated", func(t *testing.T) {
+		zone := newFakeZone(getOwnedTXTRecordSet(""))
+		provider := getFakeProviderWithZone(zone)
+		if _, err := provider.createRecords(context.TODO(), "example.com.", []libdns.Record{
+			libdns.TXT{Name: "record-txt", Text: "TEST VALUE", TTL: time.Duration(30) * time.Second},
+			libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Duration(30) * time.Second},
+			libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Duration(30) * time.Second},
+		}); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(zone.written) != 1 {
+			t.Fatalf("got: %d record sets, want: 1", len(zone.written))
+		}
+		want := []*armdns.TxtRecord{
+			{Value: []*string{to.Ptr("TEST VALUE")}},
+			{Value: []*string{to.Ptr("NEW VALUE")}},
+		}
+		if diff := cmp.Diff(zone.written[0].Properties.TxtRecords, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("recordset=without properties", func(t *testing.T) {
+		zone := newFakeZone(&armdns.RecordSet{
+			Name: to.Ptr("record-txt"),
+			Type: to.Ptr("Microsoft.Network/dnszones/TXT"),
+			Etag: to.Ptr("ETAG_TXT"),
+		})
+		provider := getFakeProviderWithZone(zone)
+		if _, err := provider.createRecords(context.TODO(), "example.com.", []libdns.Record{
+			libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Duration(30) * time.Second},
+		}); err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := []*armdns.TxtRecord{
+			{Value: []*string{to.Ptr("NEW VALUE")}},
+		}
+		if diff := cmp.Diff(zone.get("record-txt", "TXT").Properties.TxtRecords, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
+
+func Test_updateRecords(t *testing.T) {
+	var got []armdns.RecordSet
+	fakeRecordSetsServer := getFakeRecordSetsServer()
+	createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+	fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+		got = append(got, parameters)
+		return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+	}
+	provider := getFakeProviderWithServer(fakeRecordSetsServer)
+	records, err := provider.updateRecords(context.TODO(), "example.com.", []libdns.Record{
+		libdnsFakeRecords[0],
+		libdns.RR{
+			Type: "MX",
+			Name: "record-mx",
+			Data: "10 mail.example.com",
+			TTL:  time.Duration(30) * time.Second,
+		},
+		libdns.RR{
+			Type: "MX",
+			Name: "record-mx",
+			Data: "20 backup.example.com",
+			TTL:  time.Duration(30) * time.Second,
+		},
+	})
+	t.Log(records)
+	if err != nil {
+		t.Errorf("%s", err)
+	}
+	if len(records) != 3 {
+		t.Errorf("got: %d, want: %d", len(records), 3)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got: %d, want: %d", len(got), 2)
+	}
+	want := []*armdns.MxRecord{
+		{Preference: to.Ptr[int32](10), Exchange: to.Ptr("mail.example.com")},
+		{Preference: to.Ptr[int32](20), Exchange: to.Ptr("backup.example.com")},
+	}
+	if diff := cmp.Diff(got[1].Properties.MxRecords, want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
+
+func Test_replaceRecordSet(t *testing.T) {
+	newProvider := func(existing *armdns.RecordSet, got *[]armdns.RecordSet, gotOptions *[]*armdns.RecordSetsClientCreateOrUpdateOptions) *Provider {
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		fakeRecordSetsServer.Get = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientGetOptions) (resp azfake.Responder[armdns.RecordSetsClientGetResponse], errResp azfake.ErrorResponder) {
+			if existing == nil {
+				errResp.SetResponseError(http.StatusNotFound, "NotFound")
+				return
+			}
+			resp.SetResponse(http.StatusOK, armdns.RecordSetsClientGetResponse{RecordSet: *existing}, nil)
+			return
+		}
+		createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+		fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			*got = append(*got, parameters)
+			*gotOptions = append(*gotOptions, options)
+			return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+		}
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		return &provider
+	}
+	records := []libdns.Record{
+		libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Duration(60) * time.Second},
+	}
+
+	t.Run("recordset=existing", func(t *testing.T) {
+		var got []armdns.RecordSet
+		var gotOptions []*armdns.RecordSetsClientCreateOrUpdateOptions
+		provider := newProvider(&armdns.RecordSet{
+			Name: to.Ptr("record-txt"),
+			Type: to.Ptr("Microsoft.Network/dnszones/TXT"),
+			Etag: to.Ptr("ETAG_TXT"),
+			Properties: &armdns.RecordSetProperties{
+				TTL:        to.Ptr[int64](30),
+				Metadata:   map[string]*string{"owner": to.Ptr("team-a")},
+				TxtRecords: []*armdns.TxtRecord{{Value: []*string{to.Ptr("OLD VALUE")}}},
+			},
+		}, &got, &gotOptions)
+		if _, err := provider.updateRecords(context.TODO(), "example.com.", records); err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := []armdns.RecordSet{{
+			Properties: &armdns.RecordSetProperties{
+				TTL:        to.Ptr[int64](60),
+				Metadata:   map[string]*string{"owner": to.Ptr("team-a")},
+				TxtRecords: []*armdns.TxtRecord{{Value: []*string{to.Ptr("NEW VALUE")}}},
+			},
+		}}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if len(gotOptions) != 1 || gotOptions[0] == nil || stringValue(gotOptions[0].IfMatch) != "ETAG_TXT" {
+			t.Errorf("the request is not conditioned on the ETag of the existing record set")
+		}
+	})
+	t.Run("recordset=new", func(t *testing.T) {
+		var got []armdns.RecordSet
+		var gotOptions []*armdns.RecordSetsClientCreateOrUpdateOptions
+		provider := newProvider(nil, &got, &gotOptions)
+		if _, err := provider.updateRecords(context.TODO(), "example.com.", records); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(gotOptions) != 1 || gotOptions[0] == nil || stringValue(gotOptions[0].IfNoneMatch) != "*" {
+			t.Errorf("the request does not prevent overwriting a record set created concurrently")
+		}
+	})
+	t.Run("recordset=alias", func(t *testing.T) {
+		var got []armdns.RecordSet
+		var gotOptions []*armdns.RecordSetsClientCreateOrUpdateOptions
+		provider := newProvider(&armdns.RecordSet{
+			Name: to.Ptr("record-txt"),
+			Type: to.Ptr("Microsoft.Network/dnszones/TXT"),
+			Etag: to.Ptr("ETAG_TXT"),
+			Properties: &armdns.RecordSetProperties{
+				TTL:            to.Ptr[int64](30),
+				TargetResource: &armdns.SubResource{ID: to.Ptr("/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/trafficManagerProfiles/fake")},
+			},
+		}, &got, &gotOptions)
+		_, err := provider.updateRecords(context.TODO(), "example.com.", records)
+		if err == nil || !strings.Contains(err.Error(), "is an alias of") {
+			t.Errorf("got: %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("the alias record set is overwritten")
+		}
+	})
+}
+
+func Test_deleteRecords(t *testing.T) {
+	type call struct {
+		Method  string
+		Name    string
+		IfMatch string
+		Values  []*armdns.NsRecord
+	}
+	newProvider := func(calls *[]call) *Provider {
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		get := fakeRecordSetsServer.Get
+		fakeRecordSetsServer.Get = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientGetOptions) (resp azfake.Responder[armdns.RecordSetsClientGetResponse], errResp azfake.ErrorResponder) {
+			*calls = append(*calls, call{Method: "Get", Name: relativeRecordSetName})
+			return get(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, options)
+		}
+		createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+		fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			c := call{Method: "CreateOrUpdate", Name: relativeRecordSetName, Values: parameters.Properties.NsRecords}
+			if options != nil {
+				c.IfMatch = stringValue(options.IfMatch)
+			}
+			*calls = append(*calls, c)
+			return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+		}
+		deleteRecordSet := fakeRecordSetsServer.Delete
+		fakeRecordSetsServer.Delete = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientDeleteOptions) (resp azfake.Responder[armdns.RecordSetsClientDeleteResponse], errResp azfake.ErrorResponder) {
+			c := call{Method: "Delete", Name: relativeRecordSetName}
+			if options != nil {
+				c.IfMatch = stringValue(options.IfMatch)
+			}
+			*calls = append(*calls, c)
+			if c.IfMatch != "" && c.IfMatch != "ETAG_NS_DELEGATION" {
+				errResp.SetResponseError(http.StatusPreconditionFailed, "PreconditionFailed")
+				return
+			}
+			return deleteRecordSet(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, options)
+		}
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		return &provider
+	}
+
+	tests := []struct {
+		name      string
+		records   []libdns.Record
+		options   DeleteOptions
+		wantCalls []call
+		wantNames []string
+		wantErr   string
+	}{
+		{
+			name:      "value=none",
+			records:   []libdns.Record{libdns.RR{Name: "record-ns.example.com.", Type: "NS"}},
+			wantCalls: []call{{Method: "Delete", Name: "record-ns"}},
+			wantNames: []string{"record-ns"},
+		},
+		{
+			name:    "value=partial",
+			records: []libdns.Record{libdns.NS{Name: "record-ns", Target: "ns1.example.net"}},
+			wantCalls: []call{
+				{Method: "Get", Name: "record-ns"},
+				{Method: "CreateOrUpdate", Name: "record-ns", IfMatch: "ETAG_NS_DELEGATION", Values: []*armdns.NsRecord{{Nsdname: to.Ptr("ns2.example.net")}}},
+			},
+			wantNames: []string{"record-ns"},
+		},
+		{
+			name: "value=all",
+			records: []libdns.Record{
+				libdns.NS{Name: "record-ns", Target: "ns1.example.net"},
+				libdns.NS{Name: "record-ns", Target: "ns2.example.net"},
+			},
+			wantCalls: []call{
+				{Method: "Get", Name: "record-ns"},
+				{Method: "Delete", Name: "record-ns", IfMatch: "ETAG_NS_DELEGATION"},
+			},
+			wantNames: []string{"record-ns", "record-ns"},
+		},
+		{
+			name:      "value=missing",
+			records:   []libdns.Record{libdns.NS{Name: "record-ns", Target: "ns9.example.net"}},
+			wantCalls: []call{{Method: "Get", Name: "record-ns"}},
+		},
+		{
+			name:      "recordset=missing",
+			records:   []libdns.Record{libdns.TXT{Name: "record-missing", Text: "TEST VALUE"}},
+			wantCalls: []call{{Method: "Get", Name: "record-missing"}},
+		},
+		{
+			name: "exact=true,value=same",
+			records: []libdns.Record{
+				libdns.NS{Name: "record-ns", Target: "ns2.example.net"},
+				libdns.NS{Name: "record-ns", Target: "ns1.example.net"},
+			},
+			options: DeleteOptions{Exact: true},
+			wantCalls: []call{
+				{Method: "Get", Name: "record-ns"},
+				{Method: "Delete", Name: "record-ns", IfMatch: "ETAG_NS_DELEGATION"},
+			},
+			wantNames: []string{"record-ns", "record-ns"},
+		},
+		{
+			name:      "exact=true,value=modified",
+			records:   []libdns.Record{libdns.NS{Name: "record-ns", Target: "ns1.example.net"}},
+			options:   DeleteOptions{Exact: true},
+			wantCalls: []call{{Method: "Get", Name: "record-ns"}},
+			wantErr:   "the record set record-ns NS has been modified",
+		},
+		{
+			name:    "exact=true,value=none",
+			records: []libdns.Record{libdns.RR{Name: "record-ns", Type: "NS"}},
+			options: DeleteOptions{Exact: true},
+			wantErr: "the records record-ns NS must have values to be deleted exactly",
+		},
+		{
+			name:      "etag=current,value=none",
+			records:   []libdns.Record{libdns.RR{Name: "record-ns", Type: "NS"}},
+			options:   DeleteOptions{ETags: map[string]string{"record-ns/NS": "ETAG_NS_DELEGATION"}},
+			wantCalls: []call{{Method: "Delete", Name: "record-ns", IfMatch: "ETAG_NS_DELEGATION"}},
+			wantNames: []string{"record-ns"},
+		},
+		{
+			name:      "etag=stale,value=none",
+			records:   []libdns.Record{libdns.RR{Name: "record-ns", Type: "NS"}},
+			options:   DeleteOptions{ETags: map[string]string{"record-ns/NS": "ETAG_STALE"}},
+			wantCalls: []call{{Method: "Delete", Name: "record-ns", IfMatch: "ETAG_STALE"}},
+			wantErr:   "the record set record-ns NS has been modified",
+		},
+		{
+			name:      "etag=stale,value=partial",
+			records:   []libdns.Record{libdns.NS{Name: "record-ns", Target: "ns1.example.net"}},
+			options:   DeleteOptions{ETags: map[string]string{"record-ns/NS": "ETAG_STALE"}},
+			wantCalls: []call{{Method: "Get", Name: "record-ns"}},
+			wantErr:   "the record set record-ns NS has been modified",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []call
+			provider := newProvider(&calls)
+			records, err := provider.deleteRecords(context.TODO(), "example.com.", tt.records, tt.options)
+			if tt.wantErr != "" {
+				var modifiedError *ModifiedError
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("got: %v, want: %v", err, tt.wantErr)
+				} else if strings.Contains(tt.wantErr, "modified") && !errors.As(err, &modifiedError) {
+					t.Errorf("the error is not a ModifiedError: %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(calls, tt.wantCalls); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+			var names []string
+			for _, record := range records {
+				names = append(names, record.RR().Name)
+			}
+			if diff := cmp.Diff(names, tt.wantNames); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_generateRecordSetName(t *testing.T) {
+	t.Run("name=\"\"", func(t *testing.T) {
+		got := generateRecordSetName("", "example.com.")
+		want := "@"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("name=@", func(t *testing.T) {
+		got := generateRecordSetName("@", "example.com.")
+		want := "@"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("name=test", func(t *testing.T) {
+		got := generateRecordSetName("test", "example.com.")
+		want := "test"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("name=test.example.com", func(t *testing.T) {
+		got := generateRecordSetName("test.example.com", "example.com.")
+		want := "test"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("name=test.example.com.", func(t *testing.T) {
+		got := generateRecordSetName("test.example.com.", "example.com.")
+		want := "test"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("name=example.com.", func(t *testing.T) {
+		got := generateRecordSetName("example.com.", "example.com.")
+		want := "@"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	tests := []struct {
+		name string
+		zone string
+		want string
+	}{
+		{"test.example.com.", "example.com", "test"},
+		{"test.example.com", "example.com", "test"},
+		{"example.com", "example.com", "@"},
+		{"Test.Example.COM.", "example.com.", "Test"},
+		{"EXAMPLE.COM.", "example.com.", "@"},
+		{"_sip._tcp.example.com.", "example.com.", "_sip._tcp"},
+		{"_acme-challenge.sub.example.com.", "example.com.", "_acme-challenge.sub"},
+		{"testexample.com.", "example.com.", "testexample.com"},
+		{"t", "sub.example.com.", "t"},
+		{"test", "", "test"},
+		{"dev.eu.example.com.", "dev.eu.example.com.", "@"},
+		{"Dev.EU.example.com", "dev.eu.example.com.", "@"},
+		{"www.dev.eu.example.com.", "dev.eu.example.com.", "www"},
+		{"a.b.c.dev.eu.example.com.", "dev.eu.example.com.", "a.b.c"},
+		{"_sip._tcp.app.dev.eu.example.com.", "dev.eu.example.com.", "_sip._tcp.app"},
+		{"www.adev.eu.example.com.", "dev.eu.example.com.", "www.adev.eu.example.com"},
+		{"www.dev", "dev.eu.example.com.", "www.dev"},
+	}
+	for _, tt := range tests {
+		t.Run("name="+tt.name+",zone="+tt.zone, func(t *testing.T) {
+			got := generateRecordSetName(tt.name, tt.zone)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_splitRecordValue(t *testing.T) {
+	for _, record := range []libdns.Record{
+		libdns.RR{Type: "CAA", Name: "test", Data: "0 issue"},
+		libdns.RR{Type: "MX", Name: "test", Data: "10"},
+		libdns.RR{Type: "SOA", Name: "@", Data: "ns1.example.com hostmaster.example.com 1"},
+		libdns.RR{Type: "SRV", Name: "_sip._tcp", Data: "10 20 5060"},
+	} {
+		t.Run("type="+record.RR().Type, func(t *testing.T) {
+			if _, err := convertLibdnsRecordToAzureRecordSet(record); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func Test_updateRecords_absoluteNames(t *testing.T) {
+	var written []string
+	fakeRecordSetsServer := getFakeRecordSetsServer()
+	createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+	fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+		written = append(written, relativeRecordSetName)
+		return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+	}
+	provider := getFakeProviderWithServer(fakeRecordSetsServer)
+	records := []libdns.Record{
+		libdns.RR{Type: "TXT", Name: "_acme-challenge", Data: "relative", TTL: time.Minute},
+		libdns.RR{Type: "TXT", Name: "_acme-challenge.example.com", Data: "absolute without trailing dot", TTL: time.Minute},
+		libdns.RR{Type: "TXT", Name: "_acme-challenge.example.com.", Data: "absolute", TTL: time.Minute},
+		libdns.RR{Type: "SRV", Name: "_sip._tcp.Example.com.", Data: "10 20 5060 sip.example.com", TTL: time.Minute},
+	}
+	if _, err := provider.updateRecords(context.TODO(), "example.com", records); err != nil {
+		t.Fatalf("%s", err)
+	}
+	if diff := cmp.Diff(written, []string{"_acme-challenge", "_sip._tcp"}); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
+
+func Test_deepZone(t *testing.T) {
+	var written []string
+	fakeRecordSetsServer := getFakeRecordSetsServer()
+	createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+	fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+		written = append(written, zoneName+"/"+relativeRecordSetName+"/"+string(recordType))
+		return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+	}
+	zone := "dev.eu.example.com."
+
+	t.Run("operation=set", func(t *testing.T) {
+		written = nil
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		records := []libdns.Record{
+			libdns.SRV{Service: "sip", Transport: "tcp", Name: "app.dev.eu.example.com.", Priority: 10, Weight: 20, Port: 5060, Target: "sip.example.com.", TTL: time.Minute},
+			libdns.SRV{Service: "sip", Transport: "tcp", Name: "@", Priority: 10, Weight: 20, Port: 5060, Target: "sip.example.com.", TTL: time.Minute},
+			libdns.TXT{Name: "_acme-challenge.a.b.c.dev.eu.example.com.", Text: "TOKEN", TTL: time.Minute},
+			libdns.TXT{Name: "Dev.EU.Example.com.", Text: "APEX", TTL: time.Minute},
+		}
+		got, err := provider.updateRecords(context.TODO(), zone, records)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := []string{
+			"dev.eu.example.com/_sip._tcp.app/SRV",
+			"dev.eu.example.com/_sip._tcp/SRV",
+			"dev.eu.example.com/_acme-challenge.a.b.c/TXT",
+			"dev.eu.example.com/@/TXT",
+		}
+		if diff := cmp.Diff(written, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		wantRecords := []libdns.Record{
+			libdns.SRV{Service: "sip", Transport: "tcp", Name: "app", Priority: 10, Weight: 20, Port: 5060, Target: "sip.example.com.", TTL: time.Minute},
+			libdns.SRV{Service: "sip", Transport: "tcp", Name: "@", Priority: 10, Weight: 20, Port: 5060, Target: "sip.example.com.", TTL: time.Minute},
+			libdns.TXT{Name: "_acme-challenge.a.b.c", Text: "TOKEN", TTL: time.Minute},
+			libdns.TXT{Name: "@", Text: "APEX", TTL: time.Minute},
+		}
+		if diff := cmp.Diff(got, wantRecords); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("name=parent", func(t *testing.T) {
+		written = nil
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		_, err := provider.updateRecords(context.TODO(), zone, []libdns.Record{
+			libdns.TXT{Name: "eu.example.com.", Text: "PARENT", TTL: time.Minute},
+		})
+		if err == nil || !strings.Contains(err.Error(), "is outside the zone dev.eu.example.com.") {
+			t.Errorf("got: %v", err)
+		}
+		if len(written) != 0 {
+			t.Errorf("got: %v, want: nothing written", written)
+		}
+	})
+	t.Run("operation=get", func(t *testing.T) {
+		records, err := convertAzureRecordSetsToLibdnsRecords([]*armdns.RecordSet{{
+			Name: to.Ptr("_sip._tcp.app.a.b"),
+			Type: to.Ptr("Microsoft.Network/dnszones/SRV"),
+			Properties: &armdns.RecordSetProperties{
+				TTL:        to.Ptr[int64](60),
+				Fqdn:       to.Ptr("_sip._tcp.app.a.b.dev.eu.example.com."),
+				SrvRecords: []*armdns.SrvRecord{{Priority: to.Ptr[int32](10), Weight: to.Ptr[int32](20), Port: to.Ptr[int32](5060), Target: to.Ptr("sip.example.com.")}},
+			},
+		}})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := []libdns.Record{
+			libdns.SRV{Service: "sip", Transport: "tcp", Name: "app.a.b", Priority: 10, Weight: 20, Port: 5060, Target: "sip.example.com.", TTL: time.Minute},
+		}
+		if diff := cmp.Diff(records, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
+
+func Test_recordSetName(t *testing.T) {
+	var written []string
+	fakeRecordSetsServer := getFakeRecordSetsServer()
+	createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+	fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+		written = append(written, relativeRecordSetName)
+		return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+	}
+	provider := getFakeProviderWithServer(fakeRecordSetsServer)
+	// The zone is served under the alias "example.internal."
+	provider.RecordSetName = func(name string, zone string) string {
+		if trimmed := strings.TrimSuffix(strings.TrimSuffix(name, "."), ".example.internal"); trimmed != strings.TrimSuffix(name, ".") {
+			return DefaultRecordSetName(trimmed+"."+zone, zone)
+		}
+		return DefaultRecordSetName(name, zone)
+	}
+	records := []libdns.Record{
+		libdns.RR{Type: "TXT", Name: "_acme-challenge.example.internal.", Data: "alias", TTL: time.Minute},
+		libdns.RR{Type: "TXT", Name: "_acme-challenge", Data: "relative", TTL: time.Minute},
+		libdns.RR{Type: "TXT", Name: "www.example.com.", Data: "absolute", TTL: time.Minute},
+	}
+	if _, err := provider.updateRecords(context.TODO(), "example.com.", records); err != nil {
+		t.Fatalf("%s", err)
+	}
+	// The records sharing the record set are written at once
+	if diff := cmp.Diff(written, []string{"_acme-challenge", "www"}); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
+
+func Test_recordNames(t *testing.T) {
+	t.Run("operation=get", func(t *testing.T) {
+		provider := getFakeProvider()
+		records, err := provider.GetRecords(context.TODO(), "example.com.")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		for _, record := range records {
+			name := record.RR().Name
+			if name == "" || strings.HasSuffix(name, ".") || strings.Contains(name, "example.com") {
+				t.Errorf("the name %v is not relative to the zone", name)
+			}
+		}
+	})
+
+	operations := map[WriteOperation]func(p *Provider, ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error){
+		WriteOperationAppend: (*Provider).AppendRecords,
+		WriteOperationSet:    (*Provider).SetRecords,
+		WriteOperationDelete: (*Provider).DeleteRecords,
+	}
+	tests := []struct {
+		name string
+		zone string
+		want string
+	}{
+		{name: "record-new", zone: "example.com.", want: "record-new"},
+		{name: "record-new.example.com.", zone: "example.com.", want: "record-new"},
+		{name: "record-new.example.com", zone: "example.com.", want: "record-new"},
+		{name: "record-new.EXAMPLE.com.", zone: "example.com.", want: "record-new"},
+		{name: "record-new.example.com.", zone: "example.com", want: "record-new"},
+		{name: "sub.record-new", zone: "example.com.", want: "sub.record-new"},
+		{name: "@", zone: "example.com.", want: "@"},
+		{name: "", zone: "example.com.", want: "@"},
+		{name: "example.com.", zone: "example.com.", want: "@"},
+		{name: "example.com", zone: "example.com", want: "@"},
+	}
+	for operation, call := range operations {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("operation=%v,name=%q,zone=%q", operation, tt.name, tt.zone), func(t *testing.T) {
+				var written []string
+				fakeRecordSetsServer := getFakeRecordSetsServer()
+				createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+				fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+					written = append(written, relativeRecordSetName)
+					return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+				}
+				deleteRecordSet := fakeRecordSetsServer.Delete
+				fakeRecordSetsServer.Delete = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientDeleteOptions) (resp azfake.Responder[armdns.RecordSetsClientDeleteResponse], errResp azfake.ErrorResponder) {
+					written = append(written, relativeRecordSetName)
+					return deleteRecordSet(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, options)
+				}
+				provider := getFakeProviderWithServer(fakeRecordSetsServer)
+				record := libdns.TXT{Name: tt.name, Text: "TEST VALUE", TTL: time.Minute}
+				if operation == WriteOperationDelete {
+					// A record without a value deletes the record set without reading it
+					record = libdns.TXT{Name: tt.name}
+				}
+				records, err := call(&provider, context.TODO(), tt.zone, []libdns.Record{record})
+				if err != nil {
+					t.Fatalf("%s", err)
+				}
+				if diff := cmp.Diff(written, []string{tt.want}); diff != "" {
+					t.Errorf("diff: %s", diff)
+				}
+				record.Name = tt.want
+				want := []libdns.Record{record}
+				if diff := cmp.Diff(records, want); diff != "" {
+					t.Errorf("diff: %s", diff)
+				}
+			})
+		}
+	}
+
+	t.Run("type=SRV", func(t *testing.T) {
+		provider := getFakeProvider()
+		records, err := provider.SetRecords(context.TODO(), "example.com.", []libdns.Record{
+			libdns.RR{Type: "SRV", Name: "_sip._tcp.Example.com.", Data: "10 20 5060 sip.example.com.", TTL: time.Minute},
+			libdns.SRV{Service: "sip", Transport: "udp", Name: "example.com.", Priority: 10, Weight: 20, Port: 5060, Target: "sip.example.com.", TTL: time.Minute},
+		})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		var got []string
+		for _, record := range records {
+			got = append(got, record.RR().Name)
+		}
+		if diff := cmp.Diff(got, []string{"_sip._tcp", "_sip._udp"}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if srv, ok := records[0].(libdns.SRV); !ok || srv.Service != "sip" || srv.Transport != "tcp" || srv.Name != "@" {
+			t.Errorf("got: %#v", records[0])
+		}
+	})
+}
+
+func Test_convertStringToRecordType(t *testing.T) {
+	typeNames := []string{"A", "AAAA", "CAA", "CNAME", "MX", "NS", "PTR", "SOA", "SRV", "TXT"}
+	for _, typeName := range typeNames {
+		t.Run("type="+typeName, func(t *testing.T) {
+			recordType, _ := convertStringToRecordType(typeName)
+			got := fmt.Sprintf("%T:%v", recordType, recordType)
+			want := "armdns.RecordType:" + typeName
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+	t.Run("type=ERR", func(t *testing.T) {
+		_, err := convertStringToRecordType("ERR")
+		got := err.Error()
+		want := "the type ERR cannot be interpreted"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
+
+func Test_convertAzureRecordSetsToLibdnsRecords(t *testing.T) {
+	t.Run("type=supported", func(t *testing.T) {
+		azureRecordSets := []*armdns.RecordSet{}
+		for _, v := range azureFakeRecords {
+			record := v
+			azureRecordSets = append(azureRecordSets, &record)
+		}
+		got, _ := convertAzureRecordSetsToLibdnsRecords(azureRecordSets)
+		want := libdnsFakeRecords
+		if diff := cmp.Diff(got, want, recordComparer); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("type=unsupported", func(t *testing.T) {
+		azureRecordSets := []*armdns.RecordSet{{
+			Name: to.Ptr("record-err"),
+			Type: to.Ptr("Microsoft.Network/dnszones/ERR"),
+		}}
+		_, err := convertAzureRecordSetsToLibdnsRecords(azureRecordSets)
+		got := err.Error()
+		want := "the type ERR of the record set record-err cannot be interpreted"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
+
+func Test_waitForProvisioning(t *testing.T) {
+	newProvider := func(states []string) (*Provider, *int) {
+		calls := 0
+		written := false
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+		fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			written = true
+			parameters.Properties.ProvisioningState = to.Ptr(states[0])
+			return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+		}
+		get := fakeRecordSetsServer.Get
+		fakeRecordSetsServer.Get = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientGetOptions) (resp azfake.Responder[armdns.RecordSetsClientGetResponse], errResp azfake.ErrorResponder) {
+			// The record set is read before it is written
+			if !written {
+				return get(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, options)
+			}
+			calls++
+			response := armdns.RecordSetsClientGetResponse{
+				RecordSet: armdns.RecordSet{
+					Properties: &armdns.RecordSetProperties{
+						ProvisioningState: to.Ptr(states[calls]),
+					},
+				},
+			}
+			resp.SetResponse(http.StatusOK, response, nil)
+			return
+		}
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		provider.WaitForProvisioning = true
+		provider.ProvisioningPollInterval = time.Millisecond
+		return &provider, &calls
+	}
+
+	t.Run("state=Succeeded", func(t *testing.T) {
+		provider, calls := newProvider([]string{"Updating", "Updating", "Succeeded"})
+		if _, err := provider.updateRecords(context.TODO(), "example.com.", libdnsFakeRecords[:1]); err != nil {
+			t.Errorf("%s", err)
+		}
+		if *calls != 2 {
+			t.Errorf("got: %d, want: %d", *calls, 2)
+		}
+	})
+	t.Run("state=Failed", func(t *testing.T) {
+		provider, _ := newProvider([]string{"Updating", "Failed"})
+		_, err := provider.updateRecords(context.TODO(), "example.com.", libdnsFakeRecords[:1])
+		got := err.Error()
+		want := "the provisioning of the record set record-a A has ended in the state Failed"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
+
+func Test_groupRecordsByRecordSet(t *testing.T) {
+	records := []libdns.Record{
+		libdns.RR{Type: "MX", Name: "record-mx", Data: "10 mail.example.com"},
+		libdns.RR{Type: "A", Name: "record-mx", Data: "127.0.0.1"},
+		libdns.RR{Type: "MX", Name: "record-mx.example.com.", Data: "20 backup.example.com"},
+		libdns.RR{Type: "TXT", Name: "@", Data: "TEST VALUE"},
+	}
+	got := groupRecordsByRecordSet(records, "example.com.", generateRecordSetName)
+	want := [][]libdns.Record{
+		{records[0], records[2]},
+		{records[1]},
+		{records[3]},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
+
+func Test_convertLibdnsRecordsToAzureRecordSet(t *testing.T) {
+	t.Run("type=MX", func(t *testing.T) {
+		got, err := convertLibdnsRecordsToAzureRecordSet([]libdns.Record{
+			libdns.RR{Type: "MX", Name: "record-mx", Data: "10 mail.example.com", TTL: time.Duration(30) * time.Second},
+			libdns.RR{Type: "MX", Name: "record-mx", Data: "20 backup.example.com", TTL: time.Duration(30) * time.Second},
+		}, TTLConflictPolicyError)
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		want := armdns.RecordSet{
+			Properties: &armdns.RecordSetProperties{
+				TTL: to.Ptr[int64](30),
+				MxRecords: []*armdns.MxRecord{
+					{Preference: to.Ptr[int32](10), Exchange: to.Ptr("mail.example.com")},
+					{Preference: to.Ptr[int32](20), Exchange: to.Ptr("backup.example.com")},
+				},
+			},
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("type=CNAME", func(t *testing.T) {
+		_, err := convertLibdnsRecordsToAzureRecordSet([]libdns.Record{
+			libdns.RR{Type: "CNAME", Name: "record-cname", Data: "www.example.com"},
+			libdns.RR{Type: "CNAME", Name: "record-cname", Data: "www2.example.com"},
+		}, TTLConflictPolicyError)
+		got := err.Error()
+		want := "the type CNAME cannot have multiple values"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("ttl=conflicting", func(t *testing.T) {
+		_, err := convertLibdnsRecordsToAzureRecordSet([]libdns.Record{
+			libdns.RR{Type: "MX", Name: "record-mx", Data: "10 mail.example.com", TTL: time.Duration(30) * time.Second},
+			libdns.RR{Type: "MX", Name: "record-mx", Data: "20 backup.example.com", TTL: time.Duration(60) * time.Second},
+		}, TTLConflictPolicyError)
+		got := err.Error()
+		want := "the records record-mx MX have conflicting TTLs 30s and 1m0s"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
+
+func Test_resolveTTL(t *testing.T) {
+	records := []libdns.Record{
+		libdns.RR{Type: "MX", Name: "record-mx", Data: "10 mail.example.com", TTL: time.Duration(60) * time.Second},
+		libdns.RR{Type: "MX", Name: "record-mx", Data: "20 backup.example.com", TTL: time.Duration(30) * time.Second},
+		libdns.RR{Type: "MX", Name: "record-mx", Data: "30 backup.example.com", TTL: time.Duration(90) * time.Second},
+	}
+	tests := map[TTLConflictPolicy]time.Duration{
+		TTLConflictPolicyFirst:   time.Duration(60) * time.Second,
+		TTLConflictPolicyLowest:  time.Duration(30) * time.Second,
+		TTLConflictPolicyHighest: time.Duration(90) * time.Second,
+	}
+	for policy, want := range tests {
+		t.Run("policy="+string(policy), func(t *testing.T) {
+			got, err := resolveTTL(records, policy)
+			if err != nil {
+				t.Errorf("%s", err)
+			}
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+	for _, policy := range []TTLConflictPolicy{"", TTLConflictPolicyError} {
+		t.Run("policy="+string(policy), func(t *testing.T) {
+			_, err := resolveTTL(records, policy)
+			if err == nil {
+				t.Errorf("expected an error for the conflicting TTLs")
+			}
+		})
+	}
+	t.Run("policy=ERR", func(t *testing.T) {
+		_, err := resolveTTL(records, "ERR")
+		got := err.Error()
+		want := "the TTL conflict policy ERR cannot be interpreted"
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
+
+func Test_convertLibdnsRecordToAzureRecordSet(t *testing.T) {
+	t.Run("type=supported", func(t *testing.T) {
+		var got []armdns.RecordSet
+		for _, libdnsRecords := range groupRecordsByRecordSet(libdnsFakeRecords, "example.com.", generateRecordSetName) {
+			convertedRecord, _ := convertLibdnsRecordsToAzureRecordSet(libdnsRecords, TTLConflictPolicyError)
+			got = append(got, convertedRecord)
+		}
+		want := azureFakeRecords
+		opts := []cmp.Option{
+			cmpopts.IgnoreFields(armdns.RecordSet{}, "Name", "Type", "Etag"),
+			cmpopts.IgnoreFields(armdns.RecordSetProperties{}, "Fqdn"),
+		}
+		if diff := cmp.Diff(got, want, opts...); diff != "" {
+			t.Errorf("diff: %s", diff)
 		}
 	})
 	t.Run("type=unsupported", func(t *testing.T) {
-		libdnsRecords := []libdns.Record{{
+		libdnsRecords := []libdns.Record{libdns.RR{
 			Type: "ERR",
 		}}
 		_, err := convertLibdnsRecordToAzureRecordSet(libdnsRecords[0])
@@ -453,3 +2466,75 @@ func Test_convertLibdnsRecordToAzureRecordSet(t *testing.T) {
 		}
 	})
 }
+
+func Test_retryOnConflict(t *testing.T) {
+	newProvider := func(created *[]armdns.RecordSet) *Provider {
+		existing := armdns.RecordSet{
+			Name:       to.Ptr("_acme-challenge"),
+			Type:       to.Ptr("Microsoft.Network/dnszones/TXT"),
+			Etag:       to.Ptr("ETAG_1"),
+			Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](30)},
+		}
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		fakeRecordSetsServer.Get = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientGetOptions) (resp azfake.Responder[armdns.RecordSetsClientGetResponse], errResp azfake.ErrorResponder) {
+			resp.SetResponse(http.StatusOK, armdns.RecordSetsClientGetResponse{RecordSet: existing}, nil)
+			return
+		}
+		fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			// Another writer appends a value just before the first write
+			if stringValue(existing.Etag) == "ETAG_1" {
+				existing = armdns.RecordSet{
+					Name: existing.Name,
+					Type: existing.Type,
+					Etag: to.Ptr("ETAG_2"),
+					Properties: &armdns.RecordSetProperties{
+						TTL:        to.Ptr[int64](30),
+						TxtRecords: []*armdns.TxtRecord{{Value: []*string{to.Ptr("OTHER VALUE")}}},
+					},
+				}
+			}
+			if stringValue(options.IfMatch) != stringValue(existing.Etag) {
+				errResp.SetResponseError(http.StatusPreconditionFailed, "PreconditionFailed")
+				return
+			}
+			*created = append(*created, parameters)
+			resp.SetResponse(http.StatusOK, armdns.RecordSetsClientCreateOrUpdateResponse{RecordSet: parameters}, nil)
+			return
+		}
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		return &provider
+	}
+	records := []libdns.Record{
+		libdns.TXT{Name: "_acme-challenge", Text: "NEW VALUE", TTL: time.Duration(30) * time.Second},
+	}
+
+	t.Run("retries=default", func(t *testing.T) {
+		var created []armdns.RecordSet
+		provider := newProvider(&created)
+		if _, err := provider.createRecords(context.TODO(), "example.com.", records); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(created) != 1 {
+			t.Fatalf("got: %d record sets, want: 1", len(created))
+		}
+		var got []string
+		for _, txtRecord := range created[0].Properties.TxtRecords {
+			got = append(got, *txtRecord.Value[0])
+		}
+		if diff := cmp.Diff(got, []string{"OTHER VALUE", "NEW VALUE"}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("retries=disabled", func(t *testing.T) {
+		var created []armdns.RecordSet
+		provider := newProvider(&created)
+		provider.ConflictRetries = -1
+		_, err := provider.createRecords(context.TODO(), "example.com.", records)
+		if !isPreconditionFailedError(err) {
+			t.Errorf("got: %v", err)
+		}
+		if len(created) != 0 {
+			t.Errorf("got: %d record sets, want: 0", len(created))
+		}
+	})
+}
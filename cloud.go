@@ -0,0 +1,190 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+)
+
+// cloudAuto is the value of Cloud to detect the cloud in which the provider is running.
+const cloudAuto = "auto"
+
+// imdsEnvironmentURL is the endpoint of Azure Instance Metadata Service telling the cloud of the virtual machine.
+var imdsEnvironmentURL = "http://169.254.169.254/metadata/instance/compute/azEnvironment?api-version=2021-02-01&format=text"
+
+// cloudMetadataTimeout is how long to wait for the metadata endpoints to tell the cloud.
+const cloudMetadataTimeout = 5 * time.Second
+
+// cloudDetection caches the cloud configuration resolved from Cloud, so that the metadata endpoints are queried only once.
+type cloudDetection struct {
+	configuration *cloud.Configuration
+	mutex         sync.Mutex
+}
+
+// getCloud returns the cloud configuration selected by Cloud, or nil if Cloud is not set.
+// The configuration is resolved on first use and cached, since it does not change while the provider is running.
+func (p *Provider) getCloud() (*cloud.Configuration, error) {
+	if p.Cloud == "" {
+		return nil, nil
+	}
+
+	p.client.cloud.mutex.Lock()
+	defer p.client.cloud.mutex.Unlock()
+
+	if p.client.cloud.configuration != nil {
+		return p.client.cloud.configuration, nil
+	}
+	configuration, err := p.resolveCloud()
+	if err != nil {
+		return nil, err
+	}
+	p.client.cloud.configuration = &configuration
+	return &configuration, nil
+}
+
+// resolveCloud resolves the cloud configuration from Cloud, which is either the name of a cloud, the URL of
+// the Resource Manager of a cloud to read its metadata endpoints from, or "auto" to detect the cloud from
+// AZURE_ENVIRONMENT, or from Azure Instance Metadata Service on virtual machines, falling back to the public cloud.
+func (p *Provider) resolveCloud() (cloud.Configuration, error) {
+	if strings.HasPrefix(p.Cloud, "https://") || strings.HasPrefix(p.Cloud, "http://") {
+		return p.getCloudFromMetadata(p.Cloud)
+	}
+	if !strings.EqualFold(p.Cloud, cloudAuto) {
+		configuration, ok := getCloudByName(p.Cloud)
+		if !ok {
+			return cloud.Configuration{}, fmt.Errorf("the cloud %v cannot be interpreted", p.Cloud)
+		}
+		return configuration, nil
+	}
+
+	if name := os.Getenv("AZURE_ENVIRONMENT"); name != "" {
+		configuration, ok := getCloudByName(name)
+		if !ok {
+			return cloud.Configuration{}, fmt.Errorf("the cloud %v in AZURE_ENVIRONMENT cannot be interpreted", name)
+		}
+		return configuration, nil
+	}
+	if usesIMDS() {
+		if name, err := getIMDSEnvironment(imdsProbeTimeout); err == nil {
+			if configuration, ok := getCloudByName(name); ok {
+				p.getLogger().Debug("detected the cloud from Azure Instance Metadata Service", "cloud", name)
+				return configuration, nil
+			}
+		}
+	}
+	return cloud.AzurePublic, nil
+}
+
+// getCloudByName returns the configuration of the cloud with the name, regardless of the case.
+// The names of AZURE_ENVIRONMENT and Azure Instance Metadata Service, e.g. "AzureUSGovernmentCloud", and of Azure CLI,
+// e.g. "AzureUSGovernment", are accepted, as are the short names, e.g. "usgovernment".
+func getCloudByName(name string) (cloud.Configuration, bool) {
+	switch strings.ToLower(name) {
+	case "azurepubliccloud", "azurecloud", "public":
+		return cloud.AzurePublic, true
+	case "azurechinacloud", "china":
+		return cloud.AzureChina, true
+	case "azureusgovernmentcloud", "azureusgovernment", "usgovernment":
+		return cloud.AzureGovernment, true
+	default:
+		return cloud.Configuration{}, false
+	}
+}
+
+// getIMDSEnvironment returns the name of the cloud of the virtual machine told by Azure Instance Metadata Service,
+// e.g. "AzureUSGovernmentCloud".
+func getIMDSEnvironment(timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsEnvironmentURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+
+	// Azure Instance Metadata Service is reachable only directly
+	client := &http.Client{Transport: &http.Transport{Proxy: nil}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("the metadata service responded with %v", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// cloudMetadata is the part of the response of the metadata endpoints of Azure Resource Manager used to configure the cloud.
+type cloudMetadata struct {
+	Authentication struct {
+		LoginEndpoint string   `json:"loginEndpoint"`
+		Audiences     []string `json:"audiences"`
+	} `json:"authentication"`
+}
+
+// getCloudFromMetadata builds the cloud configuration from the metadata endpoints of the Resource Manager at the URL,
+// e.g. of Azure Stack Hub or a national cloud, which tell the authority host and the audience to authenticate with.
+func (p *Provider) getCloudFromMetadata(resourceManagerURL string) (cloud.Configuration, error) {
+	endpoint, err := url.Parse(resourceManagerURL)
+	if err != nil || endpoint.Host == "" {
+		return cloud.Configuration{}, fmt.Errorf("the cloud %v cannot be interpreted", resourceManagerURL)
+	}
+	metadataURL := endpoint.JoinPath("metadata", "endpoints")
+	metadataURL.RawQuery = "api-version=2019-05-01"
+
+	ctx, cancel := context.WithTimeout(context.Background(), cloudMetadataTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL.String(), nil)
+	if err != nil {
+		return cloud.Configuration{}, err
+	}
+	client := http.DefaultClient
+	transport, err := p.newTransport("")
+	if err != nil {
+		return cloud.Configuration{}, err
+	}
+	if transport != nil {
+		client = transport.(*http.Client)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return cloud.Configuration{}, fmt.Errorf("the metadata of the cloud %v cannot be read: %w", resourceManagerURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return cloud.Configuration{}, fmt.Errorf("the metadata of the cloud %v cannot be read: %v", resourceManagerURL, resp.Status)
+	}
+
+	var metadata cloudMetadata
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return cloud.Configuration{}, fmt.Errorf("the metadata of the cloud %v cannot be interpreted: %w", resourceManagerURL, err)
+	}
+	if metadata.Authentication.LoginEndpoint == "" || len(metadata.Authentication.Audiences) == 0 {
+		return cloud.Configuration{}, fmt.Errorf("the metadata of the cloud %v has no authentication endpoint or audience", resourceManagerURL)
+	}
+
+	return cloud.Configuration{
+		ActiveDirectoryAuthorityHost: metadata.Authentication.LoginEndpoint,
+		Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+			cloud.ResourceManager: {
+				Endpoint: strings.TrimSuffix(resourceManagerURL, "/"),
+				Audience: metadata.Authentication.Audiences[0],
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,99 @@
+package azure
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_getCloud(t *testing.T) {
+	imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("AzureChinaCloud"))
+	}))
+	defer imds.Close()
+	absent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	absent.Close()
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metadata/endpoints" || r.URL.Query().Get("api-version") == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"authentication":{"loginEndpoint":"https://login.stack.example.com/adfs","audiences":["https://management.stack.example.com/app"]}}`))
+	}))
+	defer metadata.Close()
+
+	tests := []struct {
+		name        string
+		cloud       string
+		environment string
+		imdsURL     string
+		want        *cloud.Configuration
+		wantErr     bool
+	}{
+		{name: "cloud=none", cloud: "", want: nil},
+		{name: "cloud=AzureChinaCloud", cloud: "AzureChinaCloud", want: &cloud.AzureChina},
+		{name: "cloud=usgovernment", cloud: "usgovernment", want: &cloud.AzureGovernment},
+		{name: "cloud=unknown", cloud: "AzureGermanCloud", wantErr: true},
+		{name: "cloud=auto,environment", cloud: "auto", environment: "AzureUSGovernmentCloud", imdsURL: imds.URL, want: &cloud.AzureGovernment},
+		{name: "cloud=auto,environment=unknown", cloud: "auto", environment: "AzureGermanCloud", imdsURL: imds.URL, wantErr: true},
+		{name: "cloud=auto,imds", cloud: "auto", imdsURL: imds.URL, want: &cloud.AzureChina},
+		{name: "cloud=auto,imds=absent", cloud: "auto", imdsURL: absent.URL, want: &cloud.AzurePublic},
+		{name: "cloud=metadata", cloud: metadata.URL, want: &cloud.Configuration{
+			ActiveDirectoryAuthorityHost: "https://login.stack.example.com/adfs",
+			Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+				cloud.ResourceManager: {Endpoint: metadata.URL, Audience: "https://management.stack.example.com/app"},
+			},
+		}},
+		{name: "cloud=metadata,absent", cloud: absent.URL, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"IDENTITY_ENDPOINT", "MSI_ENDPOINT"} {
+				t.Setenv(name, "")
+				os.Unsetenv(name)
+			}
+			t.Setenv("AZURE_ENVIRONMENT", tt.environment)
+			defaultIMDSEnvironmentURL := imdsEnvironmentURL
+			imdsEnvironmentURL = tt.imdsURL + "/metadata/instance/compute/azEnvironment"
+			defer func() { imdsEnvironmentURL = defaultIMDSEnvironmentURL }()
+
+			provider := Provider{Cloud: tt.cloud}
+			got, err := provider.getCloud()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error for the cloud %v", tt.cloud)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+	t.Run("cloud=AzureChinaCloud,clientOptions", func(t *testing.T) {
+		provider := Provider{Cloud: "AzureChinaCloud"}
+		clientOptions, err := provider.getClientOptions()
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		credentialOptions, err := provider.getCredentialOptions()
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if clientOptions.Cloud.ActiveDirectoryAuthorityHost != cloud.AzureChina.ActiveDirectoryAuthorityHost ||
+			credentialOptions.Cloud.ActiveDirectoryAuthorityHost != cloud.AzureChina.ActiveDirectoryAuthorityHost {
+			t.Errorf("the cloud is not applied to the clients and the credentials")
+		}
+	})
+}
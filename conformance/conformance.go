@@ -0,0 +1,251 @@
+// Package conformance is an acceptance test suite for the semantics of the libdns interfaces, i.e. multi-value record sets,
+// idempotency, relative names, unicode text, and large batches, runnable against a fake server or a real zone.
+//
+// The suite writes only records under a name unique to each run, and deletes them when the test finishes,
+// so that it can run against a zone in use.
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// Provider is the part of a libdns provider exercised by the suite.
+type Provider interface {
+	libdns.RecordGetter
+	libdns.RecordAppender
+	libdns.RecordSetter
+	libdns.RecordDeleter
+}
+
+// ttl is the TTL of the records written by the suite.
+const ttl = 5 * time.Minute
+
+// largeBatchSize is the number of record sets written in a single call by the large batch test.
+const largeBatchSize = 50
+
+// RunConformance runs the suite against the provider and the zone, e.g. "example.com.", as subtests of t.
+// Each subtest starts from a name without records and checks the records in the zone after each call.
+func RunConformance(t *testing.T, provider Provider, zone string) {
+	t.Helper()
+
+	prefix := fmt.Sprintf("libdns-conformance-%x", time.Now().UnixNano())
+	t.Cleanup(func() {
+		cleanUp(t, provider, zone, prefix)
+	})
+
+	t.Run("append/multi-value", func(t *testing.T) {
+		name := prefix + "-multi"
+		first := libdns.Address{Name: name, TTL: ttl, IP: netip.MustParseAddr("192.0.2.1")}
+		second := libdns.Address{Name: name, TTL: ttl, IP: netip.MustParseAddr("192.0.2.2")}
+		appendRecords(t, provider, zone, first)
+		appendRecords(t, provider, zone, second)
+		// Appending a value must not overwrite the other values of the record set
+		expectRecords(t, provider, zone, name, first, second)
+	})
+
+	t.Run("append/idempotency", func(t *testing.T) {
+		name := prefix + "-append"
+		record := libdns.TXT{Name: name, TTL: ttl, Text: "conformance"}
+		appendRecords(t, provider, zone, record)
+		appendRecords(t, provider, zone, record)
+		expectRecords(t, provider, zone, name, record)
+	})
+
+	t.Run("set/idempotency", func(t *testing.T) {
+		name := prefix + "-set"
+		records := []libdns.Record{
+			libdns.Address{Name: name, TTL: ttl, IP: netip.MustParseAddr("192.0.2.1")},
+			libdns.Address{Name: name, TTL: ttl, IP: netip.MustParseAddr("192.0.2.2")},
+		}
+		setRecords(t, provider, zone, records...)
+		setRecords(t, provider, zone, records...)
+		expectRecords(t, provider, zone, name, records...)
+	})
+
+	t.Run("set/record set only", func(t *testing.T) {
+		name := prefix + "-replace"
+		txt := libdns.TXT{Name: name, TTL: ttl, Text: "kept"}
+		appendRecords(t, provider, zone,
+			libdns.Address{Name: name, TTL: ttl, IP: netip.MustParseAddr("192.0.2.1")},
+			libdns.Address{Name: name, TTL: ttl, IP: netip.MustParseAddr("192.0.2.2")},
+			txt,
+		)
+		replacement := libdns.Address{Name: name, TTL: ttl, IP: netip.MustParseAddr("192.0.2.3")}
+		setRecords(t, provider, zone, replacement)
+		// Only the record set of the name and type of the input is replaced
+		expectRecords(t, provider, zone, name, replacement, txt)
+	})
+
+	t.Run("delete/value", func(t *testing.T) {
+		name := prefix + "-delete"
+		kept := libdns.TXT{Name: name, TTL: ttl, Text: "kept"}
+		deleted := libdns.TXT{Name: name, TTL: ttl, Text: "deleted"}
+		appendRecords(t, provider, zone, kept, deleted)
+		got, err := provider.DeleteRecords(context.Background(), zone, []libdns.Record{deleted})
+		if err != nil {
+			t.Fatalf("DeleteRecords: %v", err)
+		}
+		expectSame(t, "DeleteRecords returned", got, []libdns.Record{deleted})
+		expectRecords(t, provider, zone, name, kept)
+
+		// Deleting records that do not exist is not an error, and returns nothing
+		got, err = provider.DeleteRecords(context.Background(), zone, []libdns.Record{deleted})
+		if err != nil {
+			t.Fatalf("DeleteRecords: %v", err)
+		}
+		expectSame(t, "DeleteRecords returned", got, nil)
+	})
+
+	t.Run("names/relative", func(t *testing.T) {
+		name := "www." + prefix + "-relative"
+		record := libdns.TXT{Name: name, TTL: ttl, Text: "relative"}
+		got := appendRecords(t, provider, zone, record)
+		for _, r := range got {
+			if r.RR().Name != name {
+				t.Errorf("AppendRecords returned the name %q, want the relative name %q", r.RR().Name, name)
+			}
+		}
+		expectRecords(t, provider, zone, name, record)
+	})
+
+	t.Run("text/unicode", func(t *testing.T) {
+		name := prefix + "-unicode"
+		record := libdns.TXT{Name: name, TTL: ttl, Text: `héllo wörld, こんにちは 🌐 "quoted" \ backslash`}
+		appendRecords(t, provider, zone, record)
+		expectRecords(t, provider, zone, name, record)
+	})
+
+	t.Run("text/long", func(t *testing.T) {
+		name := prefix + "-long"
+		// Longer than a character string of a TXT record, with a multi-byte character across the boundary of 255 bytes
+		record := libdns.TXT{Name: name, TTL: ttl, Text: strings.Repeat("a", 254) + "é" + strings.Repeat("b", 300)}
+		appendRecords(t, provider, zone, record)
+		expectRecords(t, provider, zone, name, record)
+	})
+
+	t.Run("batch/large", func(t *testing.T) {
+		name := prefix + "-batch"
+		var records []libdns.Record
+		for i := 0; i < largeBatchSize; i++ {
+			records = append(records, libdns.TXT{Name: fmt.Sprintf("%s-%02d", name, i), TTL: ttl, Text: fmt.Sprintf("batch %d", i)})
+		}
+		got := appendRecords(t, provider, zone, records...)
+		expectSame(t, "AppendRecords returned", got, records)
+
+		all, err := provider.GetRecords(context.Background(), zone)
+		if err != nil {
+			t.Fatalf("GetRecords: %v", err)
+		}
+		var batch []libdns.Record
+		for _, r := range all {
+			if strings.HasPrefix(r.RR().Name, name+"-") {
+				batch = append(batch, r)
+			}
+		}
+		expectSame(t, "GetRecords returned", batch, records)
+	})
+}
+
+// appendRecords appends the records, failing the test on an error, and checks that the records returned are the input.
+func appendRecords(t *testing.T, provider Provider, zone string, records ...libdns.Record) []libdns.Record {
+	t.Helper()
+	got, err := provider.AppendRecords(context.Background(), zone, records)
+	if err != nil {
+		t.Fatalf("AppendRecords: %v", err)
+	}
+	expectConcrete(t, "AppendRecords", got)
+	return got
+}
+
+// setRecords sets the records, failing the test on an error, and checks that the records returned are the input.
+func setRecords(t *testing.T, provider Provider, zone string, records ...libdns.Record) []libdns.Record {
+	t.Helper()
+	got, err := provider.SetRecords(context.Background(), zone, records)
+	if err != nil {
+		t.Fatalf("SetRecords: %v", err)
+	}
+	expectConcrete(t, "SetRecords", got)
+	expectSame(t, "SetRecords returned", got, records)
+	return got
+}
+
+// expectRecords checks that the records in the zone with the name are exactly the records wanted.
+func expectRecords(t *testing.T, provider Provider, zone string, name string, want ...libdns.Record) {
+	t.Helper()
+	all, err := provider.GetRecords(context.Background(), zone)
+	if err != nil {
+		t.Fatalf("GetRecords: %v", err)
+	}
+	expectConcrete(t, "GetRecords", all)
+	var got []libdns.Record
+	for _, r := range all {
+		if r.RR().Name == name {
+			got = append(got, r)
+		}
+	}
+	expectSame(t, "GetRecords returned", got, want)
+}
+
+// expectConcrete checks that the records returned by the method are of the types of libdns for their RR types,
+// rather than the opaque libdns.RR, as the libdns interfaces require.
+func expectConcrete(t *testing.T, method string, records []libdns.Record) {
+	t.Helper()
+	for _, r := range records {
+		if _, ok := r.(libdns.RR); ok {
+			switch r.RR().Type {
+			case "A", "AAAA", "CAA", "CNAME", "MX", "NS", "SRV", "TXT":
+				t.Errorf("%s returned an opaque RR for %v %v", method, r.RR().Name, r.RR().Type)
+			}
+		}
+	}
+}
+
+// expectSame checks that the records are the records wanted in any order, comparing their names, types, TTLs, and data.
+func expectSame(t *testing.T, what string, got []libdns.Record, want []libdns.Record) {
+	t.Helper()
+	gotKeys, wantKeys := recordKeys(got), recordKeys(want)
+	if strings.Join(gotKeys, "\n") != strings.Join(wantKeys, "\n") {
+		t.Errorf("%s:\n%s\nwant:\n%s", what, strings.Join(gotKeys, "\n"), strings.Join(wantKeys, "\n"))
+	}
+}
+
+// recordKeys returns the records in the zone file syntax, sorted.
+func recordKeys(records []libdns.Record) []string {
+	keys := make([]string, 0, len(records))
+	for _, r := range records {
+		rr := r.RR()
+		keys = append(keys, fmt.Sprintf("%s\t%d\t%s\t%q", rr.Name, int(rr.TTL.Seconds()), rr.Type, rr.Data))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cleanUp deletes the records written by the suite, i.e. the records with a name under the prefix.
+func cleanUp(t *testing.T, provider Provider, zone string, prefix string) {
+	all, err := provider.GetRecords(context.Background(), zone)
+	if err != nil {
+		t.Errorf("the records written cannot be listed to delete them: %v", err)
+		return
+	}
+	var records []libdns.Record
+	for _, r := range all {
+		name := r.RR().Name
+		if strings.HasPrefix(name, prefix) || strings.Contains(name, "."+prefix) {
+			records = append(records, r)
+		}
+	}
+	if len(records) == 0 {
+		return
+	}
+	if _, err := provider.DeleteRecords(context.Background(), zone, records); err != nil {
+		t.Errorf("the records written cannot be deleted: %v", err)
+	}
+}
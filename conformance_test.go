@@ -0,0 +1,29 @@
+package azure
+
+import (
+	"os"
+	"testing"
+
+	"github.com/libdns/azure/conformance"
+)
+
+func Test_conformance(t *testing.T) {
+	conformance.RunConformance(t, getFakeProviderWithZone(newFakeZone()), "example.com.")
+}
+
+// Test_conformanceAzure runs the suite against a real zone, with the same environment variables as the example.
+func Test_conformanceAzure(t *testing.T) {
+	zone := os.Getenv("AZURE_DNS_ZONE_FQDN")
+	if zone == "" {
+		t.Skip("AZURE_DNS_ZONE_FQDN is not set")
+	}
+	provider := &Provider{
+		SubscriptionId:    os.Getenv("AZURE_SUBSCRIPTION_ID"),
+		ResourceGroupName: os.Getenv("AZURE_RESOURCE_GROUP_NAME"),
+		TenantId:          os.Getenv("AZURE_TENANT_ID"),
+		ClientId:          os.Getenv("AZURE_CLIENT_ID"),
+		ClientSecret:      os.Getenv("AZURE_CLIENT_SECRET"),
+	}
+	defer provider.Close()
+	conformance.RunConformance(t, provider, zone)
+}
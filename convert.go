@@ -0,0 +1,46 @@
+package azure
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/libdns/libdns"
+)
+
+// FromRecordSet converts a record set of Azure DNS, e.g. one listed with armdns, to libdns records in the same way as GetRecords.
+// The records are of the type-specific structs of libdns, or the opaque RR for the types that libdns does not define,
+// with the name of the record set relative to the zone. A record set that is an alias of an Azure resource is converted
+// to a single Alias carrying the ID of the target resource. A record set with a record missing any of its fields,
+// e.g. an A record without the address, fails the conversion rather than being converted partially.
+func FromRecordSet(recordSet armdns.RecordSet) ([]libdns.Record, error) {
+	if recordSet.Name == nil || recordSet.Type == nil {
+		return nil, fmt.Errorf("the record set without a name or a type cannot be interpreted")
+	}
+	return convertAzureRecordSetsToLibdnsRecords([]*armdns.RecordSet{&recordSet})
+}
+
+// ToRecordSets converts libdns records to record sets of Azure DNS in the same way as SetRecords, grouping the records
+// sharing the same name and type into a single record set, in the order of appearance. The names of the records may be
+// relative to the zone or fully qualified. The record sets have the name relative to the zone, the resource type,
+// and the properties with the values and the TTL, which must be the same for all the records of a record set.
+func ToRecordSets(zone string, records []libdns.Record) ([]armdns.RecordSet, error) {
+	records, err := decodeGenericRecords(records)
+	if err != nil {
+		return nil, err
+	}
+
+	var recordSets []armdns.RecordSet
+	for _, recordGroup := range groupRecordsByRecordSet(records, zone, generateRecordSetName) {
+		rr := recordGroup[0].RR()
+		recordSet, err := convertLibdnsRecordsToAzureRecordSet(recordGroup, TTLConflictPolicyError)
+		if err != nil {
+			return nil, err
+		}
+		recordSet.Name = to.Ptr(generateRecordSetName(rr.Name, zone))
+		recordSet.Type = to.Ptr("Microsoft.Network/dnszones/" + rr.Type)
+		recordSets = append(recordSets, recordSet)
+	}
+
+	return recordSets, nil
+}
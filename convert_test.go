@@ -0,0 +1,173 @@
+package azure
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_FromRecordSet(t *testing.T) {
+	tests := []struct {
+		name      string
+		recordSet armdns.RecordSet
+		want      []libdns.Record
+		wantErr   bool
+	}{
+		{
+			name:      "type=A",
+			recordSet: azureFakeRecords[0],
+			want:      libdnsFakeRecords[:1],
+		},
+		{
+			name: "type=TXT",
+			recordSet: armdns.RecordSet{
+				Name: to.Ptr("record-txt"),
+				Type: to.Ptr("Microsoft.Network/dnszones/TXT"),
+				Properties: &armdns.RecordSetProperties{
+					TTL:        to.Ptr[int64](30),
+					TxtRecords: []*armdns.TxtRecord{{Value: []*string{to.Ptr("TEST "), to.Ptr("VALUE")}}},
+				},
+			},
+			want: []libdns.Record{libdns.TXT{Name: "record-txt", Text: "TEST VALUE", TTL: 30 * time.Second}},
+		},
+		{
+			name: "properties=none",
+			recordSet: armdns.RecordSet{
+				Name: to.Ptr("record-a"),
+				Type: to.Ptr("Microsoft.Network/dnszones/A"),
+			},
+			want: nil,
+		},
+		{
+			name: "type=CNAME,value=none",
+			recordSet: armdns.RecordSet{
+				Name:       to.Ptr("record-cname"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/CNAME"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](30)},
+			},
+			want: nil,
+		},
+		{
+			name: "type=CNAME,value=missing",
+			recordSet: armdns.RecordSet{
+				Name:       to.Ptr("record-cname"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/CNAME"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](30), CnameRecord: &armdns.CnameRecord{}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "type=SOA,value=missing",
+			recordSet: armdns.RecordSet{
+				Name:       to.Ptr("@"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/SOA"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](30), SoaRecord: &armdns.SoaRecord{Host: to.Ptr("ns1.example.com.")}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "type=A,value=missing",
+			recordSet: armdns.RecordSet{
+				Name:       to.Ptr("record-a"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/A"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](30), ARecords: []*armdns.ARecord{nil}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "type=MX,value=missing",
+			recordSet: armdns.RecordSet{
+				Name:       to.Ptr("record-mx"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/MX"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](30), MxRecords: []*armdns.MxRecord{{Exchange: to.Ptr("mail.example.com.")}}},
+			},
+			wantErr: true,
+		},
+		{
+			name:      "type=none",
+			recordSet: armdns.RecordSet{Name: to.Ptr("record-a")},
+			wantErr:   true,
+		},
+		{
+			name: "type=unsupported",
+			recordSet: armdns.RecordSet{
+				Name:       to.Ptr("record-ds"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/DS"),
+				Properties: &armdns.RecordSetProperties{},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromRecordSet(tt.recordSet)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got: nil, want: error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got, tt.want, recordComparer); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_ToRecordSets(t *testing.T) {
+	t.Run("roundtrip", func(t *testing.T) {
+		recordSets, err := ToRecordSets("example.com.", libdnsFakeRecords)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		// The two NS records named "record-ns" share a record set
+		if len(recordSets) != len(libdnsFakeRecords)-1 {
+			t.Errorf("got: %d, want: %d", len(recordSets), len(libdnsFakeRecords)-1)
+		}
+		var got []libdns.Record
+		for _, recordSet := range recordSets {
+			records, err := FromRecordSet(recordSet)
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			got = append(got, records...)
+		}
+		if diff := cmp.Diff(got, libdnsFakeRecords, recordComparer); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("name=fqdn", func(t *testing.T) {
+		recordSets, err := ToRecordSets("example.com.", []libdns.Record{
+			libdns.TXT{Name: "_acme-challenge.example.com.", Text: "TOKEN", TTL: 60 * time.Second},
+		})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := []armdns.RecordSet{{
+			Name: to.Ptr("_acme-challenge"),
+			Type: to.Ptr("Microsoft.Network/dnszones/TXT"),
+			Properties: &armdns.RecordSetProperties{
+				TTL:        to.Ptr[int64](60),
+				TxtRecords: []*armdns.TxtRecord{{Value: []*string{to.Ptr("TOKEN")}}},
+			},
+		}}
+		if diff := cmp.Diff(recordSets, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("ttl=conflicting", func(t *testing.T) {
+		if _, err := ToRecordSets("example.com.", []libdns.Record{
+			libdns.TXT{Name: "record-txt", Text: "A", TTL: 30 * time.Second},
+			libdns.TXT{Name: "record-txt", Text: "B", TTL: 60 * time.Second},
+		}); err == nil {
+			t.Errorf("got: nil, want: error")
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// credentialFailure is a common failure to acquire a token, recognized by a pattern in the error message.
+type credentialFailure struct {
+	// matches reports whether the error is the failure.
+	matches func(err error, message string) bool
+
+	// reason explains the failure.
+	reason string
+
+	// hint suggests how to fix the failure.
+	hint string
+}
+
+// credentialFailures are the common failures of the credentials, checked in order.
+// The messages are matched since the errors of azidentity are wrapped by the chained credential and the pipeline.
+var credentialFailures = []credentialFailure{
+	{
+		matches: containsAny("AADSTS7000215"),
+		reason:  "the client secret is invalid",
+		hint:    "set ClientSecret to the value of the client secret, not its ID, or create a new client secret for the app registration",
+	},
+	{
+		matches: containsAny("AADSTS7000222"),
+		reason:  "the client secret has expired",
+		hint:    "create a new client secret for the app registration and update ClientSecret",
+	},
+	{
+		matches: containsAny("AADSTS700016"),
+		reason:  "the application is not found in the tenant",
+		hint:    "check that ClientId is the application (client) ID of the app registration, and that TenantId is the tenant in which it is registered",
+	},
+	{
+		matches: containsAny("AADSTS90002"),
+		reason:  "the tenant is not found",
+		hint:    "check that TenantId is the directory (tenant) ID, and that the cloud of the provider is the one the tenant belongs to",
+	},
+	{
+		matches: containsAny("the requested identity isn't assigned to this resource"),
+		reason:  "the user-assigned managed identity is not assigned to this resource",
+		hint:    "assign the identity to the resource, or check ManagedIdentityClientId and ManagedIdentityResourceId",
+	},
+	{
+		matches: func(err error, message string) bool {
+			return strings.Contains(message, "ManagedIdentityCredential") &&
+				(errors.Is(err, context.DeadlineExceeded) || containsAny("169.254.169.254", "i/o timeout", "no route to host", "connection refused")(err, message))
+		},
+		reason: "Azure Instance Metadata Service is unreachable",
+		hint:   "enable a managed identity on the resource running the provider, or set ClientId and ClientSecret, or FallbackToDeveloperCredentials on machines without a managed identity",
+	},
+}
+
+// containsAny returns a matcher reporting whether the error message contains any of the substrings.
+func containsAny(substrings ...string) func(err error, message string) bool {
+	return func(err error, message string) bool {
+		for _, substring := range substrings {
+			if strings.Contains(message, substring) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// enrichCredentialError converts a common failure to acquire a token to CredentialError with a hint to fix it.
+// Other errors are returned as is.
+func enrichCredentialError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var credentialError *CredentialError
+	if errors.As(err, &credentialError) {
+		return err
+	}
+
+	message := err.Error()
+	for _, failure := range credentialFailures {
+		if failure.matches(err, message) {
+			return &CredentialError{
+				Reason: failure.reason,
+				Hint:   failure.hint,
+				Err:    err,
+			}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,102 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_enrichCredentialError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantReason string
+	}{
+		{
+			name:       "error=AADSTS7000215",
+			err:        errors.New("ClientSecretCredential authentication failed\nAADSTS7000215: Invalid client secret provided."),
+			wantReason: "the client secret is invalid",
+		},
+		{
+			name:       "error=AADSTS7000222",
+			err:        errors.New("ClientSecretCredential authentication failed\nAADSTS7000222: The provided client secret keys are expired."),
+			wantReason: "the client secret has expired",
+		},
+		{
+			name:       "error=AADSTS700016",
+			err:        errors.New("ClientSecretCredential authentication failed\nAADSTS700016: Application with identifier 'fake-client-id' was not found in the directory."),
+			wantReason: "the application is not found in the tenant",
+		},
+		{
+			name:       "error=AADSTS90002",
+			err:        errors.New("ClientSecretCredential authentication failed\nAADSTS90002: Tenant 'fake-tenant-id' not found."),
+			wantReason: "the tenant is not found",
+		},
+		{
+			name:       "error=identity-not-assigned",
+			err:        errors.New("ManagedIdentityCredential: the requested identity isn't assigned to this resource"),
+			wantReason: "the user-assigned managed identity is not assigned to this resource",
+		},
+		{
+			name:       "error=imds-timeout",
+			err:        errors.New(`ManagedIdentityCredential: Get "http://169.254.169.254/metadata/identity/oauth2/token": dial tcp 169.254.169.254:80: i/o timeout`),
+			wantReason: "Azure Instance Metadata Service is unreachable",
+		},
+		{
+			name:       "error=imds-deadline",
+			err:        fmt.Errorf("ManagedIdentityCredential: %w", context.DeadlineExceeded),
+			wantReason: "Azure Instance Metadata Service is unreachable",
+		},
+		{
+			name: "error=other",
+			err:  errors.New("other"),
+		},
+		{
+			name: "error=deadline",
+			err:  context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := enrichCredentialError(tt.err)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("the original error is not in the chain: %v", err)
+			}
+			var credentialError *CredentialError
+			if tt.wantReason == "" {
+				if errors.As(err, &credentialError) {
+					t.Errorf("got: %v, want: %v", err, tt.err)
+				}
+				return
+			}
+			if !errors.As(err, &credentialError) {
+				t.Fatalf("got: %T, want: %T", err, credentialError)
+			}
+			if diff := cmp.Diff(credentialError.Reason, tt.wantReason); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+			if credentialError.Hint == "" {
+				t.Errorf("the hint is empty")
+			}
+			if got := enrichCredentialError(err); got != err {
+				t.Errorf("got: %v, want: %v", got, err)
+			}
+		})
+	}
+	t.Run("error=provider", func(t *testing.T) {
+		original := errors.New("ClientSecretCredential authentication failed\nAADSTS7000215: Invalid client secret provided.")
+		provider := Provider{SubscriptionId: "fake-subscription-id", ResourceGroupName: "fake-resource-group-name"}
+		provider.client.credential = &fakeJWTCredential{err: original}
+		_, err := provider.GetRecords(context.TODO(), "example.com.")
+		var credentialError *CredentialError
+		if !errors.As(err, &credentialError) {
+			t.Fatalf("got: %T, want: %T", err, credentialError)
+		}
+		if diff := cmp.Diff(credentialError.Reason, "the client secret is invalid"); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
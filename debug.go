@@ -0,0 +1,148 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	azlog "github.com/Azure/azure-sdk-for-go/sdk/azcore/log"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// sensitiveKeyPattern matches names of fields, parameters, and headers whose values must not be logged.
+var sensitiveKeyPattern = regexp.MustCompile(`(?i)(authorization|secret|password|token|assertion|signature|sig$|key$)`)
+
+// redactedValue replaces sensitive values in logs.
+const redactedValue = "REDACTED"
+
+// debugLoggingPolicy is a pipeline policy that logs every HTTP request and response with sensitive values redacted.
+type debugLoggingPolicy struct {
+	logger *slog.Logger
+}
+
+// Do logs the request and the response after sending the request to the next policy.
+func (d debugLoggingPolicy) Do(req *policy.Request) (*http.Response, error) {
+	raw := req.Raw()
+
+	var requestBody []byte
+	if body := req.Body(); body != nil {
+		requestBody, _ = io.ReadAll(body)
+		if err := req.RewindBody(); err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	resp, err := req.Next()
+	duration := time.Since(start)
+
+	attrs := []any{
+		slog.String("method", raw.Method),
+		slog.String("url", sanitizeURL(raw.URL)),
+		slog.Duration("duration", duration),
+		slog.String("request_body", sanitizeBody(requestBody, raw.Header.Get("Content-Type"))),
+	}
+	if err != nil {
+		d.logger.Info("azure request failed", append(attrs, slog.Any("error", err))...)
+		return resp, err
+	}
+
+	var responseBody []byte
+	if resp.Body != nil {
+		responseBody, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+	}
+	attrs = append(attrs,
+		slog.Int("status", resp.StatusCode),
+		slog.String("response_body", sanitizeBody(responseBody, resp.Header.Get("Content-Type"))),
+	)
+	d.logger.Info("azure request", attrs...)
+
+	return resp, nil
+}
+
+// sanitizeURL returns the URL with the values of sensitive query parameters redacted.
+func sanitizeURL(u *url.URL) string {
+	sanitized := *u
+	sanitized.User = nil
+	query := sanitized.Query()
+	for key := range query {
+		if sensitiveKeyPattern.MatchString(key) {
+			query.Set(key, redactedValue)
+		}
+	}
+	sanitized.RawQuery = query.Encode()
+	return sanitized.String()
+}
+
+// sanitizeBody returns the body with the values of sensitive fields redacted.
+// Bodies that cannot be parsed as JSON or a form are omitted entirely, since they may contain anything.
+func sanitizeBody(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err == nil {
+		sanitized, _ := json.Marshal(sanitizeJSONValue(value))
+		return string(sanitized)
+	}
+
+	if contentType == "application/x-www-form-urlencoded" {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			for key := range form {
+				if sensitiveKeyPattern.MatchString(key) {
+					form.Set(key, redactedValue)
+				}
+			}
+			return form.Encode()
+		}
+	}
+
+	return redactedValue
+}
+
+// sanitizeJSONValue redacts the values of sensitive fields in the decoded JSON value recursively.
+func sanitizeJSONValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if sensitiveKeyPattern.MatchString(key) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = sanitizeJSONValue(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = sanitizeJSONValue(child)
+		}
+	}
+	return value
+}
+
+// setSDKLogListener forwards the log events of the Azure SDK to the logger at the level.
+// Since the listener of the Azure SDK is shared by the whole process, the last call wins.
+func setSDKLogListener(logger *slog.Logger, levelName string) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(levelName)); err != nil {
+		return fmt.Errorf("the log level %v cannot be interpreted", levelName)
+	}
+
+	azlog.SetListener(func(event azlog.Event, message string) {
+		logger.Log(context.Background(), level, message, slog.String("event", string(event)))
+	})
+
+	return nil
+}
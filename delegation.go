@@ -0,0 +1,92 @@
+package azure
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/libdns/libdns"
+)
+
+// followedKey is the context key marking the writes of records whose challenge CNAMEs have been followed,
+// so that the CNAMEs are not followed again in the zones of their targets.
+type followedKey struct{}
+
+// followChallengeCNAMEs returns the records with the names of the TXT records for ACME DNS challenges replaced by the
+// fully-qualified targets of the CNAME record sets at their names in the zone, if any, reporting whether any name was replaced.
+// Only a single CNAME is followed for each record, and records already followed are returned as they are.
+func (p *Provider) followChallengeCNAMEs(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, bool, error) {
+	if !p.FollowChallengeCNAMEs || zone == "" || ctx.Value(followedKey{}) != nil {
+		return records, false, nil
+	}
+
+	targets := map[string]string{}
+	var followed bool
+	followedRecords := make([]libdns.Record, 0, len(records))
+	for _, record := range records {
+		rr := record.RR()
+		name := libdns.RelativeName(rr.Name, zone)
+		if !isChallengeRecordSet(name, rr.Type) {
+			followedRecords = append(followedRecords, record)
+			continue
+		}
+		target, ok := targets[name]
+		if !ok {
+			var err error
+			if target, err = p.getChallengeCNAME(ctx, zone, name); err != nil {
+				return nil, false, err
+			}
+			targets[name] = target
+		}
+		if target == "" {
+			followedRecords = append(followedRecords, record)
+			continue
+		}
+		p.getLogger().Debug("following the CNAME of the challenge record", "zone", zone, "name", name, "target", target)
+		followedRecords = append(followedRecords, newLibdnsRecord(target, rr.TTL, rr.Type, rr.Data))
+		followed = true
+	}
+	return followedRecords, followed, nil
+}
+
+// getChallengeCNAME returns the fully-qualified target of the CNAME record set with the name in the zone,
+// or an empty string if there is none.
+func (p *Provider) getChallengeCNAME(ctx context.Context, zone string, name string) (string, error) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return "", err
+	}
+
+	var target string
+	err := p.retryOnAuthenticationError(func() error {
+		response, err := p.client.azureClient.Get(
+			ctx,
+			p.ResourceGroupName,
+			strings.TrimSuffix(zone, "."),
+			p.recordSetName(name, zone),
+			armdns.RecordTypeCNAME,
+			&armdns.RecordSetsClientGetOptions{},
+		)
+		if err != nil {
+			if isNotFoundError(err) {
+				return nil
+			}
+			return err
+		}
+		if response.Properties != nil && response.Properties.CnameRecord != nil {
+			target = stringValue(response.Properties.CnameRecord.Cname)
+		}
+		return nil
+	})
+	if err != nil || target == "" {
+		return "", err
+	}
+	return strings.TrimSuffix(target, ".") + ".", nil
+}
+
+// writeFollowed writes the records whose challenge CNAMEs have been followed to the zones of their names with write.
+func (p *Provider) writeFollowed(ctx context.Context, zone string, records []libdns.Record, write func(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error)) ([]libdns.Record, error) {
+	return p.writeToZones(context.WithValue(ctx, followedKey{}, true), zone, records, write)
+}
@@ -0,0 +1,134 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_FollowChallengeCNAMEs(t *testing.T) {
+	// CNAME record sets by zone and name, the second pointing back to the first
+	cnames := map[string]string{
+		"example.com/_acme-challenge.www":      "1234.acme.sub.example.com.",
+		"sub.example.com/_acme-challenge.loop": "_acme-challenge.loop.example.com.",
+		"example.com/_acme-challenge.loop":     "_acme-challenge.loop.sub.example.com.",
+	}
+	tests := []struct {
+		name   string
+		follow bool
+		write  func(provider *Provider, ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error)
+		record libdns.Record
+		want   []string
+		// wantNames are the names of the returned records
+		wantNames []string
+	}{
+		{
+			name:      "follow=false",
+			write:     (*Provider).AppendRecords,
+			record:    libdns.TXT{Name: "_acme-challenge.www", Text: "TOKEN", TTL: time.Minute},
+			want:      []string{"write _acme-challenge.www example.com"},
+			wantNames: []string{"_acme-challenge.www"},
+		},
+		{
+			name:      "follow=true,append",
+			follow:    true,
+			write:     (*Provider).AppendRecords,
+			record:    libdns.TXT{Name: "_acme-challenge.www", Text: "TOKEN", TTL: time.Minute},
+			want:      []string{"write 1234.acme sub.example.com"},
+			wantNames: []string{"1234.acme.sub.example.com."},
+		},
+		{
+			name:      "follow=true,set",
+			follow:    true,
+			write:     (*Provider).SetRecords,
+			record:    libdns.TXT{Name: "_acme-challenge.www.example.com.", Text: "TOKEN", TTL: time.Minute},
+			want:      []string{"write 1234.acme sub.example.com"},
+			wantNames: []string{"1234.acme.sub.example.com."},
+		},
+		{
+			name:      "follow=true,delete",
+			follow:    true,
+			write:     (*Provider).DeleteRecords,
+			record:    libdns.RR{Name: "_acme-challenge.www", Type: "TXT"},
+			want:      []string{"delete 1234.acme sub.example.com"},
+			wantNames: []string{"1234.acme.sub.example.com."},
+		},
+		{
+			name:      "follow=true,no cname",
+			follow:    true,
+			write:     (*Provider).AppendRecords,
+			record:    libdns.TXT{Name: "_acme-challenge.api", Text: "TOKEN", TTL: time.Minute},
+			want:      []string{"write _acme-challenge.api example.com"},
+			wantNames: []string{"_acme-challenge.api"},
+		},
+		{
+			name:      "follow=true,not challenge",
+			follow:    true,
+			write:     (*Provider).AppendRecords,
+			record:    libdns.TXT{Name: "www", Text: "TOKEN", TTL: time.Minute},
+			want:      []string{"write www example.com"},
+			wantNames: []string{"www"},
+		},
+		{
+			name:      "follow=true,loop",
+			follow:    true,
+			write:     (*Provider).AppendRecords,
+			record:    libdns.TXT{Name: "_acme-challenge.loop", Text: "TOKEN", TTL: time.Minute},
+			want:      []string{"write _acme-challenge.loop sub.example.com"},
+			wantNames: []string{"_acme-challenge.loop.sub.example.com."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			fakeRecordSetsServer := getFakeRecordSetsServer()
+			fakeRecordSetsServer.Get = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientGetOptions) (resp azfake.Responder[armdns.RecordSetsClientGetResponse], errResp azfake.ErrorResponder) {
+				target, ok := cnames[zoneName+"/"+relativeRecordSetName]
+				if !ok || recordType != armdns.RecordTypeCNAME {
+					errResp.SetResponseError(http.StatusNotFound, "NotFound")
+					return
+				}
+				resp.SetResponse(http.StatusOK, armdns.RecordSetsClientGetResponse{RecordSet: armdns.RecordSet{
+					Name:       to.Ptr(relativeRecordSetName),
+					Type:       to.Ptr("Microsoft.Network/dnszones/CNAME"),
+					Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](300), CnameRecord: &armdns.CnameRecord{Cname: to.Ptr(target)}},
+				}}, nil)
+				return
+			}
+			createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+			fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+				got = append(got, "write "+relativeRecordSetName+" "+zoneName)
+				return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+			}
+			deleteRecordSet := fakeRecordSetsServer.Delete
+			fakeRecordSetsServer.Delete = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientDeleteOptions) (resp azfake.Responder[armdns.RecordSetsClientDeleteResponse], errResp azfake.ErrorResponder) {
+				got = append(got, "delete "+relativeRecordSetName+" "+zoneName)
+				return deleteRecordSet(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, options)
+			}
+			provider := getFakeProviderWithServer(fakeRecordSetsServer)
+			provider.FollowChallengeCNAMEs = tt.follow
+
+			records, err := tt.write(&provider, context.TODO(), "example.com.", []libdns.Record{tt.record})
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+			var gotNames []string
+			for _, record := range records {
+				gotNames = append(gotNames, record.RR().Name)
+			}
+			if diff := cmp.Diff(gotNames, tt.wantNames); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
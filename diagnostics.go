@@ -0,0 +1,146 @@
+package azure
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// CredentialDiagnostics describes the credential that acquired the access token used by the provider.
+type CredentialDiagnostics struct {
+	// Credential is the type of the credential in the chain that acquired the token, e.g. "ManagedIdentityCredential".
+	Credential string
+
+	// TenantId is the ID of the tenant that issued the token.
+	TenantId string
+
+	// ClientId is the ID of the application or managed identity that the token was issued to.
+	ClientId string
+
+	// ObjectId is the object ID of the service principal that the token was issued to, on which roles are assigned.
+	ObjectId string
+
+	// ExpiresOn is when the token expires.
+	ExpiresOn time.Time
+}
+
+// credentialDiagnostics keeps the diagnostics of the last token acquired by the credentials of the provider.
+// It has its own mutex, since tokens are acquired while the client is locked.
+type credentialDiagnostics struct {
+	last  *CredentialDiagnostics
+	mutex sync.Mutex
+}
+
+// diagnosingCredential is a credential in the chain that records the diagnostics of the tokens it acquires.
+type diagnosingCredential struct {
+	credential  azcore.TokenCredential
+	diagnostics *credentialDiagnostics
+	logger      *slog.Logger
+}
+
+// GetToken acquires a token with the credential, recording which credential acquired it.
+func (c *diagnosingCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	token, err := c.credential.GetToken(ctx, options)
+	if err != nil {
+		return token, err
+	}
+
+	diagnostics := newCredentialDiagnostics(c.credential, token)
+	c.diagnostics.mutex.Lock()
+	c.diagnostics.last = &diagnostics
+	c.diagnostics.mutex.Unlock()
+
+	c.logger.Debug("acquired a token",
+		slog.String("credential", diagnostics.Credential),
+		slog.String("tenant_id", diagnostics.TenantId),
+		slog.String("client_id", diagnostics.ClientId),
+		slog.String("object_id", diagnostics.ObjectId),
+		slog.Time("expires_on", diagnostics.ExpiresOn),
+	)
+
+	return token, nil
+}
+
+// newCredentialDiagnostics builds the diagnostics of the token acquired by the credential.
+// The claims are read from the token without verifying it, since they are only reported.
+func newCredentialDiagnostics(credential azcore.TokenCredential, token azcore.AccessToken) CredentialDiagnostics {
+	credentialType := strings.TrimPrefix(fmt.Sprintf("%T", credential), "*")
+	if i := strings.LastIndex(credentialType, "."); i >= 0 {
+		credentialType = credentialType[i+1:]
+	}
+	diagnostics := CredentialDiagnostics{
+		Credential: credentialType,
+		ExpiresOn:  token.ExpiresOn,
+	}
+
+	segments := strings.Split(token.Token, ".")
+	if len(segments) != 3 {
+		return diagnostics
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(segments[1])
+	if err != nil {
+		return diagnostics
+	}
+	var claims struct {
+		Tid   string `json:"tid"`
+		Appid string `json:"appid"`
+		Azp   string `json:"azp"`
+		Oid   string `json:"oid"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return diagnostics
+	}
+	diagnostics.TenantId = claims.Tid
+	diagnostics.ClientId = claims.Appid
+	if diagnostics.ClientId == "" {
+		diagnostics.ClientId = claims.Azp
+	}
+	diagnostics.ObjectId = claims.Oid
+
+	return diagnostics
+}
+
+// DiagnoseCredential acquires an access token for Azure Resource Manager with the credentials of the provider,
+// and reports which credential in the chain acquired it, the tenant and the application it was issued for, and its expiry.
+// It helps to find out why the provider authenticates differently on different machines.
+// Tokens cached by the credential are reported as they are.
+func (p *Provider) DiagnoseCredential(ctx context.Context) (CredentialDiagnostics, error) {
+	p.client.mutex.Lock()
+	if err := p.setupClient(); err != nil {
+		p.client.mutex.Unlock()
+		return CredentialDiagnostics{}, err
+	}
+	credential := p.client.tokenCredential
+	clientOptions, err := p.getClientOptions()
+	p.client.mutex.Unlock()
+	if err != nil {
+		return CredentialDiagnostics{}, err
+	}
+
+	audience := cloud.AzurePublic.Services[cloud.ResourceManager].Audience
+	if service, ok := clientOptions.Cloud.Services[cloud.ResourceManager]; ok && service.Audience != "" {
+		audience = service.Audience
+	}
+	if _, err := credential.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{strings.TrimSuffix(audience, "/") + "/.default"},
+	}); err != nil {
+		return CredentialDiagnostics{}, enrichCredentialError(err)
+	}
+
+	p.client.diagnostics.mutex.Lock()
+	defer p.client.diagnostics.mutex.Unlock()
+
+	if p.client.diagnostics.last == nil {
+		return CredentialDiagnostics{}, fmt.Errorf("the credential that acquired the token cannot be determined")
+	}
+	return *p.client.diagnostics.last, nil
+}
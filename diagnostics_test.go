@@ -0,0 +1,98 @@
+package azure
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/google/go-cmp/cmp"
+)
+
+type fakeJWTCredential struct {
+	payload   string
+	expiresOn time.Time
+	err       error
+	scopes    []string
+}
+
+func (c *fakeJWTCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.scopes = options.Scopes
+	if c.err != nil {
+		return azcore.AccessToken{}, c.err
+	}
+	token := "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(c.payload)) + ".signature"
+	return azcore.AccessToken{Token: token, ExpiresOn: c.expiresOn}, nil
+}
+
+func Test_DiagnoseCredential(t *testing.T) {
+	expiresOn := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		payload string
+		err     error
+		want    CredentialDiagnostics
+		wantErr bool
+	}{
+		{
+			name:    "claims=appid",
+			payload: `{"tid":"fake-tenant-id","appid":"fake-client-id","oid":"fake-object-id"}`,
+			want: CredentialDiagnostics{
+				Credential: "fakeJWTCredential",
+				TenantId:   "fake-tenant-id",
+				ClientId:   "fake-client-id",
+				ObjectId:   "fake-object-id",
+				ExpiresOn:  expiresOn,
+			},
+		},
+		{
+			name:    "claims=azp",
+			payload: `{"tid":"fake-tenant-id","azp":"fake-client-id"}`,
+			want: CredentialDiagnostics{
+				Credential: "fakeJWTCredential",
+				TenantId:   "fake-tenant-id",
+				ClientId:   "fake-client-id",
+				ExpiresOn:  expiresOn,
+			},
+		},
+		{
+			name:    "claims=invalid",
+			payload: `not json`,
+			want: CredentialDiagnostics{
+				Credential: "fakeJWTCredential",
+				ExpiresOn:  expiresOn,
+			},
+		},
+		{
+			name:    "error",
+			err:     errors.New("fake error"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential := &fakeJWTCredential{payload: tt.payload, expiresOn: expiresOn, err: tt.err}
+			provider := Provider{SubscriptionId: "fake-subscription-id"}
+			provider.client.credential = credential
+			got, err := provider.DiagnoseCredential(context.TODO())
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got: nil, want: error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+			if diff := cmp.Diff(credential.scopes, []string{"https://management.core.windows.net/.default"}); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/libdns/libdns"
+)
+
+// dnssecAPIVersion is the version of the API of Azure DNS serving the DNSSEC configurations of zones,
+// which armdns v1.2.0, the version this module requires, has no client for.
+const dnssecAPIVersion = "2023-07-01-preview"
+
+// dnssecProvisioningSucceeded is the provisioning state of a DNSSEC configuration whose keys are ready.
+const dnssecProvisioningSucceeded = "Succeeded"
+
+// DNSSECDelegation is the material to complete the delegation of a zone signed with DNSSEC at the registrar of
+// its parent zone, with the key signing keys of the zone and their digests.
+type DNSSECDelegation struct {
+	// Zone is the name of the zone, with a trailing dot.
+	Zone string
+
+	// DelegationSigners are the digests of the key signing keys, to be added to the parent zone as DS records.
+	DelegationSigners []DelegationSigner
+
+	// SigningKeys are the key signing keys, for registrars that take DNSKEY records and compute the digests themselves.
+	SigningKeys []SigningKey
+}
+
+// DelegationSigner is the digest of a key signing key, as in a DS record.
+type DelegationSigner struct {
+	KeyTag     int
+	Algorithm  int
+	DigestType int
+	Digest     string
+}
+
+// String returns the data of the DS record, e.g. "12345 13 2 3A1F...", which is what the forms of most registrars take.
+func (d DelegationSigner) String() string {
+	return fmt.Sprintf("%d %d %d %s", d.KeyTag, d.Algorithm, d.DigestType, d.Digest)
+}
+
+// SigningKey is a key signing key, as in a DNSKEY record.
+type SigningKey struct {
+	Flags     int
+	Protocol  int
+	Algorithm int
+	KeyTag    int
+	PublicKey string
+}
+
+// String returns the data of the DNSKEY record, e.g. "257 3 13 mdsswUyr3DPW...".
+func (k SigningKey) String() string {
+	return fmt.Sprintf("%d %d %d %s", k.Flags, k.Protocol, k.Algorithm, k.PublicKey)
+}
+
+// DSRecords returns the DS records to add to the parent zone with the TTL, named by the fully-qualified name of the zone,
+// e.g. for a libdns provider of the parent zone that supports DS records.
+func (d DNSSECDelegation) DSRecords(ttl time.Duration) []libdns.RR {
+	records := make([]libdns.RR, 0, len(d.DelegationSigners))
+	for _, delegationSigner := range d.DelegationSigners {
+		records = append(records, libdns.RR{
+			Name: d.Zone,
+			TTL:  ttl,
+			Type: "DS",
+			Data: delegationSigner.String(),
+		})
+	}
+	return records
+}
+
+// dnssecConfig is the DNSSEC configuration of a zone returned by Azure DNS.
+type dnssecConfig struct {
+	Properties struct {
+		ProvisioningState string `json:"provisioningState"`
+		SigningKeys       []struct {
+			DelegationSignerInfo []struct {
+				DigestAlgorithmType int    `json:"digestAlgorithmType"`
+				DigestValue         string `json:"digestValue"`
+			} `json:"delegationSignerInfo"`
+			Flags                 int    `json:"flags"`
+			KeyTag                int    `json:"keyTag"`
+			Protocol              int    `json:"protocol"`
+			PublicKey             string `json:"publicKey"`
+			SecurityAlgorithmType int    `json:"securityAlgorithmType"`
+		} `json:"signingKeys"`
+	} `json:"properties"`
+}
+
+// GetDNSSECDelegation returns the DS and DNSKEY material of the zone signed with DNSSEC by Azure DNS, so that its
+// delegation can be completed at the registrar of the parent zone. Signing is enabled on Azure DNS, e.g. with
+// "az network dns dnssec-config create"; it fails if the zone is not signed, or if its keys are still being provisioned.
+func (p *Provider) GetDNSSECDelegation(ctx context.Context, zone string) (DNSSECDelegation, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.GetDNSSECDelegation(ctx, zone)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	delegation, err := p.getDNSSECDelegation(ctx, zone)
+	if err != nil {
+		return DNSSECDelegation{}, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+
+	return delegation, nil
+}
+
+// getDNSSECDelegation reads the DNSSEC configuration of the zone and converts its key signing keys.
+// The client is not kept locked during the request.
+func (p *Provider) getDNSSECDelegation(ctx context.Context, zone string) (DNSSECDelegation, error) {
+	var config dnssecConfig
+	err := p.readWithARMClient(func(armClient *arm.Client) error {
+		endpoint := runtime.JoinPaths(armClient.Endpoint(), p.generateZoneID(zone), "dnssecConfigs/default") + "?api-version=" + dnssecAPIVersion
+		req, err := runtime.NewRequest(ctx, http.MethodGet, endpoint)
+		if err != nil {
+			return err
+		}
+		req.Raw().Header["Accept"] = []string{"application/json"}
+
+		resp, err := armClient.Pipeline().Do(req)
+		if err != nil {
+			return err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return runtime.NewResponseError(resp)
+		}
+		return runtime.UnmarshalAsJSON(resp, &config)
+	})
+	if isNotFoundError(err) {
+		return DNSSECDelegation{}, fmt.Errorf("the zone %v is not signed with DNSSEC: %w", zone, err)
+	}
+	if err != nil {
+		return DNSSECDelegation{}, err
+	}
+
+	state := config.Properties.ProvisioningState
+	if state != "" && !strings.EqualFold(state, dnssecProvisioningSucceeded) {
+		return DNSSECDelegation{}, fmt.Errorf("the signing of the zone %v is %v; try again once it has succeeded", zone, state)
+	}
+
+	delegation := DNSSECDelegation{Zone: strings.TrimSuffix(zone, ".") + "."}
+	for _, key := range config.Properties.SigningKeys {
+		delegation.SigningKeys = append(delegation.SigningKeys, SigningKey{
+			Flags:     key.Flags,
+			Protocol:  key.Protocol,
+			Algorithm: key.SecurityAlgorithmType,
+			KeyTag:    key.KeyTag,
+			PublicKey: key.PublicKey,
+		})
+		for _, info := range key.DelegationSignerInfo {
+			delegation.DelegationSigners = append(delegation.DelegationSigners, DelegationSigner{
+				KeyTag:     key.KeyTag,
+				Algorithm:  key.SecurityAlgorithmType,
+				DigestType: info.DigestAlgorithmType,
+				Digest:     info.DigestValue,
+			})
+		}
+	}
+	if len(delegation.DelegationSigners) == 0 {
+		return DNSSECDelegation{}, fmt.Errorf("the zone %v has no key signing keys yet; try again once its signing has succeeded", zone)
+	}
+
+	return delegation, nil
+}
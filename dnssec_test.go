@@ -0,0 +1,135 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func getFakeProviderWithDNSSECConfig(statusCode int, config string) *Provider {
+	return getFakeProviderWithTransport(func(req *http.Request, next policy.Transporter) (*http.Response, error) {
+		if !strings.HasSuffix(req.URL.Path, "/dnszones/example.com/dnssecConfigs/default") {
+			return next.Do(req)
+		}
+		return newFakeJSONResponse(req, statusCode, config), nil
+	})
+}
+
+func Test_GetDNSSECDelegation(t *testing.T) {
+	signedConfig := `{
+		"name": "default",
+		"type": "Microsoft.Network/dnszones/dnssecConfigs",
+		"properties": {
+			"provisioningState": "Succeeded",
+			"signingKeys": [{
+				"delegationSignerInfo": [
+					{"digestAlgorithmType": 2, "digestValue": "3A1F0B", "record": "12345 13 2 3A1F0B"},
+					{"digestAlgorithmType": 4, "digestValue": "9C8D7E", "record": "12345 13 4 9C8D7E"}
+				],
+				"flags": 257,
+				"keyTag": 12345,
+				"protocol": 3,
+				"publicKey": "mdsswUyr3DPW",
+				"securityAlgorithmType": 13
+			}]
+		}
+	}`
+	tests := []struct {
+		name       string
+		statusCode int
+		config     string
+		want       DNSSECDelegation
+		wantErr    string
+	}{
+		{
+			name:       "signed",
+			statusCode: http.StatusOK,
+			config:     signedConfig,
+			want: DNSSECDelegation{
+				Zone: "example.com.",
+				DelegationSigners: []DelegationSigner{
+					{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: "3A1F0B"},
+					{KeyTag: 12345, Algorithm: 13, DigestType: 4, Digest: "9C8D7E"},
+				},
+				SigningKeys: []SigningKey{{Flags: 257, Protocol: 3, Algorithm: 13, KeyTag: 12345, PublicKey: "mdsswUyr3DPW"}},
+			},
+		},
+		{
+			name:       "provisioning",
+			statusCode: http.StatusOK,
+			config:     `{"properties": {"provisioningState": "Creating", "signingKeys": []}}`,
+			wantErr:    "the signing of the zone example.com. is Creating",
+		},
+		{
+			name:       "not signed",
+			statusCode: http.StatusNotFound,
+			config:     `{"error": {"code": "NotFound", "message": "The resource is not found."}}`,
+			wantErr:    "the zone example.com. is not signed with DNSSEC",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := getFakeProviderWithDNSSECConfig(tt.statusCode, tt.config)
+			got, err := provider.GetDNSSECDelegation(context.TODO(), "example.com.")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got: %v, want: %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_DNSSECDelegation_DSRecords(t *testing.T) {
+	delegation := DNSSECDelegation{
+		Zone:              "example.com.",
+		DelegationSigners: []DelegationSigner{{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: "3A1F0B"}},
+		SigningKeys:       []SigningKey{{Flags: 257, Protocol: 3, Algorithm: 13, KeyTag: 12345, PublicKey: "mdsswUyr3DPW"}},
+	}
+	want := []libdns.RR{{Name: "example.com.", TTL: time.Hour, Type: "DS", Data: "12345 13 2 3A1F0B"}}
+	if diff := cmp.Diff(delegation.DSRecords(time.Hour), want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+	if got := delegation.SigningKeys[0].String(); got != "257 3 13 mdsswUyr3DPW" {
+		t.Errorf("got: %v", got)
+	}
+}
+
+func Test_GetDNSSECDelegation_unlocked(t *testing.T) {
+	var provider *Provider
+	var requests int
+	provider = getFakeProviderWithTransport(func(req *http.Request, next policy.Transporter) (*http.Response, error) {
+		requests++
+		// The client is not kept locked during the request
+		if !provider.client.mutex.TryLock() {
+			t.Errorf("the client is locked during the request")
+		} else {
+			provider.client.mutex.Unlock()
+		}
+		// The first request fails to authenticate, and is retried with the client rebuilt
+		if requests == 1 {
+			return newFakeJSONResponse(req, http.StatusUnauthorized, `{"error": {"code": "InvalidAuthenticationToken", "message": "The token is expired."}}`), nil
+		}
+		return newFakeJSONResponse(req, http.StatusOK, `{"properties": {"provisioningState": "Succeeded", "signingKeys": [{"delegationSignerInfo": [{"digestAlgorithmType": 2, "digestValue": "3A1F0B"}], "flags": 257, "keyTag": 12345, "protocol": 3, "publicKey": "mdsswUyr3DPW", "securityAlgorithmType": 13}]}}`), nil
+	})
+
+	if _, err := provider.GetDNSSECDelegation(context.TODO(), "example.com."); err != nil {
+		t.Fatalf("%s", err)
+	}
+	if requests != 2 {
+		t.Errorf("got: %d requests, want: 2", requests)
+	}
+}
@@ -0,0 +1,285 @@
+// Package dnsserver serves the current contents of a zone on Azure DNS over DNS, read-only, for local testing and
+// for debugging propagation, e.g. by comparing what Azure DNS holds with what the resolvers answer.
+// The records are read from the provider on start and refreshed on an interval.
+package dnsserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libdns/azure"
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+// defaultRefreshInterval is how often the records are read from the provider if Refresh Interval is not set.
+const defaultRefreshInterval = time.Minute
+
+// Server is an authoritative DNS server answering from the records of a zone read from a provider, e.g. azure.Provider.
+type Server struct {
+	// Provider is the provider to read the records of the zone from. Required.
+	Provider libdns.RecordGetter
+
+	// Zone is the name of the zone to serve, e.g. "example.com.". Required.
+	Zone string
+
+	// (Optional)
+	// Refresh Interval is how often the records are read from the provider again. Defaults to a minute.
+	// The records read last are served while reading them fails.
+	RefreshInterval time.Duration
+
+	// (Optional)
+	// Logger is the logger to write logs to. Defaults to slog.Default().
+	Logger *slog.Logger
+
+	records   []dns.RR
+	refreshed time.Time
+	mutex     sync.RWMutex
+}
+
+// ListenAndServe reads the records of the zone, and serves them over UDP and TCP on the address, e.g. "127.0.0.1:5353",
+// until the context is done. It fails if the records cannot be read at first, or if the address cannot be listened on.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	packetConn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		packetConn.Close()
+		return err
+	}
+	return s.Serve(ctx, packetConn, listener)
+}
+
+// Serve reads the records of the zone, and serves them on the UDP connection and the TCP listener until the context is done.
+// The connection and the listener are closed when it returns.
+func (s *Server) Serve(ctx context.Context, packetConn net.PacketConn, listener net.Listener) error {
+	defer packetConn.Close()
+	defer listener.Close()
+
+	if err := s.Refresh(ctx); err != nil {
+		return err
+	}
+
+	servers := []*dns.Server{
+		{PacketConn: packetConn, Handler: s},
+		{Listener: listener, Handler: s},
+	}
+	errs := make(chan error, len(servers))
+	for _, server := range servers {
+		go func(server *dns.Server) {
+			errs <- server.ActivateAndServe()
+		}(server)
+	}
+
+	interval := s.RefreshInterval
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var serveErr error
+	for serveErr == nil {
+		select {
+		case <-ctx.Done():
+			serveErr = ctx.Err()
+		case err := <-errs:
+			serveErr = fmt.Errorf("the DNS server stopped: %w", err)
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+				s.getLogger().Warn("failed to refresh the records; serving the records read last", "zone", s.Zone, "refreshed", s.lastRefreshed(), "error", err)
+			}
+		}
+	}
+	for _, server := range servers {
+		server.Shutdown()
+	}
+	if errors.Is(serveErr, context.Canceled) || errors.Is(serveErr, context.DeadlineExceeded) {
+		return nil
+	}
+	return serveErr
+}
+
+// Refresh reads the records of the zone from the provider, replacing the records served.
+func (s *Server) Refresh(ctx context.Context) error {
+	records, err := s.Provider.GetRecords(ctx, s.Zone)
+	if err != nil {
+		return err
+	}
+	var rrs []dns.RR
+	for _, record := range records {
+		rr, err := azure.ToDNSRR(s.Zone, record)
+		if err != nil {
+			// Aliases of Azure resources have no records to serve
+			s.getLogger().Debug("skipped a record that cannot be served", "zone", s.Zone, "name", record.RR().Name, "type", record.RR().Type, "error", err)
+			continue
+		}
+		rrs = append(rrs, rr)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.records = rrs
+	s.refreshed = time.Now()
+	s.getLogger().Debug("refreshed the records", "zone", s.Zone, "records", len(rrs))
+	return nil
+}
+
+// ServeDNS answers the queries for the names in the zone from the records read last, and refuses the others.
+func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := s.answer(r)
+	if err := w.WriteMsg(m); err != nil {
+		s.getLogger().Debug("failed to write the response", "error", err)
+	}
+}
+
+// answer builds the authoritative response to the query. A CNAME record is followed within the zone,
+// wildcard records answer for the names below their parent that do not exist, and the SOA record of the zone
+// is put in the authority section of negative responses.
+func (s *Server) answer(r *dns.Msg) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+	if len(r.Question) != 1 {
+		m.SetRcode(r, dns.RcodeFormatError)
+		return m
+	}
+	q := r.Question[0]
+	zone := dns.Fqdn(s.Zone)
+	if q.Qclass != dns.ClassINET || !dns.IsSubDomain(zone, q.Name) {
+		m.Authoritative = false
+		m.SetRcode(r, dns.RcodeRefused)
+		return m
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	name := q.Name
+	for hops := 0; hops < 8; hops++ {
+		rrs, exists := s.lookup(name)
+		if !exists {
+			if len(m.Answer) == 0 {
+				m.Rcode = dns.RcodeNameError
+			}
+			break
+		}
+		var answered bool
+		var cname *dns.CNAME
+		for _, rr := range rrs {
+			if rr.Header().Rrtype == q.Qtype || q.Qtype == dns.TypeANY {
+				m.Answer = append(m.Answer, rr)
+				answered = true
+			} else if c, ok := rr.(*dns.CNAME); ok {
+				cname = c
+			}
+		}
+		if answered || cname == nil {
+			break
+		}
+		m.Answer = append(m.Answer, cname)
+		if !dns.IsSubDomain(zone, cname.Target) {
+			break
+		}
+		name = cname.Target
+	}
+
+	if len(m.Answer) == 0 || m.Rcode == dns.RcodeNameError {
+		if soa := s.soa(); soa != nil {
+			m.Ns = append(m.Ns, soa)
+		}
+	}
+	return m
+}
+
+// lookup returns the records at the name, reporting whether the name exists. If it does not, the records of the wildcard
+// at the closest existing ancestor are returned with the name. The caller must hold the mutex.
+func (s *Server) lookup(name string) ([]dns.RR, bool) {
+	if rrs := s.recordsAt(name); len(rrs) > 0 {
+		return rrs, true
+	}
+	if s.hasDescendants(name) {
+		return nil, true
+	}
+	zone := dns.Fqdn(s.Zone)
+	for ancestor := parentName(name); dns.IsSubDomain(zone, ancestor); ancestor = parentName(ancestor) {
+		if wildcards := s.recordsAt("*." + ancestor); len(wildcards) > 0 {
+			var rrs []dns.RR
+			for _, wildcard := range wildcards {
+				rr := dns.Copy(wildcard)
+				rr.Header().Name = name
+				rrs = append(rrs, rr)
+			}
+			return rrs, true
+		}
+		if s.hasDescendants(ancestor) {
+			// The closest encloser exists without a wildcard
+			break
+		}
+	}
+	return nil, false
+}
+
+// recordsAt returns the records with the name. The caller must hold the mutex.
+func (s *Server) recordsAt(name string) []dns.RR {
+	var rrs []dns.RR
+	for _, rr := range s.records {
+		if strings.EqualFold(rr.Header().Name, name) {
+			rrs = append(rrs, rr)
+		}
+	}
+	return rrs
+}
+
+// hasDescendants reports whether any record is at or below the name, which then exists, if only as an empty non-terminal.
+// The caller must hold the mutex.
+func (s *Server) hasDescendants(name string) bool {
+	for _, rr := range s.records {
+		if dns.IsSubDomain(name, rr.Header().Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// soa returns the SOA record of the zone, if any. The caller must hold the mutex.
+func (s *Server) soa() dns.RR {
+	for _, rr := range s.records {
+		if rr.Header().Rrtype == dns.TypeSOA {
+			return rr
+		}
+	}
+	return nil
+}
+
+// lastRefreshed returns the time the records were read last.
+func (s *Server) lastRefreshed() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.refreshed
+}
+
+func (s *Server) getLogger() *slog.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return slog.Default()
+}
+
+// parentName returns the name without its first label, e.g. "example.com." for "www.example.com.".
+func parentName(name string) string {
+	if i, end := dns.NextLabel(name, 0); !end {
+		return name[i:]
+	}
+	return "."
+}
@@ -0,0 +1,161 @@
+package dnsserver
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+type fakeRecordGetter struct {
+	records []libdns.Record
+	mutex   sync.Mutex
+}
+
+func (g *fakeRecordGetter) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.records, nil
+}
+
+func (g *fakeRecordGetter) setRecords(records []libdns.Record) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.records = records
+}
+
+func getFakeRecords() []libdns.Record {
+	return []libdns.Record{
+		libdns.RR{Name: "@", TTL: time.Hour, Type: "SOA", Data: "ns1-01.azure-dns.com. azuredns-hostmaster.microsoft.com. 1 3600 300 2419200 300"},
+		libdns.Address{Name: "@", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.Address{Name: "www", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.2")},
+		libdns.Address{Name: "www", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.3")},
+		libdns.CNAME{Name: "alias", TTL: 5 * time.Minute, Target: "www.example.com."},
+		libdns.TXT{Name: "txt", TTL: 5 * time.Minute, Text: "hello world"},
+		libdns.Address{Name: "*.wild", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.4")},
+		libdns.Address{Name: "host.empty", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.5")},
+	}
+}
+
+// startServer serves the records of the fake getter on local ports, returning the address to query.
+func startServer(t *testing.T, getter *fakeRecordGetter, refreshInterval time.Duration) string {
+	t.Helper()
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	listener, err := net.Listen("tcp", packetConn.LocalAddr().String())
+	if err != nil {
+		packetConn.Close()
+		t.Skipf("the TCP port of the UDP port cannot be listened on: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	server := &Server{Provider: getter, Zone: "example.com.", RefreshInterval: refreshInterval}
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(ctx, packetConn, listener)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("%s", err)
+		}
+	})
+	return packetConn.LocalAddr().String()
+}
+
+func query(t *testing.T, network string, addr string, name string, qtype uint16) *dns.Msg {
+	t.Helper()
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+	client := &dns.Client{Net: network, Timeout: 2 * time.Second}
+	var resp *dns.Msg
+	var err error
+	// The servers are started in the background
+	for i := 0; i < 20; i++ {
+		if resp, _, err = client.Exchange(m, addr); err == nil {
+			return resp
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("%s", err)
+	return nil
+}
+
+func answerData(rrs []dns.RR) []string {
+	var data []string
+	for _, rr := range rrs {
+		data = append(data, rr.String())
+	}
+	sort.Strings(data)
+	return data
+}
+
+func Test_Server(t *testing.T) {
+	getter := &fakeRecordGetter{records: getFakeRecords()}
+	addr := startServer(t, getter, time.Hour)
+
+	tests := []struct {
+		name      string
+		qname     string
+		qtype     uint16
+		wantRcode int
+		want      []string
+		wantSOA   bool
+	}{
+		{name: "A", qname: "www.example.com.", qtype: dns.TypeA, want: []string{"www.example.com.\t300\tIN\tA\t192.0.2.2", "www.example.com.\t300\tIN\tA\t192.0.2.3"}},
+		{name: "A,case", qname: "WWW.Example.com.", qtype: dns.TypeA, want: []string{"www.example.com.\t300\tIN\tA\t192.0.2.2", "www.example.com.\t300\tIN\tA\t192.0.2.3"}},
+		{name: "A,apex", qname: "example.com.", qtype: dns.TypeA, want: []string{"example.com.\t300\tIN\tA\t192.0.2.1"}},
+		{name: "TXT", qname: "txt.example.com.", qtype: dns.TypeTXT, want: []string{"txt.example.com.\t300\tIN\tTXT\t\"hello world\""}},
+		{name: "CNAME", qname: "alias.example.com.", qtype: dns.TypeA, want: []string{"alias.example.com.\t300\tIN\tCNAME\twww.example.com.", "www.example.com.\t300\tIN\tA\t192.0.2.2", "www.example.com.\t300\tIN\tA\t192.0.2.3"}},
+		{name: "wildcard", qname: "any.wild.example.com.", qtype: dns.TypeA, want: []string{"any.wild.example.com.\t300\tIN\tA\t192.0.2.4"}},
+		{name: "NODATA", qname: "www.example.com.", qtype: dns.TypeAAAA, wantSOA: true},
+		{name: "NODATA,empty non-terminal", qname: "empty.example.com.", qtype: dns.TypeA, wantSOA: true},
+		{name: "NXDOMAIN", qname: "missing.example.com.", qtype: dns.TypeA, wantRcode: dns.RcodeNameError, wantSOA: true},
+		{name: "REFUSED", qname: "www.example.net.", qtype: dns.TypeA, wantRcode: dns.RcodeRefused},
+	}
+	for _, network := range []string{"udp", "tcp"} {
+		for _, tt := range tests {
+			t.Run(network+","+tt.name, func(t *testing.T) {
+				resp := query(t, network, addr, tt.qname, tt.qtype)
+				if resp.Rcode != tt.wantRcode {
+					t.Errorf("got rcode: %v", dns.RcodeToString[resp.Rcode])
+				}
+				if diff := cmp.Diff(answerData(resp.Answer), tt.want); diff != "" {
+					t.Errorf("diff: %s", diff)
+				}
+				if gotSOA := len(resp.Ns) == 1 && resp.Ns[0].Header().Rrtype == dns.TypeSOA; gotSOA != tt.wantSOA {
+					t.Errorf("got authority: %v", resp.Ns)
+				}
+			})
+		}
+	}
+}
+
+func Test_Server_refresh(t *testing.T) {
+	getter := &fakeRecordGetter{records: getFakeRecords()}
+	addr := startServer(t, getter, 50*time.Millisecond)
+
+	getter.setRecords([]libdns.Record{
+		libdns.Address{Name: "www", TTL: 5 * time.Minute, IP: netip.MustParseAddr("192.0.2.9")},
+	})
+	want := []string{"www.example.com.\t300\tIN\tA\t192.0.2.9"}
+	var got []string
+	for i := 0; i < 40; i++ {
+		got = answerData(query(t, "udp", addr, "www.example.com.", dns.TypeA).Answer)
+		if cmp.Equal(got, want) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Errorf("the records were not refreshed: %v", got)
+}
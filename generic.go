@@ -0,0 +1,214 @@
+package azure
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// typeNumbers are the numbers of the record types that Azure DNS supports, for the generic type names of RFC 3597, e.g. "TYPE16".
+var typeNumbers = map[string]uint16{
+	"A":     1,
+	"NS":    2,
+	"CNAME": 5,
+	"SOA":   6,
+	"PTR":   12,
+	"MX":    15,
+	"TXT":   16,
+	"AAAA":  28,
+	"SRV":   33,
+	"CAA":   257,
+}
+
+// decodeGenericRecords converts the records given as opaque RRs in the generic representation of RFC 3597,
+// i.e. with the type "TYPE" followed by its number, or with the data "\# length hex", to the records of the type
+// that Azure DNS supports, so that records preserved in the generic representation, e.g. by a tool that does not know
+// their type, are written and compared in the same manner as the same records given as they are.
+// The other records are returned as they are.
+func decodeGenericRecords(records []libdns.Record) ([]libdns.Record, error) {
+	decodedRecords := make([]libdns.Record, 0, len(records))
+	for _, record := range records {
+		decodedRecord, err := decodeGenericRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		decodedRecords = append(decodedRecords, decodedRecord)
+	}
+	return decodedRecords, nil
+}
+
+// decodeGenericRecord converts a record given as an opaque RR in the generic representation of RFC 3597 to the record
+// of the type that Azure DNS supports. It throws an error if the data cannot be decoded as the type.
+func decodeGenericRecord(record libdns.Record) (libdns.Record, error) {
+	rr, ok := record.(libdns.RR)
+	if !ok {
+		return record, nil
+	}
+	rr.Type = genericTypeName(rr.Type)
+	data := strings.TrimSpace(rr.Data)
+	if data != `\#` && !strings.HasPrefix(data, `\# `) {
+		return rr, nil
+	}
+
+	rdata, err := parseGenericData(data)
+	if err != nil {
+		return nil, fmt.Errorf("the value %v of the record %v %v cannot be interpreted: %w", rr.Data, rr.Name, rr.Type, err)
+	}
+	decodedRecord, err := decodeRData(rr, rdata)
+	if err != nil {
+		return nil, fmt.Errorf("the value %v of the record %v %v cannot be interpreted: %w", rr.Data, rr.Name, rr.Type, err)
+	}
+	return decodedRecord, nil
+}
+
+// genericTypeName returns the mnemonic of the record type given as "TYPE" followed by its number, e.g. "TXT" for "TYPE16".
+// Other type names, and the numbers of the types that Azure DNS does not support, are returned as they are.
+func genericTypeName(typeName string) string {
+	if len(typeName) <= 4 || !strings.EqualFold(typeName[:4], "TYPE") {
+		return typeName
+	}
+	number, err := strconv.ParseUint(typeName[4:], 10, 16)
+	if err != nil {
+		return typeName
+	}
+	for name, n := range typeNumbers {
+		if uint64(n) == number {
+			return name
+		}
+	}
+	return typeName
+}
+
+// parseGenericData parses the data in the generic representation of RFC 3597, i.e. "\#", the length of the data in bytes,
+// and the data in hexadecimal, which may be split by whitespace.
+func parseGenericData(data string) ([]byte, error) {
+	fields := strings.Fields(data)
+	if len(fields) < 2 || fields[0] != `\#` {
+		return nil, fmt.Errorf("the generic data must start with \\# and the length")
+	}
+	length, err := strconv.Atoi(fields[1])
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("the length %v is not a number of bytes", fields[1])
+	}
+	rdata, err := hex.DecodeString(strings.Join(fields[2:], ""))
+	if err != nil {
+		return nil, err
+	}
+	if len(rdata) != length {
+		return nil, fmt.Errorf("the length %d does not match the %d bytes of the data", length, len(rdata))
+	}
+	return rdata, nil
+}
+
+// decodeRData decodes the RDATA in the wire format of the type of the RR.
+func decodeRData(rr libdns.RR, rdata []byte) (libdns.Record, error) {
+	switch rr.Type {
+	case "A", "AAAA":
+		ip, ok := netip.AddrFromSlice(rdata)
+		if !ok || (rr.Type == "A") != ip.Is4() {
+			return nil, fmt.Errorf("the %d bytes are not an address of the type", len(rdata))
+		}
+		return libdns.Address{Name: rr.Name, TTL: rr.TTL, IP: ip}, nil
+	case "CNAME", "NS", "PTR":
+		target, rest, err := decodeName(rdata)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) > 0 {
+			return nil, fmt.Errorf("the data has %d extra bytes", len(rest))
+		}
+		return newLibdnsRecord(rr.Name, rr.TTL, rr.Type, target), nil
+	case "MX":
+		if len(rdata) < 2 {
+			return nil, fmt.Errorf("the data is too short")
+		}
+		target, rest, err := decodeName(rdata[2:])
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) > 0 {
+			return nil, fmt.Errorf("the data has %d extra bytes", len(rest))
+		}
+		return libdns.MX{Name: rr.Name, TTL: rr.TTL, Preference: binary.BigEndian.Uint16(rdata), Target: target}, nil
+	case "SRV":
+		if len(rdata) < 6 {
+			return nil, fmt.Errorf("the data is too short")
+		}
+		target, rest, err := decodeName(rdata[6:])
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) > 0 {
+			return nil, fmt.Errorf("the data has %d extra bytes", len(rest))
+		}
+		data := fmt.Sprintf("%d %d %d %s", binary.BigEndian.Uint16(rdata), binary.BigEndian.Uint16(rdata[2:]), binary.BigEndian.Uint16(rdata[4:]), target)
+		return newLibdnsRecord(rr.Name, rr.TTL, rr.Type, data), nil
+	case "TXT":
+		// The character strings of a TXT record are concatenated into a single text in libdns
+		var text strings.Builder
+		for len(rdata) > 0 {
+			n := int(rdata[0])
+			if len(rdata) < 1+n {
+				return nil, fmt.Errorf("the character string is truncated")
+			}
+			text.Write(rdata[1 : 1+n])
+			rdata = rdata[1+n:]
+		}
+		return libdns.TXT{Name: rr.Name, TTL: rr.TTL, Text: text.String()}, nil
+	case "CAA":
+		if len(rdata) < 2 || len(rdata) < 2+int(rdata[1]) {
+			return nil, fmt.Errorf("the data is too short")
+		}
+		return libdns.CAA{Name: rr.Name, TTL: rr.TTL, Flags: rdata[0], Tag: string(rdata[2 : 2+int(rdata[1])]), Value: string(rdata[2+int(rdata[1]):])}, nil
+	case "SOA":
+		host, rest, err := decodeName(rdata)
+		if err != nil {
+			return nil, err
+		}
+		email, rest, err := decodeName(rest)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) != 20 {
+			return nil, fmt.Errorf("the data has %d bytes of the timers instead of 20", len(rest))
+		}
+		data := fmt.Sprintf("%s %s %d %d %d %d %d", host, email, binary.BigEndian.Uint32(rest), binary.BigEndian.Uint32(rest[4:]),
+			binary.BigEndian.Uint32(rest[8:]), binary.BigEndian.Uint32(rest[12:]), binary.BigEndian.Uint32(rest[16:]))
+		return newLibdnsRecord(rr.Name, rr.TTL, rr.Type, data), nil
+	default:
+		return nil, fmt.Errorf("the type %v is not supported by Azure DNS", rr.Type)
+	}
+}
+
+// decodeName decodes an uncompressed domain name in the wire format, returning it fully qualified and the bytes after it.
+// Compressed names are rejected, since the generic representation carries the data of a single record.
+func decodeName(rdata []byte) (string, []byte, error) {
+	var labels []string
+	for {
+		if len(rdata) == 0 {
+			return "", nil, fmt.Errorf("the domain name is truncated")
+		}
+		n := int(rdata[0])
+		if n == 0 {
+			break
+		}
+		if n > maxLabelLength {
+			return "", nil, fmt.Errorf("the domain name is compressed or has a label too long")
+		}
+		if len(rdata) < 1+n {
+			return "", nil, fmt.Errorf("the domain name is truncated")
+		}
+		label := string(rdata[1 : 1+n])
+		if strings.ContainsAny(label, ". ") {
+			return "", nil, fmt.Errorf("the label %q cannot be represented", label)
+		}
+		labels = append(labels, label)
+		rdata = rdata[1+n:]
+	}
+	return strings.Join(labels, ".") + ".", rdata[1:], nil
+}
@@ -0,0 +1,64 @@
+package azure
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_decodeGenericRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  libdns.Record
+		want    libdns.Record
+		wantErr string
+	}{
+		{name: "type=A", record: libdns.RR{Name: "www", Type: "A", Data: `\# 4 C0000201`}, want: libdns.Address{Name: "www", IP: netip.MustParseAddr("192.0.2.1")}},
+		{name: "type=TYPE28", record: libdns.RR{Name: "www", Type: "TYPE28", Data: `\# 16 20010db8 00000000 00000000 00000001`}, want: libdns.Address{Name: "www", IP: netip.MustParseAddr("2001:db8::1")}},
+		{name: "type=CNAME", record: libdns.RR{Name: "alias", Type: "CNAME", Data: `\# 17 03777777076578616d706c6503636f6d00`}, want: libdns.CNAME{Name: "alias", Target: "www.example.com."}},
+		{name: "type=MX", record: libdns.RR{Name: "@", Type: "MX", Data: `\# 20 000a 046d61696c076578616d706c6503636f6d00`}, want: libdns.MX{Name: "@", Preference: 10, Target: "mail.example.com."}},
+		{name: "type=TXT", record: libdns.RR{Name: "@", Type: "TYPE16", Data: `\# 12 0568656c6c6f 05776f726c64`}, want: libdns.TXT{Name: "@", Text: "helloworld"}},
+		{name: "type=CAA", record: libdns.RR{Name: "@", Type: "TYPE257", Data: `\# 22 00056973737565 6c657473656e63727970742e6f7267`}, want: libdns.CAA{Name: "@", Tag: "issue", Value: "letsencrypt.org"}},
+		{name: "type=PTR", record: libdns.RR{Name: "1", Type: "PTR", Data: `\# 17 03777777076578616d706c6503636f6d00`}, want: libdns.RR{Name: "1", Type: "PTR", Data: "www.example.com."}},
+		{name: "type=TYPE16,presentation", record: libdns.RR{Name: "@", Type: "TYPE16", Data: "TEST VALUE"}, want: libdns.RR{Name: "@", Type: "TXT", Data: "TEST VALUE"}},
+		{name: "type=TXT,struct", record: libdns.TXT{Name: "@", Text: `\# 1 00`}, want: libdns.TXT{Name: "@", Text: `\# 1 00`}},
+		{name: "length=mismatch", record: libdns.RR{Name: "www", Type: "A", Data: `\# 5 C0000201`}, wantErr: "does not match"},
+		{name: "address=short", record: libdns.RR{Name: "www", Type: "A", Data: `\# 3 C00002`}, wantErr: "not an address"},
+		{name: "name=compressed", record: libdns.RR{Name: "alias", Type: "CNAME", Data: `\# 2 c00c`}, wantErr: "compressed"},
+		{name: "type=unsupported", record: libdns.RR{Name: "www", Type: "HTTPS", Data: `\# 3 000100`}, wantErr: "not supported by Azure DNS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeGenericRecord(tt.record)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got: %v, want: %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got, tt.want, recordComparer); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_createRecords_generic(t *testing.T) {
+	provider := getFakeProvider()
+	records, err := provider.createRecords(context.TODO(), "example.com.", []libdns.Record{
+		libdns.RR{Name: "record-a", Type: "TYPE1", Data: `\# 4 7f000001`},
+	})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if diff := cmp.Diff(records, []libdns.Record{libdns.Address{Name: "record-a", IP: netip.MustParseAddr("127.0.0.1")}}, recordComparer); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
@@ -0,0 +1,73 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+)
+
+// PlannedWrite is a write to a record set that the provider is about to send to Azure DNS, passed to Before Write.
+type PlannedWrite struct {
+	// Operation is the write operation that plans the write. A deletion of values may update the record set
+	// with the remaining values rather than deleting it.
+	Operation WriteOperation
+
+	// Zone is the name of the zone.
+	Zone string
+
+	// Name is the name of the record set relative to the zone, e.g. "_acme-challenge", or "@" for the apex.
+	Name string
+
+	// Type is the type of the record set, e.g. "TXT".
+	Type string
+
+	// Before is the record set as it was read before the write. It is nil if the record set does not exist,
+	// or if it is deleted without being read. Changes to it have no effect.
+	Before *armdns.RecordSet
+
+	// After is the record set to be written, which may be modified to change what is written, e.g. its TTL or metadata.
+	// It is nil if the record set is to be deleted.
+	After *armdns.RecordSet
+}
+
+// beforeWrite is called just before each write to a record set is sent to Azure DNS.
+// It calls Before Write, if any, with the planned write, throwing an error if the write is vetoed,
+// and then counts the write against Mutation Budget. The after record set may be modified by Before Write.
+func (p *Provider) beforeWrite(ctx context.Context, operation WriteOperation, zone string, name string, typeName string, before *armdns.RecordSet, after *armdns.RecordSet) error {
+	if p.BeforeWrite != nil {
+		write := &PlannedWrite{
+			Operation: operation,
+			Zone:      zone,
+			Name:      p.recordSetName(name, zone),
+			Type:      typeName,
+			Before:    before,
+			After:     after,
+		}
+		p.client.hookMutex.Lock()
+		err := p.BeforeWrite(ctx, write)
+		p.client.hookMutex.Unlock()
+		if err != nil {
+			return fmt.Errorf("the write to the record set %v %v is vetoed: %w", write.Name, write.Type, err)
+		}
+	}
+	return p.spendMutationBudget(zone)
+}
+
+// snapshotRecordSet copies the record set to be passed to Before Write as it was before being modified.
+// It returns nil if Before Write is not set, since the copy is not needed then.
+func (p *Provider) snapshotRecordSet(recordSet *armdns.RecordSet) *armdns.RecordSet {
+	if p.BeforeWrite == nil || recordSet == nil {
+		return nil
+	}
+	data, err := json.Marshal(recordSet)
+	if err != nil {
+		return nil
+	}
+	var snapshot armdns.RecordSet
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil
+	}
+	return &snapshot
+}
@@ -0,0 +1,156 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_beforeWrite(t *testing.T) {
+	errVetoed := errors.New("vetoed")
+	tests := []struct {
+		name       string
+		operate    func(p *Provider) error
+		wantWrites []string
+		wantCalls  []string
+	}{
+		{
+			name: "operation=append,recordset=new",
+			operate: func(p *Provider) error {
+				_, err := p.AppendRecords(context.TODO(), "example.com.", []libdns.Record{
+					libdns.TXT{Name: "record-new", Text: "NEW VALUE", TTL: time.Minute},
+				})
+				return err
+			},
+			wantWrites: []string{"append record-new TXT before=<nil> after=[NEW VALUE]"},
+			wantCalls:  []string{"get", "write"},
+		},
+		{
+			name: "operation=append,recordset=existing",
+			operate: func(p *Provider) error {
+				_, err := p.AppendRecords(context.TODO(), "example.com.", []libdns.Record{
+					libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Minute},
+				})
+				return err
+			},
+			wantWrites: []string{"append record-txt TXT before=[TEST VALUE] after=[TEST VALUE NEW VALUE]"},
+			wantCalls:  []string{"get", "write"},
+		},
+		{
+			name: "operation=set,recordset=existing",
+			operate: func(p *Provider) error {
+				_, err := p.SetRecords(context.TODO(), "example.com.", []libdns.Record{
+					libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Minute},
+				})
+				return err
+			},
+			wantWrites: []string{"set record-txt TXT before=[TEST VALUE] after=[NEW VALUE]"},
+			wantCalls:  []string{"get", "write"},
+		},
+		{
+			name: "operation=delete,recordset=read",
+			operate: func(p *Provider) error {
+				_, err := p.DeleteRecords(context.TODO(), "example.com.", []libdns.Record{
+					libdns.TXT{Name: "record-txt", Text: "TEST VALUE"},
+				})
+				return err
+			},
+			wantWrites: []string{"delete record-txt TXT before=[TEST VALUE] after=<nil>"},
+			wantCalls:  []string{"get", "delete"},
+		},
+		{
+			name: "operation=delete,recordset=unread",
+			operate: func(p *Provider) error {
+				_, err := p.DeleteRecords(context.TODO(), "example.com.", []libdns.Record{
+					libdns.TXT{Name: "record-txt"},
+				})
+				return err
+			},
+			wantWrites: []string{"delete record-txt TXT before=<nil> after=<nil>"},
+			wantCalls:  []string{"delete"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := newFakeZone(getOwnedTXTRecordSet(""))
+			var writes []string
+			provider := getFakeProviderWithZone(zone)
+			provider.BeforeWrite = func(ctx context.Context, write *PlannedWrite) error {
+				writes = append(writes, string(write.Operation)+" "+write.Name+" "+write.Type+" before="+formatTxtValues(write.Before)+" after="+formatTxtValues(write.After))
+				return nil
+			}
+			if err := tt.operate(provider); err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(writes, tt.wantWrites); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+			if diff := cmp.Diff(zone.calls, tt.wantCalls); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+		t.Run(tt.name+",vetoed", func(t *testing.T) {
+			zone := newFakeZone(getOwnedTXTRecordSet(""))
+			provider := getFakeProviderWithZone(zone)
+			provider.BeforeWrite = func(ctx context.Context, write *PlannedWrite) error {
+				return errVetoed
+			}
+			if err := tt.operate(provider); !errors.Is(err, errVetoed) {
+				t.Errorf("got: %v, want: %v", err, errVetoed)
+			}
+			for _, call := range zone.calls {
+				if call != "get" {
+					t.Errorf("got: %v, want: no writes", zone.calls)
+					break
+				}
+			}
+		})
+	}
+	t.Run("mutated", func(t *testing.T) {
+		zone := newFakeZone(getOwnedTXTRecordSet(""))
+		provider := getFakeProviderWithZone(zone)
+		provider.BeforeWrite = func(ctx context.Context, write *PlannedWrite) error {
+			write.After.Properties.TTL = to.Ptr[int64](3600)
+			write.After.Properties.Metadata = map[string]*string{"approved": to.Ptr("true")}
+			return nil
+		}
+		if _, err := provider.SetRecords(context.TODO(), "example.com.", []libdns.Record{
+			libdns.TXT{Name: "record-new", Text: "NEW VALUE", TTL: time.Minute},
+		}); err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := []armdns.RecordSet{{
+			Properties: &armdns.RecordSetProperties{
+				TTL:        to.Ptr[int64](3600),
+				Metadata:   map[string]*string{"approved": to.Ptr("true")},
+				TxtRecords: []*armdns.TxtRecord{{Value: []*string{to.Ptr("NEW VALUE")}}},
+			},
+		}}
+		if diff := cmp.Diff(zone.written, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
+
+// formatTxtValues formats the values of the TXT record set for comparison.
+func formatTxtValues(recordSet *armdns.RecordSet) string {
+	if recordSet == nil {
+		return "<nil>"
+	}
+	var values []string
+	if recordSet.Properties != nil {
+		for _, txtRecord := range recordSet.Properties.TxtRecords {
+			for _, value := range txtRecord.Value {
+				values = append(values, stringValue(value))
+			}
+		}
+	}
+	return fmt.Sprint(values)
+}
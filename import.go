@@ -0,0 +1,188 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/libdns/libdns"
+)
+
+// ImportOptions are options to import records from another provider.
+type ImportOptions struct {
+	// Source Zone is the name of the zone on the source provider. Defaults to the name of the zone imported to.
+	SourceZone string
+
+	// Dry Run makes the import report what would be changed without writing anything to Azure DNS.
+	DryRun bool
+}
+
+// ImportReport is the report of an import, listing the records by how they are handled.
+type ImportReport struct {
+	// Created are the records in the record sets that do not exist on Azure DNS.
+	Created []libdns.Record
+
+	// Updated are the records in the record sets that exist on Azure DNS with other values or TTLs, and are replaced.
+	Updated []libdns.Record
+
+	// Unchanged are the records in the record sets that exist on Azure DNS as they are.
+	Unchanged []libdns.Record
+
+	// Skipped are the records that are not imported, such as the records specific to the source zone and invalid records.
+	Skipped []ImportIssue
+
+	// Failed are the records in the record sets that failed to be written to Azure DNS.
+	Failed []ImportIssue
+}
+
+// ImportIssue is a group of records that are not imported, with the reason.
+type ImportIssue struct {
+	// Records are the records sharing the same name and type.
+	Records []libdns.Record
+
+	// Err is the reason why the records are not imported.
+	Err error
+}
+
+// ImportFrom imports all the records in the zone on the source provider into the zone on Azure DNS.
+// It is a shorthand for ImportFromWithOptions with the default options.
+func (p *Provider) ImportFrom(ctx context.Context, src libdns.RecordGetter, zone string) (ImportReport, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.ImportFrom(ctx, src, zone)
+	}
+
+	return p.ImportFromWithOptions(ctx, src, zone, ImportOptions{})
+}
+
+// ImportFromWithOptions imports all the records in the zone on the source provider into the zone on Azure DNS.
+// Records are grouped into record sets and validated before anything is written, and each record set that differs
+// from the one on Azure DNS is written with SetRecords. The SOA record and the NS records at the apex are skipped,
+// since they are specific to each zone. Record sets on Azure DNS that are not in the source zone are left as they are.
+// Invalid records and record sets that fail to be written do not stop the import, but are listed in the report.
+func (p *Provider) ImportFromWithOptions(ctx context.Context, src libdns.RecordGetter, zone string, options ImportOptions) (ImportReport, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.ImportFromWithOptions(ctx, src, zone, options)
+	}
+
+	sourceZone := options.SourceZone
+	if sourceZone == "" {
+		sourceZone = zone
+	}
+
+	sourceRecords, err := src.GetRecords(ctx, sourceZone)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("failed to get the records from the source: %w", err)
+	}
+	existingRecords, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	existingRecordSets := map[string][]libdns.Record{}
+	// The existing records are named as the record sets on Azure DNS, which are not to be renamed again
+	for _, recordGroup := range groupRecordsByRecordSet(existingRecords, zone, generateRecordSetName) {
+		existingRecordSets[recordSetKey(recordGroup[0], zone)] = recordGroup
+	}
+
+	var report ImportReport
+	var changedRecordGroups [][]libdns.Record
+	for _, recordGroup := range groupRecordsByRecordSet(sourceRecords, sourceZone, generateRecordSetName) {
+		for i := range recordGroup {
+			recordGroup[i] = normalizeRecord(recordGroup[i], sourceZone)
+		}
+		name, typeName := recordGroup[0].RR().Name, recordGroup[0].RR().Type
+
+		if typeName == "SOA" || (typeName == "NS" && name == "@") {
+			report.Skipped = append(report.Skipped, ImportIssue{
+				Records: recordGroup,
+				Err:     errors.New("the records are specific to the source zone"),
+			})
+			continue
+		}
+
+		recordSetName := p.recordSetName(name, zone)
+		normalizedRecords, err := p.normalizeRecordSet(recordGroup, recordSetName)
+		if err != nil {
+			report.Skipped = append(report.Skipped, ImportIssue{
+				Records: recordGroup,
+				Err:     err,
+			})
+			continue
+		}
+
+		existing, ok := existingRecordSets[recordSetName+"/"+typeName]
+		switch {
+		case !ok:
+			report.Created = append(report.Created, recordGroup...)
+		case equalRecordSets(normalizedRecords, existing):
+			report.Unchanged = append(report.Unchanged, recordGroup...)
+			continue
+		default:
+			report.Updated = append(report.Updated, recordGroup...)
+		}
+		changedRecordGroups = append(changedRecordGroups, recordGroup)
+	}
+
+	if options.DryRun {
+		return report, nil
+	}
+
+	ctx = p.withProgress(ctx, len(changedRecordGroups))
+	for _, recordGroup := range changedRecordGroups {
+		if _, err := p.SetRecords(ctx, zone, recordGroup); err != nil {
+			report.Failed = append(report.Failed, ImportIssue{
+				Records: recordGroup,
+				Err:     err,
+			})
+		}
+	}
+	if len(report.Failed) > 0 {
+		return report, fmt.Errorf("failed to import %v of %v record sets", len(report.Failed), len(changedRecordGroups))
+	}
+
+	return report, nil
+}
+
+// normalizeRecordSet validates the records sharing the same name and type, and returns them as they would be stored on Azure DNS.
+func (p *Provider) normalizeRecordSet(records []libdns.Record, name string) ([]libdns.Record, error) {
+	typeName := records[0].RR().Type
+	if _, err := convertStringToRecordType(typeName); err != nil {
+		return nil, err
+	}
+	recordSet, err := convertLibdnsRecordsToAzureRecordSet(records, p.TTLConflictPolicy)
+	if err != nil {
+		return nil, err
+	}
+	recordSet.Name = to.Ptr(name)
+	recordSet.Type = to.Ptr("Microsoft.Network/dnszones/" + typeName)
+	recordSet.Etag = to.Ptr("")
+	return convertAzureRecordSetsToLibdnsRecords([]*armdns.RecordSet{&recordSet})
+}
+
+// recordSetKey returns the key identifying the record set that the record belongs to.
+func recordSetKey(record libdns.Record, zone string) string {
+	rr := record.RR()
+	return generateRecordSetName(rr.Name, zone) + "/" + rr.Type
+}
+
+// equalRecordSets reports whether the records sharing the same name and type have the same values and TTL regardless of the order.
+func equalRecordSets(a []libdns.Record, b []libdns.Record) bool {
+	if len(a) != len(b) || a[0].RR().TTL != b[0].RR().TTL {
+		return false
+	}
+	values := func(records []libdns.Record) string {
+		var values []string
+		for _, record := range records {
+			values = append(values, recordData(record))
+		}
+		sort.Strings(values)
+		return strings.Join(values, "\n")
+	}
+	return values(a) == values(b)
+}
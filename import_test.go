@@ -0,0 +1,73 @@
+package azure
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+// fakeGetter is a source provider returning the fixed records.
+type fakeGetter []libdns.Record
+
+func (f fakeGetter) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	return f, nil
+}
+
+var importFakeRecords = fakeGetter{
+	libdns.RR{Type: "A", Name: "record-a.example.com.", Data: "127.0.0.1", TTL: 30 * time.Second},
+	libdns.Address{Name: "record-aaaa", IP: netip.MustParseAddr("::2"), TTL: 30 * time.Second},
+	libdns.TXT{Name: "www", Text: "TEST VALUE", TTL: time.Hour},
+	libdns.RR{Type: "SOA", Name: "@", Data: "ns1.example.net. hostmaster.example.net. 1 3600 600 86400 300", TTL: time.Hour},
+	libdns.RR{Type: "HTTPS", Name: "www", Data: "1 . alpn=h2", TTL: time.Hour},
+}
+
+func Test_ImportFrom(t *testing.T) {
+	t.Run("dry run", func(t *testing.T) {
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		fakeRecordSetsServer.CreateOrUpdate = nil
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		report, err := provider.ImportFromWithOptions(context.TODO(), importFakeRecords, "example.com.", ImportOptions{DryRun: true})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := ImportReport{
+			Created:   []libdns.Record{libdns.TXT{Name: "www", Text: "TEST VALUE", TTL: time.Hour}},
+			Updated:   []libdns.Record{libdns.Address{Name: "record-aaaa", IP: netip.MustParseAddr("::2"), TTL: 30 * time.Second}},
+			Unchanged: []libdns.Record{libdns.Address{Name: "record-a", IP: netip.MustParseAddr("127.0.0.1"), TTL: 30 * time.Second}},
+		}
+		if diff := cmp.Diff(report, want, recordComparer, cmp.FilterPath(func(p cmp.Path) bool {
+			return p.Last().String() == ".Skipped"
+		}, cmp.Ignore())); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if len(report.Skipped) != 2 || report.Skipped[0].Records[0].RR().Type != "SOA" || report.Skipped[1].Records[0].RR().Type != "HTTPS" {
+			t.Errorf("got: %v", report.Skipped)
+		}
+	})
+	t.Run("import", func(t *testing.T) {
+		var written []string
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+		fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			written = append(written, relativeRecordSetName+" "+string(recordType))
+			return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+		}
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		report, err := provider.ImportFrom(context.TODO(), importFakeRecords, "example.com.")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if diff := cmp.Diff(written, []string{"record-aaaa AAAA", "www TXT"}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if len(report.Failed) != 0 {
+			t.Errorf("got: %v", report.Failed)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package azure
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/libdns/libdns"
+)
+
+// SkippedRecordSet is a record set skipped by the listing of records with Skip Invalid Record Sets,
+// since it cannot be converted to libdns records.
+type SkippedRecordSet struct {
+	// Zone is the name of the zone listed.
+	Zone string
+
+	// Name is the name of the record set relative to the zone.
+	Name string
+
+	// Type is the type of the record set, e.g. "A".
+	Type string
+
+	// Err is the reason why the record set cannot be converted.
+	Err error
+}
+
+// convertAzureRecordSet converts a record set listed on Azure DNS to libdns records, throwing an error if the record set
+// has a type that cannot be interpreted, a missing value, or an address that cannot be parsed.
+func convertAzureRecordSet(recordSet *armdns.RecordSet) ([]libdns.Record, error) {
+	name := stringValue(recordSet.Name)
+	typeName := strings.TrimPrefix(stringValue(recordSet.Type), "Microsoft.Network/dnszones/")
+	if recordSet.Type == nil || recordSet.Properties == nil {
+		return nil, fmt.Errorf("the record set %v %v has no type or properties", name, typeName)
+	}
+
+	records, err := convertAzureRecordSetsToLibdnsRecords([]*armdns.RecordSet{recordSet})
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if rr, ok := record.(libdns.RR); ok && (rr.Type == "A" || rr.Type == "AAAA") {
+			return nil, fmt.Errorf("the value %v of the record %v %v cannot be interpreted", rr.Data, rr.Name, rr.Type)
+		}
+	}
+	return records, nil
+}
+
+// convertListedRecordSets converts the record sets listed in the zone to libdns records.
+// A record set that cannot be converted fails the listing, or is skipped and reported with Skip Invalid Record Sets.
+func (p *Provider) convertListedRecordSets(zone string, recordSets []*armdns.RecordSet) ([]libdns.Record, error) {
+	var records []libdns.Record
+	for _, recordSet := range recordSets {
+		recordSetRecords, _, err := p.convertListedRecordSet(zone, recordSet)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, recordSetRecords...)
+	}
+	return records, nil
+}
+
+// convertListedRecordSet converts a record set listed in the zone to libdns records, reporting false if it is skipped,
+// either since it is soft-deleted, or since it cannot be converted with Skip Invalid Record Sets.
+// Without Skip Invalid Record Sets, a record set without properties has no records, and an address that cannot be parsed
+// is converted to an opaque RR as it always has been, so that only the record sets that cannot be converted at all fail the listing.
+func (p *Provider) convertListedRecordSet(zone string, recordSet *armdns.RecordSet) ([]libdns.Record, bool, error) {
+	// A soft-deleted record set does not exist to the provider until it is purged
+	if p.isSoftDeleted(recordSet) {
+		return nil, false, nil
+	}
+	if !p.SkipInvalidRecordSets {
+		records, err := convertAzureRecordSetsToLibdnsRecords([]*armdns.RecordSet{recordSet})
+		if err != nil {
+			return nil, false, err
+		}
+		return records, true, nil
+	}
+	records, err := convertAzureRecordSet(recordSet)
+	if err != nil {
+		p.reportSkippedRecordSet(SkippedRecordSet{
+			Zone: zone,
+			Name: stringValue(recordSet.Name),
+			Type: strings.TrimPrefix(stringValue(recordSet.Type), "Microsoft.Network/dnszones/"),
+			Err:  err,
+		})
+		return nil, false, nil
+	}
+	return records, true, nil
+}
+
+// reportSkippedRecordSet reports a record set skipped by the listing to On Skipped Record Set, or logs it as a warning.
+func (p *Provider) reportSkippedRecordSet(skipped SkippedRecordSet) {
+	if p.OnSkippedRecordSet != nil {
+		p.OnSkippedRecordSet(skipped)
+		return
+	}
+	p.getLogger().Warn("skipped a record set that cannot be interpreted",
+		slog.String("zone", skipped.Zone),
+		slog.String("name", skipped.Name),
+		slog.String("type", skipped.Type),
+		slog.Any("error", skipped.Err),
+	)
+}
@@ -0,0 +1,99 @@
+package azure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func getFakeProviderWithInvalidRecordSets() *Provider {
+	return getFakeProviderWithZone(newFakeZone(
+		&armdns.RecordSet{
+			Name:       to.Ptr("record-a"),
+			Type:       to.Ptr("Microsoft.Network/dnszones/A"),
+			Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](30), ARecords: []*armdns.ARecord{{IPv4Address: to.Ptr("127.0.0.1")}}},
+		},
+		&armdns.RecordSet{
+			Name:       to.Ptr("record-ds"),
+			Type:       to.Ptr("Microsoft.Network/dnszones/DS"),
+			Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](30)},
+		},
+		&armdns.RecordSet{
+			Name:       to.Ptr("record-missing"),
+			Type:       to.Ptr("Microsoft.Network/dnszones/A"),
+			Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](30), ARecords: []*armdns.ARecord{{}}},
+		},
+		&armdns.RecordSet{
+			Name:       to.Ptr("record-invalid"),
+			Type:       to.Ptr("Microsoft.Network/dnszones/A"),
+			Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](30), ARecords: []*armdns.ARecord{{IPv4Address: to.Ptr("not-an-address")}}},
+		},
+		&armdns.RecordSet{
+			Name:       to.Ptr("record-txt"),
+			Type:       to.Ptr("Microsoft.Network/dnszones/TXT"),
+			Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](30), TxtRecords: []*armdns.TxtRecord{{Value: []*string{to.Ptr("TEST VALUE")}}}},
+		},
+	))
+}
+
+func Test_SkipInvalidRecordSets(t *testing.T) {
+	t.Run("skip=false", func(t *testing.T) {
+		provider := getFakeProviderWithInvalidRecordSets()
+		if _, err := provider.GetRecords(context.TODO(), "example.com."); err == nil {
+			t.Errorf("expected an error for the invalid record sets")
+		}
+	})
+	t.Run("skip=false,loose", func(t *testing.T) {
+		provider := getFakeProviderWithZone(newFakeZone(
+			&armdns.RecordSet{
+				Name: to.Ptr("record-empty"),
+				Type: to.Ptr("Microsoft.Network/dnszones/A"),
+			},
+			&armdns.RecordSet{
+				Name:       to.Ptr("record-invalid"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/A"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](30), ARecords: []*armdns.ARecord{{IPv4Address: to.Ptr("not-an-address")}}},
+			},
+		))
+		records, err := provider.GetRecords(context.TODO(), "example.com.")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := []libdns.Record{
+			libdns.RR{Name: "record-invalid", TTL: time.Duration(30) * time.Second, Type: "A", Data: "not-an-address"},
+		}
+		if diff := cmp.Diff(records, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("skip=true", func(t *testing.T) {
+		provider := getFakeProviderWithInvalidRecordSets()
+		provider.SkipInvalidRecordSets = true
+		var skipped []string
+		provider.OnSkippedRecordSet = func(skippedRecordSet SkippedRecordSet) {
+			if skippedRecordSet.Zone != "example.com." || skippedRecordSet.Err == nil {
+				t.Errorf("got: %+v", skippedRecordSet)
+			}
+			skipped = append(skipped, skippedRecordSet.Name+" "+skippedRecordSet.Type)
+		}
+		records, err := provider.GetRecords(context.TODO(), "example.com.")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		var names []string
+		for _, record := range records {
+			names = append(names, record.RR().Name)
+		}
+		if diff := cmp.Diff(names, []string{"record-a", "record-txt"}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if diff := cmp.Diff(skipped, []string{"record-ds DS", "record-missing A", "record-invalid A"}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package azure
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/libdns/libdns"
+)
+
+const (
+	// maxRecordsPerRecordSet is the maximum number of values in a record set of Azure DNS.
+	maxRecordsPerRecordSet = 20
+
+	// maxTXTValueLength is the maximum length of a value of a TXT record set, which is split into strings of 255 characters.
+	maxTXTValueLength = 1024
+
+	// maxTXTRecordSetLength is the maximum total length of the values of a TXT record set.
+	maxTXTRecordSetLength = 4096
+
+	// maxLabelLength is the maximum length of a label of a domain name.
+	maxLabelLength = 63
+
+	// maxNameLength is the maximum length of a domain name, including the zone, in its text form without the trailing dot.
+	maxNameLength = 253
+)
+
+// checkLimits throws an error if any of the records to be written violates a constraint of Azure DNS,
+// naming the violated limit, so that nothing is written when the records are rejected by Azure DNS anyway.
+// The values are counted within the records; appendRecordSet counts them again with the existing values.
+func (p *Provider) checkLimits(zone string, records []libdns.Record) error {
+	typesByName := map[string][]string{}
+	for _, recordGroup := range groupRecordsByRecordSet(records, zone, p.recordSetName) {
+		rr := recordGroup[0].RR()
+		name := p.recordSetName(rr.Name, zone)
+		if err := checkNameLimits(name, zone); err != nil {
+			return err
+		}
+		typesByName[strings.ToLower(name)] = append(typesByName[strings.ToLower(name)], rr.Type)
+
+		recordGroup = uniqueRecords(recordGroup)
+		if rr.Type == "CNAME" && name == "@" {
+			return fmt.Errorf("the record set %v %v violates the limit: a CNAME record set cannot be at the apex of the zone", name, rr.Type)
+		}
+		if rr.Type == "CNAME" && len(recordGroup) > 1 {
+			return fmt.Errorf("the record set %v %v violates the limit: a CNAME record set can have only 1 value, not %d", name, rr.Type, len(recordGroup))
+		}
+		if len(recordGroup) > maxRecordsPerRecordSet {
+			return fmt.Errorf("the record set %v %v violates the limit: a record set can have at most %d values, not %d", name, rr.Type, maxRecordsPerRecordSet, len(recordGroup))
+		}
+		if rr.Type == "TXT" {
+			var values []string
+			for _, record := range recordGroup {
+				values = append(values, recordData(record))
+			}
+			if err := checkTXTLimits(name, values); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Azure DNS does not allow a CNAME record set to share its name with a record set of another type
+	for name, typeNames := range typesByName {
+		if len(typeNames) < 2 {
+			continue
+		}
+		for _, typeName := range typeNames {
+			if typeName == "CNAME" {
+				return fmt.Errorf("the record set %v CNAME violates the limit: a CNAME record set cannot share its name with the types %v", name, strings.Join(typeNames, ", "))
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRecordSetLimits throws an error if the values of the record set to be written exceed the limits of Azure DNS,
+// e.g. after the values of the records are merged into the existing values.
+func checkRecordSetLimits(name string, typeName string, properties *armdns.RecordSetProperties) error {
+	count := len(properties.ARecords) + len(properties.AaaaRecords) + len(properties.CaaRecords) + len(properties.MxRecords) +
+		len(properties.NsRecords) + len(properties.PtrRecords) + len(properties.SrvRecords) + len(properties.TxtRecords)
+	if count > maxRecordsPerRecordSet {
+		return fmt.Errorf("the record set %v %v violates the limit: a record set can have at most %d values, not %d", name, typeName, maxRecordsPerRecordSet, count)
+	}
+	var values []string
+	for _, txtRecord := range properties.TxtRecords {
+		var value strings.Builder
+		for _, s := range txtRecord.Value {
+			value.WriteString(stringValue(s))
+		}
+		values = append(values, value.String())
+	}
+	return checkTXTLimits(name, values)
+}
+
+// checkTXTLimits throws an error if a value of the TXT record set or their total length is too long for Azure DNS.
+func checkTXTLimits(name string, values []string) error {
+	total := 0
+	for _, value := range values {
+		if len(value) > maxTXTValueLength {
+			return fmt.Errorf("the record set %v TXT violates the limit: a TXT value can have at most %d characters, not %d", name, maxTXTValueLength, len(value))
+		}
+		total += len(value)
+	}
+	if total > maxTXTRecordSetLength {
+		return fmt.Errorf("the record set %v TXT violates the limit: the TXT values can have at most %d characters in total, not %d", name, maxTXTRecordSetLength, total)
+	}
+	return nil
+}
+
+// checkNameLimits throws an error if the name of the record set relative to the zone has a label too long,
+// or is too long with the zone.
+func checkNameLimits(name string, zone string) error {
+	if name == "@" {
+		return nil
+	}
+	for _, label := range strings.Split(name, ".") {
+		if len(label) > maxLabelLength {
+			return fmt.Errorf("the record set %v violates the limit: a label can have at most %d characters, not %d", name, maxLabelLength, len(label))
+		}
+	}
+	if length := len(name) + 1 + len(strings.TrimSuffix(zone, ".")); length > maxNameLength {
+		return fmt.Errorf("the record set %v violates the limit: a name can have at most %d characters with the zone, not %d", name, maxNameLength, length)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_checkLimits(t *testing.T) {
+	var addresses []libdns.Record
+	for i := 0; i < 21; i++ {
+		addresses = append(addresses, libdns.Address{Name: "www", IP: netip.AddrFrom4([4]byte{192, 0, 2, byte(i)})})
+	}
+	tests := []struct {
+		name    string
+		records []libdns.Record
+		wantErr string
+	}{
+		{name: "valid", records: []libdns.Record{
+			libdns.Address{Name: "www", IP: netip.MustParseAddr("192.0.2.1")},
+			libdns.TXT{Name: "www", Text: strings.Repeat("a", 1024)},
+			libdns.CNAME{Name: "alias", Target: "www.example.com."},
+		}},
+		{name: "values=20", records: addresses[:20]},
+		{name: "values=21", records: addresses, wantErr: "a record set can have at most 20 values, not 21"},
+		{name: "values=21,duplicated", records: append(addresses[:20:20], addresses[0])},
+		{name: "txt=long", records: []libdns.Record{
+			libdns.TXT{Name: "www", Text: strings.Repeat("a", 1025)},
+		}, wantErr: "a TXT value can have at most 1024 characters, not 1025"},
+		{name: "txt=long-total", records: []libdns.Record{
+			libdns.TXT{Name: "www", Text: strings.Repeat("a", 1024)},
+			libdns.TXT{Name: "www", Text: strings.Repeat("b", 1024)},
+			libdns.TXT{Name: "www", Text: strings.Repeat("c", 1024)},
+			libdns.TXT{Name: "www", Text: strings.Repeat("d", 1024)},
+			libdns.TXT{Name: "www", Text: "e"},
+		}, wantErr: "the TXT values can have at most 4096 characters in total, not 4097"},
+		{name: "cname=multiple", records: []libdns.Record{
+			libdns.CNAME{Name: "alias", Target: "www.example.com."},
+			libdns.CNAME{Name: "alias", Target: "mail.example.com."},
+		}, wantErr: "a CNAME record set can have only 1 value, not 2"},
+		{name: "cname=apex", records: []libdns.Record{
+			libdns.CNAME{Name: "@", Target: "www.example.net."},
+		}, wantErr: "a CNAME record set cannot be at the apex of the zone"},
+		{name: "cname=other-type", records: []libdns.Record{
+			libdns.CNAME{Name: "alias", Target: "www.example.com."},
+			libdns.TXT{Name: "Alias", Text: "TEST VALUE"},
+		}, wantErr: "a CNAME record set cannot share its name with the types CNAME, TXT"},
+		{name: "label=long", records: []libdns.Record{
+			libdns.TXT{Name: strings.Repeat("a", 64) + ".www", Text: "TEST VALUE"},
+		}, wantErr: "a label can have at most 63 characters, not 64"},
+		{name: "name=long", records: []libdns.Record{
+			libdns.TXT{Name: strings.Repeat(strings.Repeat("a", 63)+".", 3) + strings.Repeat("a", 50), Text: "TEST VALUE"},
+		}, wantErr: "a name can have at most 253 characters with the zone, not 254"},
+	}
+	for _, tt := range tests {
+		for operation, write := range map[string]func(p *Provider, records []libdns.Record) ([]libdns.Record, error){
+			"append": func(p *Provider, records []libdns.Record) ([]libdns.Record, error) {
+				return p.createRecords(context.TODO(), "example.com.", records)
+			},
+			"set": func(p *Provider, records []libdns.Record) ([]libdns.Record, error) {
+				return p.updateRecords(context.TODO(), "example.com.", records)
+			},
+		} {
+			t.Run(tt.name+",operation="+operation, func(t *testing.T) {
+				provider := getFakeProvider()
+				_, err := write(&provider, tt.records)
+				if tt.wantErr == "" {
+					if err != nil {
+						t.Errorf("%s", err)
+					}
+					return
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got: %v, want: %s", err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func Test_checkRecordSetLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    int
+		wantErr   bool
+		wantCalls []string
+	}{
+		{name: "values=19+1", values: 19, wantCalls: []string{"get", "write"}},
+		{name: "values=20+1", values: 20, wantErr: true, wantCalls: []string{"get"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := newFakeZone(getOwnedTXTRecordSet(""))
+			provider := getFakeProviderWithZone(zone)
+			var records []libdns.Record
+			for i := 0; i < tt.values; i++ {
+				records = append(records, libdns.TXT{Name: "record-txt", Text: fmt.Sprintf("NEW VALUE %d", i), TTL: time.Duration(30) * time.Second})
+			}
+			_, err := provider.createRecords(context.TODO(), "example.com.", records)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "a record set can have at most 20 values, not 21") {
+					t.Errorf("got: %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("%s", err)
+			}
+			if diff := cmp.Diff(zone.calls, tt.wantCalls); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
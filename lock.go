@@ -0,0 +1,314 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/uuid"
+	"github.com/libdns/libdns"
+)
+
+const (
+	// defaultLockName is the name of the marker record set of a zone lock if not specified.
+	defaultLockName = "_libdns-lock"
+
+	// defaultLockDuration is the duration for which a zone lock is held without renewal if not specified.
+	defaultLockDuration = time.Minute
+
+	// defaultLockRetryInterval is the interval to retry acquiring a zone lock held by another holder if not specified.
+	defaultLockRetryInterval = time.Second
+
+	// lockHolderMetadataKey is the key of the metadata of the marker record set with the holder of the lock.
+	lockHolderMetadataKey = "libdns_lock_holder"
+
+	// lockExpiresMetadataKey is the key of the metadata of the marker record set with the time when the lock expires.
+	lockExpiresMetadataKey = "libdns_lock_expires"
+)
+
+// LockOptions configures how a zone lock is acquired.
+type LockOptions struct {
+	// Name is the name of the TXT record set used as the marker of the lock. Defaults to "_libdns-lock".
+	Name string
+
+	// Holder identifies the holder of the lock. Defaults to the host name followed by a random suffix,
+	// so that every lock is held by a distinct holder.
+	Holder string
+
+	// Duration is how long the lock is held without renewal, after which other holders can take it over. Defaults to 1 minute.
+	Duration time.Duration
+
+	// Retry Interval is the interval to retry acquiring the lock while it is held by another holder. Defaults to 1 second.
+	RetryInterval time.Duration
+}
+
+// ZoneLock is a lease on a zone, held by a single holder across the instances of an application sharing the zone.
+// It is implemented by a TXT record set without values, whose metadata records the holder and the expiry,
+// and is updated only with the ETag read, so that no two holders can take it at the same time.
+// Since the expiry is set by the clock of the holder, the clocks of the instances should be reasonably in sync.
+type ZoneLock struct {
+	provider  *Provider
+	zone      string
+	name      string
+	holder    string
+	duration  time.Duration
+	etag      *string
+	expiresOn time.Time
+}
+
+// LockZone acquires the lock of the zone, waiting until it is released or expires if it is held by another holder.
+// It returns an error if the context is done before the lock is acquired.
+// The lock should be released with Unlock, and renewed with Renew if held for longer than its duration.
+// The marker record set is written like any other record set, subject to Read Only, Namespaces, the rules, Before Write,
+// and Mutation Budget, so that a provider not allowed to write it cannot lock the zone.
+func (p *Provider) LockZone(ctx context.Context, zone string, options LockOptions) (*ZoneLock, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.LockZone(ctx, zone, options)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	lock := &ZoneLock{
+		provider: p,
+		zone:     zone,
+		name:     options.Name,
+		holder:   options.Holder,
+		duration: options.Duration,
+	}
+	if lock.name == "" {
+		lock.name = defaultLockName
+	}
+	if lock.holder == "" {
+		hostname, _ := os.Hostname()
+		lock.holder = strings.TrimPrefix(hostname+"-"+uuid.NewString(), "-")
+	}
+	if lock.duration <= 0 {
+		lock.duration = defaultLockDuration
+	}
+	interval := options.RetryInterval
+	if interval <= 0 {
+		interval = defaultLockRetryInterval
+	}
+
+	for {
+		acquired, err := lock.acquire(ctx)
+		if err != nil {
+			return nil, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+		}
+		if acquired {
+			return lock, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, wrapCorrelationID(fmt.Errorf("the zone %v is locked by another holder: %w", zone, ctx.Err()), correlationID)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// WithZoneLock calls fn while holding the lock of the zone, and releases the lock after fn returns.
+// The lock is not renewed, so fn should complete within the duration of the lock.
+func (p *Provider) WithZoneLock(ctx context.Context, zone string, options LockOptions, fn func(ctx context.Context) error) error {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.WithZoneLock(ctx, zone, options, fn)
+	}
+
+	lock, err := p.LockZone(ctx, zone, options)
+	if err != nil {
+		return err
+	}
+	err = fn(ctx)
+
+	// The lock is released even if fn has been stopped by the cancellation of ctx, so that it is not held until it expires
+	unlockErr := lock.Unlock(context.WithoutCancel(ctx))
+	return errors.Join(err, unlockErr)
+}
+
+// Holder returns the holder of the lock.
+func (l *ZoneLock) Holder() string {
+	return l.holder
+}
+
+// ExpiresOn returns the time when the lock expires unless renewed.
+func (l *ZoneLock) ExpiresOn() time.Time {
+	return l.expiresOn
+}
+
+// Renew extends the lock by its duration from now. It returns an error if the lock has been taken over by another holder.
+func (l *ZoneLock) Renew(ctx context.Context) error {
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	if err := l.renew(ctx); err != nil {
+		return wrapCorrelationID(enrichAuthorizationError(err, l.provider.generateZoneID(l.zone)), correlationID)
+	}
+
+	return nil
+}
+
+// Unlock releases the lock by deleting its marker record set. It returns an error if the lock has been taken over by another holder.
+func (l *ZoneLock) Unlock(ctx context.Context) error {
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	if err := l.unlock(ctx); err != nil {
+		return wrapCorrelationID(enrichAuthorizationError(err, l.provider.generateZoneID(l.zone)), correlationID)
+	}
+
+	return nil
+}
+
+// acquire reads the marker record set and takes the lock if it is free, expired, or already held by the holder.
+// It reports false if the lock is held by another holder or taken by another holder at the same time.
+func (l *ZoneLock) acquire(ctx context.Context) (bool, error) {
+	p := l.provider
+	if err := l.checkWritable(); err != nil {
+		return false, err
+	}
+
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return false, err
+	}
+
+	var acquired bool
+	err := p.retryOnAuthenticationError(func() error {
+		response, err := p.client.azureClient.Get(ctx, p.ResourceGroupName, strings.TrimSuffix(l.zone, "."), l.name, armdns.RecordTypeTXT, nil)
+		if err != nil && !isNotFoundError(err) {
+			return err
+		}
+
+		var ifMatch, ifNoneMatch *string
+		if err != nil {
+			// Prevent taking a lock created after it was found missing
+			ifNoneMatch = to.Ptr("*")
+		} else {
+			holder, expiresOn := getLockHolder(&response.RecordSet)
+			if holder != "" && holder != l.holder && time.Now().Before(expiresOn) {
+				acquired = false
+				return nil
+			}
+			// Prevent taking a lock renewed or taken over after it was read
+			ifMatch = response.Etag
+		}
+
+		var before *armdns.RecordSet
+		if response.Etag != nil {
+			before = &response.RecordSet
+		}
+		acquired, err = l.write(ctx, before, ifMatch, ifNoneMatch)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return acquired, nil
+}
+
+// renew extends the lock with the ETag of the marker record set written last.
+func (l *ZoneLock) renew(ctx context.Context) error {
+	p := l.provider
+	if err := l.checkWritable(); err != nil {
+		return err
+	}
+
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return err
+	}
+
+	return p.retryOnAuthenticationError(func() error {
+		renewed, err := l.write(ctx, nil, l.etag, nil)
+		if err != nil {
+			return err
+		}
+		if !renewed {
+			return fmt.Errorf("the lock of the zone %v has been taken over by another holder", l.zone)
+		}
+		return nil
+	})
+}
+
+// unlock deletes the marker record set with the ETag written last.
+func (l *ZoneLock) unlock(ctx context.Context) error {
+	p := l.provider
+	if err := l.checkWritable(); err != nil {
+		return err
+	}
+
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return err
+	}
+
+	return p.retryOnAuthenticationError(func() error {
+		if err := p.beforeWrite(ctx, WriteOperationDelete, l.zone, l.name, string(armdns.RecordTypeTXT), nil, nil); err != nil {
+			return err
+		}
+		_, err := p.client.azureClient.Delete(ctx, p.ResourceGroupName, strings.TrimSuffix(l.zone, "."), l.name, armdns.RecordTypeTXT, &armdns.RecordSetsClientDeleteOptions{
+			IfMatch: l.etag,
+		})
+		if isPreconditionFailedError(err) {
+			return fmt.Errorf("the lock of the zone %v has been taken over by another holder", l.zone)
+		}
+		return err
+	})
+}
+
+// checkWritable throws an error if the provider is not allowed to write the marker record set.
+func (l *ZoneLock) checkWritable() error {
+	return l.provider.checkWritable(l.zone, []libdns.Record{libdns.TXT{Name: l.name}})
+}
+
+// write writes the marker record set with the holder and a new expiry under the preconditions, after passing it to Before Write,
+// through the same path as any other record set, so that it is stamped with the record set metadata of the zone and the provenance.
+// The before record set is the marker record set as it was read, if any.
+// It reports false if the preconditions are not met, which means the lock has been taken by another holder.
+func (l *ZoneLock) write(ctx context.Context, before *armdns.RecordSet, ifMatch *string, ifNoneMatch *string) (bool, error) {
+	p := l.provider
+	expiresOn := time.Now().Add(l.duration)
+	after := armdns.RecordSet{
+		Properties: &armdns.RecordSetProperties{
+			TTL: to.Ptr[int64](1),
+			Metadata: map[string]*string{
+				lockHolderMetadataKey:  to.Ptr(l.holder),
+				lockExpiresMetadataKey: to.Ptr(expiresOn.UTC().Format(time.RFC3339Nano)),
+			},
+		},
+	}
+	if err := p.beforeWrite(ctx, WriteOperationSet, l.zone, l.name, string(armdns.RecordTypeTXT), before, &after); err != nil {
+		return false, err
+	}
+
+	written, err := p.writeRecordSet(ctx, l.zone, libdns.TXT{Name: l.name}, after, ifMatch, ifNoneMatch)
+	if isPreconditionFailedError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	l.etag = written.Etag
+	l.expiresOn = expiresOn
+	return true, nil
+}
+
+// getLockHolder returns the holder of the lock and the time when it expires recorded on the marker record set.
+// A lock without a valid expiry is taken as expired.
+func getLockHolder(recordSet *armdns.RecordSet) (string, time.Time) {
+	expiresOn, _ := time.Parse(time.RFC3339Nano, getMetadata(recordSet, lockExpiresMetadataKey))
+	return getMetadata(recordSet, lockHolderMetadataKey), expiresOn
+}
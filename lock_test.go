@@ -0,0 +1,214 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_LockZone(t *testing.T) {
+	t.Run("lock=free", func(t *testing.T) {
+		zone := newFakeZone()
+		provider := getFakeProviderWithZone(zone)
+		lock, err := provider.LockZone(context.TODO(), "example.com.", LockOptions{Holder: "host-a"})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if holder, _ := getLockHolder(zone.get(defaultLockName, "TXT")); holder != "host-a" {
+			t.Errorf("got: %v, want: host-a", holder)
+		}
+		if diff := cmp.Diff(zone.keys, []string{defaultLockName + "/TXT"}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if err := lock.Unlock(context.TODO()); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if zone.get(defaultLockName, "TXT") != nil {
+			t.Errorf("the marker record set is not deleted")
+		}
+	})
+	t.Run("lock=held", func(t *testing.T) {
+		zone := newFakeZone()
+		holderA := getFakeProviderWithZone(zone)
+		holderB := getFakeProviderWithZone(zone)
+		lock, err := holderA.LockZone(context.TODO(), "example.com.", LockOptions{Holder: "host-a"})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+		defer cancel()
+		_, err = holderB.LockZone(ctx, "example.com.", LockOptions{Holder: "host-b", RetryInterval: 10 * time.Millisecond})
+		if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "is locked by another holder") {
+			t.Errorf("got: %v", err)
+		}
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			lock.Unlock(context.TODO())
+		}()
+		lockB, err := holderB.LockZone(context.TODO(), "example.com.", LockOptions{Holder: "host-b", RetryInterval: 10 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if lockB.Holder() != "host-b" {
+			t.Errorf("got: %v, want: host-b", lockB.Holder())
+		}
+	})
+	t.Run("lock=expired", func(t *testing.T) {
+		zone := newFakeZone()
+		holderA := getFakeProviderWithZone(zone)
+		holderB := getFakeProviderWithZone(zone)
+		lock, err := holderA.LockZone(context.TODO(), "example.com.", LockOptions{Holder: "host-a", Duration: time.Millisecond})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		if _, err := holderB.LockZone(context.TODO(), "example.com.", LockOptions{Holder: "host-b"}); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if err := lock.Renew(context.TODO()); err == nil || !strings.Contains(err.Error(), "has been taken over") {
+			t.Errorf("got: %v", err)
+		}
+		if err := lock.Unlock(context.TODO()); err == nil || !strings.Contains(err.Error(), "has been taken over") {
+			t.Errorf("got: %v", err)
+		}
+		if holder, _ := getLockHolder(zone.get(defaultLockName, "TXT")); holder != "host-b" {
+			t.Errorf("got: %v, want: host-b", holder)
+		}
+	})
+	t.Run("lock=renewed", func(t *testing.T) {
+		zone := newFakeZone()
+		provider := getFakeProviderWithZone(zone)
+		lock, err := provider.LockZone(context.TODO(), "example.com.", LockOptions{})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		expiresOn := lock.ExpiresOn()
+		time.Sleep(time.Millisecond)
+		if err := lock.Renew(context.TODO()); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if !lock.ExpiresOn().After(expiresOn) {
+			t.Errorf("the lock is not extended")
+		}
+	})
+	t.Run("provider=restricted", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			configure func(provider *Provider)
+		}{
+			{name: "read_only", configure: func(provider *Provider) { provider.ReadOnly = true }},
+			{name: "namespaces", configure: func(provider *Provider) { provider.Namespaces = []string{"_acme-challenge.*"} }},
+			{name: "deny_rules", configure: func(provider *Provider) { provider.DenyRules = []RecordRule{{Type: "TXT"}} }},
+			{name: "before_write", configure: func(provider *Provider) {
+				provider.BeforeWrite = func(ctx context.Context, write *PlannedWrite) error {
+					return errors.New("vetoed")
+				}
+			}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				zone := newFakeZone()
+				provider := getFakeProviderWithZone(zone)
+				tt.configure(provider)
+				if _, err := provider.LockZone(context.TODO(), "example.com.", LockOptions{}); err == nil {
+					t.Errorf("got: nil error")
+				}
+				if zone.get(defaultLockName, "TXT") != nil {
+					t.Errorf("the marker record set is written")
+				}
+			})
+		}
+	})
+	t.Run("before_write", func(t *testing.T) {
+		zone := newFakeZone()
+		provider := getFakeProviderWithZone(zone)
+		var writes []string
+		provider.BeforeWrite = func(ctx context.Context, write *PlannedWrite) error {
+			writes = append(writes, string(write.Operation)+" "+write.Name+" "+write.Type)
+			return nil
+		}
+		lock, err := provider.LockZone(context.TODO(), "example.com.", LockOptions{})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if err := lock.Unlock(context.TODO()); err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := []string{
+			string(WriteOperationSet) + " " + defaultLockName + " TXT",
+			string(WriteOperationDelete) + " " + defaultLockName + " TXT",
+		}
+		if diff := cmp.Diff(writes, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("stamp=provenance", func(t *testing.T) {
+		zone := newFakeZone()
+		provider := getFakeProviderWithZone(zone)
+		provider.StampProvenance = true
+		if _, err := provider.LockZone(context.TODO(), "example.com.", LockOptions{Holder: "host-a"}); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if getMetadata(zone.get(defaultLockName, "TXT"), "libdns_written_by") == "" {
+			t.Errorf("the marker record set is not stamped with the provenance")
+		}
+		if holder, _ := getLockHolder(zone.get(defaultLockName, "TXT")); holder != "host-a" {
+			t.Errorf("got: %v, want: host-a", holder)
+		}
+	})
+}
+
+func Test_WithZoneLock(t *testing.T) {
+	t.Run("fn=failed", func(t *testing.T) {
+		zone := newFakeZone()
+		provider := getFakeProviderWithZone(zone)
+		errFailed := errors.New("failed")
+		err := provider.WithZoneLock(context.TODO(), "example.com.", LockOptions{}, func(ctx context.Context) error {
+			if zone.get(defaultLockName, "TXT") == nil {
+				t.Errorf("the lock is not held")
+			}
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) {
+			t.Errorf("got: %v", err)
+		}
+		if zone.get(defaultLockName, "TXT") != nil {
+			t.Errorf("the lock is not released")
+		}
+	})
+	t.Run("fn=canceled", func(t *testing.T) {
+		zone := newFakeZone()
+		provider := getFakeProviderWithZone(zone)
+		ctx, cancel := context.WithCancel(context.TODO())
+		err := provider.WithZoneLock(ctx, "example.com.", LockOptions{}, func(ctx context.Context) error {
+			cancel()
+			return ctx.Err()
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got: %v", err)
+		}
+		if zone.get(defaultLockName, "TXT") != nil {
+			t.Errorf("the lock is not released")
+		}
+	})
+	t.Run("unlock=failed", func(t *testing.T) {
+		zone := newFakeZone()
+		provider := getFakeProviderWithZone(zone)
+		errFailed := errors.New("failed")
+		err := provider.WithZoneLock(context.TODO(), "example.com.", LockOptions{}, func(ctx context.Context) error {
+			// Another holder takes over the lock
+			zone.get(defaultLockName, "TXT").Etag = to.Ptr("ETAG_OTHER")
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) || !strings.Contains(err.Error(), "taken over") {
+			t.Errorf("got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/libdns/libdns"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// providerMetrics are the OpenTelemetry instruments of the provider.
+// The methods of a nil *providerMetrics do nothing, so that the metrics can be recorded unconditionally.
+type providerMetrics struct {
+	requests metric.Int64Counter
+	duration metric.Float64Histogram
+	retries  metric.Int64Counter
+	records  metric.Int64Counter
+}
+
+// getMetrics returns the instruments created with Meter Provider, or nil if Meter Provider is not set.
+// The instruments are created once and shared by the clients rebuilt afterwards.
+func (p *Provider) getMetrics() *providerMetrics {
+	if p.MeterProvider == nil {
+		return nil
+	}
+
+	p.client.metricsOnce.Do(func() {
+		metrics, err := newProviderMetrics(p.MeterProvider)
+		if err != nil {
+			p.getLogger().Warn("failed to create the metrics", "error", err)
+			return
+		}
+		p.client.metrics = metrics
+	})
+	return p.client.metrics
+}
+
+// newProviderMetrics creates the instruments with the meter provider.
+func newProviderMetrics(meterProvider metric.MeterProvider) (*providerMetrics, error) {
+	meter := meterProvider.Meter(moduleName, metric.WithInstrumentationVersion(moduleVersion))
+
+	requests, err := meter.Int64Counter("libdns.azure.requests",
+		metric.WithDescription("The number of requests sent to Azure Resource Manager, excluding retries."),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := meter.Float64Histogram("libdns.azure.request.duration",
+		metric.WithDescription("The duration of the requests sent to Azure Resource Manager, including retries."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	retries, err := meter.Int64Counter("libdns.azure.request.retries",
+		metric.WithDescription("The number of times the requests sent to Azure Resource Manager are retried."),
+		metric.WithUnit("{retry}"))
+	if err != nil {
+		return nil, err
+	}
+	records, err := meter.Int64Counter("libdns.azure.records",
+		metric.WithDescription("The number of records appended, set, or deleted."),
+		metric.WithUnit("{record}"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &providerMetrics{
+		requests: requests,
+		duration: duration,
+		retries:  retries,
+		records:  records,
+	}, nil
+}
+
+// recordRecords adds the records written by the operation to the zone.
+func (m *providerMetrics) recordRecords(ctx context.Context, operation WriteOperation, zone string, records []libdns.Record) {
+	if m == nil || len(records) == 0 {
+		return
+	}
+	m.records.Add(ctx, int64(len(records)), metric.WithAttributes(
+		attribute.String("libdns.operation", string(operation)),
+		attribute.String("libdns.zone", zone),
+	))
+}
+
+// requestAttempts counts the attempts to send a request, shared by the metrics policies through the operation values of the request.
+type requestAttempts struct {
+	count int
+}
+
+// metricsPolicy is a per-call pipeline policy that records the number and the duration of the requests, and their retries.
+type metricsPolicy struct {
+	metrics *providerMetrics
+}
+
+// Do records the request after sending it to the next policy, which retries it as necessary.
+func (m metricsPolicy) Do(req *policy.Request) (*http.Response, error) {
+	attempts := &requestAttempts{}
+	req.SetOperationValue(attempts)
+
+	start := time.Now()
+	resp, err := req.Next()
+	duration := time.Since(start)
+
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Raw().Method),
+	}
+	if resp != nil {
+		attrs = append(attrs, attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	if err != nil {
+		attrs = append(attrs, attribute.String("error.type", "transport"))
+	}
+	options := metric.WithAttributes(attrs...)
+
+	ctx := req.Raw().Context()
+	m.metrics.requests.Add(ctx, 1, options)
+	m.metrics.duration.Record(ctx, duration.Seconds(), options)
+	if attempts.count > 1 {
+		m.metrics.retries.Add(ctx, int64(attempts.count-1), options)
+	}
+
+	return resp, err
+}
+
+// attemptCountingPolicy is a per-retry pipeline policy that counts the attempts to send a request for metricsPolicy.
+type attemptCountingPolicy struct{}
+
+// Do counts the attempt and sends the request to the next policy.
+func (attemptCountingPolicy) Do(req *policy.Request) (*http.Response, error) {
+	var attempts *requestAttempts
+	if req.OperationValue(&attempts) {
+		attempts.count++
+	}
+	return req.Next()
+}
@@ -0,0 +1,106 @@
+package azure
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func collectMetrics(t *testing.T, reader sdkmetric.Reader) map[string]int64 {
+	var resourceMetrics metricdata.ResourceMetrics
+	if err := reader.Collect(context.TODO(), &resourceMetrics); err != nil {
+		t.Fatalf("%s", err)
+	}
+	got := map[string]int64{}
+	for _, scopeMetrics := range resourceMetrics.ScopeMetrics {
+		for _, m := range scopeMetrics.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, point := range data.DataPoints {
+					got[m.Name] += point.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, point := range data.DataPoints {
+					got[m.Name] += int64(point.Count)
+				}
+			}
+		}
+	}
+	return got
+}
+
+func Test_metrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		want     map[string]int64
+	}{
+		{
+			name:     "failures=0",
+			failures: 0,
+			want: map[string]int64{
+				"libdns.azure.requests":         1,
+				"libdns.azure.request.duration": 1,
+				"libdns.azure.records":          1,
+			},
+		},
+		{
+			name:     "failures=2",
+			failures: 2,
+			want: map[string]int64{
+				"libdns.azure.requests":         1,
+				"libdns.azure.request.duration": 1,
+				"libdns.azure.request.retries":  2,
+				"libdns.azure.records":          1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			provider := getFakeProvider()
+			provider.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+			failures := tt.failures
+			transport := provider.client.clientOptions.Transport
+			provider.client.clientOptions.Transport = transportFunc(func(req *http.Request) (*http.Response, error) {
+				if failures > 0 {
+					failures--
+					return &http.Response{
+						StatusCode: http.StatusServiceUnavailable,
+						Header:     http.Header{},
+						Body:       io.NopCloser(strings.NewReader("")),
+						Request:    req,
+					}, nil
+				}
+				return transport.Do(req)
+			})
+			provider.client.clientOptions.Retry = policy.RetryOptions{RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond}
+			provider.resetClient()
+
+			if _, err := provider.DeleteRecords(context.TODO(), "example.com.", []libdns.Record{
+				libdns.RR{Name: "record-a", Type: "A"},
+			}); err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(collectMetrics(t, reader), tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+	t.Run("provider=none", func(t *testing.T) {
+		provider := getFakeProvider()
+		if metrics := provider.getMetrics(); metrics != nil {
+			t.Errorf("got: %v, want: nil", metrics)
+		}
+		provider.getMetrics().recordRecords(context.TODO(), WriteOperationAppend, "example.com.", libdnsFakeRecords)
+	})
+}
@@ -0,0 +1,117 @@
+package azure
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+// ToDNSRR converts a libdns record to a resource record of miekg/dns, with the name fully qualified in the zone.
+// The names of the records may be relative to the zone or fully qualified, as may the names in their data.
+// The text of a TXT record is split into character strings of at most 255 bytes and escaped, so that it is preserved byte-for-byte.
+// An alias of an Azure resource has no resource record and cannot be converted.
+func ToDNSRR(zone string, record libdns.Record) (dns.RR, error) {
+	if alias, ok := record.(Alias); ok {
+		return nil, fmt.Errorf("the alias %v of the resource %v has no resource record", alias.Name, alias.TargetResourceID)
+	}
+	record, err := decodeGenericRecord(record)
+	if err != nil {
+		return nil, err
+	}
+	record = normalizeTXTRecord(record)
+	rr := record.RR()
+	origin := dns.Fqdn(zone)
+	header := dns.RR_Header{
+		Name:   libdns.AbsoluteName(generateRecordSetName(rr.Name, zone), origin),
+		Rrtype: dns.StringToType[rr.Type],
+		Class:  dns.ClassINET,
+		Ttl:    uint32(rr.TTL.Seconds()),
+	}
+
+	if rr.Type == "TXT" {
+		// The data of an RR that is not entirely quoted strings is taken literally, as when it is written to Azure DNS
+		text := rr.Data
+		if txt, ok := record.(libdns.TXT); ok {
+			text = txt.Text
+		}
+		var strs []string
+		for _, s := range splitTXT(text) {
+			strs = append(strs, escapeTXT(*s))
+		}
+		return &dns.TXT{Hdr: header, Txt: strs}, nil
+	}
+
+	if header.Rrtype == 0 {
+		return nil, fmt.Errorf("the type %v of the record %v cannot be interpreted", rr.Type, rr.Name)
+	}
+	parser := dns.NewZoneParser(strings.NewReader(fmt.Sprintf("%s %d IN %s %s", header.Name, header.Ttl, rr.Type, rr.Data)), origin, "")
+	dnsRR, ok := parser.Next()
+	if err := parser.Err(); err != nil {
+		return nil, fmt.Errorf("the value %v of the record %v %v cannot be interpreted: %w", rr.Data, rr.Name, rr.Type, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("the value %v of the record %v %v cannot be interpreted", rr.Data, rr.Name, rr.Type)
+	}
+	return dnsRR, nil
+}
+
+// FromDNSRR converts a resource record of miekg/dns to a libdns record in the same way as GetRecords, i.e. of the
+// type-specific struct of libdns, or the opaque RR for the types that libdns does not define, with the name relative to the zone.
+// The character strings of a TXT record are unescaped and concatenated into a single text.
+func FromDNSRR(zone string, dnsRR dns.RR) (libdns.Record, error) {
+	header := dnsRR.Header()
+	if header.Class != dns.ClassINET {
+		return nil, fmt.Errorf("the class %v of the record %v cannot be interpreted", dns.ClassToString[header.Class], header.Name)
+	}
+	if !dns.IsSubDomain(dns.Fqdn(zone), dns.Fqdn(header.Name)) {
+		return nil, fmt.Errorf("the record %v is not in the zone %v", header.Name, zone)
+	}
+	name := generateRecordSetName(header.Name, zone)
+	ttl := time.Duration(header.Ttl) * time.Second
+	// The presentation format separates the name, the TTL, the class, and the type from the data by tabs
+	var data string
+	if fields := strings.SplitN(dnsRR.String(), "\t", 5); len(fields) == 5 {
+		data = strings.TrimSpace(fields[4])
+	}
+
+	if txt, ok := dnsRR.(*dns.TXT); ok {
+		text, ok := unquoteTXT(data)
+		if !ok && len(txt.Txt) > 0 {
+			return nil, fmt.Errorf("the value %v of the record %v TXT cannot be interpreted", data, name)
+		}
+		return libdns.TXT{Name: name, TTL: ttl, Text: text}, nil
+	}
+
+	typeName, ok := dns.TypeToString[header.Rrtype]
+	if !ok {
+		typeName = fmt.Sprintf("TYPE%d", header.Rrtype)
+	}
+	return newLibdnsRecord(name, ttl, typeName, data), nil
+}
+
+// ParseRecords parses resource records in the presentation format of zone files, e.g. "www 300 IN A 192.0.2.1",
+// into libdns records with the names relative to the zone, as FromDNSRR does. Names without a trailing dot are relative
+// to the zone, and the directives of zone files, e.g. $TTL, are honored. Records without a TTL have a TTL of 1 hour.
+func ParseRecords(zone string, text string) ([]libdns.Record, error) {
+	parser := dns.NewZoneParser(strings.NewReader(text), dns.Fqdn(zone), "")
+	var records []libdns.Record
+	for dnsRR, ok := parser.Next(); ok; dnsRR, ok = parser.Next() {
+		record, err := FromDNSRR(zone, dnsRR)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err := parser.Err(); err != nil {
+		return nil, fmt.Errorf("the records cannot be interpreted: %w", err)
+	}
+	return records, nil
+}
+
+// escapeTXT escapes a character string of a TXT record as miekg/dns holds it, i.e. with backslashes and quotes escaped.
+func escapeTXT(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
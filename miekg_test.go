@@ -0,0 +1,134 @@
+package azure
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+	"github.com/miekg/dns"
+)
+
+func Test_ToDNSRR(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  libdns.Record
+		want    string
+		wantErr bool
+	}{
+		{name: "A", record: libdns.Address{Name: "www", TTL: 300 * time.Second, IP: netip.MustParseAddr("192.0.2.1")}, want: "www.example.com.\t300\tIN\tA\t192.0.2.1"},
+		{name: "A,fqdn", record: libdns.Address{Name: "www.example.com.", TTL: 300 * time.Second, IP: netip.MustParseAddr("192.0.2.1")}, want: "www.example.com.\t300\tIN\tA\t192.0.2.1"},
+		{name: "A,apex", record: libdns.Address{Name: "@", TTL: 300 * time.Second, IP: netip.MustParseAddr("192.0.2.1")}, want: "example.com.\t300\tIN\tA\t192.0.2.1"},
+		{name: "MX", record: libdns.MX{Name: "@", TTL: 300 * time.Second, Preference: 10, Target: "mail.example.com."}, want: "example.com.\t300\tIN\tMX\t10 mail.example.com."},
+		{name: "CNAME,relative", record: libdns.RR{Name: "www", TTL: 300 * time.Second, Type: "CNAME", Data: "web"}, want: "www.example.com.\t300\tIN\tCNAME\tweb.example.com."},
+		{name: "CAA", record: libdns.CAA{Name: "@", TTL: 300 * time.Second, Tag: "issue", Value: "letsencrypt.org"}, want: "example.com.\t300\tIN\tCAA\t0 issue \"letsencrypt.org\""},
+		{name: "TXT", record: libdns.TXT{Name: "txt", TTL: 300 * time.Second, Text: `say "hi" \ é`}, want: "txt.example.com.\t300\tIN\tTXT\t\"say \\\"hi\\\" \\\\ \\195\\169\""},
+		{name: "TXT,long", record: libdns.TXT{Name: "txt", TTL: 300 * time.Second, Text: strings.Repeat("a", 300)}, want: "txt.example.com.\t300\tIN\tTXT\t\"" + strings.Repeat("a", 255) + "\" \"" + strings.Repeat("a", 45) + "\""},
+		{name: "TXT,quoted RR", record: libdns.RR{Name: "txt", TTL: 300 * time.Second, Type: "TXT", Data: `"a b" "c"`}, want: "txt.example.com.\t300\tIN\tTXT\t\"a bc\""},
+		{name: "generic", record: libdns.RR{Name: "www", TTL: 300 * time.Second, Type: "TYPE1", Data: `\# 4 c0000201`}, want: "www.example.com.\t300\tIN\tA\t192.0.2.1"},
+		{name: "alias", record: Alias{Name: "www", TTL: 300 * time.Second, RecordType: "A", TargetResourceID: "/subscriptions/x"}, wantErr: true},
+		{name: "invalid", record: libdns.RR{Name: "www", TTL: 300 * time.Second, Type: "MX", Data: "mail"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToDNSRR("example.com.", tt.record)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got.String(), tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_FromDNSRR(t *testing.T) {
+	tests := []struct {
+		name    string
+		rr      string
+		want    libdns.Record
+		wantErr bool
+	}{
+		{name: "A", rr: "www.example.com. 300 IN A 192.0.2.1", want: libdns.Address{Name: "www", TTL: 300 * time.Second, IP: netip.MustParseAddr("192.0.2.1")}},
+		{name: "A,apex", rr: "example.com. 300 IN A 192.0.2.1", want: libdns.Address{Name: "@", TTL: 300 * time.Second, IP: netip.MustParseAddr("192.0.2.1")}},
+		{name: "MX", rr: "example.com. 300 IN MX 10 mail.example.com.", want: libdns.MX{Name: "@", TTL: 300 * time.Second, Preference: 10, Target: "mail.example.com."}},
+		{name: "TXT", rr: `txt.example.com. 300 IN TXT "say \"hi\" \\ \195\169" "!"`, want: libdns.TXT{Name: "txt", TTL: 300 * time.Second, Text: `say "hi" \ é!`}},
+		{name: "unknown", rr: `www.example.com. 300 IN TYPE65280 \# 2 abcd`, want: libdns.RR{Name: "www", TTL: 300 * time.Second, Type: "TYPE65280", Data: `\# 2 abcd`}},
+		{name: "outside", rr: "www.example.net. 300 IN A 192.0.2.1", wantErr: true},
+		{name: "class", rr: "www.example.com. 300 CH A 192.0.2.1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, err := dns.NewRR(tt.rr)
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			got, err := FromDNSRR("example.com.", rr)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got, tt.want, recordComparer); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_DNSRR_roundTrip(t *testing.T) {
+	records := []libdns.Record{
+		libdns.TXT{Name: "txt", TTL: 300 * time.Second, Text: `quotes " backslashes \ unicode こんにちは ` + strings.Repeat("x", 300)},
+		libdns.SRV{Service: "sip", Transport: "tcp", Name: "@", TTL: 300 * time.Second, Priority: 10, Weight: 20, Port: 5060, Target: "sip.example.com."},
+		libdns.CAA{Name: "@", TTL: 300 * time.Second, Flags: 128, Tag: "iodef", Value: "mailto:security@example.com"},
+	}
+	for _, record := range records {
+		rr, err := ToDNSRR("example.com.", record)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		got, err := FromDNSRR("example.com.", rr)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if diff := cmp.Diff(got, record, recordComparer); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	}
+}
+
+func Test_ParseRecords(t *testing.T) {
+	text := `$TTL 600
+@     IN A     192.0.2.1
+www   300 IN CNAME @
+txt       IN TXT   "v=spf1" " -all"
+`
+	got, err := ParseRecords("example.com.", text)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	want := []libdns.Record{
+		libdns.Address{Name: "@", TTL: 600 * time.Second, IP: netip.MustParseAddr("192.0.2.1")},
+		libdns.CNAME{Name: "www", TTL: 300 * time.Second, Target: "example.com."},
+		libdns.TXT{Name: "txt", TTL: 600 * time.Second, Text: "v=spf1 -all"},
+	}
+	if diff := cmp.Diff(got, want, recordComparer); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+
+	if _, err := ParseRecords("example.com.", "www IN A not-an-address"); err == nil {
+		t.Errorf("expected an error for the invalid record")
+	}
+}
@@ -0,0 +1,130 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/libdns/libdns"
+)
+
+// MirrorResult is the result of mirroring a record to a mirror.
+type MirrorResult struct {
+	// Mirror is the mirror the record was written to.
+	Mirror libdns.RecordSetter
+
+	// Operation is the write operation mirrored.
+	Operation WriteOperation
+
+	// Zone is the name of the zone.
+	Zone string
+
+	// Record is the record written to the zone on Azure DNS.
+	Record libdns.Record
+
+	// Err is the error returned by the mirror, or nil if the record was mirrored successfully.
+	Err error
+}
+
+// mirror replicates a successful write to all the mirrors, reporting the result of each record.
+func (p *Provider) mirror(ctx context.Context, operation WriteOperation, zone string, records []libdns.Record) {
+	if len(p.Mirrors) == 0 || len(records) == 0 {
+		return
+	}
+
+	var results []MirrorResult
+	for _, mirror := range p.Mirrors {
+		err := p.mirrorTo(ctx, mirror, operation, zone, records)
+		results = append(results, newMirrorResults(mirror, operation, zone, records, err)...)
+	}
+
+	if p.OnMirror != nil {
+		p.OnMirror(results)
+		return
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			p.getLogger().Warn("failed to mirror a record",
+				slog.String("operation", string(result.Operation)),
+				slog.String("zone", result.Zone),
+				slog.String("name", result.Record.RR().Name),
+				slog.String("type", result.Record.RR().Type),
+				slog.Any("error", result.Err),
+			)
+		}
+	}
+}
+
+// mirrorTo replicates a write to the mirror.
+func (p *Provider) mirrorTo(ctx context.Context, mirror libdns.RecordSetter, operation WriteOperation, zone string, records []libdns.Record) error {
+	var err error
+	switch operation {
+	case WriteOperationAppend:
+		if appender, ok := mirror.(libdns.RecordAppender); ok {
+			_, err = appender.AppendRecords(ctx, zone, records)
+			break
+		}
+		// Set the whole record sets so that the existing values in the mirror are kept
+		var recordSetRecords []libdns.Record
+		recordSetRecords, err = p.getRecordSetRecords(ctx, zone, records)
+		if err == nil {
+			_, err = mirror.SetRecords(ctx, zone, recordSetRecords)
+		}
+	case WriteOperationSet:
+		_, err = mirror.SetRecords(ctx, zone, records)
+	case WriteOperationDelete:
+		if deleter, ok := mirror.(libdns.RecordDeleter); ok {
+			_, err = deleter.DeleteRecords(ctx, zone, records)
+			break
+		}
+		err = errors.New("the mirror does not support deleting records")
+	}
+	return err
+}
+
+// getRecordSetRecords gets all the records in the record sets that the records belong to.
+func (p *Provider) getRecordSetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return nil, err
+	}
+
+	var scopes []RecordSetScope
+	for _, recordGroup := range groupRecordsByRecordSet(records, zone, p.recordSetName) {
+		scopes = append(scopes, RecordSetScope{
+			Name: p.recordSetName(recordGroup[0].RR().Name, zone),
+			Type: recordGroup[0].RR().Type,
+		})
+	}
+
+	var recordSets []*armdns.RecordSet
+	err := p.retryOnAuthenticationError(func() error {
+		var err error
+		recordSets, err = p.getRecordSets(ctx, zone, scopes)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return convertAzureRecordSetsToLibdnsRecords(recordSets)
+}
+
+// newMirrorResults builds the result of each record mirrored to the mirror.
+// If the mirror failed, all the records are reported as failed, since it is unknown which of them were written.
+func newMirrorResults(mirror libdns.RecordSetter, operation WriteOperation, zone string, records []libdns.Record, err error) []MirrorResult {
+	results := make([]MirrorResult, len(records))
+	for i, record := range records {
+		results[i] = MirrorResult{
+			Mirror:    mirror,
+			Operation: operation,
+			Zone:      zone,
+			Record:    record,
+			Err:       err,
+		}
+	}
+	return results
+}
@@ -0,0 +1,108 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+// fakeSetter is a mirror that supports only setting records.
+type fakeSetter struct {
+	records []libdns.Record
+	err     error
+}
+
+func (f *fakeSetter) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.records = append(f.records, records...)
+	return records, nil
+}
+
+// fakeDeleter is a mirror that supports setting and deleting records.
+type fakeDeleter struct {
+	fakeSetter
+	deleted []libdns.Record
+}
+
+func (f *fakeDeleter) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	f.deleted = append(f.deleted, records...)
+	return records, nil
+}
+
+func Test_mirror(t *testing.T) {
+	t.Run("operation=append", func(t *testing.T) {
+		setter := &fakeSetter{}
+		provider := getFakeProvider()
+		provider.Mirrors = []libdns.RecordSetter{setter}
+		var results []MirrorResult
+		provider.OnMirror = func(r []MirrorResult) {
+			results = append(results, r...)
+		}
+		record := libdns.NS{Name: "record-ns", Target: "ns3.example.com"}
+		if _, err := provider.AppendRecords(context.TODO(), "example.com.", []libdns.Record{record}); err != nil {
+			t.Fatalf("%s", err)
+		}
+		// The whole record set is set since the mirror does not support appending records
+		var want []libdns.Record
+		for _, r := range libdnsFakeRecords {
+			if r.RR().Type == "NS" && r.RR().Name == "record-ns" {
+				want = append(want, r)
+			}
+		}
+		if diff := cmp.Diff(setter.records, want, recordComparer); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if len(results) != 1 || results[0].Err != nil || results[0].Operation != WriteOperationAppend {
+			t.Errorf("got: %v", results)
+		}
+	})
+	t.Run("operation=delete", func(t *testing.T) {
+		setter := &fakeSetter{}
+		deleter := &fakeDeleter{}
+		provider := getFakeProvider()
+		provider.Mirrors = []libdns.RecordSetter{setter, deleter}
+		var results []MirrorResult
+		provider.OnMirror = func(r []MirrorResult) {
+			results = append(results, r...)
+		}
+		if _, err := provider.DeleteRecords(context.TODO(), "example.com.", libdnsFakeRecords[:2]); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(results) != 4 {
+			t.Fatalf("got: %d, want: 4", len(results))
+		}
+		for _, result := range results[:2] {
+			if result.Mirror != setter || result.Err == nil {
+				t.Errorf("got: %v", result)
+			}
+		}
+		for _, result := range results[2:] {
+			if result.Mirror != deleter || result.Err != nil {
+				t.Errorf("got: %v", result)
+			}
+		}
+		if len(deleter.deleted) != 2 {
+			t.Errorf("got: %v", deleter.deleted)
+		}
+	})
+	t.Run("mirror=failed", func(t *testing.T) {
+		setter := &fakeSetter{err: errors.New("failed")}
+		provider := getFakeProvider()
+		provider.Mirrors = []libdns.RecordSetter{setter}
+		var results []MirrorResult
+		provider.OnMirror = func(r []MirrorResult) {
+			results = append(results, r...)
+		}
+		if _, err := provider.SetRecords(context.TODO(), "example.com.", libdnsFakeRecords[:1]); err != nil {
+			t.Fatalf("the failure of the mirror fails the call: %s", err)
+		}
+		if len(results) != 1 || results[0].Err == nil {
+			t.Errorf("got: %v", results)
+		}
+	})
+}
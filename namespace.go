@@ -0,0 +1,65 @@
+package azure
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// checkWritable throws an error if any of the records is outside what the provider is allowed to modify,
+// or if the zone is read-only, so that nothing is written when the records are rejected.
+// A fully-qualified name outside the zone, e.g. the parent "eu.example.com." of the zone "dev.eu.example.com.",
+// is rejected rather than taken as relative to the zone, unless Record Set Name is set to rewrite such names.
+func (p *Provider) checkWritable(zone string, records []libdns.Record) error {
+	if err := p.checkReadOnly(zone); err != nil {
+		return err
+	}
+	for _, record := range records {
+		rr := record.RR()
+		if p.RecordSetName == nil && zone != "" && strings.HasSuffix(rr.Name, ".") && !isWithinZone(rr.Name, zone) {
+			return fmt.Errorf("the record %v %v is outside the zone %v", rr.Name, rr.Type, zone)
+		}
+		name := p.recordSetName(rr.Name, zone)
+		if !p.isWithinNamespaces(name) {
+			return fmt.Errorf("the record %v %v is outside the namespaces %v of the provider", name, rr.Type, strings.Join(p.Namespaces, ", "))
+		}
+		if err := p.checkRules(name, rr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isWithinNamespaces reports whether the name relative to the zone matches any of the namespaces of the provider.
+// Any name is within the namespaces if no namespace is configured.
+func (p *Provider) isWithinNamespaces(name string) bool {
+	if len(p.Namespaces) == 0 {
+		return true
+	}
+	for _, namespace := range p.Namespaces {
+		if matchNamespace(namespace, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchNamespace reports whether the name relative to the zone matches the namespace, regardless of the case.
+// A namespace ending with ".*" matches the names whose leftmost labels are the prefix, e.g. "_acme-challenge.*"
+// matches "_acme-challenge" and "_acme-challenge.www". A namespace starting with "*." matches the names under the suffix,
+// e.g. "*.dev" matches "dev" and "www.dev". Any other namespace matches only the name itself.
+func matchNamespace(namespace string, name string) bool {
+	namespace = strings.ToLower(strings.TrimSuffix(namespace, "."))
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(namespace, ".*"):
+		prefix := strings.TrimSuffix(namespace, ".*")
+		return name == prefix || strings.HasPrefix(name, prefix+".")
+	case strings.HasPrefix(namespace, "*."):
+		suffix := strings.TrimPrefix(namespace, "*.")
+		return name == suffix || strings.HasSuffix(name, "."+suffix)
+	default:
+		return name == namespace
+	}
+}
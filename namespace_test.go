@@ -0,0 +1,124 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/libdns/libdns"
+)
+
+func Test_matchNamespace(t *testing.T) {
+	tests := []struct {
+		namespace string
+		name      string
+		want      bool
+	}{
+		{namespace: "_acme-challenge.*", name: "_acme-challenge", want: true},
+		{namespace: "_acme-challenge.*", name: "_acme-challenge.www", want: true},
+		{namespace: "_acme-challenge.*", name: "_ACME-Challenge.www", want: true},
+		{namespace: "_acme-challenge.*", name: "www._acme-challenge", want: false},
+		{namespace: "_acme-challenge.*", name: "_acme-challenge-www", want: false},
+		{namespace: "*.dev", name: "dev", want: true},
+		{namespace: "*.dev", name: "www.dev", want: true},
+		{namespace: "*.dev", name: "api.www.dev", want: true},
+		{namespace: "*.dev", name: "dev.www", want: false},
+		{namespace: "*.dev", name: "mydev", want: false},
+		{namespace: "@", name: "@", want: true},
+		{namespace: "www", name: "www", want: true},
+		{namespace: "www", name: "api.www", want: false},
+	}
+	for _, tt := range tests {
+		t.Run("namespace="+tt.namespace+",name="+tt.name, func(t *testing.T) {
+			if got := matchNamespace(tt.namespace, tt.name); got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkWritable(t *testing.T) {
+	operations := map[string]func(p *Provider, zone string, records []libdns.Record) error{
+		"append": func(p *Provider, zone string, records []libdns.Record) error {
+			_, err := p.AppendRecords(context.TODO(), zone, records)
+			return err
+		},
+		"set": func(p *Provider, zone string, records []libdns.Record) error {
+			_, err := p.SetRecords(context.TODO(), zone, records)
+			return err
+		},
+		"delete": func(p *Provider, zone string, records []libdns.Record) error {
+			_, err := p.DeleteRecords(context.TODO(), zone, records)
+			return err
+		},
+	}
+	tests := []struct {
+		name    string
+		zone    string
+		records []libdns.Record
+		wantErr bool
+	}{
+		{
+			name: "within",
+			zone: "example.com.",
+			records: []libdns.Record{
+				libdns.TXT{Name: "_acme-challenge", Text: "TEST VALUE", TTL: time.Minute},
+				libdns.TXT{Name: "_acme-challenge.www.example.com.", Text: "TEST VALUE", TTL: time.Minute},
+			},
+		},
+		{
+			name: "outside",
+			zone: "example.com.",
+			records: []libdns.Record{
+				libdns.TXT{Name: "_acme-challenge", Text: "TEST VALUE", TTL: time.Minute},
+				libdns.TXT{Name: "www", Text: "TEST VALUE", TTL: time.Minute},
+			},
+			wantErr: true,
+		},
+		{
+			name: "outside,routed",
+			zone: "",
+			records: []libdns.Record{
+				libdns.TXT{Name: "_acme-challenge.example.com.", Text: "TEST VALUE", TTL: time.Minute},
+				libdns.TXT{Name: "www.sub.example.com.", Text: "TEST VALUE", TTL: time.Minute},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		for operation, operate := range operations {
+			t.Run(tt.name+",operation="+operation, func(t *testing.T) {
+				writes := 0
+				fakeRecordSetsServer := getFakeRecordSetsServer()
+				createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+				fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+					writes++
+					return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+				}
+				deleteRecordSet := fakeRecordSetsServer.Delete
+				fakeRecordSetsServer.Delete = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientDeleteOptions) (resp azfake.Responder[armdns.RecordSetsClientDeleteResponse], errResp azfake.ErrorResponder) {
+					writes++
+					return deleteRecordSet(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, options)
+				}
+				provider := getFakeProviderWithServer(fakeRecordSetsServer)
+				provider.Namespaces = []string{"_acme-challenge.*"}
+				err := operate(&provider, tt.zone, tt.records)
+				if !tt.wantErr {
+					if err != nil && strings.Contains(err.Error(), "is outside the namespaces") {
+						t.Errorf("got: %v", err)
+					}
+					return
+				}
+				if err == nil || !strings.Contains(err.Error(), "is outside the namespaces _acme-challenge.*") {
+					t.Errorf("got: %v", err)
+				}
+				if writes != 0 {
+					t.Errorf("got: %d writes, want: 0", writes)
+				}
+			})
+		}
+	}
+}
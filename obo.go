@@ -0,0 +1,44 @@
+package azure
+
+import (
+	"errors"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// OnBehalfOf returns a provider with the same settings that authenticates on behalf of the signed-in user
+// whose access token for the application is the user assertion, e.g. the bearer token of a request to a web service,
+// so that the changes are made under the delegated permissions of the user instead of the broad ones of the service principal.
+// The service principal of Tenant ID, Client ID, and Client Secret exchanges the assertion for the tokens of the user,
+// and must be granted the user_impersonation permission of Azure Service Management.
+// Create a provider for each assertion, since it expires with the token of the user.
+func (p *Provider) OnBehalfOf(userAssertion string) (*Provider, error) {
+	if userAssertion == "" {
+		return nil, errors.New("the user assertion is required to authenticate on behalf of the user")
+	}
+
+	// The settings are read and copied with the client locked, so that a rotation by SetClientSecret is not copied halfway
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if p.TenantId == "" || p.ClientId == "" || p.ClientSecret == "" {
+		return nil, errors.New("the tenant ID, client ID, and client secret are required to authenticate on behalf of the user")
+	}
+
+	credentialOptions, err := p.getCredentialOptions()
+	if err != nil {
+		return nil, err
+	}
+	credential, err := azidentity.NewOnBehalfOfCredentialWithSecret(p.TenantId, p.ClientId, userAssertion, p.ClientSecret, &azidentity.OnBehalfOfCredentialOptions{
+		ClientOptions:              credentialOptions,
+		AdditionallyAllowedTenants: p.AdditionallyAllowedTenants,
+		DisableInstanceDiscovery:   p.DisableInstanceDiscovery,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	provider := p.derive(ZoneLocation{SubscriptionId: p.SubscriptionId, ResourceGroupName: p.ResourceGroupName})
+	provider.client.credential = credential
+	return provider, nil
+}
@@ -0,0 +1,56 @@
+package azure
+
+import (
+	"fmt"
+	"testing"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+)
+
+func Test_OnBehalfOf(t *testing.T) {
+	tests := []struct {
+		name          string
+		clientSecret  string
+		userAssertion string
+		wantErr       bool
+	}{
+		{name: "valid", clientSecret: "fake-client-secret", userAssertion: "fake-user-assertion"},
+		{name: "assertion=none", clientSecret: "fake-client-secret", userAssertion: "", wantErr: true},
+		{name: "secret=none", clientSecret: "", userAssertion: "fake-user-assertion", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &Provider{
+				SubscriptionId:    "subscription",
+				ResourceGroupName: "resource-group",
+				TenantId:          "fake-tenant-id",
+				ClientId:          "fake-client-id",
+				ClientSecret:      tt.clientSecret,
+			}
+			credential := &azfake.TokenCredential{}
+			provider.SetCredential(credential)
+			onBehalfOf, err := provider.OnBehalfOf(tt.userAssertion)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if got := fmt.Sprintf("%T", onBehalfOf.client.credential); got != "*azidentity.OnBehalfOfCredential" {
+				t.Errorf("got: %s, want: *azidentity.OnBehalfOfCredential", got)
+			}
+			if onBehalfOf.SubscriptionId != provider.SubscriptionId || onBehalfOf.ResourceGroupName != provider.ResourceGroupName {
+				t.Errorf("the location is not kept")
+			}
+			if provider.client.credential != credential {
+				t.Errorf("the credential of the provider is replaced")
+			}
+			if err := onBehalfOf.setupClient(); err != nil {
+				t.Errorf("%s", err)
+			}
+		})
+	}
+}
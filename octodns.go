@@ -0,0 +1,229 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+)
+
+// yamlPlain matches the strings that can be written as plain scalars in YAML without being read as another string or type.
+var yamlPlain = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._/+-]*$`)
+
+// yamlSpecial matches the plain scalars that YAML reads as numbers, booleans, or null.
+var yamlSpecial = regexp.MustCompile(`^(?i:[-+]?([0-9_]+(\.[0-9_]*)?|\.[0-9_]+)(e[-+]?[0-9]+)?|0x[0-9a-f]+|0o[0-7]+|y|yes|n|no|true|false|on|off|null|~)$`)
+
+// ExportOctoDNS renders the record sets of the zone as the YAML config of a zone for the YamlProvider of octoDNS,
+// so that teams managing zones with octoDNS can bootstrap from their zones on Azure DNS. The config is meant to be saved
+// as the file of the zone, e.g. "example.com.yaml". The names and the keys are in the order octoDNS enforces.
+// The SOA record set, the NS record set at the apex, which Azure DNS manages with the zone, and aliases of Azure resources,
+// which octoDNS cannot represent, are skipped.
+func (p *Provider) ExportOctoDNS(ctx context.Context, zone string, w io.Writer) error {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.ExportOctoDNS(ctx, zone, w)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	recordSets, err := p.listRecordSets(ctx, zone)
+	if err != nil {
+		return wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+
+	_, err = io.WriteString(w, renderOctoDNS(recordSets))
+	return err
+}
+
+// renderOctoDNS renders the record sets as the YAML config of a zone for octoDNS.
+func renderOctoDNS(recordSets []*armdns.RecordSet) string {
+	recordSetsByName := map[string][]*armdns.RecordSet{}
+	for _, recordSet := range recordSets {
+		name := stringValue(recordSet.Name)
+		typeName := recordSetTypeName(recordSet)
+		if _, ok := terraformResourceTypes[typeName]; !ok || (typeName == "NS" && name == "@") {
+			continue
+		}
+		if recordSet.Properties == nil || (recordSet.Properties.TargetResource != nil && recordSet.Properties.TargetResource.ID != nil) {
+			continue
+		}
+		// octoDNS names the apex with an empty string
+		if name == "@" {
+			name = ""
+		}
+		name = strings.ToLower(name)
+		recordSetsByName[name] = append(recordSetsByName[name], recordSet)
+	}
+
+	names := make([]string, 0, len(recordSetsByName))
+	for name := range recordSetsByName {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return naturalLess(names[i], names[j])
+	})
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	for _, name := range names {
+		group := recordSetsByName[name]
+		sort.Slice(group, func(i, j int) bool {
+			return recordSetTypeName(group[i]) < recordSetTypeName(group[j])
+		})
+		fmt.Fprintf(&b, "%s:\n", yamlString(name))
+		for _, recordSet := range group {
+			indent := "  "
+			if len(group) > 1 {
+				b.WriteString("  - ")
+				indent = "    "
+			} else {
+				b.WriteString(indent)
+			}
+			renderOctoDNSRecord(&b, indent, recordSet)
+		}
+	}
+	return b.String()
+}
+
+// renderOctoDNSRecord renders the record set as a record of octoDNS, whose first line is already indented.
+// The keys are in alphabetical order, i.e. ttl, type, and value or values.
+func renderOctoDNSRecord(b *strings.Builder, indent string, recordSet *armdns.RecordSet) {
+	typeName := recordSetTypeName(recordSet)
+	properties := recordSet.Properties
+	if properties.TTL != nil {
+		fmt.Fprintf(b, "ttl: %d\n%s", *properties.TTL, indent)
+	}
+	fmt.Fprintf(b, "type: %s\n", typeName)
+
+	var values []string
+	mappings := typeName == "CAA" || typeName == "MX" || typeName == "SRV"
+	switch typeName {
+	case "A":
+		for _, record := range properties.ARecords {
+			values = append(values, yamlString(stringValue(record.IPv4Address)))
+		}
+	case "AAAA":
+		for _, record := range properties.AaaaRecords {
+			values = append(values, yamlString(stringValue(record.IPv6Address)))
+		}
+	case "NS":
+		for _, record := range properties.NsRecords {
+			values = append(values, yamlString(stringValue(record.Nsdname)))
+		}
+	case "PTR":
+		for _, record := range properties.PtrRecords {
+			values = append(values, yamlString(stringValue(record.Ptrdname)))
+		}
+	case "CNAME":
+		if properties.CnameRecord != nil {
+			values = append(values, yamlString(stringValue(properties.CnameRecord.Cname)))
+		}
+	case "TXT":
+		for _, record := range properties.TxtRecords {
+			var value strings.Builder
+			for _, s := range record.Value {
+				value.WriteString(stringValue(s))
+			}
+			// octoDNS requires semicolons in TXT values to be escaped
+			values = append(values, yamlString(strings.ReplaceAll(value.String(), ";", `\;`)))
+		}
+	case "CAA":
+		for _, record := range properties.CaaRecords {
+			values = append(values, yamlMapping(indent, []string{
+				"flags", strconv.Itoa(int(int32Value(record.Flags))),
+				"tag", yamlString(stringValue(record.Tag)),
+				"value", yamlString(stringValue(record.Value)),
+			}))
+		}
+	case "MX":
+		for _, record := range properties.MxRecords {
+			values = append(values, yamlMapping(indent, []string{
+				"exchange", yamlString(stringValue(record.Exchange)),
+				"preference", strconv.Itoa(int(int32Value(record.Preference))),
+			}))
+		}
+	case "SRV":
+		for _, record := range properties.SrvRecords {
+			values = append(values, yamlMapping(indent, []string{
+				"port", strconv.Itoa(int(int32Value(record.Port))),
+				"priority", strconv.Itoa(int(int32Value(record.Priority))),
+				"target", yamlString(stringValue(record.Target)),
+				"weight", strconv.Itoa(int(int32Value(record.Weight))),
+			}))
+		}
+	}
+
+	if len(values) == 1 {
+		if mappings {
+			// A single mapping starts on the next line, indented as an item of a list would be
+			fmt.Fprintf(b, "%svalue:\n%s  %s\n", indent, indent, values[0])
+			return
+		}
+		fmt.Fprintf(b, "%svalue: %s\n", indent, values[0])
+		return
+	}
+	fmt.Fprintf(b, "%svalues:\n", indent)
+	for _, value := range values {
+		fmt.Fprintf(b, "%s- %s\n", indent, value)
+	}
+}
+
+// yamlMapping renders the pairs of keys and values as a YAML block mapping, continuing an item of a list indented by the indent.
+func yamlMapping(indent string, pairs []string) string {
+	var lines []string
+	for i := 0; i < len(pairs); i += 2 {
+		lines = append(lines, pairs[i]+": "+pairs[i+1])
+	}
+	return strings.Join(lines, "\n"+indent+"  ")
+}
+
+// yamlString renders the string as a YAML scalar, quoted unless it is read back as the same string when plain.
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !yamlSpecial.MatchString(s) {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// naturalLess reports whether a sorts before b with the runs of digits compared by their numeric values,
+// e.g. "host2" before "host10", as octoDNS orders the names of a zone.
+func naturalLess(a string, b string) bool {
+	for a != "" && b != "" {
+		aDigits, bDigits := isDigit(a[0]), isDigit(b[0])
+		if aDigits && bDigits {
+			i, j := digitRunLength(a), digitRunLength(b)
+			x, y := strings.TrimLeft(a[:i], "0"), strings.TrimLeft(b[:j], "0")
+			if len(x) != len(y) {
+				return len(x) < len(y)
+			}
+			if x != y {
+				return x < y
+			}
+			a, b = a[i:], b[j:]
+			continue
+		}
+		if aDigits != bDigits {
+			// Numbers sort before text
+			return aDigits
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// digitRunLength returns the number of the decimal digits at the start of the string.
+func digitRunLength(s string) int {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
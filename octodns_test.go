@@ -0,0 +1,142 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_ExportOctoDNS(t *testing.T) {
+	fakeRecordSetsServer := getFakeRecordSetsServer()
+	fakeRecordSetsServer.NewListByDNSZonePager = func(resourceGroupName string, zoneName string, options *armdns.RecordSetsClientListByDNSZoneOptions) (resp azfake.PagerResponder[armdns.RecordSetsClientListByDNSZoneResponse]) {
+		values := []*armdns.RecordSet{
+			{
+				Name:       to.Ptr("host10"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/CNAME"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](300), CnameRecord: &armdns.CnameRecord{Cname: to.Ptr("www.example.com.")}},
+			},
+			{
+				Name:       to.Ptr("@"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/SOA"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](3600), SoaRecord: &armdns.SoaRecord{Host: to.Ptr("ns1-01.azure-dns.com.")}},
+			},
+			{
+				Name:       to.Ptr("@"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/NS"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](172800), NsRecords: []*armdns.NsRecord{{Nsdname: to.Ptr("ns1-01.azure-dns.com.")}}},
+			},
+			{
+				Name: to.Ptr("@"),
+				Type: to.Ptr("Microsoft.Network/dnszones/TXT"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](3600), TxtRecords: []*armdns.TxtRecord{
+					{Value: []*string{to.Ptr("v=DKIM1; k=rsa")}},
+					{Value: []*string{to.Ptr("yes")}},
+				}},
+			},
+			{
+				Name:       to.Ptr("@"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/MX"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](3600), MxRecords: []*armdns.MxRecord{{Preference: to.Ptr[int32](10), Exchange: to.Ptr("mail.example.com.")}}},
+			},
+			{
+				Name: to.Ptr("host2"),
+				Type: to.Ptr("Microsoft.Network/dnszones/A"),
+				Properties: &armdns.RecordSetProperties{
+					TTL:      to.Ptr[int64](300),
+					ARecords: []*armdns.ARecord{{IPv4Address: to.Ptr("192.0.2.1")}, {IPv4Address: to.Ptr("192.0.2.2")}},
+				},
+			},
+			{
+				Name: to.Ptr("_sip._tcp"),
+				Type: to.Ptr("Microsoft.Network/dnszones/SRV"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](60), SrvRecords: []*armdns.SrvRecord{
+					{Priority: to.Ptr[int32](10), Weight: to.Ptr[int32](5), Port: to.Ptr[int32](5060), Target: to.Ptr("sip.example.com.")},
+					{Priority: to.Ptr[int32](20), Weight: to.Ptr[int32](0), Port: to.Ptr[int32](5060), Target: to.Ptr("sip2.example.com.")},
+				}},
+			},
+			{
+				Name:       to.Ptr("*.app"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/A"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](60), TargetResource: &armdns.SubResource{ID: to.Ptr("/subscriptions/x/resourceGroups/y/providers/Microsoft.Network/publicIPAddresses/z")}},
+			},
+			{
+				Name:       to.Ptr("*"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/CAA"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](3600), CaaRecords: []*armdns.CaaRecord{{Flags: to.Ptr[int32](0), Tag: to.Ptr("issue"), Value: to.Ptr("letsencrypt.org")}}},
+			},
+		}
+		resp.AddPage(http.StatusOK, armdns.RecordSetsClientListByDNSZoneResponse{
+			RecordSetListResult: armdns.RecordSetListResult{Value: values},
+		}, nil)
+		return
+	}
+	provider := getFakeProviderWithServer(fakeRecordSetsServer)
+
+	var b strings.Builder
+	if err := provider.ExportOctoDNS(context.TODO(), "example.com.", &b); err != nil {
+		t.Fatalf("%s", err)
+	}
+	want := `---
+"":
+  - ttl: 3600
+    type: MX
+    value:
+      exchange: mail.example.com.
+      preference: 10
+  - ttl: 3600
+    type: TXT
+    values:
+    - "v=DKIM1\\; k=rsa"
+    - "yes"
+"*":
+  ttl: 3600
+  type: CAA
+  value:
+    flags: 0
+    tag: issue
+    value: letsencrypt.org
+_sip._tcp:
+  ttl: 60
+  type: SRV
+  values:
+  - port: 5060
+    priority: 10
+    target: sip.example.com.
+    weight: 5
+  - port: 5060
+    priority: 20
+    target: sip2.example.com.
+    weight: 0
+host2:
+  ttl: 300
+  type: A
+  values:
+  - 192.0.2.1
+  - 192.0.2.2
+host10:
+  ttl: 300
+  type: CNAME
+  value: www.example.com.
+`
+	if diff := cmp.Diff(b.String(), want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
+
+func Test_naturalLess(t *testing.T) {
+	got := []string{"host10", "host2", "", "b", "a01", "a1b", "a"}
+	sort.Slice(got, func(i, j int) bool {
+		return naturalLess(got[i], got[j])
+	})
+	want := []string{"", "a", "a01", "a1b", "b", "host2", "host10"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
@@ -0,0 +1,131 @@
+package azure
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// subscriptionKey is the context key for the subscription ID overriding the one of the provider.
+type subscriptionKey struct{}
+
+// resourceGroupKey is the context key for the resource group name overriding the one of the provider.
+type resourceGroupKey struct{}
+
+// WithSubscription returns a copy of ctx that redirects the calls of a provider to the subscription,
+// so that a single provider can serve zones in many subscriptions, e.g. of the tenants of a multi-tenant service.
+// The other settings of the provider, including the credentials, are used as they are.
+func WithSubscription(ctx context.Context, subscriptionID string) context.Context {
+	return context.WithValue(ctx, subscriptionKey{}, subscriptionID)
+}
+
+// WithResourceGroup returns a copy of ctx that redirects the calls of a provider to the resource group,
+// in the subscription of the provider or the one set by WithSubscription.
+func WithResourceGroup(ctx context.Context, resourceGroupName string) context.Context {
+	return context.WithValue(ctx, resourceGroupKey{}, resourceGroupName)
+}
+
+// overrides holds the providers derived from a provider for the locations set by contexts,
+// and for the private DNS zones in the locations.
+// The generation is advanced whenever they are dropped, so that a provider derived from settings replaced meanwhile is not cached.
+type overrides struct {
+	providers        map[ZoneLocation]*Provider
+	privateProviders map[ZoneLocation]*Provider
+	generation       int
+	mutex            sync.Mutex
+}
+
+// forContext returns the provider for the subscription and resource group set by the context, if they differ from those of the provider.
+// It returns p itself if the context sets neither.
+func (p *Provider) forContext(ctx context.Context) *Provider {
+	location := ZoneLocation{
+		SubscriptionId:    p.SubscriptionId,
+		ResourceGroupName: p.ResourceGroupName,
+	}
+	if subscriptionID, ok := ctx.Value(subscriptionKey{}).(string); ok && subscriptionID != "" {
+		location.SubscriptionId = subscriptionID
+	}
+	if resourceGroupName, ok := ctx.Value(resourceGroupKey{}).(string); ok && resourceGroupName != "" {
+		location.ResourceGroupName = resourceGroupName
+	}
+	return p.forLocation(location)
+}
+
+// forLocation returns the provider for the location, or p itself if the location is that of the provider.
+// The provider is derived from p with the same settings on first use and cached, so that its client and token are reused.
+func (p *Provider) forLocation(location ZoneLocation) *Provider {
+	if location.SubscriptionId == p.SubscriptionId && location.ResourceGroupName == p.ResourceGroupName {
+		return p
+	}
+	return p.getOverride(&p.overrides.providers, location, func(provider *Provider) {})
+}
+
+// getOverride returns the provider for the location cached in providers, one of the maps of the overrides.
+// If there is none, it is derived from p, adjusted by configure, and cached.
+func (p *Provider) getOverride(providers *map[ZoneLocation]*Provider, location ZoneLocation, configure func(provider *Provider)) *Provider {
+	p.overrides.mutex.Lock()
+	provider, ok := (*providers)[location]
+	generation := p.overrides.generation
+	p.overrides.mutex.Unlock()
+	if ok {
+		return provider
+	}
+
+	// The settings are copied before locking the overrides, since resetting them is called with the client locked
+	p.client.mutex.Lock()
+	provider = p.derive(location)
+	p.client.mutex.Unlock()
+	configure(provider)
+
+	p.overrides.mutex.Lock()
+	defer p.overrides.mutex.Unlock()
+
+	if cached, ok := (*providers)[location]; ok {
+		return cached
+	}
+	// A provider derived from the settings replaced by SetClientSecret or SetCredential meanwhile serves this call only
+	if p.overrides.generation != generation {
+		return provider
+	}
+	if *providers == nil {
+		*providers = map[ZoneLocation]*Provider{}
+	}
+	(*providers)[location] = provider
+	return provider
+}
+
+// derive returns a new provider for the location with the same settings as p, copying all the exported fields
+// and the credential set by SetCredential, but none of the state such as the clients and the caches.
+// It must be called with the client locked, so that the settings rotated by SetClientSecret or SetCredential
+// are copied either whole or not at all.
+func (p *Provider) derive(location ZoneLocation) *Provider {
+	provider := &Provider{}
+	copySettings(provider, p)
+	provider.SubscriptionId = location.SubscriptionId
+	provider.ResourceGroupName = location.ResourceGroupName
+	provider.client.credential = p.client.credential
+	provider.private = p.private
+
+	return provider
+}
+
+// copySettings copies all the exported fields of src to dst.
+func copySettings(dst *Provider, src *Provider) {
+	srcValue := reflect.ValueOf(src).Elem()
+	dstValue := reflect.ValueOf(dst).Elem()
+	for i := 0; i < srcValue.NumField(); i++ {
+		if srcValue.Type().Field(i).IsExported() {
+			dstValue.Field(i).Set(srcValue.Field(i))
+		}
+	}
+}
+
+// resetOverrides drops the providers derived for the locations set by contexts, so that they are derived again with the current settings.
+func (p *Provider) resetOverrides() {
+	p.overrides.mutex.Lock()
+	defer p.overrides.mutex.Unlock()
+
+	p.overrides.providers = nil
+	p.overrides.privateProviders = nil
+	p.overrides.generation++
+}
@@ -0,0 +1,122 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/libdns/libdns"
+)
+
+func getFakeProviderCapturingPaths(paths *[]string) *Provider {
+	return getFakeProviderWithTransport(func(req *http.Request, next policy.Transporter) (*http.Response, error) {
+		*paths = append(*paths, req.URL.Path)
+		return next.Do(req)
+	})
+}
+
+func Test_forContext(t *testing.T) {
+	tests := []struct {
+		name              string
+		ctx               context.Context
+		wantSubscription  string
+		wantResourceGroup string
+	}{
+		{
+			name:              "override=none",
+			ctx:               context.TODO(),
+			wantSubscription:  "fake-subscription-id",
+			wantResourceGroup: "fake-resource-group-name",
+		},
+		{
+			name:              "override=same",
+			ctx:               WithResourceGroup(context.TODO(), "fake-resource-group-name"),
+			wantSubscription:  "fake-subscription-id",
+			wantResourceGroup: "fake-resource-group-name",
+		},
+		{
+			name:              "override=resourcegroup",
+			ctx:               WithResourceGroup(context.TODO(), "other-resource-group-name"),
+			wantSubscription:  "fake-subscription-id",
+			wantResourceGroup: "other-resource-group-name",
+		},
+		{
+			name:              "override=subscription,resourcegroup",
+			ctx:               WithSubscription(WithResourceGroup(context.TODO(), "other-resource-group-name"), "other-subscription-id"),
+			wantSubscription:  "other-subscription-id",
+			wantResourceGroup: "other-resource-group-name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := getFakeProvider()
+			provider.TTLConflictPolicy = TTLConflictPolicyLowest
+			got := provider.forContext(tt.ctx)
+			if got.SubscriptionId != tt.wantSubscription || got.ResourceGroupName != tt.wantResourceGroup {
+				t.Errorf("got: %v/%v, want: %v/%v", got.SubscriptionId, got.ResourceGroupName, tt.wantSubscription, tt.wantResourceGroup)
+			}
+			if got.TTLConflictPolicy != TTLConflictPolicyLowest {
+				t.Errorf("the settings are not copied")
+			}
+			wantSame := tt.wantSubscription == provider.SubscriptionId && tt.wantResourceGroup == provider.ResourceGroupName
+			if (got == &provider) != wantSame {
+				t.Errorf("got: %p, provider: %p", got, &provider)
+			}
+			if provider.forContext(tt.ctx) != got {
+				t.Errorf("the derived provider is not cached")
+			}
+		})
+	}
+	t.Run("override=resourcegroup,rotated", func(t *testing.T) {
+		provider := getFakeProvider()
+		ctx := WithResourceGroup(context.TODO(), "other-resource-group-name")
+		derived := provider.forContext(ctx)
+		provider.SetClientSecret("new-client-secret")
+		rotated := provider.forContext(ctx)
+		if rotated == derived || rotated.ClientSecret != "new-client-secret" {
+			t.Errorf("the derived provider is not rebuilt with the new secret")
+		}
+	})
+	t.Run("override=resourcegroup,rotating", func(t *testing.T) {
+		provider := getFakeProvider()
+		ctx := WithResourceGroup(context.TODO(), "other-resource-group-name")
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				provider.SetClientSecret("new-client-secret")
+			}
+		}()
+		for i := 0; i < 100; i++ {
+			if secret := provider.forContext(ctx).ClientSecret; secret != "" && secret != "new-client-secret" {
+				t.Errorf("got: %v, want: either secret", secret)
+			}
+		}
+		<-done
+		if rotated := provider.forContext(ctx); rotated.ClientSecret != "new-client-secret" {
+			t.Errorf("got: %v, want: new-client-secret", rotated.ClientSecret)
+		}
+	})
+}
+
+func Test_WithResourceGroup(t *testing.T) {
+	var paths []string
+	provider := getFakeProviderCapturingPaths(&paths)
+	ctx := WithSubscription(WithResourceGroup(context.TODO(), "other-resource-group-name"), "other-subscription-id")
+	if _, err := provider.SetRecords(ctx, "example.com.", []libdns.Record{
+		libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Duration(30) * time.Second},
+	}); err != nil {
+		t.Fatalf("%s", err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("no requests are sent through the derived provider")
+	}
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/subscriptions/other-subscription-id/resourceGroups/other-resource-group-name/") {
+			t.Errorf("got: %v", path)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package azure
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+)
+
+// ownerMetadataKey is the key of the metadata stamped on record sets with the owner ID of the provider that created them.
+const ownerMetadataKey = "libdns_owner"
+
+// enforcesOwnership reports whether the provider refuses to modify record sets stamped by a different owner.
+func (p *Provider) enforcesOwnership() bool {
+	return p.OwnerId != "" && !p.OverrideOwnership
+}
+
+// stampOwner stamps the owner ID of the provider, if any, on the properties of a record set to be created.
+func (p *Provider) stampOwner(properties *armdns.RecordSetProperties) {
+	if p.OwnerId == "" {
+		return
+	}
+	if properties.Metadata == nil {
+		properties.Metadata = map[string]*string{}
+	}
+	properties.Metadata[ownerMetadataKey] = to.Ptr(p.OwnerId)
+}
+
+// checkOwner throws an error if the record set is stamped by an owner other than the provider and ownership is enforced.
+// Record sets without an owner are not restricted.
+func (p *Provider) checkOwner(recordSet *armdns.RecordSet, name string, typeName string) error {
+	if !p.enforcesOwnership() {
+		return nil
+	}
+	owner := getRecordSetOwner(recordSet)
+	if owner != "" && owner != p.OwnerId {
+		return fmt.Errorf("the record set %v %v is owned by %v", name, typeName, owner)
+	}
+	return nil
+}
+
+// getRecordSetOwner returns the owner ID stamped on the record set, or an empty string if not stamped.
+func getRecordSetOwner(recordSet *armdns.RecordSet) string {
+	return getMetadata(recordSet, ownerMetadataKey)
+}
+
+// getMetadata returns the metadata of the key on the record set, or an empty string if there is none.
+// Metadata keys are compared case-insensitively, since Azure DNS does not preserve their case.
+func getMetadata(recordSet *armdns.RecordSet, key string) string {
+	if recordSet.Properties == nil {
+		return ""
+	}
+	for existingKey, value := range recordSet.Properties.Metadata {
+		if strings.EqualFold(existingKey, key) {
+			return stringValue(value)
+		}
+	}
+	return ""
+}
+
+// setMetadata sets the metadata of the key on the properties, replacing the existing metadata whose keys differ only in case.
+func setMetadata(properties *armdns.RecordSetProperties, key string, value string) {
+	if properties.Metadata == nil {
+		properties.Metadata = map[string]*string{}
+	}
+	deleteMetadata(properties, key)
+	properties.Metadata[key] = to.Ptr(value)
+}
+
+// deleteMetadata deletes the metadata of the key from the properties, in whatever case its key is.
+func deleteMetadata(properties *armdns.RecordSetProperties, key string) {
+	for existingKey := range properties.Metadata {
+		if strings.EqualFold(existingKey, key) {
+			delete(properties.Metadata, existingKey)
+		}
+	}
+}
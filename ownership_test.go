@@ -0,0 +1,185 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+// getOwnedTXTRecordSet returns the TXT record set record-txt stamped with the owner, or unstamped if the owner is empty.
+func getOwnedTXTRecordSet(owner string) *armdns.RecordSet {
+	recordSet := &armdns.RecordSet{
+		Name: to.Ptr("record-txt"),
+		Type: to.Ptr("Microsoft.Network/dnszones/TXT"),
+		Etag: to.Ptr("ETAG_TXT"),
+		Properties: &armdns.RecordSetProperties{
+			TTL:        to.Ptr[int64](30),
+			TxtRecords: []*armdns.TxtRecord{{Value: []*string{to.Ptr("TEST VALUE")}}},
+		},
+	}
+	if owner != "" {
+		// Azure DNS does not preserve the case of metadata keys
+		recordSet.Properties.Metadata = map[string]*string{"Libdns_owner": to.Ptr(owner)}
+	}
+	return recordSet
+}
+
+func Test_ownership(t *testing.T) {
+	operations := map[string]func(p *Provider) error{
+		"append": func(p *Provider) error {
+			_, err := p.createRecords(context.TODO(), "example.com.", []libdns.Record{
+				libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Duration(30) * time.Second},
+			})
+			return err
+		},
+		"set": func(p *Provider) error {
+			_, err := p.updateRecords(context.TODO(), "example.com.", []libdns.Record{
+				libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Duration(30) * time.Second},
+			})
+			return err
+		},
+		"delete": func(p *Provider) error {
+			_, err := p.deleteRecords(context.TODO(), "example.com.", []libdns.Record{
+				libdns.TXT{Name: "record-txt", Text: "TEST VALUE"},
+			}, DeleteOptions{})
+			return err
+		},
+		"delete-set": func(p *Provider) error {
+			_, err := p.deleteRecords(context.TODO(), "example.com.", []libdns.Record{
+				libdns.TXT{Name: "record-txt"},
+			}, DeleteOptions{})
+			return err
+		},
+	}
+	tests := []struct {
+		name     string
+		ownerId  string
+		override bool
+		owner    string
+		wantErr  bool
+	}{
+		{name: "owner=none", ownerId: "", owner: "team-b", wantErr: false},
+		{name: "owner=same", ownerId: "team-a", owner: "team-a", wantErr: false},
+		{name: "owner=unstamped", ownerId: "team-a", owner: "", wantErr: false},
+		{name: "owner=other", ownerId: "team-a", owner: "team-b", wantErr: true},
+		{name: "owner=other,override", ownerId: "team-a", override: true, owner: "team-b", wantErr: false},
+	}
+	for _, tt := range tests {
+		for operation, operate := range operations {
+			t.Run(tt.name+",operation="+operation, func(t *testing.T) {
+				zone := newFakeZone(getOwnedTXTRecordSet(tt.owner))
+				provider := getFakeProviderWithZone(zone)
+				provider.OwnerId = tt.ownerId
+				provider.OverrideOwnership = tt.override
+				err := operate(provider)
+				if tt.wantErr {
+					if err == nil || !strings.Contains(err.Error(), "is owned by team-b") {
+						t.Errorf("got: %v", err)
+					}
+					if diff := cmp.Diff(zone.calls, []string{"get"}); diff != "" {
+						t.Errorf("diff: %s", diff)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("%s", err)
+				}
+			})
+		}
+	}
+}
+
+func Test_ownership_deleteRecordSet(t *testing.T) {
+	tests := []struct {
+		name      string
+		ownerId   string
+		override  bool
+		wantCalls []string
+	}{
+		{name: "owner=none", ownerId: "", wantCalls: []string{"delete"}},
+		{name: "owner=team-a", ownerId: "team-a", wantCalls: []string{"get", "delete"}},
+		{name: "owner=team-a,override", ownerId: "team-a", override: true, wantCalls: []string{"delete"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := newFakeZone(getOwnedTXTRecordSet("team-a"))
+			provider := getFakeProviderWithZone(zone)
+			provider.OwnerId = tt.ownerId
+			provider.OverrideOwnership = tt.override
+			if _, err := provider.deleteRecords(context.TODO(), "example.com.", []libdns.Record{
+				libdns.TXT{Name: "record-txt"},
+			}, DeleteOptions{}); err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(zone.calls, tt.wantCalls); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_stampOwner(t *testing.T) {
+	tests := []struct {
+		name    string
+		ownerId string
+		want    map[string]*string
+	}{
+		{name: "owner=none", ownerId: "", want: nil},
+		{name: "owner=team-a", ownerId: "team-a", want: map[string]*string{"libdns_owner": to.Ptr("team-a")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := newFakeZone(getOwnedTXTRecordSet(""))
+			provider := getFakeProviderWithZone(zone)
+			provider.OwnerId = tt.ownerId
+			if _, err := provider.createRecords(context.TODO(), "example.com.", []libdns.Record{
+				libdns.TXT{Name: "record-new", Text: "NEW VALUE", TTL: time.Duration(30) * time.Second},
+			}); err != nil {
+				t.Fatalf("%s", err)
+			}
+			if len(zone.written) != 1 {
+				t.Fatalf("got: %d record sets, want: 1", len(zone.written))
+			}
+			if diff := cmp.Diff(zone.written[0].Properties.Metadata, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_setMetadata(t *testing.T) {
+	properties := &armdns.RecordSetProperties{
+		Metadata: map[string]*string{"Libdns_owner": to.Ptr("old"), "other": to.Ptr("kept")},
+	}
+	setMetadata(properties, ownerMetadataKey, "new")
+	want := map[string]*string{"libdns_owner": to.Ptr("new"), "other": to.Ptr("kept")}
+	if diff := cmp.Diff(properties.Metadata, want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
+
+func Test_getMetadata(t *testing.T) {
+	tests := []struct {
+		name      string
+		recordSet *armdns.RecordSet
+		want      string
+	}{
+		{name: "metadata=exact", recordSet: &armdns.RecordSet{Properties: &armdns.RecordSetProperties{Metadata: map[string]*string{"libdns_owner": to.Ptr("owner")}}}, want: "owner"},
+		{name: "metadata=case", recordSet: &armdns.RecordSet{Properties: &armdns.RecordSetProperties{Metadata: map[string]*string{"Libdns_Owner": to.Ptr("owner")}}}, want: "owner"},
+		{name: "metadata=none", recordSet: &armdns.RecordSet{Properties: &armdns.RecordSetProperties{}}, want: ""},
+		{name: "properties=nil", recordSet: &armdns.RecordSet{}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getMetadata(tt.recordSet, ownerMetadataKey); got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+// permissionsAPIVersion is the API version of the Microsoft.Authorization permissions API.
+const permissionsAPIVersion = "2022-04-01"
+
+// permission is the set of actions granted to the caller by a role assignment.
+type permission struct {
+	Actions    []string `json:"actions"`
+	NotActions []string `json:"notActions"`
+}
+
+// permissionListResult is a page of the permissions granted to the caller.
+type permissionListResult struct {
+	Value    []permission `json:"value"`
+	NextLink *string      `json:"nextLink"`
+}
+
+// checkPermissions gets the permissions granted to the identity on the zone and returns the required actions that are not allowed.
+func (p *Provider) checkPermissions(ctx context.Context, zone string) ([]string, error) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return nil, err
+	}
+
+	if len(p.ScopedRecordSets) > 0 {
+		return p.checkScopedPermissions(ctx, zone)
+	}
+
+	var permissions []permission
+	err := p.retryOnAuthenticationError(func() error {
+		var err error
+		permissions, err = p.getPermissions(ctx, p.generateZoneID(zone))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var missingActions []string
+	for _, action := range requiredActions() {
+		if !isActionAllowed(action, permissions) {
+			missingActions = append(missingActions, action)
+		}
+	}
+
+	return missingActions, nil
+}
+
+// checkScopedPermissions gets the permissions granted to the identity on each scoped record set
+// and returns the required actions that are not allowed on any of them.
+func (p *Provider) checkScopedPermissions(ctx context.Context, zone string) ([]string, error) {
+	var missingActions []string
+	missing := map[string]bool{}
+	for _, scope := range p.ScopedRecordSets {
+		var permissions []permission
+		err := p.retryOnAuthenticationError(func() error {
+			var err error
+			permissions, err = p.getPermissions(ctx, p.generateRecordSetID(zone, scope))
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, action := range requiredRecordSetActions(scope.Type) {
+			if !missing[action] && !isActionAllowed(action, permissions) {
+				missing[action] = true
+				missingActions = append(missingActions, action)
+			}
+		}
+	}
+
+	return missingActions, nil
+}
+
+// getPermissions gets all the permissions granted to the identity on the resource.
+func (p *Provider) getPermissions(ctx context.Context, resourceID string) ([]permission, error) {
+	var permissions []permission
+
+	endpoint := runtime.JoinPaths(p.client.armClient.Endpoint(), resourceID, "providers/Microsoft.Authorization/permissions") + "?api-version=" + permissionsAPIVersion
+	for endpoint != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		req.Raw().Header["Accept"] = []string{"application/json"}
+
+		resp, err := p.client.armClient.Pipeline().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+
+		var result permissionListResult
+		if err := runtime.UnmarshalAsJSON(resp, &result); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, result.Value...)
+
+		endpoint = ""
+		if result.NextLink != nil {
+			endpoint = *result.NextLink
+		}
+	}
+
+	return permissions, nil
+}
+
+// generateZoneID generates the resource ID of the zone on Azure Resource Manager.
+func (p *Provider) generateZoneID(zone string) string {
+	zoneType := "dnszones"
+	if p.private {
+		zoneType = "privateDnsZones"
+	}
+	return fmt.Sprintf(
+		"/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/%s/%s",
+		p.SubscriptionId,
+		p.ResourceGroupName,
+		zoneType,
+		strings.TrimSuffix(zone, "."),
+	)
+}
+
+// generateRecordSetID generates the resource ID of the record set on Azure Resource Manager.
+func (p *Provider) generateRecordSetID(zone string, scope RecordSetScope) string {
+	return p.generateZoneID(zone) + "/" + scope.Type + "/" + p.recordSetName(scope.Name, zone)
+}
+
+// generateListScope generates the resource ID of the scope in which zones are listed with the options.
+func (p *Provider) generateListScope(options ListZonesOptions) string {
+	if options.ResourceGroupName != "" {
+		return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", p.SubscriptionId, options.ResourceGroupName)
+	}
+	return fmt.Sprintf("/subscriptions/%s", p.SubscriptionId)
+}
+
+// authorizationFailedActionPattern extracts the action from the message of an AuthorizationFailed error.
+var authorizationFailedActionPattern = regexp.MustCompile(`perform action '([^']+)'`)
+
+// enrichAuthorizationError converts an AuthorizationFailed error to AuthorizationError with the missing action and the scope to assign a role on,
+// and a common failure to acquire a token to CredentialError. The failures of a *BatchError are converted one by one.
+// Other errors are returned as is.
+func enrichAuthorizationError(err error, scope string) error {
+	var batchError *BatchError
+	if errors.As(err, &batchError) {
+		for i, failure := range batchError.Failures {
+			batchError.Failures[i].Err = enrichAuthorizationError(failure.Err, scope)
+		}
+		return err
+	}
+
+	var responseError *azcore.ResponseError
+	if !errors.As(err, &responseError) || responseError.ErrorCode != "AuthorizationFailed" {
+		return enrichCredentialError(err)
+	}
+
+	action := ""
+	if matches := authorizationFailedActionPattern.FindStringSubmatch(err.Error()); matches != nil {
+		action = matches[1]
+	}
+
+	return &AuthorizationError{
+		Action: action,
+		Scope:  scope,
+		Err:    err,
+	}
+}
+
+// requiredActions returns the actions that the identity needs on the zone to use all the features of the provider.
+func requiredActions() []string {
+	actions := []string{
+		"Microsoft.Network/dnszones/read",
+		"Microsoft.Network/dnszones/recordsets/read",
+	}
+	for _, typeName := range supportedRecordTypes {
+		actions = append(actions, requiredRecordSetActions(typeName)...)
+	}
+	return actions
+}
+
+// requiredRecordSetActions returns the actions that the identity needs on a record set of the type.
+func requiredRecordSetActions(typeName string) []string {
+	actions := []string{
+		"Microsoft.Network/dnszones/" + typeName + "/read",
+		"Microsoft.Network/dnszones/" + typeName + "/write",
+	}
+	// The SOA record set cannot be deleted
+	if typeName != "SOA" {
+		actions = append(actions, "Microsoft.Network/dnszones/"+typeName+"/delete")
+	}
+	return actions
+}
+
+// isActionAllowed reports whether any of the permissions allows the action without excluding it.
+func isActionAllowed(action string, permissions []permission) bool {
+	for _, permission := range permissions {
+		allowed := false
+		for _, pattern := range permission.Actions {
+			if matchAction(pattern, action) {
+				allowed = true
+				break
+			}
+		}
+		for _, pattern := range permission.NotActions {
+			if matchAction(pattern, action) {
+				allowed = false
+				break
+			}
+		}
+		if allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAction reports whether the action matches the pattern, which may contain wildcards, case-insensitively.
+func matchAction(pattern string, action string) bool {
+	expression := "(?i)^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+	matched, err := regexp.MatchString(expression, action)
+	return err == nil && matched
+}
@@ -0,0 +1,154 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func getFakeProviderWithPermissions(permissions string) *Provider {
+	return getFakeProviderWithTransport(func(req *http.Request, next policy.Transporter) (*http.Response, error) {
+		if !strings.HasSuffix(req.URL.Path, "/providers/Microsoft.Authorization/permissions") {
+			return next.Do(req)
+		}
+		return newFakeJSONResponse(req, http.StatusOK, permissions), nil
+	})
+}
+
+func Test_checkPermissions(t *testing.T) {
+	t.Run("role=DNS Zone Contributor", func(t *testing.T) {
+		provider := getFakeProviderWithPermissions(`{"value":[{"actions":["Microsoft.Authorization/*/read","Microsoft.Network/dnsZones/*"],"notActions":[]}]}`)
+		got, err := provider.checkPermissions(context.TODO(), "example.com.")
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		if got != nil {
+			t.Errorf("got: %v, want: nil", got)
+		}
+	})
+	t.Run("role=TXT only", func(t *testing.T) {
+		provider := getFakeProviderWithPermissions(`{"value":[{"actions":["Microsoft.Network/dnszones/*"],"notActions":["Microsoft.Network/dnszones/*/write","Microsoft.Network/dnszones/*/delete"]},{"actions":["Microsoft.Network/dnszones/TXT/*"],"notActions":[]}]}`)
+		got, err := provider.checkPermissions(context.TODO(), "example.com.")
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		var want []string
+		for _, typeName := range supportedRecordTypes {
+			if typeName == "TXT" {
+				continue
+			}
+			want = append(want, "Microsoft.Network/dnszones/"+typeName+"/write")
+			if typeName != "SOA" {
+				want = append(want, "Microsoft.Network/dnszones/"+typeName+"/delete")
+			}
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("role=none", func(t *testing.T) {
+		provider := getFakeProviderWithPermissions(`{"value":[]}`)
+		got, err := provider.checkPermissions(context.TODO(), "example.com.")
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+		if diff := cmp.Diff(got, requiredActions()); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
+
+func Test_checkScopedPermissions(t *testing.T) {
+	provider := getFakeProviderWithPermissions(`{"value":[{"actions":["Microsoft.Network/dnszones/TXT/*"],"notActions":[]}]}`)
+	provider.ScopedRecordSets = []RecordSetScope{
+		{Name: "_acme-challenge", Type: "TXT"},
+		{Name: "_acme-challenge.www", Type: "TXT"},
+		{Name: "@", Type: "CAA"},
+	}
+	got, err := provider.checkPermissions(context.TODO(), "example.com.")
+	if err != nil {
+		t.Errorf("%s", err)
+	}
+	if diff := cmp.Diff(got, requiredRecordSetActions("CAA")); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+	want := "/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/dnszones/example.com/TXT/_acme-challenge"
+	if diff := cmp.Diff(provider.generateRecordSetID("example.com.", provider.ScopedRecordSets[0]), want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
+
+func Test_matchAction(t *testing.T) {
+	tests := map[string]bool{
+		"*":                                    true,
+		"Microsoft.Network/*":                  true,
+		"microsoft.network/dnszones/txt/write": true,
+		"Microsoft.Network/dnszones/*/write":   true,
+		"Microsoft.Network/dnszones/*/read":    false,
+		"Microsoft.Network/dnszones/TXT":       false,
+	}
+	for pattern, want := range tests {
+		t.Run("pattern="+pattern, func(t *testing.T) {
+			got := matchAction(pattern, "Microsoft.Network/dnsZones/TXT/write")
+			if got != want {
+				t.Errorf("got: %v, want: %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_enrichAuthorizationError(t *testing.T) {
+	t.Run("error=AuthorizationFailed", func(t *testing.T) {
+		body := `{"error":{"code":"AuthorizationFailed","message":"The client 'client-id' with object id 'object-id' does not have authorization to perform action 'Microsoft.Network/dnszones/TXT/write' over scope '/subscriptions/fake-subscription-id' or the scope is invalid."}}`
+		req, _ := http.NewRequest(http.MethodPut, "https://management.azure.com/", nil)
+		resp := &http.Response{
+			StatusCode: http.StatusForbidden,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}
+		err := enrichAuthorizationError(runtime.NewResponseError(resp), "/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/dnszones/example.com")
+		var authorizationError *AuthorizationError
+		if !errors.As(err, &authorizationError) {
+			t.Fatalf("got: %T, want: %T", err, authorizationError)
+		}
+		if diff := cmp.Diff(authorizationError.Action, "Microsoft.Network/dnszones/TXT/write"); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if !strings.Contains(err.Error(), "assign the 'DNS Zone Contributor' role, or a custom role allowing the action, on /subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/dnszones/example.com") {
+			t.Errorf("the hint is not included in the error: %s", err)
+		}
+	})
+	t.Run("error=provider", func(t *testing.T) {
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		fakeRecordSetsServer.Delete = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientDeleteOptions) (resp azfake.Responder[armdns.RecordSetsClientDeleteResponse], errResp azfake.ErrorResponder) {
+			errResp.SetResponseError(http.StatusForbidden, "AuthorizationFailed")
+			return
+		}
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		_, err := provider.DeleteRecords(context.TODO(), "example.com.", libdnsFakeRecords[:1])
+		var authorizationError *AuthorizationError
+		if !errors.As(err, &authorizationError) {
+			t.Fatalf("got: %T, want: %T", err, authorizationError)
+		}
+		if diff := cmp.Diff(authorizationError.Scope, provider.generateZoneID("example.com.")); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("error=other", func(t *testing.T) {
+		err := errors.New("other")
+		if got := enrichAuthorizationError(err, "/subscriptions/fake-subscription-id"); got != err {
+			t.Errorf("got: %v, want: %v", got, err)
+		}
+	})
+}
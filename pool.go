@@ -0,0 +1,314 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/libdns/libdns"
+)
+
+// Pool routes libdns calls to providers by zone, for zones spread across many subscriptions and resource groups.
+// It constructs a provider for each pair of subscription and resource group on first use and caches it.
+// The zero value is not usable; set Subscriptions or Zones before use.
+type Pool struct {
+	// New Provider returns a new provider for the resource group in the subscription, configured with the credential and other settings to use.
+	// Defaults to a provider authenticating using managed identity.
+	// Providers authenticating as the same identity share one credential.
+	NewProvider func(subscriptionID string, resourceGroupName string) (*Provider, error)
+
+	// Zones maps the names of zones, e.g. "example.com.", to their locations.
+	// Zones that are not in it are looked up in Subscriptions.
+	Zones map[string]ZoneLocation
+
+	// Subscriptions are the IDs of the subscriptions to look up zones in.
+	Subscriptions []string
+
+	// Concurrency is the maximum number of subscriptions in which zones are listed at the same time,
+	// so that discovering hundreds of zones across many subscriptions does not take minutes. Defaults to 8.
+	Concurrency int
+
+	registry  Registry
+	locations map[string]ZoneLocation
+	listing   *poolListing
+	mutex     sync.Mutex
+}
+
+// poolListing is a listing of the zones in Subscriptions in progress, shared by the calls waiting for it,
+// so that concurrent lookups of zones not cached yet list the subscriptions once.
+type poolListing struct {
+	done      chan struct{}
+	zoneInfos []ZoneInfo
+	err       error
+}
+
+// defaultPoolConcurrency is the default maximum number of subscriptions in which a pool lists zones at the same time.
+const defaultPoolConcurrency = 8
+
+// ZoneLocation is the location of a zone on Azure Resource Manager.
+type ZoneLocation struct {
+	SubscriptionId    string `json:"subscription_id,omitempty"`
+	ResourceGroupName string `json:"resource_group_name,omitempty"`
+}
+
+// Provider returns the provider for the resource group in which the zone is located.
+// If the zone is not in Zones, it is looked up in Subscriptions, and the result is cached.
+// The pool is not locked while the subscriptions are listed, so that the calls for other zones are not blocked.
+func (p *Pool) Provider(ctx context.Context, zone string) (*Provider, error) {
+	p.mutex.Lock()
+	location, ok := p.lookupLocation(zone)
+	if ok {
+		defer p.mutex.Unlock()
+		return p.getProvider(location)
+	}
+	p.mutex.Unlock()
+
+	if _, err := p.listZones(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	location, ok = p.lookupLocation(zone)
+	if !ok {
+		return nil, fmt.Errorf("the zone %v is not found in any of the subscriptions", zone)
+	}
+	return p.getProvider(location)
+}
+
+// InvalidateZone drops the cached location of the zone, so that it is looked up again in Subscriptions on next use,
+// e.g. after the zone has been moved to another resource group.
+func (p *Pool) InvalidateZone(zone string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.locations, normalizeZoneName(zone))
+}
+
+// ListZones lists all the zones in Subscriptions.
+func (p *Pool) ListZones(ctx context.Context) ([]libdns.Zone, error) {
+	zoneInfos, err := p.listZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var zones []libdns.Zone
+	for _, zoneInfo := range zoneInfos {
+		zones = append(zones, libdns.Zone{
+			Name: zoneInfo.Name,
+		})
+	}
+
+	return zones, nil
+}
+
+// GetRecords lists all the records in the zone.
+func (p *Pool) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	provider, err := p.Provider(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return provider.GetRecords(ctx, zone)
+}
+
+// AppendRecords adds records to the zone. It returns the records that were added.
+func (p *Pool) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	provider, err := p.Provider(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return provider.AppendRecords(ctx, zone, records)
+}
+
+// SetRecords sets the records in the zone, either by updating existing records
+// or creating new ones. It returns the updated records.
+func (p *Pool) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	provider, err := p.Provider(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return provider.SetRecords(ctx, zone, records)
+}
+
+// DeleteRecords deletes the records from the zone. It returns the records that were deleted.
+func (p *Pool) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	provider, err := p.Provider(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return provider.DeleteRecords(ctx, zone, records)
+}
+
+// lookupLocation returns the location of the zone in Zones or in the locations cached, reporting false if it is in neither.
+// It must be called with the pool locked.
+func (p *Pool) lookupLocation(zone string) (ZoneLocation, bool) {
+	name := normalizeZoneName(zone)
+
+	for zoneName, location := range p.Zones {
+		if normalizeZoneName(zoneName) == name {
+			return location, true
+		}
+	}
+	location, ok := p.locations[name]
+	return location, ok
+}
+
+// listZones lists all the zones in Subscriptions and caches their locations, without locking the pool during the listing.
+// A call made while another is listing waits for it and shares its result rather than listing again. The listing runs
+// with the values of ctx but not its cancellation, so that a caller giving up does not fail the others waiting for it;
+// each caller stops waiting when its own ctx is done.
+func (p *Pool) listZones(ctx context.Context) ([]ZoneInfo, error) {
+	p.mutex.Lock()
+	listing := p.listing
+	if listing == nil {
+		listing = p.startListing(ctx)
+	}
+	p.mutex.Unlock()
+
+	select {
+	case <-listing.done:
+		return listing.zoneInfos, listing.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// startListing starts listing the zones in Subscriptions in the background, and caches their locations once listed.
+// It must be called with the pool locked.
+func (p *Pool) startListing(ctx context.Context) *poolListing {
+	listing := &poolListing{done: make(chan struct{})}
+
+	// The providers without a resource group are used to list zones across the subscriptions.
+	// They are constructed up front with the pool locked, since New Provider is not required to be safe for concurrent use.
+	providers := make([]*Provider, len(p.Subscriptions))
+	for i, subscriptionID := range p.Subscriptions {
+		provider, err := p.getProvider(ZoneLocation{SubscriptionId: subscriptionID})
+		if err != nil {
+			listing.err = err
+			close(listing.done)
+			return listing
+		}
+		providers[i] = provider
+	}
+
+	p.listing = listing
+	go func() {
+		results, errs := p.listSubscriptions(context.WithoutCancel(ctx), providers)
+
+		p.mutex.Lock()
+		listing.zoneInfos, listing.err = p.cacheLocations(results, errs)
+		p.listing = nil
+		p.mutex.Unlock()
+		close(listing.done)
+	}()
+	return listing
+}
+
+// listSubscriptions lists the zones with the providers of the subscriptions concurrently by at most Concurrency workers,
+// returning the zones and the errors in the order of the providers.
+func (p *Pool) listSubscriptions(ctx context.Context, providers []*Provider) ([][]ZoneInfo, []error) {
+	concurrency := p.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultPoolConcurrency
+	}
+
+	results := make([][]ZoneInfo, len(providers))
+	errs := make([]error, len(providers))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency && worker < len(providers); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], errs[i] = providers[i].ListZonesWithOptions(ctx, ListZonesOptions{})
+			}
+		}()
+	}
+	for i := range providers {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results, errs
+}
+
+// cacheLocations caches the locations of the zones listed in Subscriptions, returning the zones in the order of Subscriptions.
+// If any subscription failed to be listed, the first error in the order of Subscriptions is returned.
+// It must be called with the pool locked.
+func (p *Pool) cacheLocations(results [][]ZoneInfo, errs []error) ([]ZoneInfo, error) {
+	if p.locations == nil {
+		p.locations = map[string]ZoneLocation{}
+	}
+	var zoneInfos []ZoneInfo
+	for i, subscriptionID := range p.Subscriptions[:len(results)] {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		for _, zoneInfo := range results[i] {
+			p.locations[normalizeZoneName(zoneInfo.Name)] = ZoneLocation{
+				SubscriptionId:    subscriptionID,
+				ResourceGroupName: parseResourceGroupName(zoneInfo.ID),
+			}
+		}
+		zoneInfos = append(zoneInfos, results[i]...)
+	}
+
+	return zoneInfos, nil
+}
+
+// getProvider returns the cached provider for the location, constructing it if necessary.
+func (p *Pool) getProvider(location ZoneLocation) (*Provider, error) {
+	id := location.SubscriptionId + "/" + location.ResourceGroupName
+	if provider, ok := p.registry.Get(id); ok {
+		return provider, nil
+	}
+
+	var provider *Provider
+	if p.NewProvider != nil {
+		var err error
+		provider, err = p.NewProvider(location.SubscriptionId, location.ResourceGroupName)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		provider = &Provider{
+			SubscriptionId:    location.SubscriptionId,
+			ResourceGroupName: location.ResourceGroupName,
+		}
+	}
+
+	if err := p.registry.Register(id, provider); err != nil {
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+// normalizeZoneName returns the zone name in lower case with a trailing dot.
+func normalizeZoneName(zone string) string {
+	return strings.ToLower(strings.TrimSuffix(zone, ".")) + "."
+}
+
+// parseResourceGroupName extracts the name of the resource group from the resource ID.
+func parseResourceGroupName(resourceID string) string {
+	segments := strings.Split(resourceID, "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if strings.EqualFold(segments[i], "resourceGroups") {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
+
+// Interface guards
+var (
+	_ libdns.RecordGetter   = (*Pool)(nil)
+	_ libdns.RecordAppender = (*Pool)(nil)
+	_ libdns.RecordSetter   = (*Pool)(nil)
+	_ libdns.RecordDeleter  = (*Pool)(nil)
+	_ libdns.ZoneLister     = (*Pool)(nil)
+)
@@ -0,0 +1,305 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns/fake"
+	"github.com/google/go-cmp/cmp"
+)
+
+func getFakePool() (*Pool, *[]ZoneLocation) {
+	var constructed []ZoneLocation
+	pool := &Pool{
+		NewProvider: func(subscriptionID string, resourceGroupName string) (*Provider, error) {
+			constructed = append(constructed, ZoneLocation{SubscriptionId: subscriptionID, ResourceGroupName: resourceGroupName})
+			provider := getFakeProvider()
+			provider.SubscriptionId = subscriptionID
+			provider.ResourceGroupName = resourceGroupName
+			return &provider, nil
+		},
+		Subscriptions: []string{"fake-subscription-id"},
+	}
+	return pool, &constructed
+}
+
+func Test_Pool(t *testing.T) {
+	t.Run("zone=discovered", func(t *testing.T) {
+		pool, constructed := getFakePool()
+		provider, err := pool.Provider(context.TODO(), "MyExample.com")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if diff := cmp.Diff(provider.ResourceGroupName, "another-resource-group-name"); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if _, err := pool.Provider(context.TODO(), "myexample.com."); err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := []ZoneLocation{
+			{SubscriptionId: "fake-subscription-id"},
+			{SubscriptionId: "fake-subscription-id", ResourceGroupName: "another-resource-group-name"},
+		}
+		if diff := cmp.Diff(*constructed, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("zone=invalidated", func(t *testing.T) {
+		pool, constructed := getFakePool()
+		if _, err := pool.Provider(context.TODO(), "myexample.com."); err != nil {
+			t.Fatalf("%s", err)
+		}
+		pool.InvalidateZone("MyExample.com")
+		if _, ok := pool.locations["myexample.com."]; ok {
+			t.Errorf("the location of the zone is still cached")
+		}
+		if _, err := pool.Provider(context.TODO(), "myexample.com."); err != nil {
+			t.Fatalf("%s", err)
+		}
+		// The providers are kept, since they do not depend on the zone
+		if len(*constructed) != 2 {
+			t.Errorf("got: %d, want: 2", len(*constructed))
+		}
+	})
+	t.Run("zone=static", func(t *testing.T) {
+		pool, constructed := getFakePool()
+		pool.Zones = map[string]ZoneLocation{
+			"example.org.": {SubscriptionId: "another-subscription-id", ResourceGroupName: "fake-resource-group-name"},
+		}
+		records, err := pool.GetRecords(context.TODO(), "example.org.")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(records) != len(libdnsFakeRecords) {
+			t.Errorf("got: %d, want: %d", len(records), len(libdnsFakeRecords))
+		}
+		if diff := cmp.Diff(*constructed, []ZoneLocation{{SubscriptionId: "another-subscription-id", ResourceGroupName: "fake-resource-group-name"}}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("zone=unknown", func(t *testing.T) {
+		pool, _ := getFakePool()
+		if _, err := pool.Provider(context.TODO(), "unknown.example."); err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+	t.Run("method=ListZones", func(t *testing.T) {
+		pool, _ := getFakePool()
+		zones, err := pool.ListZones(context.TODO())
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(zones) != len(azureFakeZones) {
+			t.Errorf("got: %d, want: %d", len(zones), len(azureFakeZones))
+		}
+	})
+}
+
+func Test_Pool_concurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		want        int
+	}{
+		{name: "concurrency=default", concurrency: 0, want: 8},
+		{name: "concurrency=1", concurrency: 1, want: 1},
+		{name: "concurrency=3", concurrency: 3, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			running, maxRunning := 0, 0
+			pool := &Pool{
+				NewProvider: func(subscriptionID string, resourceGroupName string) (*Provider, error) {
+					fakeZonesServer := getFakeZonesServer()
+					newListPager := fakeZonesServer.NewListPager
+					fakeZonesServer.NewListPager = func(options *armdns.ZonesClientListOptions) (resp azfake.PagerResponder[armdns.ZonesClientListResponse]) {
+						mutex.Lock()
+						running++
+						maxRunning = max(maxRunning, running)
+						mutex.Unlock()
+						time.Sleep(10 * time.Millisecond)
+						mutex.Lock()
+						running--
+						mutex.Unlock()
+						return newListPager(options)
+					}
+					provider := getFakeProviderWithServerFactory(fake.ServerFactory{
+						RecordSetsServer: getFakeRecordSetsServer(),
+						ZonesServer:      fakeZonesServer,
+					})
+					provider.SubscriptionId = subscriptionID
+					provider.ResourceGroupName = resourceGroupName
+					return &provider, nil
+				},
+				Concurrency: tt.concurrency,
+			}
+			for i := 0; i < 20; i++ {
+				pool.Subscriptions = append(pool.Subscriptions, fmt.Sprintf("fake-subscription-id-%d", i))
+			}
+			zones, err := pool.ListZones(context.TODO())
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if len(zones) != 20*len(azureFakeZones) {
+				t.Errorf("got: %d, want: %d", len(zones), 20*len(azureFakeZones))
+			}
+			if maxRunning != tt.want {
+				t.Errorf("got: %d, want: %d", maxRunning, tt.want)
+			}
+			// The zones are located in the subscription listed last in the order of Subscriptions
+			if diff := cmp.Diff(pool.locations["myexample.com."].SubscriptionId, "fake-subscription-id-19"); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+	t.Run("error", func(t *testing.T) {
+		pool := &Pool{
+			NewProvider: func(subscriptionID string, resourceGroupName string) (*Provider, error) {
+				fakeZonesServer := getFakeZonesServer()
+				if subscriptionID == "failing-subscription-id" {
+					fakeZonesServer.NewListPager = func(options *armdns.ZonesClientListOptions) (resp azfake.PagerResponder[armdns.ZonesClientListResponse]) {
+						resp.AddError(errors.New("fake error"))
+						return
+					}
+				}
+				provider := getFakeProviderWithServerFactory(fake.ServerFactory{
+					RecordSetsServer: getFakeRecordSetsServer(),
+					ZonesServer:      fakeZonesServer,
+				})
+				return &provider, nil
+			},
+			Subscriptions: []string{"fake-subscription-id", "failing-subscription-id"},
+		}
+		if _, err := pool.ListZones(context.TODO()); err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
+
+// getFakePoolWithBlockedListing returns a pool whose listing of zones blocks until release is closed,
+// closing started when the first listing begins and counting the listings.
+func getFakePoolWithBlockedListing() (pool *Pool, listings *atomic.Int32, started chan struct{}, release chan struct{}) {
+	listings = &atomic.Int32{}
+	started = make(chan struct{})
+	release = make(chan struct{})
+	pool = &Pool{
+		NewProvider: func(subscriptionID string, resourceGroupName string) (*Provider, error) {
+			fakeZonesServer := getFakeZonesServer()
+			newListPager := fakeZonesServer.NewListPager
+			fakeZonesServer.NewListPager = func(options *armdns.ZonesClientListOptions) (resp azfake.PagerResponder[armdns.ZonesClientListResponse]) {
+				if listings.Add(1) == 1 {
+					close(started)
+				}
+				<-release
+				return newListPager(options)
+			}
+			provider := getFakeProviderWithServerFactory(fake.ServerFactory{
+				RecordSetsServer: getFakeRecordSetsServer(),
+				ZonesServer:      fakeZonesServer,
+			})
+			provider.SubscriptionId = subscriptionID
+			provider.ResourceGroupName = resourceGroupName
+			return &provider, nil
+		},
+		Zones:         map[string]ZoneLocation{"known.com.": {SubscriptionId: "fake-subscription-id", ResourceGroupName: "fake-resource-group-name"}},
+		Subscriptions: []string{"fake-subscription-id"},
+	}
+	return pool, listings, started, release
+}
+
+func Test_Pool_listing(t *testing.T) {
+	pool, listings, started, release := getFakePoolWithBlockedListing()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = pool.Provider(context.TODO(), "example.com.")
+		}(i)
+	}
+	<-started
+
+	// The pool is not locked while the subscriptions are listed
+	done := make(chan error)
+	go func() {
+		_, err := pool.Provider(context.TODO(), "known.com.")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("the zone in Zones is blocked by the listing")
+	}
+
+	close(release)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+	}
+	if n := listings.Load(); n != 1 {
+		t.Errorf("got: %d listings, want: 1", n)
+	}
+}
+
+func Test_Pool_listing_canceled(t *testing.T) {
+	pool, listings, started, release := getFakePoolWithBlockedListing()
+
+	// The first caller gives up while the second one is waiting for the listing
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := pool.Provider(ctx, "example.com.")
+		first <- err
+	}()
+	<-started
+	second := make(chan error)
+	go func() {
+		_, err := pool.Provider(context.Background(), "example.com.")
+		second <- err
+	}()
+	cancel()
+	select {
+	case err := <-first:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got: %v, want: %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("the canceled caller waits for the listing")
+	}
+
+	close(release)
+	if err := <-second; err != nil {
+		t.Errorf("%s", err)
+	}
+	if n := listings.Load(); n != 1 {
+		t.Errorf("got: %d listings, want: 1", n)
+	}
+}
+
+func Test_parseResourceGroupName(t *testing.T) {
+	tests := map[string]string{
+		"/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/dnszones/example.com": "fake-resource-group-name",
+		"/subscriptions/fake-subscription-id/resourcegroups/another-resource-group-name":                                               "another-resource-group-name",
+		"/subscriptions/fake-subscription-id": "",
+	}
+	for resourceID, want := range tests {
+		if got := parseResourceGroupName(resourceID); got != want {
+			t.Errorf("got: %v, want: %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/privatedns/armprivatedns"
+	"github.com/libdns/libdns"
+)
+
+// publicZoneType and privateZoneType are the prefixes of the types of record sets in the APIs of Azure DNS and Azure Private DNS.
+const (
+	publicZoneType  = "Microsoft.Network/dnszones/"
+	privateZoneType = "Microsoft.Network/privateDnsZones/"
+)
+
+// privateRecordSetsClient is the record sets client of Azure Private DNS behind the interface of that of Azure DNS,
+// converting the record sets between them, so that a provider manages private zones with the same code.
+type privateRecordSetsClient struct {
+	client *armprivatedns.RecordSetsClient
+}
+
+func (c privateRecordSetsClient) Get(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientGetOptions) (armdns.RecordSetsClientGetResponse, error) {
+	privateRecordType, err := convertRecordTypeToPrivate(recordType)
+	if err != nil {
+		return armdns.RecordSetsClientGetResponse{}, err
+	}
+	response, err := c.client.Get(ctx, resourceGroupName, zoneName, privateRecordType, relativeRecordSetName, nil)
+	if err != nil {
+		return armdns.RecordSetsClientGetResponse{}, err
+	}
+	return armdns.RecordSetsClientGetResponse{RecordSet: convertPrivateRecordSet(response.RecordSet)}, nil
+}
+
+func (c privateRecordSetsClient) CreateOrUpdate(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (armdns.RecordSetsClientCreateOrUpdateResponse, error) {
+	privateRecordType, err := convertRecordTypeToPrivate(recordType)
+	if err != nil {
+		return armdns.RecordSetsClientCreateOrUpdateResponse{}, err
+	}
+	privateRecordSet, err := convertRecordSetToPrivate(parameters)
+	if err != nil {
+		return armdns.RecordSetsClientCreateOrUpdateResponse{}, err
+	}
+	var privateOptions *armprivatedns.RecordSetsClientCreateOrUpdateOptions
+	if options != nil {
+		privateOptions = &armprivatedns.RecordSetsClientCreateOrUpdateOptions{IfMatch: options.IfMatch, IfNoneMatch: options.IfNoneMatch}
+	}
+	response, err := c.client.CreateOrUpdate(ctx, resourceGroupName, zoneName, privateRecordType, relativeRecordSetName, privateRecordSet, privateOptions)
+	if err != nil {
+		return armdns.RecordSetsClientCreateOrUpdateResponse{}, err
+	}
+	return armdns.RecordSetsClientCreateOrUpdateResponse{RecordSet: convertPrivateRecordSet(response.RecordSet)}, nil
+}
+
+func (c privateRecordSetsClient) Delete(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientDeleteOptions) (armdns.RecordSetsClientDeleteResponse, error) {
+	privateRecordType, err := convertRecordTypeToPrivate(recordType)
+	if err != nil {
+		return armdns.RecordSetsClientDeleteResponse{}, err
+	}
+	var privateOptions *armprivatedns.RecordSetsClientDeleteOptions
+	if options != nil {
+		privateOptions = &armprivatedns.RecordSetsClientDeleteOptions{IfMatch: options.IfMatch}
+	}
+	_, err = c.client.Delete(ctx, resourceGroupName, zoneName, privateRecordType, relativeRecordSetName, privateOptions)
+	return armdns.RecordSetsClientDeleteResponse{}, err
+}
+
+func (c privateRecordSetsClient) NewListByDNSZonePager(resourceGroupName string, zoneName string, options *armdns.RecordSetsClientListByDNSZoneOptions) *runtime.Pager[armdns.RecordSetsClientListByDNSZoneResponse] {
+	privateOptions := &armprivatedns.RecordSetsClientListOptions{}
+	if options != nil {
+		privateOptions.Top, privateOptions.Recordsetnamesuffix = options.Top, options.Recordsetnamesuffix
+	}
+	pager := c.client.NewListPager(resourceGroupName, zoneName, privateOptions)
+	return runtime.NewPager(runtime.PagingHandler[armdns.RecordSetsClientListByDNSZoneResponse]{
+		More: func(armdns.RecordSetsClientListByDNSZoneResponse) bool {
+			return pager.More()
+		},
+		Fetcher: func(ctx context.Context, _ *armdns.RecordSetsClientListByDNSZoneResponse) (armdns.RecordSetsClientListByDNSZoneResponse, error) {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return armdns.RecordSetsClientListByDNSZoneResponse{}, err
+			}
+			return armdns.RecordSetsClientListByDNSZoneResponse{RecordSetListResult: armdns.RecordSetListResult{
+				NextLink: page.NextLink,
+				Value:    convertPrivateRecordSets(page.Value),
+			}}, nil
+		},
+	})
+}
+
+func (c privateRecordSetsClient) NewListByTypePager(resourceGroupName string, zoneName string, recordType armdns.RecordType, options *armdns.RecordSetsClientListByTypeOptions) *runtime.Pager[armdns.RecordSetsClientListByTypeResponse] {
+	privateRecordType, typeErr := convertRecordTypeToPrivate(recordType)
+	privateOptions := &armprivatedns.RecordSetsClientListByTypeOptions{}
+	if options != nil {
+		privateOptions.Top, privateOptions.Recordsetnamesuffix = options.Top, options.Recordsetnamesuffix
+	}
+	pager := c.client.NewListByTypePager(resourceGroupName, zoneName, privateRecordType, privateOptions)
+	return runtime.NewPager(runtime.PagingHandler[armdns.RecordSetsClientListByTypeResponse]{
+		More: func(armdns.RecordSetsClientListByTypeResponse) bool {
+			return pager.More()
+		},
+		Fetcher: func(ctx context.Context, _ *armdns.RecordSetsClientListByTypeResponse) (armdns.RecordSetsClientListByTypeResponse, error) {
+			if typeErr != nil {
+				return armdns.RecordSetsClientListByTypeResponse{}, typeErr
+			}
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return armdns.RecordSetsClientListByTypeResponse{}, err
+			}
+			return armdns.RecordSetsClientListByTypeResponse{RecordSetListResult: armdns.RecordSetListResult{
+				NextLink: page.NextLink,
+				Value:    convertPrivateRecordSets(page.Value),
+			}}, nil
+		},
+	})
+}
+
+// privateZonesClient is the private zones client of Azure Private DNS behind the interface of the zones client of Azure DNS.
+type privateZonesClient struct {
+	client *armprivatedns.PrivateZonesClient
+}
+
+func (c privateZonesClient) Get(ctx context.Context, resourceGroupName string, zoneName string, options *armdns.ZonesClientGetOptions) (armdns.ZonesClientGetResponse, error) {
+	response, err := c.client.Get(ctx, resourceGroupName, zoneName, nil)
+	if err != nil {
+		return armdns.ZonesClientGetResponse{}, err
+	}
+	return armdns.ZonesClientGetResponse{Zone: convertPrivateZone(response.PrivateZone)}, nil
+}
+
+func (c privateZonesClient) NewListPager(options *armdns.ZonesClientListOptions) *runtime.Pager[armdns.ZonesClientListResponse] {
+	privateOptions := &armprivatedns.PrivateZonesClientListOptions{}
+	if options != nil {
+		privateOptions.Top = options.Top
+	}
+	pager := c.client.NewListPager(privateOptions)
+	return runtime.NewPager(runtime.PagingHandler[armdns.ZonesClientListResponse]{
+		More: func(armdns.ZonesClientListResponse) bool {
+			return pager.More()
+		},
+		Fetcher: func(ctx context.Context, _ *armdns.ZonesClientListResponse) (armdns.ZonesClientListResponse, error) {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return armdns.ZonesClientListResponse{}, err
+			}
+			return armdns.ZonesClientListResponse{ZoneListResult: armdns.ZoneListResult{
+				NextLink: page.NextLink,
+				Value:    convertPrivateZones(page.Value),
+			}}, nil
+		},
+	})
+}
+
+func (c privateZonesClient) NewListByResourceGroupPager(resourceGroupName string, options *armdns.ZonesClientListByResourceGroupOptions) *runtime.Pager[armdns.ZonesClientListByResourceGroupResponse] {
+	privateOptions := &armprivatedns.PrivateZonesClientListByResourceGroupOptions{}
+	if options != nil {
+		privateOptions.Top = options.Top
+	}
+	pager := c.client.NewListByResourceGroupPager(resourceGroupName, privateOptions)
+	return runtime.NewPager(runtime.PagingHandler[armdns.ZonesClientListByResourceGroupResponse]{
+		More: func(armdns.ZonesClientListByResourceGroupResponse) bool {
+			return pager.More()
+		},
+		Fetcher: func(ctx context.Context, _ *armdns.ZonesClientListByResourceGroupResponse) (armdns.ZonesClientListByResourceGroupResponse, error) {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return armdns.ZonesClientListByResourceGroupResponse{}, err
+			}
+			return armdns.ZonesClientListByResourceGroupResponse{ZoneListResult: armdns.ZoneListResult{
+				NextLink: page.NextLink,
+				Value:    convertPrivateZones(page.Value),
+			}}, nil
+		},
+	})
+}
+
+// convertRecordTypeToPrivate converts the type of a record set of Azure DNS to that of Azure Private DNS,
+// throwing an error for the types that private zones do not support, e.g. CAA and NS.
+func convertRecordTypeToPrivate(recordType armdns.RecordType) (armprivatedns.RecordType, error) {
+	for _, privateRecordType := range armprivatedns.PossibleRecordTypeValues() {
+		if strings.EqualFold(string(privateRecordType), string(recordType)) {
+			return privateRecordType, nil
+		}
+	}
+	return "", fmt.Errorf("the record type %v is not supported by private zones", recordType)
+}
+
+// convertRecordSetToPrivate converts a record set of Azure DNS to be written to a private zone,
+// throwing an error if it has records that private zones do not support, or is an alias of an Azure resource.
+// The read-only properties are not converted.
+func convertRecordSetToPrivate(recordSet armdns.RecordSet) (armprivatedns.RecordSet, error) {
+	privateRecordSet := armprivatedns.RecordSet{
+		Etag: recordSet.Etag,
+		Name: recordSet.Name,
+	}
+	properties := recordSet.Properties
+	if properties == nil {
+		return privateRecordSet, nil
+	}
+	if len(properties.CaaRecords) > 0 || len(properties.NsRecords) > 0 {
+		return armprivatedns.RecordSet{}, fmt.Errorf("the record set %v has records not supported by private zones", stringValue(recordSet.Name))
+	}
+	if properties.TargetResource != nil && properties.TargetResource.ID != nil {
+		return armprivatedns.RecordSet{}, fmt.Errorf("the record set %v is an alias, which private zones do not support", stringValue(recordSet.Name))
+	}
+
+	privateProperties := &armprivatedns.RecordSetProperties{
+		Metadata: properties.Metadata,
+		TTL:      properties.TTL,
+	}
+	for _, record := range properties.ARecords {
+		if record != nil {
+			privateProperties.ARecords = append(privateProperties.ARecords, &armprivatedns.ARecord{IPv4Address: record.IPv4Address})
+		}
+	}
+	for _, record := range properties.AaaaRecords {
+		if record != nil {
+			privateProperties.AaaaRecords = append(privateProperties.AaaaRecords, &armprivatedns.AaaaRecord{IPv6Address: record.IPv6Address})
+		}
+	}
+	if record := properties.CnameRecord; record != nil {
+		privateProperties.CnameRecord = &armprivatedns.CnameRecord{Cname: record.Cname}
+	}
+	for _, record := range properties.MxRecords {
+		if record != nil {
+			privateProperties.MxRecords = append(privateProperties.MxRecords, &armprivatedns.MxRecord{Exchange: record.Exchange, Preference: record.Preference})
+		}
+	}
+	for _, record := range properties.PtrRecords {
+		if record != nil {
+			privateProperties.PtrRecords = append(privateProperties.PtrRecords, &armprivatedns.PtrRecord{Ptrdname: record.Ptrdname})
+		}
+	}
+	if record := properties.SoaRecord; record != nil {
+		privateProperties.SoaRecord = &armprivatedns.SoaRecord{
+			Email:        record.Email,
+			ExpireTime:   record.ExpireTime,
+			Host:         record.Host,
+			MinimumTTL:   record.MinimumTTL,
+			RefreshTime:  record.RefreshTime,
+			RetryTime:    record.RetryTime,
+			SerialNumber: record.SerialNumber,
+		}
+	}
+	for _, record := range properties.SrvRecords {
+		if record != nil {
+			privateProperties.SrvRecords = append(privateProperties.SrvRecords, &armprivatedns.SrvRecord{Port: record.Port, Priority: record.Priority, Target: record.Target, Weight: record.Weight})
+		}
+	}
+	for _, record := range properties.TxtRecords {
+		if record != nil {
+			privateProperties.TxtRecords = append(privateProperties.TxtRecords, &armprivatedns.TxtRecord{Value: record.Value})
+		}
+	}
+	privateRecordSet.Properties = privateProperties
+	return privateRecordSet, nil
+}
+
+// convertPrivateRecordSet converts a record set of a private zone to a record set of Azure DNS,
+// with the type prefixed as those of Azure DNS, e.g. "Microsoft.Network/dnszones/TXT".
+func convertPrivateRecordSet(privateRecordSet armprivatedns.RecordSet) armdns.RecordSet {
+	recordSet := armdns.RecordSet{
+		Etag: privateRecordSet.Etag,
+		ID:   privateRecordSet.ID,
+		Name: privateRecordSet.Name,
+		Type: privateRecordSet.Type,
+	}
+	if typeName := stringValue(privateRecordSet.Type); len(typeName) > len(privateZoneType) && strings.EqualFold(typeName[:len(privateZoneType)], privateZoneType) {
+		recordSet.Type = to.Ptr(publicZoneType + typeName[len(privateZoneType):])
+	}
+	privateProperties := privateRecordSet.Properties
+	if privateProperties == nil {
+		return recordSet
+	}
+
+	properties := &armdns.RecordSetProperties{
+		Fqdn:     privateProperties.Fqdn,
+		Metadata: privateProperties.Metadata,
+		TTL:      privateProperties.TTL,
+	}
+	for _, record := range privateProperties.ARecords {
+		if record != nil {
+			properties.ARecords = append(properties.ARecords, &armdns.ARecord{IPv4Address: record.IPv4Address})
+		}
+	}
+	for _, record := range privateProperties.AaaaRecords {
+		if record != nil {
+			properties.AaaaRecords = append(properties.AaaaRecords, &armdns.AaaaRecord{IPv6Address: record.IPv6Address})
+		}
+	}
+	if record := privateProperties.CnameRecord; record != nil {
+		properties.CnameRecord = &armdns.CnameRecord{Cname: record.Cname}
+	}
+	for _, record := range privateProperties.MxRecords {
+		if record != nil {
+			properties.MxRecords = append(properties.MxRecords, &armdns.MxRecord{Exchange: record.Exchange, Preference: record.Preference})
+		}
+	}
+	for _, record := range privateProperties.PtrRecords {
+		if record != nil {
+			properties.PtrRecords = append(properties.PtrRecords, &armdns.PtrRecord{Ptrdname: record.Ptrdname})
+		}
+	}
+	if record := privateProperties.SoaRecord; record != nil {
+		properties.SoaRecord = &armdns.SoaRecord{
+			Email:        record.Email,
+			ExpireTime:   record.ExpireTime,
+			Host:         record.Host,
+			MinimumTTL:   record.MinimumTTL,
+			RefreshTime:  record.RefreshTime,
+			RetryTime:    record.RetryTime,
+			SerialNumber: record.SerialNumber,
+		}
+	}
+	for _, record := range privateProperties.SrvRecords {
+		if record != nil {
+			properties.SrvRecords = append(properties.SrvRecords, &armdns.SrvRecord{Port: record.Port, Priority: record.Priority, Target: record.Target, Weight: record.Weight})
+		}
+	}
+	for _, record := range privateProperties.TxtRecords {
+		if record != nil {
+			properties.TxtRecords = append(properties.TxtRecords, &armdns.TxtRecord{Value: record.Value})
+		}
+	}
+	recordSet.Properties = properties
+	return recordSet
+}
+
+// convertPrivateRecordSets converts the record sets of a private zone, skipping nil ones.
+func convertPrivateRecordSets(privateRecordSets []*armprivatedns.RecordSet) []*armdns.RecordSet {
+	var recordSets []*armdns.RecordSet
+	for _, privateRecordSet := range privateRecordSets {
+		if privateRecordSet != nil {
+			recordSet := convertPrivateRecordSet(*privateRecordSet)
+			recordSets = append(recordSets, &recordSet)
+		}
+	}
+	return recordSets
+}
+
+// convertPrivateZone converts a private zone to a zone of Azure DNS of the private type.
+func convertPrivateZone(privateZone armprivatedns.PrivateZone) armdns.Zone {
+	zone := armdns.Zone{
+		Etag:     privateZone.Etag,
+		ID:       privateZone.ID,
+		Location: privateZone.Location,
+		Name:     privateZone.Name,
+		Tags:     privateZone.Tags,
+		Type:     privateZone.Type,
+		Properties: &armdns.ZoneProperties{
+			ZoneType: to.Ptr(armdns.ZoneTypePrivate),
+		},
+	}
+	if privateZone.Properties != nil {
+		zone.Properties.MaxNumberOfRecordSets = privateZone.Properties.MaxNumberOfRecordSets
+		zone.Properties.NumberOfRecordSets = privateZone.Properties.NumberOfRecordSets
+	}
+	return zone
+}
+
+// convertPrivateZones converts the private zones, skipping nil ones.
+func convertPrivateZones(privateZones []*armprivatedns.PrivateZone) []*armdns.Zone {
+	var zones []*armdns.Zone
+	for _, privateZone := range privateZones {
+		if privateZone != nil {
+			zone := convertPrivateZone(*privateZone)
+			zones = append(zones, &zone)
+		}
+	}
+	return zones
+}
+
+// forPrivateLocation returns the provider for the private DNS zones in the location, derived from p on first use and cached.
+// Its writes are neither checked against Private Zone Rules again, nor replicated, nor mirrored.
+func (p *Provider) forPrivateLocation(location ZoneLocation) *Provider {
+	if p.private {
+		return p.forLocation(location)
+	}
+
+	return p.getOverride(&p.overrides.privateProviders, location, func(provider *Provider) {
+		provider.private = true
+		provider.PrivateZoneRules = nil
+		provider.Replica = nil
+		provider.Mirrors = nil
+	})
+}
+
+// writePrivateZone duplicates the write of the records matching Private Zone Rules, which was made to the public zone,
+// into the private DNS zone of the same name.
+func (p *Provider) writePrivateZone(ctx context.Context, operation WriteOperation, zone string, records []libdns.Record) error {
+	if p.private || len(p.PrivateZoneRules) == 0 || len(records) == 0 {
+		return nil
+	}
+
+	var matchingRecords []libdns.Record
+	for _, record := range records {
+		rr := record.RR()
+		name := libdns.RelativeName(rr.Name, zone)
+		for _, rule := range p.PrivateZoneRules {
+			if rule.matches(name, rr.Type) {
+				matchingRecords = append(matchingRecords, record)
+				break
+			}
+		}
+	}
+	if len(matchingRecords) == 0 {
+		return nil
+	}
+
+	location := ZoneLocation{
+		SubscriptionId:    p.SubscriptionId,
+		ResourceGroupName: p.ResourceGroupName,
+	}
+	if p.PrivateZoneResourceGroupName != "" {
+		location.ResourceGroupName = p.PrivateZoneResourceGroupName
+	}
+	provider := p.forPrivateLocation(location)
+
+	var err error
+	switch operation {
+	case WriteOperationAppend:
+		_, err = provider.createRecords(ctx, zone, matchingRecords)
+	case WriteOperationSet:
+		_, err = provider.updateRecords(ctx, zone, matchingRecords)
+	case WriteOperationDelete:
+		_, err = provider.deleteRecords(ctx, zone, matchingRecords, DeleteOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("the records were written to the public zone %v, but not to the private zone in the resource group %v: %w", zone, location.ResourceGroupName, err)
+	}
+	p.getLogger().Debug("duplicated the write into the private zone", "zone", zone, "resourceGroup", location.ResourceGroupName, "records", len(matchingRecords))
+	return nil
+}
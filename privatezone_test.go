@@ -0,0 +1,360 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/privatedns/armprivatedns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+// fakePrivateZones is a fake of the API of Azure Private DNS, storing the record sets as they are written by their paths.
+type fakePrivateZones struct {
+	t          *testing.T
+	recordSets map[string]map[string]any
+	// zones are the paths of the private zones that exist
+	zones map[string]bool
+	mutex sync.Mutex
+}
+
+func (f *fakePrivateZones) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	path := req.URL.Path
+	segments := strings.Split(path, "/")
+	zonePath := strings.Join(segments[:9], "/")
+	if !f.zones[zonePath] {
+		return fakePrivateZonesResponse(req, http.StatusNotFound, map[string]any{"error": map[string]any{"code": "ParentResourceNotFound", "message": "the zone does not exist"}}), nil
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		if path == zonePath {
+			return fakePrivateZonesResponse(req, http.StatusOK, map[string]any{
+				"id":         path,
+				"name":       segments[8],
+				"type":       "Microsoft.Network/privateDnsZones",
+				"properties": map[string]any{"numberOfRecordSets": len(f.recordSets)},
+			}), nil
+		}
+		if path == zonePath+"/ALL" {
+			values := []any{}
+			for recordSetPath, recordSet := range f.recordSets {
+				if strings.HasPrefix(recordSetPath, zonePath+"/") {
+					values = append(values, recordSet)
+				}
+			}
+			return fakePrivateZonesResponse(req, http.StatusOK, map[string]any{"value": values}), nil
+		}
+		recordSet, ok := f.recordSets[path]
+		if !ok {
+			return fakePrivateZonesResponse(req, http.StatusNotFound, map[string]any{"error": map[string]any{"code": "NotFound", "message": "the record set does not exist"}}), nil
+		}
+		return fakePrivateZonesResponse(req, http.StatusOK, recordSet), nil
+	case http.MethodPut:
+		body, _ := io.ReadAll(req.Body)
+		var recordSet map[string]any
+		if err := json.Unmarshal(body, &recordSet); err != nil {
+			f.t.Fatalf("%s", err)
+		}
+		properties, _ := recordSet["properties"].(map[string]any)
+		for key := range properties {
+			switch key {
+			case "TTL", "ARecords", "AAAARecords", "CNAMERecord", "MXRecords", "PTRRecords", "SOARecord", "SRVRecords", "TXTRecords":
+				f.t.Errorf("got the key of Azure DNS: %v", key)
+			}
+		}
+		recordSet["id"] = path
+		recordSet["name"] = segments[len(segments)-1]
+		recordSet["type"] = "Microsoft.Network/privateDnsZones/" + segments[len(segments)-2]
+		recordSet["etag"] = "fake-etag"
+		f.recordSets[path] = recordSet
+		return fakePrivateZonesResponse(req, http.StatusOK, recordSet), nil
+	case http.MethodDelete:
+		delete(f.recordSets, path)
+		return fakePrivateZonesResponse(req, http.StatusOK, nil), nil
+	}
+	f.t.Errorf("unexpected request: %v %v", req.Method, path)
+	return fakePrivateZonesResponse(req, http.StatusMethodNotAllowed, nil), nil
+}
+
+// addZone makes the private zone exist in the resource group.
+func (f *fakePrivateZones) addZone(resourceGroupName string, zone string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.zones["/subscriptions/fake-subscription-id/resourceGroups/"+resourceGroupName+"/providers/Microsoft.Network/privateDnsZones/"+zone] = true
+}
+
+func fakePrivateZonesResponse(req *http.Request, statusCode int, body any) *http.Response {
+	content := []byte{}
+	header := http.Header{}
+	if body != nil {
+		content, _ = json.Marshal(body)
+		header.Set("Content-Type", "application/json")
+	}
+	if statusCode >= 400 {
+		header.Set("x-ms-error-code", body.(map[string]any)["error"].(map[string]any)["code"].(string))
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(string(content))),
+		Request:    req,
+	}
+}
+
+// getFakeProviderWithPrivateZones returns a provider managing the public zone, whose requests for private zones
+// are served by the fake of Azure Private DNS, in which the private zone example.com exists in the resource groups.
+func getFakeProviderWithPrivateZones(t *testing.T, zone *fakeZone, resourceGroupNames ...string) (*Provider, *fakePrivateZones) {
+	privateZones := &fakePrivateZones{t: t, recordSets: map[string]map[string]any{}, zones: map[string]bool{}}
+	for _, resourceGroupName := range resourceGroupNames {
+		privateZones.addZone(resourceGroupName, "example.com")
+	}
+	provider := getFakeProviderWithZone(zone)
+	transport := provider.client.clientOptions.Transport
+	provider.ClientOptions = &arm.ClientOptions{
+		ClientOptions: azcore.ClientOptions{
+			Transport: transportFunc(func(req *http.Request) (*http.Response, error) {
+				if strings.Contains(req.URL.Path, "/privateDnsZones/") {
+					return privateZones.RoundTrip(req)
+				}
+				return transport.Do(req)
+			}),
+		},
+	}
+	return provider, privateZones
+}
+
+func Test_convertRecordSetToPrivate(t *testing.T) {
+	recordSet := armdns.RecordSet{
+		Name: to.Ptr("www"),
+		Etag: to.Ptr("ETAG_www"),
+		Properties: &armdns.RecordSetProperties{
+			TTL:         to.Ptr[int64](3600),
+			Metadata:    map[string]*string{"TTL": to.Ptr("kept")},
+			ARecords:    []*armdns.ARecord{{IPv4Address: to.Ptr("127.0.0.1")}, nil},
+			AaaaRecords: []*armdns.AaaaRecord{{IPv6Address: to.Ptr("::1")}},
+			CnameRecord: &armdns.CnameRecord{Cname: to.Ptr("example.com")},
+			MxRecords:   []*armdns.MxRecord{{Exchange: to.Ptr("mail.example.com"), Preference: to.Ptr[int32](10)}},
+			PtrRecords:  []*armdns.PtrRecord{{Ptrdname: to.Ptr("www.example.com")}},
+			SoaRecord:   &armdns.SoaRecord{Email: to.Ptr("admin.example.com"), Host: to.Ptr("ns.example.com"), SerialNumber: to.Ptr[int64](1)},
+			SrvRecords:  []*armdns.SrvRecord{{Port: to.Ptr[int32](443), Priority: to.Ptr[int32](1), Target: to.Ptr("srv.example.com"), Weight: to.Ptr[int32](5)}},
+			TxtRecords:  []*armdns.TxtRecord{{Value: []*string{to.Ptr("TEXT")}}},
+		},
+	}
+	privateRecordSet, err := convertRecordSetToPrivate(recordSet)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	privateRecordSet.Type = to.Ptr("Microsoft.Network/privateDnsZones/A")
+	got := convertPrivateRecordSet(privateRecordSet)
+
+	want := recordSet
+	want.Type = to.Ptr("Microsoft.Network/dnszones/A")
+	wantProperties := *recordSet.Properties
+	wantProperties.ARecords = wantProperties.ARecords[:1]
+	want.Properties = &wantProperties
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+
+	t.Run("records=unsupported", func(t *testing.T) {
+		for _, properties := range []*armdns.RecordSetProperties{
+			{CaaRecords: []*armdns.CaaRecord{{Tag: to.Ptr("issue"), Value: to.Ptr("ca.example.com")}}},
+			{NsRecords: []*armdns.NsRecord{{Nsdname: to.Ptr("ns.example.com")}}},
+			{TargetResource: &armdns.SubResource{ID: to.Ptr("/subscriptions/s/resourceGroups/g/providers/Microsoft.Network/publicIPAddresses/ip")}},
+		} {
+			if _, err := convertRecordSetToPrivate(armdns.RecordSet{Name: to.Ptr("www"), Properties: properties}); err == nil {
+				t.Errorf("got: nil error for %+v", properties)
+			}
+		}
+	})
+}
+
+func Test_convertRecordTypeToPrivate(t *testing.T) {
+	tests := []struct {
+		recordType armdns.RecordType
+		want       armprivatedns.RecordType
+		wantErr    bool
+	}{
+		{recordType: armdns.RecordTypeTXT, want: armprivatedns.RecordTypeTXT},
+		{recordType: armdns.RecordTypeAAAA, want: armprivatedns.RecordTypeAAAA},
+		{recordType: armdns.RecordTypeCAA, wantErr: true},
+		{recordType: armdns.RecordTypeNS, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.recordType), func(t *testing.T) {
+			got, err := convertRecordTypeToPrivate(tt.recordType)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("got: %v %v, want: %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_convertPrivateZone(t *testing.T) {
+	got := convertPrivateZone(armprivatedns.PrivateZone{
+		Name:       to.Ptr("example.com"),
+		Location:   to.Ptr("global"),
+		Properties: &armprivatedns.PrivateZoneProperties{NumberOfRecordSets: to.Ptr[int64](2), MaxNumberOfRecordSets: to.Ptr[int64](25000)},
+	})
+	want := armdns.Zone{
+		Name:     to.Ptr("example.com"),
+		Location: to.Ptr("global"),
+		Properties: &armdns.ZoneProperties{
+			ZoneType:              to.Ptr(armdns.ZoneTypePrivate),
+			NumberOfRecordSets:    to.Ptr[int64](2),
+			MaxNumberOfRecordSets: to.Ptr[int64](25000),
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
+
+func Test_PrivateZoneRules(t *testing.T) {
+	challengeRule := []RecordRule{{Name: "_acme-challenge.*", Type: "TXT"}}
+	challengePath := "/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/privateDnsZones/example.com/TXT/_acme-challenge"
+	tests := []struct {
+		name   string
+		rules  []RecordRule
+		write  func(provider *Provider, ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error)
+		record libdns.Record
+		// public and stored are the record sets in the public and private zones before the write
+		public []*armdns.RecordSet
+		stored map[string]map[string]any
+		want   []string
+	}{
+		{
+			name:   "rules=none",
+			write:  (*Provider).AppendRecords,
+			record: libdns.TXT{Name: "_acme-challenge", Text: "TOKEN", TTL: time.Minute},
+		},
+		{
+			name:   "append",
+			rules:  challengeRule,
+			write:  (*Provider).AppendRecords,
+			record: libdns.TXT{Name: "_acme-challenge", Text: "TOKEN", TTL: time.Minute},
+			want:   []string{challengePath + " 60 TOKEN"},
+		},
+		{
+			name:   "append,existing",
+			rules:  challengeRule,
+			write:  (*Provider).AppendRecords,
+			record: libdns.TXT{Name: "_acme-challenge", Text: "TOKEN", TTL: time.Minute},
+			stored: map[string]map[string]any{challengePath: {
+				"name":       "_acme-challenge",
+				"type":       "Microsoft.Network/privateDnsZones/TXT",
+				"properties": map[string]any{"ttl": 60, "txtRecords": []any{map[string]any{"value": []any{"OTHER"}}}},
+			}},
+			want: []string{challengePath + " 60 OTHER,TOKEN"},
+		},
+		{
+			name:   "set",
+			rules:  challengeRule,
+			write:  (*Provider).SetRecords,
+			record: libdns.TXT{Name: "_acme-challenge", Text: "TOKEN", TTL: time.Minute},
+			want:   []string{challengePath + " 60 TOKEN"},
+		},
+		{
+			name:   "delete",
+			rules:  challengeRule,
+			write:  (*Provider).DeleteRecords,
+			record: libdns.TXT{Name: "_acme-challenge", Text: "TOKEN"},
+			public: []*armdns.RecordSet{{
+				Name:       to.Ptr("_acme-challenge"),
+				Type:       to.Ptr("Microsoft.Network/dnszones/TXT"),
+				Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](60), TxtRecords: []*armdns.TxtRecord{{Value: []*string{to.Ptr("TOKEN")}}}},
+			}},
+			stored: map[string]map[string]any{challengePath: {
+				"name":       "_acme-challenge",
+				"type":       "Microsoft.Network/privateDnsZones/TXT",
+				"properties": map[string]any{"ttl": 60, "txtRecords": []any{map[string]any{"value": []any{"TOKEN"}}}},
+			}},
+		},
+		{
+			name:   "not matching",
+			rules:  challengeRule,
+			write:  (*Provider).AppendRecords,
+			record: libdns.TXT{Name: "www", Text: "TOKEN", TTL: time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, privateZones := getFakeProviderWithPrivateZones(t, newFakeZone(tt.public...), "fake-resource-group-name")
+			provider.PrivateZoneRules = tt.rules
+			if tt.stored != nil {
+				privateZones.recordSets = tt.stored
+			}
+
+			if _, err := tt.write(provider, context.TODO(), "example.com.", []libdns.Record{tt.record}); err != nil {
+				t.Fatalf("%s", err)
+			}
+			var got []string
+			for path, recordSet := range privateZones.recordSets {
+				properties := recordSet["properties"].(map[string]any)
+				var values []string
+				for _, txtRecord := range properties["txtRecords"].([]any) {
+					for _, value := range txtRecord.(map[string]any)["value"].([]any) {
+						values = append(values, value.(string))
+					}
+				}
+				sort.Strings(values)
+				got = append(got, path+" "+string(mustMarshal(t, properties["ttl"]))+" "+strings.Join(values, ","))
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+
+	t.Run("resource group", func(t *testing.T) {
+		provider, privateZones := getFakeProviderWithPrivateZones(t, newFakeZone(), "private-resource-group-name")
+		provider.PrivateZoneRules = challengeRule
+		provider.PrivateZoneResourceGroupName = "private-resource-group-name"
+		if _, err := provider.AppendRecords(context.TODO(), "example.com.", []libdns.Record{
+			libdns.TXT{Name: "_acme-challenge", Text: "TOKEN", TTL: time.Minute},
+		}); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if _, ok := privateZones.recordSets[strings.Replace(challengePath, "fake-resource-group-name", "private-resource-group-name", 1)]; !ok {
+			t.Errorf("the record set is not written to the private zone in the resource group: %v", privateZones.recordSets)
+		}
+	})
+
+	t.Run("private zone=none", func(t *testing.T) {
+		provider, _ := getFakeProviderWithPrivateZones(t, newFakeZone())
+		provider.PrivateZoneRules = challengeRule
+		records, err := provider.AppendRecords(context.TODO(), "example.com.", []libdns.Record{
+			libdns.TXT{Name: "_acme-challenge", Text: "TOKEN", TTL: time.Minute},
+		})
+		if err == nil || !strings.Contains(err.Error(), "but not to the private zone") {
+			t.Errorf("got: %v", err)
+		}
+		if len(records) != 1 {
+			t.Errorf("the records written to the public zone are not returned: %v", records)
+		}
+	})
+}
+
+func mustMarshal(t *testing.T, value any) []byte {
+	content, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	return content
+}
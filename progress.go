@@ -0,0 +1,44 @@
+package azure
+
+import (
+	"context"
+	"sync"
+)
+
+// progressKey is the key of the progress of a bulk write in a context.
+type progressKey struct{}
+
+// progress counts the record sets written by a bulk write, reporting the count to On Progress.
+type progress struct {
+	done   int
+	total  int
+	report func(done int, total int)
+	mutex  sync.Mutex
+}
+
+// withProgress returns a copy of ctx tracking the progress of a bulk write of the total number of record sets.
+// If ctx already tracks the progress of an enclosing bulk write, or On Progress is not set, ctx is returned as is,
+// so that the record sets are counted once against the outermost bulk write.
+func (p *Provider) withProgress(ctx context.Context, total int) context.Context {
+	if p.OnProgress == nil {
+		return ctx
+	}
+	if _, ok := ctx.Value(progressKey{}).(*progress); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, progressKey{}, &progress{total: total, report: p.OnProgress})
+}
+
+// advanceProgress counts a record set written, or failed to be written, against the progress tracked by ctx, if any.
+func advanceProgress(ctx context.Context) {
+	progress, ok := ctx.Value(progressKey{}).(*progress)
+	if !ok {
+		return
+	}
+
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	progress.done++
+	progress.report(progress.done, progress.total)
+}
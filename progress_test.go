@@ -0,0 +1,73 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_OnProgress(t *testing.T) {
+	records := []libdns.Record{
+		libdns.TXT{Name: "record-a", Text: "NEW VALUE 1", TTL: time.Minute},
+		libdns.TXT{Name: "record-a", Text: "NEW VALUE 2", TTL: time.Minute},
+		libdns.TXT{Name: "record-b", Text: "NEW VALUE", TTL: time.Minute},
+		libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Minute},
+	}
+	tests := []struct {
+		name    string
+		zone    string
+		records []libdns.Record
+		write   func(p *Provider, zone string, records []libdns.Record)
+		want    []string
+	}{
+		{
+			name:    "method=SetRecords",
+			zone:    "example.com.",
+			records: records,
+			write: func(p *Provider, zone string, records []libdns.Record) {
+				p.SetRecords(context.TODO(), zone, records)
+			},
+			want: []string{"1/3", "2/3", "3/3"},
+		},
+		{
+			name:    "method=WriteRecordsStream",
+			zone:    "example.com.",
+			records: records,
+			write: func(p *Provider, zone string, records []libdns.Record) {
+				for range p.WriteRecordsStream(context.TODO(), WriteOperationSet, zone, records) {
+				}
+			},
+			want: []string{"1/3", "2/3", "3/3"},
+		},
+		{
+			name: "method=SetRecords,routed",
+			zone: "",
+			records: []libdns.Record{
+				libdns.TXT{Name: "www.example.com.", Text: "NEW VALUE", TTL: time.Minute},
+				libdns.TXT{Name: "www.sub.example.com.", Text: "NEW VALUE", TTL: time.Minute},
+			},
+			write: func(p *Provider, zone string, records []libdns.Record) {
+				p.SetRecords(context.TODO(), zone, records)
+			},
+			want: []string{"1/2", "2/2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := newFakeZone(getOwnedTXTRecordSet(""))
+			provider := getFakeProviderWithZone(zone)
+			var got []string
+			provider.OnProgress = func(done int, total int) {
+				got = append(got, fmt.Sprintf("%d/%d", done, total))
+			}
+			tt.write(provider, tt.zone, tt.records)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package azure
+
+import (
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+)
+
+const (
+	// defaultProvenanceWriter is the writer stamped on record sets if not specified.
+	defaultProvenanceWriter = "libdns/azure"
+
+	// writtenByMetadataKey is the key of the metadata stamped on record sets with the writer that last wrote them.
+	writtenByMetadataKey = "libdns_written_by"
+
+	// hostnameMetadataKey is the key of the metadata stamped on record sets with the host name of the machine that last wrote them.
+	hostnameMetadataKey = "libdns_hostname"
+)
+
+// stampProvenance stamps the writer, the host name, and the time of the write on the properties of a record set to be written,
+// so that operators can tell the record sets written by the provider from those made by hand.
+func (p *Provider) stampProvenance(properties *armdns.RecordSetProperties) {
+	writer := p.ProvenanceWriter
+	if writer == "" {
+		writer = defaultProvenanceWriter
+	}
+	setMetadata(properties, writtenByMetadataKey, writer)
+	if hostname, err := os.Hostname(); err == nil {
+		setMetadata(properties, hostnameMetadataKey, hostname)
+	}
+	stampWrittenAt(properties)
+}
@@ -0,0 +1,71 @@
+package azure
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func Test_stampProvenance(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skipf("%s", err)
+	}
+	tests := []struct {
+		name       string
+		stamp      bool
+		writer     string
+		wantWriter string
+	}{
+		{name: "stamp=false", stamp: false, wantWriter: ""},
+		{name: "stamp=true", stamp: true, wantWriter: "libdns/azure"},
+		{name: "stamp=true,writer=cert-renewer", stamp: true, writer: "cert-renewer", wantWriter: "cert-renewer"},
+	}
+	for _, tt := range tests {
+		for operation, operate := range map[string]func(p *Provider, records []libdns.Record) error{
+			"append": func(p *Provider, records []libdns.Record) error {
+				_, err := p.createRecords(context.TODO(), "example.com.", records)
+				return err
+			},
+			"set": func(p *Provider, records []libdns.Record) error {
+				_, err := p.updateRecords(context.TODO(), "example.com.", records)
+				return err
+			},
+		} {
+			t.Run(tt.name+",operation="+operation, func(t *testing.T) {
+				zone := newFakeZone(getOwnedTXTRecordSet(""))
+				provider := getFakeProviderWithZone(zone)
+				provider.StampProvenance = tt.stamp
+				provider.ProvenanceWriter = tt.writer
+				if err := operate(provider, []libdns.Record{
+					libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Duration(30) * time.Second},
+				}); err != nil {
+					t.Fatalf("%s", err)
+				}
+				if len(zone.written) != 1 {
+					t.Fatalf("got: %d record sets, want: 1", len(zone.written))
+				}
+				metadata := zone.written[0].Properties.Metadata
+				if got := stringValue(metadata[writtenByMetadataKey]); got != tt.wantWriter {
+					t.Errorf("got: %v, want: %v", got, tt.wantWriter)
+				}
+				_, stamped := getRecordSetWrittenAt(&zone.written[0])
+				if !tt.stamp {
+					if stamped || metadata[hostnameMetadataKey] != nil {
+						t.Errorf("got: %v", metadata)
+					}
+					return
+				}
+				if got := stringValue(metadata[hostnameMetadataKey]); got != hostname {
+					t.Errorf("got: %v, want: %v", got, hostname)
+				}
+				if !stamped {
+					t.Errorf("the time of the write is not stamped")
+				}
+			})
+		}
+	}
+}
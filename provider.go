@@ -2,19 +2,36 @@ package azure
 
 import (
 	"context"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
 	"github.com/libdns/libdns"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // Provider implements the libdns interfaces for Azure DNS
 type Provider struct {
 
-	// Subscription ID is the ID of the subscription in which the DNS zone is located. Required.
+	// Subscription ID is the ID of the subscription in which the DNS zone is located.
+	// Required unless the zones are given by their resource IDs.
 	SubscriptionId string `json:"subscription_id,omitempty"`
 
-	// Resource Group Name is the name of the resource group in which the DNS zone is located. Required.
+	// Resource Group Name is the name of the resource group in which the DNS zone is located.
+	// Required unless the zones are given by their resource IDs.
 	ResourceGroupName string `json:"resource_group_name,omitempty"`
 
+	// (Optional)
+	// Zone Resource IDs maps the names of zones, e.g. "example.com.", to their resource IDs on Azure Resource Manager,
+	// e.g. "/subscriptions/{id}/resourceGroups/{name}/providers/Microsoft.Network/dnszones/example.com",
+	// so that the zones are written in their own subscriptions and resource groups. A zone may also be passed to the calls
+	// by its resource ID instead of its name.
+	ZoneResourceIDs map[string]string `json:"zone_resource_ids,omitempty"`
+
 	// (Optional)
 	// Tenant ID is the ID of the tenant of the Microsoft Entra ID in which the application is located.
 	// Required only when authenticating using a service principal with a secret.
@@ -33,70 +50,994 @@ type Provider struct {
 	// Do not set any value to authenticate using a managed identity.
 	ClientSecret string `json:"client_secret,omitempty"`
 
-	client Client
+	// (Optional)
+	// Managed Identity Client ID is the client ID of the user-assigned managed identity to authenticate with,
+	// e.g. when a virtual machine, App Service app, or Functions app has several managed identities.
+	// Defaults to the system-assigned managed identity. Used only when authenticating using a managed identity.
+	ManagedIdentityClientId string `json:"managed_identity_client_id,omitempty"`
+
+	// (Optional)
+	// Managed Identity Resource ID is the resource ID of the user-assigned managed identity to authenticate with,
+	// as an alternative to Managed Identity Client ID. Used only when authenticating using a managed identity.
+	ManagedIdentityResourceId string `json:"managed_identity_resource_id,omitempty"`
+
+	// (Optional)
+	// Fallback To Developer Credentials makes the provider fall back to the credentials from the environment variables
+	// of azidentity and Azure CLI when authenticating using a managed identity, so that the same configuration works both on Azure
+	// and on developer machines. Azure Instance Metadata Service is probed for up to a second before the managed identity is used,
+	// and the managed identity is skipped if it does not respond, instead of waiting for the managed identity to time out.
+	FallbackToDeveloperCredentials bool `json:"fallback_to_developer_credentials,omitempty"`
+
+	// (Optional)
+	// Strict Credentials requires Tenant ID, Client ID, and Client Secret all to be set, and authenticates using only
+	// the service principal with them, so that a misconfiguration fails with a clear error instead of falling back
+	// to a managed identity or to the developer credentials. Failures to acquire a token are returned without retrying.
+	StrictCredentials bool `json:"strict_credentials,omitempty"`
+
+	// (Optional)
+	// Additionally Allowed Tenants are the IDs of the tenants other than Tenant ID for which the service principal
+	// may acquire tokens, so that a multi-tenant application can manage zones in the subscriptions of guest tenants.
+	// Set "*" to allow any tenant. Used only when authenticating using a service principal with a secret.
+	AdditionallyAllowedTenants []string `json:"additionally_allowed_tenants,omitempty"`
+
+	// (Optional)
+	// Disable Instance Discovery skips the request to Microsoft Entra ID to discover and validate the authority
+	// before authenticating using a service principal with a secret. Set this only for AD FS, or for disconnected
+	// and air-gapped clouds where the instance discovery endpoint is unreachable, since the authority is then trusted as is.
+	DisableInstanceDiscovery bool `json:"disable_instance_discovery,omitempty"`
+
+	// (Optional)
+	// Token Refresh Offset is how long before their expiry the access tokens are renewed, so that a long call over a slow link
+	// does not send a token that expires on the way. The Azure SDK renews the tokens 5 minutes before their expiry,
+	// which is also the minimum. Set this shorter than the lifetime of the tokens, typically 60 to 90 minutes,
+	// since otherwise a token is acquired for every request.
+	TokenRefreshOffset time.Duration `json:"token_refresh_offset,omitempty"`
+
+	// (Optional)
+	// Cloud selects the cloud to authenticate with and manage zones in, instead of the public cloud: the name of a cloud,
+	// e.g. "AzureChinaCloud" or "AzureUSGovernment", the URL of the Resource Manager of a cloud such as Azure Stack Hub
+	// to read its metadata endpoints from, or "auto" to detect the cloud from AZURE_ENVIRONMENT, or from Azure Instance
+	// Metadata Service on virtual machines. Ignored if the cloud configuration is set in Client Options.
+	Cloud string `json:"cloud,omitempty"`
+
+	// (Optional)
+	// Resource Manager Endpoint is the base URL of Azure Resource Manager, e.g. "https://management.azure.com/".
+	// Set this to reach Azure Resource Manager through a host other than the default one, such as a Private Link endpoint.
+	ResourceManagerEndpoint string `json:"resource_manager_endpoint,omitempty"`
+
+	// (Optional)
+	// Resource Manager Audience is the audience of the access tokens for Azure Resource Manager, e.g. "https://management.core.windows.net/".
+	// Defaults to the audience of Azure Resource Manager in the Azure public cloud.
+	ResourceManagerAudience string `json:"resource_manager_audience,omitempty"`
+
+	// (Optional)
+	// Resource Manager Server Name is the server name used for SNI and certificate verification when connecting to Azure Resource Manager.
+	// Set this when Resource Manager Endpoint points to a host whose certificate is issued for another name, e.g. "management.azure.com".
+	ResourceManagerServerName string `json:"resource_manager_server_name,omitempty"`
+
+	// (Optional)
+	// Client Options are the options of the Azure Resource Manager clients, as an escape hatch for the cloud configuration, retries,
+	// telemetry, transport, and policies not covered by the other settings. The other settings are applied on top of them:
+	// Resource Manager Endpoint and Audience override the cloud configuration, the transport built from the proxy and TLS settings
+	// is used only if Transport is not set, and the policies of the provider are appended to the policies.
+	// The cloud configuration and the transport are used to authenticate with Microsoft Entra ID as well.
+	ClientOptions *arm.ClientOptions `json:"-"`
+
+	// (Optional)
+	// Proxy URL is the URL of the proxy server to send requests to Azure through, e.g. "http://proxy.example.com:8080".
+	// Defaults to the proxy specified by the HTTPS_PROXY and NO_PROXY environment variables.
+	// Requests to the managed identity endpoints on the local host are always sent directly.
+	ProxyURL string `json:"proxy_url,omitempty"`
+
+	// (Optional)
+	// TLS Root CAs is the pool of root CAs to verify the certificates of the servers.
+	// Defaults to the system pool. Set this when the traffic is inspected by a TLS-inspecting proxy with a private CA.
+	TLSRootCAs *x509.CertPool `json:"-"`
+
+	// (Optional)
+	// TLS Root CA Files are the paths to PEM-encoded root CA certificates to verify the certificates of the servers.
+	// The certificates are added to TLS Root CAs, or to the system pool if TLS Root CAs is not set.
+	TLSRootCAFiles []string `json:"tls_root_ca_files,omitempty"`
+
+	// (Optional)
+	// TLS Min Version is the minimum TLS version to connect to the servers. Either "1.2" or "1.3". Defaults to "1.2".
+	TLSMinVersion string `json:"tls_min_version,omitempty"`
+
+	// (Optional)
+	// Max Idle Conns Per Host is the number of idle connections to each server, such as Azure Resource Manager, kept open
+	// to be reused by later requests. Raise it to the number of concurrent requests, e.g. of high-throughput batch syncs,
+	// so that connections are not closed and opened again, exhausting the ephemeral ports. Defaults to 10, as in the Azure SDK.
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host,omitempty"`
+
+	// (Optional)
+	// Max Idle Conns is the number of idle connections to all the servers kept open. Defaults to 100, as in the Azure SDK.
+	MaxIdleConns int `json:"max_idle_conns,omitempty"`
+
+	// (Optional)
+	// Max Conns Per Host limits the connections to each server, including those in use, so that requests beyond it
+	// wait for a connection instead of opening a new one. Defaults to no limit.
+	MaxConnsPerHost int `json:"max_conns_per_host,omitempty"`
+
+	// (Optional)
+	// Idle Conn Timeout is how long an idle connection is kept open. Defaults to 90 seconds, as in the Azure SDK.
+	IdleConnTimeout time.Duration `json:"idle_conn_timeout,omitempty"`
+
+	// (Optional)
+	// Disable HTTP2 sends the requests over HTTP/1.1 only. By default, HTTP/2 is used if the server supports it,
+	// which multiplexes concurrent requests over a single connection to each server.
+	DisableHTTP2 bool `json:"disable_http2,omitempty"`
+
+	// (Optional)
+	// Debug enables logging of every HTTP request to Azure, including the method, URL, status, duration, and bodies.
+	// Headers are not logged, and sensitive values in URLs and bodies such as secrets and tokens are redacted.
+	Debug bool `json:"debug,omitempty"`
+
+	// (Optional)
+	// SDK Log Level enables forwarding the log events of the Azure SDK, such as retries and throttling, to Logger at the level.
+	// One of "debug", "info", "warn", or "error". Since the Azure SDK shares its log listener across the whole process,
+	// the setting of the provider set up last takes effect.
+	SDKLogLevel string `json:"sdk_log_level,omitempty"`
+
+	// (Optional)
+	// Meter Provider is the OpenTelemetry meter provider to emit the metrics of the provider through, such as the number,
+	// the duration, and the retries of the requests to Azure Resource Manager, and the number of records written.
+	// No metrics are emitted if not set.
+	MeterProvider metric.MeterProvider `json:"-"`
+
+	// (Optional)
+	// Logger is the logger to write logs to. Defaults to slog.Default().
+	Logger *slog.Logger `json:"-"`
+
+	// (Optional)
+	// Wait For Provisioning makes the provider poll each written record set until its provisioning state becomes Succeeded before returning,
+	// so that the changes are known to be fully committed rather than merely accepted.
+	WaitForProvisioning bool `json:"wait_for_provisioning,omitempty"`
+
+	// (Optional)
+	// Provisioning Poll Interval is the interval to poll the provisioning state of a record set. Defaults to 2 seconds.
+	ProvisioningPollInterval time.Duration `json:"provisioning_poll_interval,omitempty"`
+
+	// (Optional)
+	// Conflict Retries is the number of times a write to a record set that fails since another writer modified the record set
+	// between reading and writing it is retried, by reading the record set again and merging the change into it,
+	// so that concurrent writers such as ACME clients on several hosts do not fail spuriously. Defaults to 3.
+	// Set a negative value to disable the retries. Deletions with ETags given in DeleteOptions are not retried.
+	ConflictRetries int `json:"conflict_retries,omitempty"`
+
+	// (Optional)
+	// TTL Conflict Policy determines which TTL is used for a record set when records sharing the same name and type have different TTLs.
+	// One of "error", "first", "lowest", or "highest". Defaults to "error".
+	TTLConflictPolicy TTLConflictPolicy `json:"ttl_conflict_policy,omitempty"`
+
+	// (Optional)
+	// Scoped Record Sets are the record sets that the identity is allowed to access, for roles assigned on specific record sets
+	// rather than on the whole zone, e.g. only the TXT record set "_acme-challenge".
+	// When set, GetRecords gets these record sets one by one instead of listing the zone,
+	// and CheckPermissions checks the permissions on these record sets only.
+	ScopedRecordSets []RecordSetScope `json:"scoped_record_sets,omitempty"`
+
+	// (Optional)
+	// Batch Mode determines how AppendRecords, SetRecords, and DeleteRecords handle a record set that fails to be written.
+	// With "fail_fast", the call stops at the first failure, leaving the rest of the record sets unwritten, and returns the records
+	// already written along with the error.
+	// With "best_effort", the call writes all the record sets regardless of failures, and returns the records written
+	// along with a *BatchError of the record sets that failed. Defaults to "fail_fast".
+	BatchMode BatchMode `json:"batch_mode,omitempty"`
+
+	// (Optional)
+	// On Progress is called after each record set is written, or failed to be written, by AppendRecords, SetRecords, DeleteRecords,
+	// WriteRecordsStream, and ImportFrom, with the number of record sets done and the total number of record sets to write in the call,
+	// so that the progress of long writes can be rendered or logged. Record sets rejected before anything is written are not counted.
+	// It is never called concurrently, but may be called from different goroutines when Write Concurrency is above 1.
+	OnProgress func(done int, total int) `json:"-"`
+
+	// (Optional)
+	// Namespaces restrict the records that the provider is allowed to modify to the names matching any of them,
+	// relative to the zone, so that the provider cannot touch the rest of a shared zone. A namespace ending with ".*"
+	// matches the names with the prefix, e.g. "_acme-challenge.*" matches "_acme-challenge" and "_acme-challenge.www".
+	// A namespace starting with "*." matches the names under the subdomain, e.g. "*.dev" matches "dev" and "www.dev".
+	// Any other namespace matches only the name itself, e.g. "@" for the apex. Records outside the namespaces are rejected
+	// before anything is written. Reading records is not restricted.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// (Optional)
+	// Allow Rules are the rules of the records that the provider is allowed to modify. When set, records matching
+	// none of them are rejected before anything is written. Reading records is not restricted.
+	AllowRules []RecordRule `json:"allow_rules,omitempty"`
+
+	// (Optional)
+	// Deny Rules are the rules of the records that the provider is never allowed to modify, e.g. {Type: "MX"} or {Name: "@"},
+	// regardless of Allow Rules. Records matching any of them are rejected before anything is written.
+	DenyRules []RecordRule `json:"deny_rules,omitempty"`
+
+	// (Optional)
+	// Before Write is called with each write to a record set planned by AppendRecords, SetRecords, and DeleteRecords,
+	// just before it is sent to Azure DNS, to enforce custom policies. Returning an error vetoes the write and fails the call,
+	// and modifying the record set to be written changes what is written. The records returned by the call are not affected.
+	// The record sets preceding a vetoed one, or written at the same time when Write Concurrency is above 1, may have already been written.
+	// It is never called concurrently, but may be called from different goroutines when Write Concurrency is above 1.
+	BeforeWrite func(ctx context.Context, write *PlannedWrite) error `json:"-"`
+
+	// (Optional)
+	// Mutation Budget is the maximum number of writes to the record sets of each zone in Mutation Budget Window,
+	// as a safety brake against runaway loops rewriting a zone. A write exceeding the budget fails the call
+	// with a *BudgetExceededError without being sent. Writes are counted when they are sent, even if they fail.
+	// Zero disables the budget, which is the default.
+	MutationBudget int `json:"mutation_budget,omitempty"`
+
+	// (Optional)
+	// Mutation Budget Window is the sliding window in which the writes are counted against Mutation Budget. Defaults to 1 hour.
+	MutationBudgetWindow time.Duration `json:"mutation_budget_window,omitempty"`
+
+	// (Optional)
+	// Owner ID identifies the provider as the owner of the record sets it creates, e.g. the name of the automation system.
+	// It is stamped on the record sets created by the provider as the metadata "libdns_owner", and the provider refuses
+	// to modify or delete the record sets stamped with another owner ID. Record sets without an owner ID are not restricted.
+	// Deleting a record set without a value reads the record set first to check its owner.
+	OwnerId string `json:"owner_id,omitempty"`
+
+	// (Optional)
+	// Override Ownership allows the provider to modify and delete record sets stamped with another owner ID.
+	// Record sets created by the provider are still stamped with Owner ID.
+	OverrideOwnership bool `json:"override_ownership,omitempty"`
+
+	// (Optional)
+	// Stamp Provenance makes the provider stamp every record set it writes with the metadata "libdns_written_by" set to Provenance Writer,
+	// "libdns_hostname" set to the host name of the machine, and "libdns_written_at" set to the time of the write,
+	// so that operators can tell automated record sets from hand-made ones in the Azure portal. The metadata is updated on every write.
+	StampProvenance bool `json:"stamp_provenance,omitempty"`
+
+	// (Optional)
+	// Provenance Writer identifies the writer stamped on record sets with Stamp Provenance, e.g. the name of the automation system.
+	// Defaults to "libdns/azure".
+	ProvenanceWriter string `json:"provenance_writer,omitempty"`
+
+	// (Optional)
+	// Stamp Challenges makes the provider stamp the TXT record sets for ACME DNS challenges it writes, i.e. "_acme-challenge"
+	// and the names under it, with the metadata "libdns_written_at" set to the time of the write, so that CleanupStaleChallenges
+	// can tell how old they are. It is not needed with Stamp Provenance, which stamps the time on every record set.
+	StampChallenges bool `json:"stamp_challenges,omitempty"`
+
+	// (Optional)
+	// Record Set Name generates the name of the record set on Azure DNS, relative to the zone, for the name of a record written
+	// to the zone, for unusual setups such as zones served under aliases or internal suffixes rewritten to the hosted zone.
+	// The name of the record may be relative to the zone or fully qualified. Defaults to DefaultRecordSetName.
+	// The names of the records read from Azure DNS are the names of their record sets as they are.
+	RecordSetName func(name string, zone string) string `json:"-"`
+
+	// (Optional)
+	// Route Records To Zones makes AppendRecords, SetRecords, and DeleteRecords write each record with a fully-qualified name
+	// to the zone in the resource group that the name belongs to, even if it is not the zone passed to the call,
+	// so that records collected for many zones can be written in a single call.
+	RouteRecordsToZones bool `json:"route_records_to_zones,omitempty"`
+
+	// (Optional)
+	// Zone Cache TTL is how long the details of the zones looked up on Azure DNS are cached, such as the zones in the resource group
+	// listed to route records and the name servers returned by GetZoneInfo. Zero disables the cache, which is the default.
+	// The numbers of record sets returned by GetZoneInfo may be outdated by up to the TTL while cached.
+	ZoneCacheTTL time.Duration `json:"zone_cache_ttl,omitempty"`
+
+	// (Optional)
+	// Replica is the provider of a second zone, e.g. a standby zone in another subscription, into which every successful write is mirrored.
+	// A write that fails on the replica does not fail the call, but is reported to On Replica Divergence.
+	Replica *Provider `json:"replica,omitempty"`
+
+	// (Optional)
+	// Replica Zone is the name of the zone on the replica. Defaults to the name of the zone written to.
+	ReplicaZone string `json:"replica_zone,omitempty"`
+
+	// (Optional)
+	// On Replica Divergence is called when a write fails to be mirrored to the replica.
+	// Defaults to logging the divergence to Logger as a warning.
+	OnReplicaDivergence func(ReplicaDivergence) `json:"-"`
+
+	// (Optional)
+	// Mirrors are other libdns providers, e.g. of a secondary DNS vendor, to which every successful write is replicated.
+	// Appends and deletions are replicated as such if the mirror supports them. Otherwise, appends are replicated by setting
+	// the whole record sets as they are on Azure DNS, and deletions fail. A write that fails on a mirror does not fail the call.
+	Mirrors []libdns.RecordSetter `json:"-"`
+
+	// (Optional)
+	// On Mirror is called with the result of mirroring each record after a write is replicated to the mirrors.
+	// Defaults to logging the records that failed to be mirrored to Logger as warnings.
+	OnMirror func([]MirrorResult) `json:"-"`
+
+	// (Optional)
+	// Skip Invalid Record Sets makes the listing of records skip the record sets that cannot be converted to libdns records,
+	// e.g. of a type that this package does not know or with an invalid value, instead of failing the whole listing.
+	// The record sets without properties or with an address that cannot be parsed, listed loosely without it, are skipped as well.
+	SkipInvalidRecordSets bool `json:"skip_invalid_record_sets,omitempty"`
+
+	// (Optional)
+	// On Skipped Record Set is called with each record set skipped by the listing with Skip Invalid Record Sets.
+	// Defaults to logging the record set to Logger as a warning.
+	OnSkippedRecordSet func(SkippedRecordSet) `json:"-"`
+
+	// (Optional)
+	// Soft Delete Grace Period makes the deletions of whole record sets soft: the record sets are stamped with the time
+	// of the deletion and their TTL is lowered, but they are kept on Azure DNS until Purge deletes them after the grace period,
+	// so that a mistaken deletion can be undone by writing the records again. Soft-deleted record sets are not listed.
+	// The record sets for ACME DNS challenges are deleted at once.
+	SoftDeleteGracePeriod time.Duration `json:"soft_delete_grace_period,omitempty"`
+
+	// (Optional)
+	// Follow Challenge CNAMEs makes AppendRecords, SetRecords, and DeleteRecords write the TXT records for ACME DNS challenges
+	// whose names have a CNAME record set in the zone, as in acme-dns style delegation, to the fully-qualified target of the CNAME
+	// instead, in the zone in the resource group that the target belongs to. The records written there are returned with
+	// fully-qualified names. A single CNAME is followed.
+	FollowChallengeCNAMEs bool `json:"follow_challenge_cnames,omitempty"`
+
+	// (Optional)
+	// Private Zone Rules are the rules of the records whose writes are duplicated into the private DNS zone of the same name
+	// as the zone, e.g. {Name: "_acme-challenge.*", Type: "TXT"}, for split-horizon setups where the validation of ACME DNS
+	// challenges from inside the virtual networks resolves the private zone. The private zone must exist, and a call fails
+	// if the records cannot be written there after they were written to the public zone.
+	PrivateZoneRules []RecordRule `json:"private_zone_rules,omitempty"`
+
+	// (Optional)
+	// Private Zone Resource Group Name is the resource group of the private DNS zones of Private Zone Rules.
+	// Defaults to the resource group of the public zone.
+	PrivateZoneResourceGroupName string `json:"private_zone_resource_group_name,omitempty"`
+
+	// (Optional)
+	// Detect Private Zones makes the calls for a zone find whether it exists as a public DNS zone, a private DNS zone,
+	// or both in the resource group, and manage the private zone if it exists only as such, so that callers need not know.
+	// The visibilities are detected on every call unless Zone Cache TTL is set. Listing the zones lists the public ones only.
+	DetectPrivateZones bool `json:"detect_private_zones,omitempty"`
+
+	// (Optional)
+	// Preferred Zone Visibility is the zone that Detect Private Zones makes the calls manage for a zone that exists as both
+	// a public and a private DNS zone, "public" or "private". Defaults to "public".
+	PreferredZoneVisibility ZoneVisibility `json:"preferred_zone_visibility,omitempty"`
+
+	// (Optional)
+	// Default TTL is the TTL of the records written by AppendRecords and SetRecords without a TTL.
+	// Defaults to zero, which writes them with a TTL of zero.
+	DefaultTTL time.Duration `json:"default_ttl,omitempty"`
+
+	// (Optional)
+	// Record Set Metadata is the metadata stamped on every record set written, e.g. {"managed_by": "cert-renewer"},
+	// in addition to the metadata kept from the existing record sets.
+	RecordSetMetadata map[string]string `json:"record_set_metadata,omitempty"`
+
+	// (Optional)
+	// Read Only makes AppendRecords, SetRecords, and DeleteRecords fail without writing anything,
+	// so that a provider can read zones that it must not modify.
+	ReadOnly bool `json:"read_only,omitempty"`
+
+	// (Optional)
+	// Write Concurrency is the maximum number of record sets written at the same time by a call to AppendRecords,
+	// SetRecords, or DeleteRecords. Before Write and On Progress are then called from the goroutines writing the record sets,
+	// one at a time. Defaults to 1, writing them one by one.
+	WriteConcurrency int `json:"write_concurrency,omitempty"`
+
+	// (Optional)
+	// Zone Options are the settings overriding Default TTL, Record Set Metadata, Read Only, and Write Concurrency
+	// for individual zones, keyed by the names of the zones, compared regardless of the case and the trailing dot.
+	ZoneOptions map[string]ZoneOptions `json:"zone_options,omitempty"`
+
+	client         Client
+	zoneCache      zoneCache
+	mutationBudget mutationBudget
+	overrides      overrides
+	stats          providerStats
+
+	// private makes the provider manage the private DNS zones of its location instead of the public ones.
+	private bool
+}
+
+// RecordSetScope identifies a record set in a zone.
+type RecordSetScope struct {
+	// Name is the name of the record set relative to the zone, e.g. "_acme-challenge", or "@" for the apex.
+	// An absolute name, e.g. "_acme-challenge.example.com.", is accepted as well.
+	Name string `json:"name,omitempty"`
+
+	// Type is the type of the record set, e.g. "TXT".
+	Type string `json:"type,omitempty"`
+}
+
+// RecordRule matches records by their name and type.
+type RecordRule struct {
+	// Name is the pattern of the names relative to the zone, in the same form as Namespaces, e.g. "_acme-challenge.*",
+	// "*.dev", or "@" for the apex. Empty or "*" matches any name.
+	Name string `json:"name,omitempty"`
+
+	// Type is the type of the records, e.g. "MX". Empty or "*" matches any type.
+	Type string `json:"type,omitempty"`
+}
+
+// TTLConflictPolicy is a policy to resolve conflicting TTLs of records in the same record set.
+type TTLConflictPolicy string
+
+const (
+	// TTLConflictPolicyError rejects records with conflicting TTLs.
+	TTLConflictPolicyError TTLConflictPolicy = "error"
+
+	// TTLConflictPolicyFirst uses the TTL of the first record.
+	TTLConflictPolicyFirst TTLConflictPolicy = "first"
+
+	// TTLConflictPolicyLowest uses the lowest TTL among the records.
+	TTLConflictPolicyLowest TTLConflictPolicy = "lowest"
+
+	// TTLConflictPolicyHighest uses the highest TTL among the records.
+	TTLConflictPolicyHighest TTLConflictPolicy = "highest"
+)
+
+// SetClientSecret replaces the client secret of the application used for authentication.
+// The client is rebuilt with the new secret on the next call, after any in-flight calls have finished,
+// so that the secret can be rotated without recreating the provider.
+// A provider given a shared credential by a registry is given the one shared under the new secret instead.
+func (p *Provider) SetClientSecret(clientSecret string) {
+	p.client.mutex.Lock()
+	p.ClientSecret = clientSecret
+	registry := p.client.registry
+	if registry != nil {
+		// The shared credential was built with the old secret
+		p.client.credential = nil
+		p.client.registry = nil
+	}
+	p.resetClient()
+	p.resetOverrides()
+	p.client.mutex.Unlock()
+
+	// The registry is called with the client unlocked, since it locks the client of the provider while registered
+	if registry != nil {
+		registry.rekey(p)
+	}
+}
+
+// SetCredential replaces the credential used for authentication.
+// The credential takes precedence over TenantId, ClientId, and ClientSecret,
+// and the client is rebuilt with it on the next call, after any in-flight calls have finished.
+// Pass nil to authenticate using the fields of the provider again.
+func (p *Provider) SetCredential(credential azcore.TokenCredential) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	p.client.credential = credential
+	p.client.registry = nil
+	p.resetClient()
+	p.resetOverrides()
+}
+
+// setSharedCredential replaces the credential used for authentication with the one shared by the registry.
+func (p *Provider) setSharedCredential(credential azcore.TokenCredential, registry *Registry) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	p.client.credential = credential
+	p.client.registry = registry
+	p.resetClient()
+	p.resetOverrides()
+}
+
+// Close releases the resources held by the provider, so that an application embedding it can shut down cleanly.
+// The idle connections of the transports built by the provider are closed, the clients and the credentials with their
+// cached tokens are dropped, and the cached zones are flushed, as are those of the providers derived for the locations
+// set by contexts and for private DNS zones. The credential set by SetCredential and the transport given in Client Options
+// are left to their owners. The provider remains usable, and sets up its client again on the next call.
+func (p *Provider) Close() error {
+	p.client.mutex.Lock()
+	p.resetClient()
+	p.client.mutex.Unlock()
+
+	p.overrides.mutex.Lock()
+	var providers []*Provider
+	for _, provider := range p.overrides.providers {
+		providers = append(providers, provider)
+	}
+	for _, provider := range p.overrides.privateProviders {
+		providers = append(providers, provider)
+	}
+	p.overrides.providers = nil
+	p.overrides.privateProviders = nil
+	p.overrides.generation++
+	p.overrides.mutex.Unlock()
+	for _, provider := range providers {
+		provider.Close()
+	}
+
+	p.InvalidateZoneCache()
+
+	return nil
+}
+
+// ARMRecordSetsClient returns the record sets client of the Azure SDK used by the provider, setting it up if necessary.
+// It allows to perform operations that this package does not wrap without duplicating the authentication.
+// Call it again after rotating the credential, since the client is rebuilt with the new credential.
+func (p *Provider) ARMRecordSetsClient() (*armdns.RecordSetsClient, error) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return nil, err
+	}
+
+	client, ok := p.client.azureClient.(*armdns.RecordSetsClient)
+	if !ok {
+		return nil, fmt.Errorf("the record sets client of private zones is not a client of Azure DNS")
+	}
+	return client, nil
+}
+
+// ARMZonesClient returns the zones client of the Azure SDK used by the provider, setting it up if necessary.
+// It allows to perform operations that this package does not wrap without duplicating the authentication.
+// Call it again after rotating the credential, since the client is rebuilt with the new credential.
+func (p *Provider) ARMZonesClient() (*armdns.ZonesClient, error) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return nil, err
+	}
+
+	client, ok := p.client.zonesClient.(*armdns.ZonesClient)
+	if !ok {
+		return nil, fmt.Errorf("the zones client of private zones is not a client of Azure DNS")
+	}
+	return client, nil
+}
+
+// ZoneInfo is the details of a DNS zone on Azure DNS.
+type ZoneInfo struct {
+	// Name is the fully-qualified name of the zone, e.g. "example.com.".
+	Name string
+
+	// ID is the resource ID of the zone on Azure Resource Manager.
+	ID string
+
+	// NameServers are the name servers assigned to the zone, to which the zone should be delegated.
+	NameServers []string
+
+	// NumberOfRecordSets is the current number of record sets in the zone.
+	NumberOfRecordSets int64
+
+	// MaxNumberOfRecordSets is the maximum number of record sets that can be created in the zone.
+	MaxNumberOfRecordSets int64
+
+	// MaxNumberOfRecordsPerRecordSet is the maximum number of records per record set that can be created in the zone.
+	MaxNumberOfRecordsPerRecordSet int64
+
+	// Tags are the resource tags of the zone.
+	Tags map[string]string
+
+	// ZoneType is the type of the zone, either "Public" or "Private".
+	ZoneType string
+}
+
+// GetZoneInfo returns the details of the zone, such as the assigned name servers and the number of record sets.
+// If Zone Cache TTL is set, the details are cached for the TTL.
+func (p *Provider) GetZoneInfo(ctx context.Context, zone string) (ZoneInfo, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.GetZoneInfo(ctx, zone)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	zoneInfo, err := p.getCachedZoneInfo(ctx, zone)
+	if err != nil {
+		return ZoneInfo{}, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+
+	return zoneInfo, nil
+}
+
+// ListZonesOptions are the conditions to filter zones listed by ListZonesWithOptions.
+type ListZonesOptions struct {
+	// ResourceGroupName is the name of the resource group to list zones in.
+	// Leave it empty to list zones in all resource groups of the subscription.
+	ResourceGroupName string
+
+	// Tags are the resource tags that zones must have.
+	// A tag with an empty value matches zones having the tag key with any value.
+	Tags map[string]string
+
+	// NameSuffix is the suffix that the fully-qualified names of zones must end with, e.g. "example.com.".
+	// The suffix matches only whole labels, so "example.com." matches "sub.example.com." but not "myexample.com.".
+	NameSuffix string
+
+	// PageSize is the maximum number of zones fetched from Azure per request. Defaults to the page size of Azure.
+	PageSize int32
+
+	// MaxResults is the maximum number of zones to return. Zero means no limit.
+	MaxResults int
+}
+
+// ListZones lists all the zones in the resource group of the provider.
+func (p *Provider) ListZones(ctx context.Context) ([]libdns.Zone, error) {
+	if provider := p.forContext(ctx); provider != p {
+		return provider.ListZones(ctx)
+	}
+
+	zoneInfos, err := p.ListZonesWithOptions(ctx, ListZonesOptions{
+		ResourceGroupName: p.ResourceGroupName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var zones []libdns.Zone
+	for _, zoneInfo := range zoneInfos {
+		zones = append(zones, libdns.Zone{
+			Name: zoneInfo.Name,
+		})
+	}
+
+	return zones, nil
+}
+
+// ListZonesWithOptions lists the details of the zones in the subscription matching the options.
+func (p *Provider) ListZonesWithOptions(ctx context.Context, options ListZonesOptions) ([]ZoneInfo, error) {
+	if provider := p.forContext(ctx); provider != p {
+		return provider.ListZonesWithOptions(ctx, options)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	zoneInfos, err := p.listZones(ctx, options)
+	if err != nil {
+		return nil, wrapCorrelationID(enrichAuthorizationError(err, p.generateListScope(options)), correlationID)
+	}
+
+	return zoneInfos, nil
+}
+
+// IterateZones returns an iterator over the details of the zones in the subscription matching the options.
+// Zones are fetched page by page as the iteration proceeds, so that a large number of zones can be handled incrementally.
+// The context passed to Next is used to fetch each page; the correlation ID, if any, should be carried by it.
+func (p *Provider) IterateZones(options ListZonesOptions) *ZoneIterator {
+	return &ZoneIterator{
+		provider: p,
+		options:  options,
+	}
+}
+
+// CheckPermissions verifies that the identity is allowed to read and write record sets in the zone,
+// using the permissions API of Azure Resource Manager without modifying anything.
+// It returns the required actions that are not allowed, or nil if all of them are allowed.
+func (p *Provider) CheckPermissions(ctx context.Context, zone string) ([]string, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.CheckPermissions(ctx, zone)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	missingActions, err := p.checkPermissions(ctx, zone)
+	if err != nil {
+		return nil, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+
+	return missingActions, nil
 }
 
 // GetRecords lists all the records in the zone.
+// The names of the records are relative to the zone, with "@" for the apex.
 func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
-	records, err := p.getRecords(ctx, zone)
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.GetRecords(ctx, zone)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	records, err := p.getRecords(ctx, zone, "")
 	if err != nil {
-		return nil, err
+		return nil, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+
+	return records, nil
+}
+
+// GetRecordsFunc lists the records in the zone page by page as they are fetched from Azure DNS, calling fn with the records of each page,
+// so that large zones are listed without holding all their records in memory. It stops at the first error returned by fn
+// and returns the error as is, so that the listing can be aborted early, e.g. once a record is found.
+// The names of the records are relative to the zone, with "@" for the apex.
+func (p *Provider) GetRecordsFunc(ctx context.Context, zone string, fn func([]libdns.Record) error) error {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.GetRecordsFunc(ctx, zone, fn)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	var fnErr error
+	err := p.getRecordsFunc(ctx, zone, "", func(records []libdns.Record) error {
+		fnErr = fn(records)
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+
+	return nil
+}
+
+// GetRecordsOfType lists the records of the type in the zone, e.g. "TXT".
+// The record sets are filtered by Azure DNS, which transfers much less data than GetRecords for large zones.
+// The names of the records are relative to the zone, with "@" for the apex.
+func (p *Provider) GetRecordsOfType(ctx context.Context, zone string, typeName string) ([]libdns.Record, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.GetRecordsOfType(ctx, zone, typeName)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	records, err := p.getRecords(ctx, zone, typeName)
+	if err != nil {
+		return nil, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
 	}
 
 	return records, nil
 }
 
 // AppendRecords adds records to the zone. It returns the records that were added.
+// Records sharing the same name and type are appended to the same record set.
+// If zone is empty, or Route Records To Zones is set, the zone of each record is inferred from its fully-qualified name.
 func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	var createdRecords []libdns.Record
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.AppendRecords(ctx, zone, records)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
 
-	for _, record := range records {
-		createdRecord, err := p.createRecord(ctx, zone, record)
-		if err != nil {
-			return nil, err
-		}
-		createdRecords = append(createdRecords, createdRecord)
+	followedRecords, followed, err := p.followChallengeCNAMEs(ctx, zone, records)
+	if err != nil {
+		return nil, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+	if followed {
+		return p.writeFollowed(ctx, zone, followedRecords, p.AppendRecords)
 	}
 
+	if p.needsRouting(zone, records) {
+		return p.writeToZones(ctx, zone, records, p.AppendRecords)
+	}
+
+	createdRecords, err := p.createRecords(ctx, zone, records)
+
+	// The records written before a failure are replicated and returned along with the error
+	p.replicate(ctx, WriteOperationAppend, zone, createdRecords)
+	p.mirror(ctx, WriteOperationAppend, zone, createdRecords)
+	p.getMetrics().recordRecords(ctx, WriteOperationAppend, zone, createdRecords)
+	if privateErr := p.writePrivateZone(ctx, WriteOperationAppend, zone, createdRecords); err == nil {
+		err = privateErr
+	}
+
+	if err != nil {
+		return createdRecords, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
 	return createdRecords, nil
 }
 
 // SetRecords sets the records in the zone, either by updating existing records
 // or creating new ones. It returns the updated records.
+// Records sharing the same name and type are written to the same record set.
+// The metadata of existing record sets is kept, and record sets that are aliases of Azure resources are not overwritten.
+// If zone is empty, or Route Records To Zones is set, the zone of each record is inferred from its fully-qualified name.
 func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	var updatedRecords []libdns.Record
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.SetRecords(ctx, zone, records)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	followedRecords, followed, err := p.followChallengeCNAMEs(ctx, zone, records)
+	if err != nil {
+		return nil, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+	if followed {
+		return p.writeFollowed(ctx, zone, followedRecords, p.SetRecords)
+	}
 
-	for _, record := range records {
-		updatedRecord, err := p.updateRecord(ctx, zone, record)
-		if err != nil {
-			return nil, err
-		}
-		updatedRecords = append(updatedRecords, updatedRecord)
+	if p.needsRouting(zone, records) {
+		return p.writeToZones(ctx, zone, records, p.SetRecords)
 	}
 
+	updatedRecords, err := p.updateRecords(ctx, zone, records)
+
+	// The records written before a failure are replicated and returned along with the error
+	p.replicate(ctx, WriteOperationSet, zone, updatedRecords)
+	p.mirror(ctx, WriteOperationSet, zone, updatedRecords)
+	p.getMetrics().recordRecords(ctx, WriteOperationSet, zone, updatedRecords)
+	if privateErr := p.writePrivateZone(ctx, WriteOperationSet, zone, updatedRecords); err == nil {
+		err = privateErr
+	}
+
+	if err != nil {
+		return updatedRecords, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
 	return updatedRecords, nil
 }
 
-// DeleteRecords deletes the records from the zone. If a record does not have an ID,
-// it will be looked up. It returns the records that were deleted.
+// DeleteRecords deletes the records from the zone. It returns the records that were deleted.
+// A record without a value, e.g. libdns.RR{Name: "_acme-challenge", Type: "TXT"}, deletes the whole record set
+// sharing its name and type without reading it. A record with a value removes only the value from the record set,
+// reading just that record set, and deletes the record set if no values remain. Values that do not exist are ignored.
+// If zone is empty, or Route Records To Zones is set, the zone of each record is inferred from its fully-qualified name.
 func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	var deletedRecords []libdns.Record
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.DeleteRecords(ctx, zone, records)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	followedRecords, followed, err := p.followChallengeCNAMEs(ctx, zone, records)
+	if err != nil {
+		return nil, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+	if followed {
+		return p.writeFollowed(ctx, zone, followedRecords, p.DeleteRecords)
+	}
+
+	if p.needsRouting(zone, records) {
+		return p.writeToZones(ctx, zone, records, p.DeleteRecords)
+	}
+
+	return p.DeleteRecordsWithOptions(ctx, zone, records, DeleteOptions{})
+}
+
+// DeleteOptions are options to delete records with DeleteRecordsWithOptions.
+type DeleteOptions struct {
+	// Exact makes each record set deleted only if its current values are exactly the values of the records
+	// sharing its name and type, regardless of the order, so that a record set modified by someone else
+	// since the caller read it is left as it is. Records must have values with this option.
+	Exact bool
+
+	// ETags are the ETags that the record sets must still have to be deleted or modified, keyed by "name/type",
+	// e.g. "_acme-challenge/TXT", as in the Record Set ETags of ZoneToken. Record sets without an ETag here are not checked.
+	ETags map[string]string
+}
+
+// DeleteRecordsWithOptions deletes the records from the zone as DeleteRecords does, but only if the record sets
+// are as the caller believes them to be. If a record set has been modified, it returns a *ModifiedError
+// without deleting the record set. Record sets are checked and deleted one by one,
+// so the record sets preceding the modified one may have already been deleted.
+func (p *Provider) DeleteRecordsWithOptions(ctx context.Context, zone string, records []libdns.Record, options DeleteOptions) ([]libdns.Record, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.DeleteRecordsWithOptions(ctx, zone, records, options)
+	}
 
-	for _, record := range records {
-		deletedRecord, err := p.deleteRecord(ctx, zone, record)
-		if err != nil {
-			return nil, err
-		}
-		deletedRecords = append(deletedRecords, deletedRecord)
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	deletedRecords, err := p.deleteRecords(ctx, zone, records, options)
+
+	// The records written before a failure are replicated and returned along with the error
+	p.replicate(ctx, WriteOperationDelete, zone, deletedRecords)
+	p.mirror(ctx, WriteOperationDelete, zone, deletedRecords)
+	p.getMetrics().recordRecords(ctx, WriteOperationDelete, zone, deletedRecords)
+	if privateErr := p.writePrivateZone(ctx, WriteOperationDelete, zone, deletedRecords); err == nil {
+		err = privateErr
 	}
 
+	if err != nil {
+		return deletedRecords, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
 	return deletedRecords, nil
 }
 
+// AuthorizationError is returned when Azure Resource Manager rejects a request since the identity lacks a permission.
+type AuthorizationError struct {
+	// Action is the action that the identity is not allowed to perform, e.g. "Microsoft.Network/dnszones/TXT/write".
+	Action string
+
+	// Scope is the resource ID on which a role granting the action should be assigned.
+	Scope string
+
+	// Err is the original error returned by Azure Resource Manager.
+	Err error
+}
+
+// Error returns the error message with a hint to resolve the error.
+func (e *AuthorizationError) Error() string {
+	action := e.Action
+	if action == "" {
+		action = "the action"
+	}
+	return fmt.Sprintf("the identity is not authorized to perform %v; assign the 'DNS Zone Contributor' role, or a custom role allowing the action, on %v: %v", action, e.Scope, e.Err)
+}
+
+// Unwrap returns the original error.
+func (e *AuthorizationError) Unwrap() error {
+	return e.Err
+}
+
+// CredentialError is returned when the credentials of the provider fail to acquire a token for a common reason,
+// such as an invalid client secret or an unreachable managed identity endpoint.
+type CredentialError struct {
+	// Reason explains why the token cannot be acquired, e.g. "the client secret is invalid".
+	Reason string
+
+	// Hint suggests how to fix the error.
+	Hint string
+
+	// Err is the original error returned by the credentials.
+	Err error
+}
+
+// Error returns the error message with a hint to resolve the error.
+func (e *CredentialError) Error() string {
+	return fmt.Sprintf("%v; %v: %v", e.Reason, e.Hint, e.Err)
+}
+
+// Unwrap returns the original error.
+func (e *CredentialError) Unwrap() error {
+	return e.Err
+}
+
+// ModifiedError is returned by DeleteRecordsWithOptions when a record set has been modified since the caller read it,
+// and thus is not deleted.
+type ModifiedError struct {
+	// Name is the name of the record set relative to the zone.
+	Name string
+
+	// Type is the type of the record set.
+	Type string
+
+	// Err is the original error returned by Azure Resource Manager, if the modification was detected by Azure Resource Manager.
+	Err error
+}
+
+// Error returns the error message.
+func (e *ModifiedError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("the record set %v %v has been modified: %v", e.Name, e.Type, e.Err)
+	}
+	return fmt.Sprintf("the record set %v %v has been modified", e.Name, e.Type)
+}
+
+// Unwrap returns the original error.
+func (e *ModifiedError) Unwrap() error {
+	return e.Err
+}
+
+// BudgetExceededError is returned when a write would exceed the Mutation Budget of the zone, and thus is not sent.
+type BudgetExceededError struct {
+	// Zone is the name of the zone whose budget has been used up.
+	Zone string
+
+	// Budget is the maximum number of writes in the window.
+	Budget int
+
+	// Window is the sliding window in which the writes are counted.
+	Window time.Duration
+
+	// RetryAfter is the time until the budget allows a write again.
+	RetryAfter time.Duration
+}
+
+// Error returns the error message.
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("the budget of %v writes per %v for the zone %v has been used up; retry after %v", e.Budget, e.Window, e.Zone, e.RetryAfter.Round(time.Second))
+}
+
+// WithCorrelationID returns a copy of ctx carrying the correlation ID.
+// The correlation ID is sent as the x-ms-correlation-request-id header with every request to Azure Resource Manager
+// made with the context, so that the requests can be traced in Azure Activity Log as one unit.
+// If the context has no correlation ID, a new one is generated for each call of the provider.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation ID carried by ctx, if any.
+func CorrelationIDFromContext(ctx context.Context) (string, bool) {
+	correlationID, ok := ctx.Value(correlationIDKey{}).(string)
+	return correlationID, ok && correlationID != ""
+}
+
 // Interface guards
 var (
 	_ libdns.RecordGetter   = (*Provider)(nil)
 	_ libdns.RecordAppender = (*Provider)(nil)
 	_ libdns.RecordSetter   = (*Provider)(nil)
 	_ libdns.RecordDeleter  = (*Provider)(nil)
+	_ libdns.ZoneLister     = (*Provider)(nil)
 )
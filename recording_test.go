@@ -0,0 +1,384 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns/fake"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+const (
+	// recordModeRecord records the interactions of the recorded tests with a real zone, set by AZURE_RECORD_MODE.
+	recordModeRecord = "record"
+
+	// recordModePlayback replays the recorded interactions offline. It is the default.
+	recordModePlayback = "playback"
+
+	// The values the subscription, the resource group, and the zone of the real zone are replaced with in the recordings.
+	recordedSubscriptionId    = "00000000-0000-0000-0000-000000000000"
+	recordedResourceGroupName = "recorded-resource-group-name"
+	recordedZone              = "example.com."
+)
+
+// recordingsDir is the directory of the recordings, named after the tests.
+var recordingsDir = filepath.Join("testdata", "recordings")
+
+// recordedInteraction is a request to Resource Manager and its response, with the values of the real zone replaced.
+type recordedInteraction struct {
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	RequestBody     string            `json:"request_body,omitempty"`
+	StatusCode      int               `json:"status_code"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ResponseBody    string            `json:"response_body,omitempty"`
+}
+
+// recordedResponseHeaders are the response headers kept in the recordings. The others, e.g. request IDs, differ on every run.
+var recordedResponseHeaders = []string{"Content-Type", "ETag", "Retry-After"}
+
+// recorder is a transport that records the interactions with Resource Manager through another transport,
+// or replays the recorded interactions in order of their method and URL, so that tests recorded once against
+// a real zone run offline without credentials. Requests to Microsoft Entra ID are passed through unrecorded.
+type recorder struct {
+	mode         string
+	path         string
+	transport    policy.Transporter
+	replacer     *strings.Replacer
+	interactions []recordedInteraction
+	used         []bool
+	mutex        sync.Mutex
+}
+
+// newRecorder returns a recorder of the mode for the recording at the path. In playback mode, the recording is loaded.
+// The replacements are pairs of the values of the real zone and the values they are replaced with.
+func newRecorder(mode string, path string, transport policy.Transporter, replacements ...string) (*recorder, error) {
+	r := &recorder{mode: mode, path: path, transport: transport, replacer: strings.NewReplacer(replacements...)}
+	if mode == recordModeRecord {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.interactions); err != nil {
+		return nil, fmt.Errorf("the recording %v cannot be interpreted: %w", path, err)
+	}
+	r.used = make([]bool, len(r.interactions))
+	return r, nil
+}
+
+func (r *recorder) Do(req *http.Request) (*http.Response, error) {
+	if r.mode == recordModeRecord {
+		return r.record(req)
+	}
+	return r.replay(req)
+}
+
+func (r *recorder) record(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		if requestBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+	resp, err := r.transport.Do(req)
+	if err != nil || !strings.Contains(req.URL.Path, "/subscriptions/") {
+		return resp, err
+	}
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	interaction := recordedInteraction{
+		Method:       req.Method,
+		URL:          r.replacer.Replace(req.URL.String()),
+		RequestBody:  r.replacer.Replace(string(requestBody)),
+		StatusCode:   resp.StatusCode,
+		ResponseBody: r.replacer.Replace(string(responseBody)),
+	}
+	for _, header := range recordedResponseHeaders {
+		if value := resp.Header.Get(header); value != "" {
+			if interaction.ResponseHeaders == nil {
+				interaction.ResponseHeaders = map[string]string{}
+			}
+			interaction.ResponseHeaders[header] = r.replacer.Replace(value)
+		}
+	}
+	r.mutex.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.mutex.Unlock()
+	return resp, nil
+}
+
+func (r *recorder) replay(req *http.Request) (*http.Response, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Method != req.Method || interaction.URL != req.URL.String() {
+			continue
+		}
+		r.used[i] = true
+		header := http.Header{}
+		for key, value := range interaction.ResponseHeaders {
+			header.Set(key, value)
+		}
+		return &http.Response{
+			StatusCode: interaction.StatusCode,
+			Status:     fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+			Header:     header,
+			Body:       io.NopCloser(strings.NewReader(interaction.ResponseBody)),
+			Request:    req,
+		}, nil
+	}
+	return nil, fmt.Errorf("the recording %v has no response left for %v %v", r.path, req.Method, req.URL)
+}
+
+// save writes the recorded interactions to the recording.
+func (r *recorder) save() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// unused returns the number of the recorded interactions not replayed.
+func (r *recorder) unused() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	count := 0
+	for _, used := range r.used {
+		if !used {
+			count++
+		}
+	}
+	return count
+}
+
+// getRecordedProvider returns the provider and the zone for a recorded test. With AZURE_RECORD_MODE=record, the provider
+// works on the real zone given by the environment variables of the example, and the recording is saved when the test finishes.
+// Otherwise, the recording named after the test is replayed, and the test is skipped if there is none.
+func getRecordedProvider(t *testing.T) (*Provider, string) {
+	t.Helper()
+	path := filepath.Join(recordingsDir, strings.ReplaceAll(t.Name(), "/", "_")+".json")
+
+	if os.Getenv("AZURE_RECORD_MODE") != recordModeRecord {
+		r, err := newRecorder(recordModePlayback, path, nil)
+		if errors.Is(err, os.ErrNotExist) {
+			t.Skipf("no recording at %v; run with AZURE_RECORD_MODE=record against a real zone to record it", path)
+		}
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		t.Cleanup(func() {
+			if n := r.unused(); n > 0 && !t.Failed() {
+				t.Errorf("%d recorded interactions were not replayed; record the test again", n)
+			}
+		})
+		provider := &Provider{
+			SubscriptionId:    recordedSubscriptionId,
+			ResourceGroupName: recordedResourceGroupName,
+			ClientOptions:     getRecordingClientOptions(r),
+		}
+		provider.SetCredential(&azfake.TokenCredential{})
+		return provider, recordedZone
+	}
+
+	zone := os.Getenv("AZURE_DNS_ZONE_FQDN")
+	if zone == "" {
+		t.Fatalf("AZURE_DNS_ZONE_FQDN must be set to record %v", t.Name())
+	}
+	provider := &Provider{
+		SubscriptionId:    os.Getenv("AZURE_SUBSCRIPTION_ID"),
+		ResourceGroupName: os.Getenv("AZURE_RESOURCE_GROUP_NAME"),
+		TenantId:          os.Getenv("AZURE_TENANT_ID"),
+		ClientId:          os.Getenv("AZURE_CLIENT_ID"),
+		ClientSecret:      os.Getenv("AZURE_CLIENT_SECRET"),
+	}
+	zoneName := strings.TrimSuffix(zone, ".")
+	r, _ := newRecorder(recordModeRecord, path, http.DefaultClient,
+		provider.SubscriptionId, recordedSubscriptionId,
+		provider.ResourceGroupName, recordedResourceGroupName,
+		zoneName, strings.TrimSuffix(recordedZone, "."),
+		strings.ToLower(zoneName), strings.TrimSuffix(recordedZone, "."),
+	)
+	provider.ClientOptions = getRecordingClientOptions(r)
+	t.Cleanup(func() {
+		provider.Close()
+		if err := r.save(); err != nil {
+			t.Errorf("the recording cannot be saved: %v", err)
+		}
+	})
+	return provider, zone
+}
+
+func getRecordingClientOptions(r *recorder) *arm.ClientOptions {
+	return &arm.ClientOptions{
+		ClientOptions: azcore.ClientOptions{
+			Transport: r,
+			// Retries would make the replayed requests depend on the timing of the recording
+			Retry: policy.RetryOptions{MaxRetries: -1},
+		},
+	}
+}
+
+// runRecordedScenario appends, lists, sets, and deletes records under a fixed name, returning the records returned by each call.
+func runRecordedScenario(t *testing.T, provider *Provider, zone string) [][]libdns.Record {
+	t.Helper()
+	ctx := context.Background()
+	name := "libdns-recorded"
+	var results [][]libdns.Record
+
+	appended, err := provider.AppendRecords(ctx, zone, []libdns.Record{
+		libdns.TXT{Name: name, TTL: 5 * time.Minute, Text: "first"},
+		libdns.TXT{Name: name, TTL: 5 * time.Minute, Text: "second"},
+	})
+	if err != nil {
+		t.Fatalf("AppendRecords: %v", err)
+	}
+	results = append(results, appended)
+
+	set, err := provider.SetRecords(ctx, zone, []libdns.Record{
+		libdns.TXT{Name: name, TTL: 5 * time.Minute, Text: "third"},
+	})
+	if err != nil {
+		t.Fatalf("SetRecords: %v", err)
+	}
+	results = append(results, set)
+
+	records, err := provider.GetRecords(ctx, zone)
+	if err != nil {
+		t.Fatalf("GetRecords: %v", err)
+	}
+	var listed []libdns.Record
+	for _, record := range records {
+		if record.RR().Name == name {
+			listed = append(listed, record)
+		}
+	}
+	results = append(results, listed)
+
+	deleted, err := provider.DeleteRecords(ctx, zone, []libdns.Record{libdns.TXT{Name: name, Text: "third"}})
+	if err != nil {
+		t.Fatalf("DeleteRecords: %v", err)
+	}
+	results = append(results, deleted)
+	return results
+}
+
+func Test_recorded(t *testing.T) {
+	provider, zone := getRecordedProvider(t)
+	results := runRecordedScenario(t, provider, zone)
+	var got [][]string
+	for _, records := range results {
+		var texts []string
+		for _, record := range records {
+			texts = append(texts, record.RR().Data)
+		}
+		got = append(got, texts)
+	}
+	want := [][]string{{"first", "second"}, {"third"}, {"third"}, {"third"}}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
+
+// errorResponseTransport returns the error responses of the fake server as responses, as Azure DNS does,
+// rather than as errors of the transport.
+type errorResponseTransport struct {
+	transport policy.Transporter
+}
+
+func (e errorResponseTransport) Do(req *http.Request) (*http.Response, error) {
+	resp, err := e.transport.Do(req)
+	var responseError *azcore.ResponseError
+	if errors.As(err, &responseError) && responseError.RawResponse != nil {
+		return responseError.RawResponse, nil
+	}
+	return resp, err
+}
+
+func Test_recorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.json")
+	zone := newFakeZone()
+	fakeTransport := fake.NewServerFactoryTransport(&fake.ServerFactory{
+		RecordSetsServer: zone.server(),
+		ZonesServer:      zone.zonesServer(),
+	})
+
+	// Record against the fake server as if it were a real zone in another subscription
+	r, err := newRecorder(recordModeRecord, path, errorResponseTransport{fakeTransport}, "real-subscription-id", recordedSubscriptionId, "real-resource-group-name", recordedResourceGroupName)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	recording := &Provider{
+		SubscriptionId:    "real-subscription-id",
+		ResourceGroupName: "real-resource-group-name",
+		ClientOptions:     getRecordingClientOptions(r),
+	}
+	recording.SetCredential(&azfake.TokenCredential{})
+	want := runRecordedScenario(t, recording, recordedZone)
+	if err := r.save(); err != nil {
+		t.Fatalf("%s", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if strings.Contains(string(data), "real-subscription-id") || strings.Contains(string(data), "real-resource-group-name") {
+		t.Errorf("the recording has the values of the real zone: %s", data)
+	}
+
+	// Replay without the fake server
+	callsRecorded := len(zone.calls)
+	r, err = newRecorder(recordModePlayback, path, nil)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	replaying := &Provider{
+		SubscriptionId:    recordedSubscriptionId,
+		ResourceGroupName: recordedResourceGroupName,
+		ClientOptions:     getRecordingClientOptions(r),
+	}
+	replaying.SetCredential(&azfake.TokenCredential{})
+	got := runRecordedScenario(t, replaying, recordedZone)
+	if diff := cmp.Diff(got, want, recordComparer); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+	if len(zone.calls) != callsRecorded {
+		t.Errorf("the fake server was called during the replay")
+	}
+	if n := r.unused(); n != 0 {
+		t.Errorf("%d recorded interactions were not replayed", n)
+	}
+}
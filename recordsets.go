@@ -0,0 +1,124 @@
+package azure
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// RecordSet is the records sharing a name and a type in a zone, which Azure DNS stores and writes as a whole.
+type RecordSet struct {
+	// Name is the name of the record set relative to the zone, e.g. "_acme-challenge", or "@" for the apex.
+	Name string
+
+	// Type is the type of the record set, e.g. "TXT".
+	Type string
+
+	// TTL is the TTL shared by the records.
+	TTL time.Duration
+
+	// ETag is the ETag of the record set, which changes whenever it is written.
+	ETag string
+
+	// Metadata is the metadata of the record set, with the keys as Azure DNS returns them.
+	Metadata map[string]string
+
+	// Records are the records in the record set, which may be empty.
+	Records []libdns.Record
+}
+
+// RecordSets is the record sets of a zone, keyed by their names and then by their types, e.g. recordSets["www"]["A"].
+type RecordSets map[string]map[string]RecordSet
+
+// Get returns the record set with the name relative to the zone and the type, regardless of the case of the type,
+// reporting whether it exists.
+func (r RecordSets) Get(name string, typeName string) (RecordSet, bool) {
+	recordSet, ok := r[name][strings.ToUpper(typeName)]
+	return recordSet, ok
+}
+
+// Records returns the records of all the record sets as a flat slice, as GetRecords does,
+// ordered by the names and the types of the record sets.
+func (r RecordSets) Records() []libdns.Record {
+	var recordSets []RecordSet
+	for _, types := range r {
+		for _, recordSet := range types {
+			recordSets = append(recordSets, recordSet)
+		}
+	}
+	sort.Slice(recordSets, func(i, j int) bool {
+		if recordSets[i].Name != recordSets[j].Name {
+			return recordSets[i].Name < recordSets[j].Name
+		}
+		return recordSets[i].Type < recordSets[j].Type
+	})
+
+	var records []libdns.Record
+	for _, recordSet := range recordSets {
+		records = append(records, recordSet.Records...)
+	}
+	return records
+}
+
+// RecordSets lists the record sets in the zone with their records, grouped by their names and types as Azure DNS
+// stores them, along with the TTLs, the ETags, and the metadata of the record sets, so that callers need not group
+// the records returned by GetRecords again. The names are relative to the zone, with "@" for the apex.
+func (p *Provider) RecordSets(ctx context.Context, zone string) (RecordSets, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.RecordSets(ctx, zone)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	recordSets, err := p.getRecordSetsGrouped(ctx, zone)
+	if err != nil {
+		return nil, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+
+	return recordSets, nil
+}
+
+// getRecordSetsGrouped lists the record sets in the zone and groups them by their names and types.
+func (p *Provider) getRecordSetsGrouped(ctx context.Context, zone string) (RecordSets, error) {
+	listedRecordSets, err := p.listRecordSets(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	recordSets := RecordSets{}
+	for _, listedRecordSet := range listedRecordSets {
+		records, ok, err := p.convertListedRecordSet(zone, listedRecordSet)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		recordSet := RecordSet{
+			Name:    stringValue(listedRecordSet.Name),
+			Type:    recordSetTypeName(listedRecordSet),
+			ETag:    stringValue(listedRecordSet.Etag),
+			Records: records,
+		}
+		if properties := listedRecordSet.Properties; properties != nil {
+			if properties.TTL != nil {
+				recordSet.TTL = time.Duration(*properties.TTL) * time.Second
+			}
+			if len(properties.Metadata) > 0 {
+				recordSet.Metadata = map[string]string{}
+				for key, value := range properties.Metadata {
+					recordSet.Metadata[key] = stringValue(value)
+				}
+			}
+		}
+		if recordSets[recordSet.Name] == nil {
+			recordSets[recordSet.Name] = map[string]RecordSet{}
+		}
+		recordSets[recordSet.Name][recordSet.Type] = recordSet
+	}
+	return recordSets, nil
+}
@@ -0,0 +1,81 @@
+package azure
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_RecordSets(t *testing.T) {
+	zone := newFakeZone(
+		&armdns.RecordSet{
+			Name: to.Ptr("www"),
+			Type: to.Ptr("Microsoft.Network/dnszones/A"),
+			Etag: to.Ptr("ETAG_www_A"),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      to.Ptr[int64](300),
+				Metadata: map[string]*string{"Owner": to.Ptr("team")},
+				ARecords: []*armdns.ARecord{{IPv4Address: to.Ptr("127.0.0.1")}, {IPv4Address: to.Ptr("127.0.0.2")}},
+			},
+		},
+		getStoredTXTRecordSet("www", "TEXT", ""),
+		getStoredTXTRecordSet("deleted", "TEXT", "2006-01-02T15:04:05Z"),
+	)
+	provider := getFakeProviderWithZone(zone)
+	provider.SoftDeleteGracePeriod = time.Hour
+
+	got, err := provider.RecordSets(context.TODO(), "example.com.")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	want := RecordSets{
+		"www": {
+			"A": {
+				Name:     "www",
+				Type:     "A",
+				TTL:      300 * time.Second,
+				ETag:     "ETAG_www_A",
+				Metadata: map[string]string{"Owner": "team"},
+				Records: []libdns.Record{
+					libdns.Address{Name: "www", TTL: 300 * time.Second, IP: netip.MustParseAddr("127.0.0.1")},
+					libdns.Address{Name: "www", TTL: 300 * time.Second, IP: netip.MustParseAddr("127.0.0.2")},
+				},
+			},
+			"TXT": {
+				Name:    "www",
+				Type:    "TXT",
+				TTL:     time.Hour,
+				ETag:    "ETAG_www",
+				Records: []libdns.Record{libdns.TXT{Name: "www", TTL: time.Hour, Text: "TEXT"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(got, want, cmp.Comparer(func(a, b libdns.Record) bool { return a.RR() == b.RR() })); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+
+	if recordSet, ok := got.Get("www", "txt"); !ok || recordSet.ETag != "ETAG_www" {
+		t.Errorf("got: %v %v", recordSet, ok)
+	}
+	if _, ok := got.Get("deleted", "TXT"); ok {
+		t.Errorf("the soft-deleted record set is returned")
+	}
+	var gotRecords []libdns.RR
+	for _, record := range got.Records() {
+		gotRecords = append(gotRecords, record.RR())
+	}
+	wantRecords := []libdns.RR{
+		{Name: "www", TTL: 300 * time.Second, Type: "A", Data: "127.0.0.1"},
+		{Name: "www", TTL: 300 * time.Second, Type: "A", Data: "127.0.0.2"},
+		{Name: "www", TTL: time.Hour, Type: "TXT", Data: "TEXT"},
+	}
+	if diff := cmp.Diff(gotRecords, wantRecords); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
@@ -0,0 +1,51 @@
+package azure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// bearerTokenRefreshWindow is how long before their expiry the bearer token policy of the Azure SDK renews the tokens.
+const bearerTokenRefreshWindow = 5 * time.Minute
+
+// refreshingCredential is a credential that makes the tokens renewed earlier than the Azure SDK does by itself.
+// It reports the tokens to expire earlier by the difference of the offset and the refresh window of the bearer token policy,
+// so that the policy acquires a new token the offset before the actual expiry. Since the credentials of azidentity
+// return cached tokens until shortly before their expiry, the credential is rebuilt if it returns a token expiring within the offset.
+type refreshingCredential struct {
+	credential    azcore.TokenCredential
+	newCredential func() (azcore.TokenCredential, error)
+	offset        time.Duration
+	mutex         sync.Mutex
+}
+
+// GetToken acquires a token that does not expire within the offset if possible, and reports its expiry shifted earlier.
+func (c *refreshingCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	token, err := c.credential.GetToken(ctx, options)
+	if err != nil {
+		return token, err
+	}
+
+	if time.Until(token.ExpiresOn) <= c.offset && c.newCredential != nil {
+		credential, err := c.newCredential()
+		if err != nil {
+			return azcore.AccessToken{}, err
+		}
+		renewed, err := credential.GetToken(ctx, options)
+		if err != nil {
+			return azcore.AccessToken{}, err
+		}
+		c.credential = credential
+		token = renewed
+	}
+
+	token.ExpiresOn = token.ExpiresOn.Add(bearerTokenRefreshWindow - c.offset)
+	return token, nil
+}
@@ -0,0 +1,82 @@
+package azure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+type fakeExpiringCredential struct {
+	lifetime time.Duration
+}
+
+func (c *fakeExpiringCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "fake-token", ExpiresOn: time.Now().Add(c.lifetime)}, nil
+}
+
+func Test_refreshingCredential(t *testing.T) {
+	tests := []struct {
+		name         string
+		lifetime     time.Duration
+		renewable    bool
+		wantRenewals int
+		wantLifetime time.Duration
+	}{
+		{name: "lifetime=60m", lifetime: time.Hour, renewable: true, wantRenewals: 0, wantLifetime: 45 * time.Minute},
+		{name: "lifetime=10m", lifetime: 10 * time.Minute, renewable: true, wantRenewals: 1, wantLifetime: 45 * time.Minute},
+		{name: "lifetime=10m,unrenewable", lifetime: 10 * time.Minute, renewable: false, wantRenewals: 0, wantLifetime: -5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renewals := 0
+			credential := &refreshingCredential{
+				credential: &fakeExpiringCredential{lifetime: tt.lifetime},
+				offset:     20 * time.Minute,
+			}
+			if tt.renewable {
+				credential.newCredential = func() (azcore.TokenCredential, error) {
+					renewals++
+					return &fakeExpiringCredential{lifetime: time.Hour}, nil
+				}
+			}
+			token, err := credential.GetToken(context.TODO(), policy.TokenRequestOptions{})
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if renewals != tt.wantRenewals {
+				t.Errorf("got: %d renewals, want: %d", renewals, tt.wantRenewals)
+			}
+			if got := time.Until(token.ExpiresOn); got > tt.wantLifetime || got < tt.wantLifetime-time.Minute {
+				t.Errorf("got: %v, want: %v", got, tt.wantLifetime)
+			}
+		})
+	}
+}
+
+func Test_setupClient_tokenRefreshOffset(t *testing.T) {
+	tests := []struct {
+		name       string
+		offset     time.Duration
+		refreshing bool
+	}{
+		{name: "offset=0", offset: 0, refreshing: false},
+		{name: "offset=5m", offset: 5 * time.Minute, refreshing: false},
+		{name: "offset=15m", offset: 15 * time.Minute, refreshing: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := Provider{SubscriptionId: "fake-subscription-id", TokenRefreshOffset: tt.offset}
+			provider.client.credential = &fakeExpiringCredential{lifetime: time.Hour}
+			if err := provider.setupClient(); err != nil {
+				t.Fatalf("%s", err)
+			}
+			_, refreshing := provider.client.tokenCredential.(*refreshingCredential)
+			if refreshing != tt.refreshing {
+				t.Errorf("got: %v, want: %v", refreshing, tt.refreshing)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package azure
+
+import (
+	"crypto/sha256"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// Registry holds fully configured providers keyed by identifiers such as tenant or customer IDs,
+// so that a single process can manage DNS for many tenants.
+// Providers authenticating as the same service principal share one credential, and thus one cache of access tokens.
+// The zero value is an empty registry ready to use.
+type Registry struct {
+	entries     map[string]registryEntry
+	credentials map[credentialKey]azcore.TokenCredential
+	mutex       sync.RWMutex
+}
+
+// registryEntry is a provider registered in a registry.
+type registryEntry struct {
+	provider *Provider
+	key      credentialKey
+	shared   bool
+}
+
+// credentialKey identifies the identity that a provider authenticates as, along with all the settings of the provider
+// that change how the credential acquires tokens, so that only providers building the same credential share it.
+// The client secret is hashed so that the registry does not keep another copy of it.
+type credentialKey struct {
+	tenantID                   string
+	clientID                   string
+	clientSecretHash           [sha256.Size]byte
+	additionallyAllowedTenants string
+	disableInstanceDiscovery   bool
+	strictCredentials          bool
+	cloud                      string
+	resourceManagerEndpoint    string
+}
+
+// Register adds the provider to the registry under the identifier, replacing any provider registered under it.
+// Unless a credential has been set on the provider with SetCredential, a provider authenticating using a service principal
+// with a secret is given the credential shared by the providers with the same Tenant ID, Client ID, Client Secret,
+// and the other settings of the credential, which is built with the connection settings of the first of them.
+// Providers authenticating otherwise, e.g. using a managed identity, or with Client Options set, build their own credentials.
+// To rotate the client secret of a registered provider, call SetClientSecret, or update Client Secret and register it again.
+func (r *Registry) Register(id string, provider *Provider) error {
+	if id == "" {
+		return errors.New("the identifier cannot be empty")
+	}
+	if provider == nil {
+		return errors.New("the provider cannot be nil")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.register(id, provider)
+}
+
+// register adds the provider to the registry under the identifier. It must be called with the registry locked.
+func (r *Registry) register(id string, provider *Provider) error {
+	if r.entries == nil {
+		r.entries = map[string]registryEntry{}
+		r.credentials = map[credentialKey]azcore.TokenCredential{}
+	}
+
+	key, sharable := provider.credentialKey()
+	entry := registryEntry{
+		provider: provider,
+		key:      key,
+	}
+
+	// A provider is given a shared credential only if it is not using its own one,
+	// including when it is registered again with the shared credential already set
+	previous, registered := r.entries[id]
+	provider.client.mutex.Lock()
+	credential := provider.client.credential
+	provider.client.mutex.Unlock()
+	wasShared := registered && previous.provider == provider && previous.shared && credential == r.credentials[previous.key]
+	switch {
+	case (credential == nil || wasShared) && sharable:
+		credential, ok := r.credentials[entry.key]
+		if !ok {
+			var err error
+			credential, err = provider.newSharedCredential()
+			if err != nil {
+				return err
+			}
+			r.credentials[entry.key] = credential
+		}
+		provider.setSharedCredential(credential, r)
+		entry.shared = true
+	case wasShared:
+		// The provider no longer authenticates in a way that can be shared, so it builds its own credential again
+		provider.SetCredential(nil)
+	}
+
+	r.entries[id] = entry
+	r.pruneCredentials()
+
+	return nil
+}
+
+// rekey registers the provider again under all its identifiers after its client secret has been rotated by SetClientSecret,
+// so that it is given the credential shared under the new secret rather than keeping the one keyed on the old secret.
+// If the new credential cannot be built, the provider builds its own one with the new secret.
+func (r *Registry) rekey(provider *Provider) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id, entry := range r.entries {
+		if entry.provider != provider {
+			continue
+		}
+		if err := r.register(id, provider); err != nil {
+			r.entries[id] = registryEntry{provider: provider}
+		}
+	}
+	r.pruneCredentials()
+}
+
+// Get returns the provider registered under the identifier.
+func (r *Registry) Get(id string) (*Provider, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entry, ok := r.entries[id]
+	return entry.provider, ok
+}
+
+// Remove removes the provider registered under the identifier, if any.
+func (r *Registry) Remove(id string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.entries, id)
+	r.pruneCredentials()
+}
+
+// IDs returns the identifiers of all the registered providers in ascending order.
+func (r *Registry) IDs() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	ids := make([]string, 0, len(r.entries))
+	for id := range r.entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// pruneCredentials drops the shared credentials that no registered provider uses anymore.
+func (r *Registry) pruneCredentials() {
+	used := map[credentialKey]bool{}
+	for _, entry := range r.entries {
+		if entry.shared {
+			used[entry.key] = true
+		}
+	}
+	for key := range r.credentials {
+		if !used[key] {
+			delete(r.credentials, key)
+		}
+	}
+}
+
+// credentialKey returns the key identifying the credential of the provider, reporting whether the credential can be shared.
+// Only credentials of service principals with a secret are shared, since a managed identity, the developer credentials,
+// and Client Options depend on more than the settings that the key can compare.
+// The settings are read with the client locked, since SetClientSecret may be rotating the secret concurrently.
+func (p *Provider) credentialKey() (credentialKey, bool) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if p.ClientSecret == "" || p.ClientOptions != nil {
+		return credentialKey{}, false
+	}
+	additionallyAllowedTenants := append([]string(nil), p.AdditionallyAllowedTenants...)
+	sort.Strings(additionallyAllowedTenants)
+	return credentialKey{
+		tenantID:                   p.TenantId,
+		clientID:                   p.ClientId,
+		clientSecretHash:           sha256.Sum256([]byte(p.ClientSecret)),
+		additionallyAllowedTenants: strings.Join(additionallyAllowedTenants, "\n"),
+		disableInstanceDiscovery:   p.DisableInstanceDiscovery,
+		strictCredentials:          p.StrictCredentials,
+		cloud:                      p.Cloud,
+		resourceManagerEndpoint:    p.ResourceManagerEndpoint,
+	}, true
+}
+
+// newSharedCredential builds the credential of the provider to share, in the same manner as the provider builds it by itself.
+func (p *Provider) newSharedCredential() (azcore.TokenCredential, error) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	credentials, err := p.newCredentials()
+	if err != nil {
+		return nil, err
+	}
+	if len(credentials) == 1 {
+		return credentials[0], nil
+	}
+	return azidentity.NewChainedTokenCredential(credentials, nil)
+}
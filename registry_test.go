@@ -0,0 +1,161 @@
+package azure
+
+import (
+	"testing"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/google/go-cmp/cmp"
+)
+
+func getRegistryTestProvider(clientSecret string) *Provider {
+	return &Provider{
+		SubscriptionId:    "fake-subscription-id",
+		ResourceGroupName: "fake-resource-group-name",
+		TenantId:          "fake-tenant-id",
+		ClientId:          "fake-client-id",
+		ClientSecret:      clientSecret,
+	}
+}
+
+func Test_Registry(t *testing.T) {
+	t.Run("credential=shared", func(t *testing.T) {
+		var registry Registry
+		first := getRegistryTestProvider("fake-client-secret")
+		second := getRegistryTestProvider("fake-client-secret")
+		another := getRegistryTestProvider("another-client-secret")
+		for id, provider := range map[string]*Provider{"first": first, "second": second, "another": another} {
+			if err := registry.Register(id, provider); err != nil {
+				t.Fatalf("%s", err)
+			}
+		}
+		if first.client.credential == nil || first.client.credential != second.client.credential {
+			t.Errorf("the credential is not shared between the providers with the same identity")
+		}
+		if first.client.credential == another.client.credential {
+			t.Errorf("the credential is shared between the providers with different identities")
+		}
+		if diff := cmp.Diff(registry.IDs(), []string{"another", "first", "second"}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if got, ok := registry.Get("first"); !ok || got != first {
+			t.Errorf("got: %v, want: %v", got, first)
+		}
+
+		registry.Remove("another")
+		if _, ok := registry.Get("another"); ok {
+			t.Errorf("the removed provider is still registered")
+		}
+		if len(registry.credentials) != 1 {
+			t.Errorf("got: %d, want: 1", len(registry.credentials))
+		}
+	})
+	t.Run("credential=rotated", func(t *testing.T) {
+		var registry Registry
+		provider := getRegistryTestProvider("fake-client-secret")
+		if err := registry.Register("tenant", provider); err != nil {
+			t.Fatalf("%s", err)
+		}
+		credential := provider.client.credential
+		provider.ClientSecret = "rotated-client-secret"
+		if err := registry.Register("tenant", provider); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if provider.client.credential == credential {
+			t.Errorf("the credential is not rebuilt with the rotated client secret")
+		}
+		if len(registry.credentials) != 1 {
+			t.Errorf("got: %d, want: 1", len(registry.credentials))
+		}
+	})
+	t.Run("credential=rotated by SetClientSecret", func(t *testing.T) {
+		var registry Registry
+		first := getRegistryTestProvider("fake-client-secret")
+		second := getRegistryTestProvider("fake-client-secret")
+		rotated := getRegistryTestProvider("rotated-client-secret")
+		for id, provider := range map[string]*Provider{"first": first, "second": second, "rotated": rotated} {
+			if err := registry.Register(id, provider); err != nil {
+				t.Fatalf("%s", err)
+			}
+		}
+		first.SetClientSecret("rotated-client-secret")
+		if first.client.credential == nil || first.client.credential == second.client.credential {
+			t.Errorf("the credential keyed on the old client secret is kept")
+		}
+		if first.client.credential != rotated.client.credential {
+			t.Errorf("the credential shared under the new client secret is not given")
+		}
+		if len(registry.credentials) != 2 {
+			t.Errorf("got: %d, want: 2", len(registry.credentials))
+		}
+
+		second.SetClientSecret("rotated-client-secret")
+		if second.client.credential != rotated.client.credential {
+			t.Errorf("the credential shared under the new client secret is not given")
+		}
+		if len(registry.credentials) != 1 {
+			t.Errorf("got: %d, want: 1", len(registry.credentials))
+		}
+	})
+	t.Run("credential=explicit", func(t *testing.T) {
+		var registry Registry
+		provider := getRegistryTestProvider("fake-client-secret")
+		credential := &azfake.TokenCredential{}
+		provider.SetCredential(credential)
+		if err := registry.Register("tenant", provider); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if provider.client.credential != credential {
+			t.Errorf("the explicit credential is replaced")
+		}
+		if len(registry.credentials) != 0 {
+			t.Errorf("got: %d, want: 0", len(registry.credentials))
+		}
+	})
+	t.Run("credential=different settings", func(t *testing.T) {
+		var registry Registry
+		first := getRegistryTestProvider("fake-client-secret")
+		second := getRegistryTestProvider("fake-client-secret")
+		second.AdditionallyAllowedTenants = []string{"*"}
+		third := getRegistryTestProvider("fake-client-secret")
+		third.Cloud = "AzureChinaCloud"
+		for id, provider := range map[string]*Provider{"first": first, "second": second, "third": third} {
+			if err := registry.Register(id, provider); err != nil {
+				t.Fatalf("%s", err)
+			}
+		}
+		if first.client.credential == second.client.credential || first.client.credential == third.client.credential {
+			t.Errorf("the credential is shared between the providers with different settings")
+		}
+	})
+	t.Run("credential=managed identity", func(t *testing.T) {
+		var registry Registry
+		first := &Provider{ManagedIdentityClientId: "first-client-id"}
+		second := &Provider{ManagedIdentityClientId: "second-client-id"}
+		for id, provider := range map[string]*Provider{"first": first, "second": second} {
+			if err := registry.Register(id, provider); err != nil {
+				t.Fatalf("%s", err)
+			}
+		}
+		if first.client.credential != nil || second.client.credential != nil {
+			t.Errorf("the credential of a managed identity is shared")
+		}
+		if len(registry.credentials) != 0 {
+			t.Errorf("got: %d, want: 0", len(registry.credentials))
+		}
+	})
+	t.Run("credential=strict", func(t *testing.T) {
+		var registry Registry
+		provider := getRegistryTestProvider("fake-client-secret")
+		provider.TenantId = ""
+		provider.StrictCredentials = true
+		if err := registry.Register("tenant", provider); err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+	t.Run("id=empty", func(t *testing.T) {
+		var registry Registry
+		if err := registry.Register("", getRegistryTestProvider("fake-client-secret")); err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// WriteOperation is a write operation mirrored to the replica or the mirrors.
+type WriteOperation string
+
+const (
+	// WriteOperationAppend is a write by AppendRecords.
+	WriteOperationAppend WriteOperation = "append"
+
+	// WriteOperationSet is a write by SetRecords.
+	WriteOperationSet WriteOperation = "set"
+
+	// WriteOperationDelete is a write by DeleteRecords.
+	WriteOperationDelete WriteOperation = "delete"
+)
+
+// ReplicaDivergence describes a write that was applied to the zone but failed to be mirrored to the replica,
+// leaving the two zones diverged.
+type ReplicaDivergence struct {
+	// Operation is the write operation that failed on the replica.
+	Operation WriteOperation
+
+	// Zone is the name of the zone on the replica.
+	Zone string
+
+	// Records are the records written to the zone.
+	Records []libdns.Record
+
+	// Err is the error returned by the replica.
+	Err error
+}
+
+// ReplicaDiff is the difference between the records in a zone and in its replica.
+type ReplicaDiff struct {
+	// Missing are the records in the zone that are not in the replica.
+	Missing []libdns.Record
+
+	// Unexpected are the records in the replica that are not in the zone.
+	Unexpected []libdns.Record
+}
+
+// CompareReplica compares the records in the zone with the records in the replica zone.
+// The SOA record and the NS records at the apex are ignored, since they are specific to each zone.
+// Records are compared by name, type, value, and TTL.
+func (p *Provider) CompareReplica(ctx context.Context, zone string) (ReplicaDiff, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.CompareReplica(ctx, zone)
+	}
+
+	if p.Replica == nil {
+		return ReplicaDiff{}, errors.New("the replica is not configured")
+	}
+
+	records, err := p.GetRecords(ctx, zone)
+	if err != nil {
+		return ReplicaDiff{}, err
+	}
+	replicaRecords, err := p.Replica.GetRecords(ctx, p.getReplicaZone(zone))
+	if err != nil {
+		return ReplicaDiff{}, err
+	}
+
+	return ReplicaDiff{
+		Missing:    subtractRecords(records, replicaRecords),
+		Unexpected: subtractRecords(replicaRecords, records),
+	}, nil
+}
+
+// replicate mirrors a successful write to the replica, if any, reporting divergence if it fails.
+func (p *Provider) replicate(ctx context.Context, operation WriteOperation, zone string, records []libdns.Record) {
+	if p.Replica == nil || len(records) == 0 {
+		return
+	}
+
+	replicaZone := p.getReplicaZone(zone)
+
+	var err error
+	switch operation {
+	case WriteOperationAppend:
+		_, err = p.Replica.AppendRecords(ctx, replicaZone, records)
+	case WriteOperationSet:
+		_, err = p.Replica.SetRecords(ctx, replicaZone, records)
+	case WriteOperationDelete:
+		_, err = p.Replica.DeleteRecords(ctx, replicaZone, records)
+	}
+	if err == nil {
+		return
+	}
+
+	divergence := ReplicaDivergence{
+		Operation: operation,
+		Zone:      replicaZone,
+		Records:   records,
+		Err:       err,
+	}
+	if p.OnReplicaDivergence != nil {
+		p.OnReplicaDivergence(divergence)
+		return
+	}
+	p.getLogger().Warn("failed to mirror a write to the replica",
+		slog.String("operation", string(divergence.Operation)),
+		slog.String("zone", divergence.Zone),
+		slog.Int("records", len(divergence.Records)),
+		slog.Any("error", divergence.Err),
+	)
+}
+
+// getReplicaZone returns the name of the replica zone of the zone.
+func (p *Provider) getReplicaZone(zone string) string {
+	if p.ReplicaZone != "" {
+		return p.ReplicaZone
+	}
+	return zone
+}
+
+// replicaRecordKey identifies a record regardless of the zone it is in.
+type replicaRecordKey struct {
+	Name string
+	Type string
+	Data string
+	TTL  time.Duration
+}
+
+// newReplicaRecordKey returns the key identifying the record.
+func newReplicaRecordKey(record libdns.Record) replicaRecordKey {
+	rr := record.RR()
+	return replicaRecordKey{rr.Name, rr.Type, recordData(record), rr.TTL}
+}
+
+// subtractRecords returns the records in a that are not in b, ignoring the SOA record and the NS records at the apex.
+func subtractRecords(a []libdns.Record, b []libdns.Record) []libdns.Record {
+	keys := map[replicaRecordKey]bool{}
+	for _, record := range b {
+		keys[newReplicaRecordKey(record)] = true
+	}
+
+	var records []libdns.Record
+	for _, record := range a {
+		key := newReplicaRecordKey(record)
+		if key.Type == "SOA" || (key.Type == "NS" && key.Name == "@") {
+			continue
+		}
+		if !keys[key] {
+			records = append(records, record)
+		}
+	}
+	return records
+}
@@ -0,0 +1,95 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns/fake"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_replicate(t *testing.T) {
+	t.Run("replica=succeeded", func(t *testing.T) {
+		var written []string
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+		fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			written = append(written, zoneName+" "+relativeRecordSetName+" "+string(recordType))
+			return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+		}
+		replica := getFakeProviderWithServer(fakeRecordSetsServer)
+		provider := getFakeProvider()
+		provider.Replica = &replica
+		provider.ReplicaZone = "example.net."
+		provider.OnReplicaDivergence = func(divergence ReplicaDivergence) {
+			t.Errorf("unexpected divergence: %v", divergence)
+		}
+		if _, err := provider.SetRecords(context.TODO(), "example.com.", libdnsFakeRecords[:1]); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if diff := cmp.Diff(written, []string{"example.net record-a A"}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("replica=failed", func(t *testing.T) {
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		fakeRecordSetsServer.Delete = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, options *armdns.RecordSetsClientDeleteOptions) (resp azfake.Responder[armdns.RecordSetsClientDeleteResponse], errResp azfake.ErrorResponder) {
+			errResp.SetResponseError(http.StatusInternalServerError, "InternalServerError")
+			return
+		}
+		replica := getFakeProviderWithServer(fakeRecordSetsServer)
+		provider := getFakeProvider()
+		provider.Replica = &replica
+		var divergences []ReplicaDivergence
+		provider.OnReplicaDivergence = func(divergence ReplicaDivergence) {
+			divergences = append(divergences, divergence)
+		}
+		if _, err := provider.DeleteRecords(context.TODO(), "example.com.", libdnsFakeRecords[:1]); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(divergences) != 1 {
+			t.Fatalf("got: %d, want: 1", len(divergences))
+		}
+		if divergences[0].Operation != WriteOperationDelete || divergences[0].Zone != "example.com." || divergences[0].Err == nil {
+			t.Errorf("got: %v", divergences[0])
+		}
+	})
+}
+
+func Test_CompareReplica(t *testing.T) {
+	fakeRecordSetsServer := getFakeRecordSetsServer()
+	fakeRecordSetsServer.NewListByDNSZonePager = func(resourceGroupName string, zoneName string, options *armdns.RecordSetsClientListByDNSZoneOptions) (resp azfake.PagerResponder[armdns.RecordSetsClientListByDNSZoneResponse]) {
+		var values []*armdns.RecordSet
+		// The replica lacks the A record set, and has its own SOA and NS records at the apex
+		for _, v := range azureFakeRecords[1:] {
+			record := v
+			values = append(values, &record)
+		}
+		resp.AddPage(http.StatusOK, armdns.RecordSetsClientListByDNSZoneResponse{
+			RecordSetListResult: armdns.RecordSetListResult{Value: values},
+		}, nil)
+		return
+	}
+	replica := getFakeProviderWithServerFactory(fake.ServerFactory{
+		RecordSetsServer: fakeRecordSetsServer,
+	})
+	provider := getFakeProvider()
+	provider.Replica = &replica
+	got, err := provider.CompareReplica(context.TODO(), "example.com.")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	var want []libdns.Record
+	for _, record := range libdnsFakeRecords {
+		if record.RR().Type == "A" {
+			want = append(want, record)
+		}
+	}
+	if diff := cmp.Diff(got, ReplicaDiff{Missing: want}, recordComparer); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
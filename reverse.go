@@ -0,0 +1,42 @@
+package azure
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ReverseRecordName returns the name of the PTR record for the IP address, relative to the reverse zone,
+// e.g. "1" for 192.0.2.1 in the zone "2.0.192.in-addr.arpa.", or the 24 nibbles of the host part
+// for an IPv6 address in a /32 zone such as "8.b.d.0.1.0.0.2.ip6.arpa.".
+// It throws an error if the reverse name of the address is not within the zone.
+func ReverseRecordName(addr netip.Addr, zone string) (string, error) {
+	name := reverseName(addr)
+	if name == "" {
+		return "", fmt.Errorf("the address %v cannot be interpreted", addr)
+	}
+	if !isWithinZone(name, zone) {
+		return "", fmt.Errorf("the address %v is outside the zone %v", addr, zone)
+	}
+	return generateRecordSetName(name, zone), nil
+}
+
+// reverseName returns the fully-qualified reverse name of the IP address in in-addr.arpa or ip6.arpa,
+// or an empty string if the address is invalid.
+func reverseName(addr netip.Addr) string {
+	addr = addr.Unmap()
+	switch {
+	case addr.Is4():
+		b := addr.As4()
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", b[3], b[2], b[1], b[0])
+	case addr.Is6():
+		b := addr.As16()
+		labels := make([]string, 0, 34)
+		for i := len(b) - 1; i >= 0; i-- {
+			labels = append(labels, fmt.Sprintf("%x", b[i]&0x0f), fmt.Sprintf("%x", b[i]>>4))
+		}
+		return strings.Join(append(labels, "ip6", "arpa"), ".") + "."
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,116 @@
+package azure
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+// ip6ArpaZone is the reverse zone of 2001:db8::/32.
+const ip6ArpaZone = "8.b.d.0.1.0.0.2.ip6.arpa."
+
+// ip6ArpaName is the name of 2001:db8::567:89ab relative to ip6ArpaZone, which has 24 labels.
+const ip6ArpaName = "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0"
+
+func Test_ReverseRecordName(t *testing.T) {
+	tests := []struct {
+		addr    string
+		zone    string
+		want    string
+		wantErr bool
+	}{
+		{addr: "192.0.2.1", zone: "2.0.192.in-addr.arpa.", want: "1"},
+		{addr: "192.0.2.1", zone: "192.in-addr.arpa", want: "1.2.0"},
+		{addr: "::ffff:192.0.2.1", zone: "2.0.192.in-addr.arpa.", want: "1"},
+		{addr: "2001:db8::567:89ab", zone: ip6ArpaZone, want: ip6ArpaName},
+		{addr: "2001:db8::567:89ab", zone: "ip6.arpa.", want: ip6ArpaName + ".8.b.d.0.1.0.0.2"},
+		{addr: "2001:db8::567:89ab", zone: "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", want: "@"},
+		{addr: "2001:db9::1", zone: ip6ArpaZone, wantErr: true},
+		{addr: "198.51.100.1", zone: "2.0.192.in-addr.arpa.", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run("addr="+tt.addr+",zone="+tt.zone, func(t *testing.T) {
+			got, err := ReverseRecordName(netip.MustParseAddr(tt.addr), tt.zone)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got: %v, want: error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+	t.Run("addr=invalid", func(t *testing.T) {
+		if _, err := ReverseRecordName(netip.Addr{}, ip6ArpaZone); err == nil {
+			t.Errorf("got: nil, want: error")
+		}
+	})
+}
+
+func Test_ip6ArpaZone(t *testing.T) {
+	fqdn := ip6ArpaName + "." + ip6ArpaZone
+
+	t.Run("name=relativized", func(t *testing.T) {
+		for _, name := range []string{fqdn, strings.TrimSuffix(fqdn, "."), ip6ArpaName} {
+			if diff := cmp.Diff(generateRecordSetName(name, ip6ArpaZone), ip6ArpaName); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		}
+	})
+	t.Run("conversion=roundtrip", func(t *testing.T) {
+		records := []libdns.Record{
+			libdns.RR{Name: fqdn, Type: "PTR", Data: "host.example.com.", TTL: time.Hour},
+		}
+		recordSets, err := ToRecordSets(ip6ArpaZone, records)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if diff := cmp.Diff(stringValue(recordSets[0].Name), ip6ArpaName); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		got, err := FromRecordSet(recordSets[0])
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := []libdns.Record{
+			libdns.RR{Name: ip6ArpaName, Type: "PTR", Data: "host.example.com.", TTL: time.Hour},
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("operation=set", func(t *testing.T) {
+		var written []string
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+		fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			written = append(written, zoneName+"/"+relativeRecordSetName+"/"+string(recordType))
+			return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+		}
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		got, err := provider.SetRecords(context.TODO(), ip6ArpaZone, []libdns.Record{
+			libdns.RR{Name: fqdn, Type: "PTR", Data: "host.example.com.", TTL: time.Hour},
+		})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if diff := cmp.Diff(written, []string{strings.TrimSuffix(ip6ArpaZone, ".") + "/" + ip6ArpaName + "/PTR"}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if diff := cmp.Diff(got[0].RR().Name, ip6ArpaName); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package azure
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// checkRules throws an error if the record is denied by the rules of the provider.
+// The record is denied if it matches any deny rule, or if allow rules are configured and it matches none of them.
+func (p *Provider) checkRules(name string, rr libdns.RR) error {
+	for _, rule := range p.DenyRules {
+		if rule.matches(name, rr.Type) {
+			return fmt.Errorf("the record %v %v is denied by the rule %v", name, rr.Type, rule)
+		}
+	}
+	if len(p.AllowRules) == 0 {
+		return nil
+	}
+	for _, rule := range p.AllowRules {
+		if rule.matches(name, rr.Type) {
+			return nil
+		}
+	}
+	return fmt.Errorf("the record %v %v is not allowed by any rule", name, rr.Type)
+}
+
+// matches reports whether the name relative to the zone and the type match the rule, regardless of the case.
+func (r RecordRule) matches(name string, typeName string) bool {
+	if r.Type != "" && r.Type != "*" && !strings.EqualFold(r.Type, typeName) {
+		return false
+	}
+	return r.Name == "" || r.Name == "*" || matchNamespace(r.Name, name)
+}
+
+// String returns the rule in the form of "name/type", with "*" for any name or type.
+func (r RecordRule) String() string {
+	name, typeName := r.Name, r.Type
+	if name == "" {
+		name = "*"
+	}
+	if typeName == "" {
+		typeName = "*"
+	}
+	return name + "/" + typeName
+}
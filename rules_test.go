@@ -0,0 +1,99 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/libdns/libdns"
+)
+
+func Test_checkRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowRules []RecordRule
+		denyRules  []RecordRule
+		record     libdns.RR
+		wantErr    string
+	}{
+		{
+			name:   "rules=none",
+			record: libdns.RR{Name: "@", Type: "MX"},
+		},
+		{
+			name:      "deny=type",
+			denyRules: []RecordRule{{Type: "MX"}},
+			record:    libdns.RR{Name: "www", Type: "mx"},
+			wantErr:   "the record www mx is denied by the rule */MX",
+		},
+		{
+			name:      "deny=type,other",
+			denyRules: []RecordRule{{Type: "MX"}},
+			record:    libdns.RR{Name: "www", Type: "TXT"},
+		},
+		{
+			name:      "deny=apex",
+			denyRules: []RecordRule{{Name: "@"}},
+			record:    libdns.RR{Name: "@", Type: "TXT"},
+			wantErr:   "the record @ TXT is denied by the rule @/*",
+		},
+		{
+			name:       "allow=acme",
+			allowRules: []RecordRule{{Name: "_acme-challenge.*", Type: "TXT"}},
+			record:     libdns.RR{Name: "_acme-challenge.www", Type: "TXT"},
+		},
+		{
+			name:       "allow=acme,type",
+			allowRules: []RecordRule{{Name: "_acme-challenge.*", Type: "TXT"}},
+			record:     libdns.RR{Name: "_acme-challenge.www", Type: "CNAME"},
+			wantErr:    "the record _acme-challenge.www CNAME is not allowed by any rule",
+		},
+		{
+			name:       "allow=any,deny=apex",
+			allowRules: []RecordRule{{Name: "*", Type: "*"}},
+			denyRules:  []RecordRule{{Name: "@"}},
+			record:     libdns.RR{Name: "@", Type: "TXT"},
+			wantErr:    "the record @ TXT is denied by the rule @/*",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := Provider{AllowRules: tt.allowRules, DenyRules: tt.denyRules}
+			err := provider.checkRules(tt.record.Name, tt.record)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("got: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("got: %v, want: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_checkRules_beforeWriting(t *testing.T) {
+	writes := 0
+	fakeRecordSetsServer := getFakeRecordSetsServer()
+	createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+	fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+		writes++
+		return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+	}
+	provider := getFakeProviderWithServer(fakeRecordSetsServer)
+	provider.DenyRules = []RecordRule{{Name: "@"}}
+	_, err := provider.SetRecords(context.TODO(), "example.com.", []libdns.Record{
+		libdns.TXT{Name: "www", Text: "TEST VALUE", TTL: time.Minute},
+		libdns.TXT{Name: "example.com.", Text: "TEST VALUE", TTL: time.Minute},
+	})
+	if err == nil || !strings.Contains(err.Error(), "is denied by the rule") {
+		t.Errorf("got: %v", err)
+	}
+	if writes != 0 {
+		t.Errorf("got: %d writes, want: 0", writes)
+	}
+}
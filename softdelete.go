@@ -0,0 +1,196 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/libdns/libdns"
+)
+
+const (
+	// deletedAtMetadataKey is the key of the metadata stamped on soft-deleted record sets with the time of the deletion.
+	deletedAtMetadataKey = "libdns_deleted_at"
+
+	// deletedTTLMetadataKey is the key of the metadata stamped on soft-deleted record sets with their TTL before the deletion.
+	deletedTTLMetadataKey = "libdns_deleted_ttl"
+)
+
+// softDeletedTTL is the TTL in seconds of soft-deleted record sets, so that resolvers stop caching them soon after they are purged.
+const softDeletedTTL = 60
+
+// purgeKey is the context key marking the deletions that purge the record sets instead of soft-deleting them.
+type purgeKey struct{}
+
+// softDeletes reports whether the record set of the name relative to the zone and the type is soft-deleted instead of deleted.
+// The record sets for ACME DNS challenges are always deleted, since they are short-lived by nature.
+func (p *Provider) softDeletes(ctx context.Context, name string, typeName string) bool {
+	return p.SoftDeleteGracePeriod > 0 && ctx.Value(purgeKey{}) == nil && !isChallengeRecordSet(name, typeName)
+}
+
+// isSoftDeleted reports whether the record set is soft-deleted and the provider takes it as deleted.
+func (p *Provider) isSoftDeleted(recordSet *armdns.RecordSet) bool {
+	if p.SoftDeleteGracePeriod <= 0 {
+		return false
+	}
+	_, ok := getRecordSetDeletedAt(recordSet)
+	return ok
+}
+
+// getRecordSetDeletedAt returns the time stamped on the record set when it was soft-deleted, if any.
+func getRecordSetDeletedAt(recordSet *armdns.RecordSet) (time.Time, bool) {
+	deletedAt, err := time.Parse(time.RFC3339, getMetadata(recordSet, deletedAtMetadataKey))
+	return deletedAt, err == nil
+}
+
+// softDeleteRecordSet marks the record set that the records sharing the same name and type belong to as deleted,
+// stamping the time of the deletion and lowering its TTL, instead of deleting it, so that it can be restored until Purge deletes it.
+// The values of the record set are kept as they are. A record set that does not exist or is already soft-deleted is left as it is.
+func (p *Provider) softDeleteRecordSet(ctx context.Context, zone string, records []libdns.Record, existing *armdns.RecordSet, ifMatch *string) ([]libdns.Record, error) {
+	rr := records[0].RR()
+	if existing == nil {
+		var err error
+		if existing, _, err = p.getExistingRecordSet(ctx, zone, records[0], ifMatch); err != nil {
+			return nil, err
+		}
+	}
+
+	var deletedRecords []libdns.Record
+	for _, record := range records {
+		deletedRecords = append(deletedRecords, normalizeRecord(record, zone))
+	}
+	if existing == nil || p.isSoftDeleted(existing) {
+		return deletedRecords, nil
+	}
+
+	before := p.snapshotRecordSet(existing)
+	properties := existing.Properties
+	if properties == nil {
+		properties = &armdns.RecordSetProperties{}
+	}
+	setMetadata(properties, deletedAtMetadataKey, time.Now().UTC().Format(time.RFC3339))
+	if properties.TTL != nil {
+		setMetadata(properties, deletedTTLMetadataKey, strconv.FormatInt(*properties.TTL, 10))
+		if *properties.TTL > softDeletedTTL {
+			properties.TTL = to.Ptr[int64](softDeletedTTL)
+		}
+	}
+	after := armdns.RecordSet{Properties: properties}
+	if err := p.beforeWrite(ctx, WriteOperationDelete, zone, rr.Name, rr.Type, before, &after); err != nil {
+		return nil, err
+	}
+
+	// Prevent overwriting a record set modified after it was read
+	if err := p.createOrUpdateRecordSet(ctx, zone, records[0], after, existing.Etag, nil); err != nil {
+		return nil, err
+	}
+	return deletedRecords, nil
+}
+
+// restoreRecordSet overwrites the values and the TTL of a soft-deleted record set with those of the record set to be written,
+// removing the marks of the soft deletion, so that the values deleted are not revived along with the record set.
+func (p *Provider) restoreRecordSet(ctx context.Context, zone string, operation WriteOperation, records []libdns.Record, existing *armdns.RecordSet, recordSet armdns.RecordSet) error {
+	rr := records[0].RR()
+	before := p.snapshotRecordSet(existing)
+	properties := existing.Properties
+	clearRecordSetValues(properties)
+	clearSoftDelete(properties)
+	if err := mergeRecordSetProperties(properties, recordSet.Properties); err != nil {
+		return err
+	}
+	properties.TTL = recordSet.Properties.TTL
+	after := armdns.RecordSet{Properties: properties}
+	if err := p.beforeWrite(ctx, operation, zone, rr.Name, rr.Type, before, &after); err != nil {
+		return err
+	}
+
+	// Prevent overwriting a record set modified after it was read
+	return p.createOrUpdateRecordSet(ctx, zone, records[0], after, existing.Etag, nil)
+}
+
+// clearSoftDelete removes the marks of the soft deletion from the properties of a record set written again.
+func clearSoftDelete(properties *armdns.RecordSetProperties) {
+	deleteMetadata(properties, deletedAtMetadataKey)
+	deleteMetadata(properties, deletedTTLMetadataKey)
+}
+
+// Purge deletes the record sets in the zone that were soft-deleted more than Soft Delete Grace Period ago,
+// so that the mistakes in deletions can be caught and the record sets restored during the grace period.
+// A record set written again after it was listed is not deleted. It returns the records of the record sets deleted.
+func (p *Provider) Purge(ctx context.Context, zone string) ([]libdns.Record, error) {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.Purge(ctx, zone)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	expiredRecordSets, err := p.listSoftDeleted(ctx, zone, time.Now().Add(-p.SoftDeleteGracePeriod))
+	if err != nil {
+		return nil, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+
+	ctx = context.WithValue(ctx, purgeKey{}, true)
+	var purgedRecords []libdns.Record
+	for _, recordSet := range expiredRecordSets {
+		name := stringValue(recordSet.Name)
+		typeName := strings.TrimPrefix(stringValue(recordSet.Type), "Microsoft.Network/dnszones/")
+		_, err := p.DeleteRecordsWithOptions(ctx, zone, []libdns.Record{
+			libdns.RR{Name: name, Type: typeName},
+		}, DeleteOptions{
+			ETags: map[string]string{name + "/" + typeName: stringValue(recordSet.Etag)},
+		})
+		var modifiedError *ModifiedError
+		if errors.As(err, &modifiedError) {
+			continue
+		}
+		if err != nil {
+			return purgedRecords, err
+		}
+		records, err := convertAzureRecordSetsToLibdnsRecords([]*armdns.RecordSet{recordSet})
+		if err != nil {
+			return purgedRecords, wrapCorrelationID(err, correlationID)
+		}
+		purgedRecords = append(purgedRecords, records...)
+	}
+
+	return purgedRecords, nil
+}
+
+// listSoftDeleted lists the record sets in the zone soft-deleted before the threshold.
+func (p *Provider) listSoftDeleted(ctx context.Context, zone string, threshold time.Time) ([]*armdns.RecordSet, error) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return nil, err
+	}
+
+	var expiredRecordSets []*armdns.RecordSet
+	err := p.retryOnAuthenticationError(func() error {
+		expiredRecordSets = nil
+		more, nextPage := p.newRecordSetPager(zone, "", "")
+		for more() {
+			recordSets, err := nextPage(ctx)
+			if err != nil {
+				return err
+			}
+			for _, recordSet := range recordSets {
+				deletedAt, ok := getRecordSetDeletedAt(recordSet)
+				if ok && deletedAt.Before(threshold) {
+					expiredRecordSets = append(expiredRecordSets, recordSet)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expiredRecordSets, nil
+}
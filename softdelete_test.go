@@ -0,0 +1,157 @@
+package azure
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func getStoredTXTRecordSet(name string, value string, deletedAt string) *armdns.RecordSet {
+	recordSet := &armdns.RecordSet{
+		Name: to.Ptr(name),
+		Type: to.Ptr("Microsoft.Network/dnszones/TXT"),
+		Etag: to.Ptr("ETAG_" + name),
+		Properties: &armdns.RecordSetProperties{
+			TTL:        to.Ptr[int64](3600),
+			TxtRecords: []*armdns.TxtRecord{{Value: []*string{to.Ptr(value)}}},
+		},
+	}
+	if deletedAt != "" {
+		recordSet.Properties.TTL = to.Ptr[int64](softDeletedTTL)
+		// Azure DNS does not preserve the case of metadata keys
+		recordSet.Properties.Metadata = map[string]*string{"Libdns_deleted_at": to.Ptr(deletedAt), "Libdns_deleted_ttl": to.Ptr("3600")}
+	}
+	return recordSet
+}
+
+func Test_softDelete(t *testing.T) {
+	t.Run("delete", func(t *testing.T) {
+		zone := newFakeZone(getStoredTXTRecordSet("record-txt", "TEST VALUE", ""))
+		provider := getFakeProviderWithZone(zone)
+		provider.SoftDeleteGracePeriod = time.Hour
+		deleted, err := provider.DeleteRecords(context.TODO(), "example.com.", []libdns.Record{libdns.RR{Name: "record-txt", Type: "TXT"}})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(deleted) != 1 {
+			t.Errorf("got: %v", deleted)
+		}
+		if diff := cmp.Diff(zone.calls, []string{"get", "write"}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		recordSet := zone.get("record-txt", "TXT")
+		if recordSet == nil {
+			t.Fatalf("the record set was deleted")
+		}
+		if _, ok := getRecordSetDeletedAt(recordSet); !ok {
+			t.Errorf("the record set was not marked as deleted: %v", recordSet.Properties.Metadata)
+		}
+		if got := *recordSet.Properties.TTL; got != softDeletedTTL {
+			t.Errorf("got TTL: %d", got)
+		}
+		if got := stringValue(recordSet.Properties.Metadata[deletedTTLMetadataKey]); got != "3600" {
+			t.Errorf("got TTL before the deletion: %v", got)
+		}
+
+		records, err := provider.GetRecords(context.TODO(), "example.com.")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(records) != 0 {
+			t.Errorf("the soft-deleted record set was listed: %v", records)
+		}
+	})
+	t.Run("delete,challenge", func(t *testing.T) {
+		zone := newFakeZone(getStoredTXTRecordSet("_acme-challenge", "TOKEN", ""))
+		provider := getFakeProviderWithZone(zone)
+		provider.SoftDeleteGracePeriod = time.Hour
+		if _, err := provider.DeleteRecords(context.TODO(), "example.com.", []libdns.Record{libdns.RR{Name: "_acme-challenge", Type: "TXT"}}); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if zone.get("_acme-challenge", "TXT") != nil {
+			t.Errorf("the record set for the challenge was not deleted")
+		}
+	})
+
+	restore := map[string]func(p *Provider) ([]libdns.Record, error){
+		"append": func(p *Provider) ([]libdns.Record, error) {
+			return p.AppendRecords(context.TODO(), "example.com.", []libdns.Record{
+				libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Duration(300) * time.Second},
+			})
+		},
+		"set": func(p *Provider) ([]libdns.Record, error) {
+			return p.SetRecords(context.TODO(), "example.com.", []libdns.Record{
+				libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Duration(300) * time.Second},
+			})
+		},
+	}
+	for operation, operate := range restore {
+		t.Run("restore,operation="+operation, func(t *testing.T) {
+			zone := newFakeZone(getStoredTXTRecordSet("record-txt", "OLD VALUE", time.Now().UTC().Format(time.RFC3339)))
+			provider := getFakeProviderWithZone(zone)
+			provider.SoftDeleteGracePeriod = time.Hour
+			if _, err := operate(provider); err != nil {
+				t.Fatalf("%s", err)
+			}
+			recordSet := zone.get("record-txt", "TXT")
+			if _, ok := getRecordSetDeletedAt(recordSet); ok {
+				t.Errorf("the record set is still marked as deleted: %v", recordSet.Properties.Metadata)
+			}
+			if len(recordSet.Properties.Metadata) != 0 {
+				t.Errorf("got metadata: %v", recordSet.Properties.Metadata)
+			}
+			want := []*armdns.TxtRecord{{Value: []*string{to.Ptr("NEW VALUE")}}}
+			if diff := cmp.Diff(recordSet.Properties.TxtRecords, want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+			if got := *recordSet.Properties.TTL; got != 300 {
+				t.Errorf("got TTL: %d", got)
+			}
+		})
+	}
+}
+
+func Test_Purge(t *testing.T) {
+	tests := []struct {
+		name        string
+		gracePeriod time.Duration
+		want        []string
+		wantLeft    []string
+	}{
+		{name: "grace=1h", gracePeriod: time.Hour, want: []string{"record-expired"}, wantLeft: []string{"record-live/TXT", "record-recent/TXT"}},
+		{name: "grace=48h", gracePeriod: 48 * time.Hour, want: nil, wantLeft: []string{"record-expired/TXT", "record-live/TXT", "record-recent/TXT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := newFakeZone(
+				getStoredTXTRecordSet("record-live", "LIVE", ""),
+				getStoredTXTRecordSet("record-recent", "RECENT", time.Now().UTC().Format(time.RFC3339)),
+				getStoredTXTRecordSet("record-expired", "EXPIRED", time.Now().Add(-24*time.Hour).UTC().Format(time.RFC3339)),
+			)
+			provider := getFakeProviderWithZone(zone)
+			provider.SoftDeleteGracePeriod = tt.gracePeriod
+			purged, err := provider.Purge(context.TODO(), "example.com.")
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			var names []string
+			for _, record := range purged {
+				names = append(names, record.RR().Name)
+			}
+			if diff := cmp.Diff(names, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+			left := append([]string(nil), zone.keys...)
+			sort.Strings(left)
+			if diff := cmp.Diff(left, tt.wantLeft); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package azure
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// Stats is a snapshot of the runtime counters of a provider, for applications embedding it to report its health
+// without instrumenting it, e.g. on a status page. The counters start when the provider is first used and are kept
+// across Close and SetCredential. For exporting metrics, set Meter Provider instead.
+type Stats struct {
+	// Calls is the number of calls to Azure Resource Manager by operation, excluding retries,
+	// e.g. "RecordSets.Get", "RecordSets.CreateOrUpdate", or "Zones.List". Other calls are counted as "Other".
+	Calls map[string]int64
+
+	// Retries is the number of times the calls were retried.
+	Retries int64
+
+	// Throttled is the number of responses with the status 429 Too Many Requests, which are retried after the delay requested.
+	Throttled int64
+
+	// Errors is the number of calls that failed after their retries. Responses with the status 404 Not Found
+	// are not counted, since the provider looks up record sets that may not exist.
+	Errors int64
+
+	// Last Error is the error of the call that failed last, or nil if none has, and Last Error At is when it failed.
+	LastError   error
+	LastErrorAt time.Time
+
+	// Average Latency is the average duration of the calls, including their retries.
+	AverageLatency time.Duration
+
+	// Zones Cached is the number of zones whose details are cached with Zone Cache TTL.
+	// The records themselves are not cached, and are always read from Azure DNS.
+	ZonesCached int
+}
+
+// providerStats holds the runtime counters of a provider, recorded by statsPolicy.
+type providerStats struct {
+	calls       map[string]int64
+	retries     int64
+	throttled   int64
+	errors      int64
+	lastError   error
+	lastErrorAt time.Time
+	latency     time.Duration
+	mutex       sync.Mutex
+}
+
+// Stats returns a snapshot of the runtime counters of the provider, including those of the providers derived
+// for the locations set by contexts and for private DNS zones.
+func (p *Provider) Stats() Stats {
+	p.overrides.mutex.Lock()
+	providers := []*Provider{p}
+	for _, provider := range p.overrides.providers {
+		providers = append(providers, provider)
+	}
+	for _, provider := range p.overrides.privateProviders {
+		providers = append(providers, provider)
+	}
+	p.overrides.mutex.Unlock()
+
+	stats := Stats{Calls: map[string]int64{}}
+	var calls int64
+	var latency time.Duration
+	for _, provider := range providers {
+		provider.stats.mutex.Lock()
+		for operation, count := range provider.stats.calls {
+			stats.Calls[operation] += count
+			calls += count
+		}
+		stats.Retries += provider.stats.retries
+		stats.Throttled += provider.stats.throttled
+		stats.Errors += provider.stats.errors
+		if provider.stats.lastErrorAt.After(stats.LastErrorAt) {
+			stats.LastError = provider.stats.lastError
+			stats.LastErrorAt = provider.stats.lastErrorAt
+		}
+		latency += provider.stats.latency
+		provider.stats.mutex.Unlock()
+
+		provider.zoneCache.mutex.Lock()
+		stats.ZonesCached += len(provider.zoneCache.zones)
+		provider.zoneCache.mutex.Unlock()
+	}
+	if calls > 0 {
+		stats.AverageLatency = latency / time.Duration(calls)
+	}
+	return stats
+}
+
+// recordCall adds the call of the operation with its duration, and the error it failed with, if any.
+func (s *providerStats) recordCall(operation string, duration time.Duration, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.calls == nil {
+		s.calls = map[string]int64{}
+	}
+	s.calls[operation]++
+	s.latency += duration
+	if err != nil {
+		s.errors++
+		s.lastError = err
+		s.lastErrorAt = time.Now()
+	}
+}
+
+// recordAttempt adds the retry, if the attempt is not the first one, and the throttling, if the attempt was throttled.
+func (s *providerStats) recordAttempt(retry bool, throttled bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if retry {
+		s.retries++
+	}
+	if throttled {
+		s.throttled++
+	}
+}
+
+// callAttempts counts the attempts to send a request, shared by the stats policies through the operation values of the request.
+type callAttempts struct {
+	count int
+}
+
+// statsPolicy is a per-call pipeline policy that records the calls, their durations, and their errors in the stats of the provider.
+type statsPolicy struct {
+	stats *providerStats
+}
+
+// Do records the request after sending it to the next policy, which retries it as necessary.
+func (s statsPolicy) Do(req *policy.Request) (*http.Response, error) {
+	req.SetOperationValue(&callAttempts{})
+
+	start := time.Now()
+	resp, err := req.Next()
+	duration := time.Since(start)
+
+	operation := statsOperation(req.Raw())
+	callErr := err
+	if callErr == nil && resp.StatusCode >= http.StatusBadRequest && resp.StatusCode != http.StatusNotFound {
+		callErr = fmt.Errorf("the call %v failed with the status %v", operation, resp.Status)
+	}
+	s.stats.recordCall(operation, duration, callErr)
+
+	return resp, err
+}
+
+// statsAttemptPolicy is a per-retry pipeline policy that records the retries and the throttled attempts for statsPolicy.
+type statsAttemptPolicy struct {
+	stats *providerStats
+}
+
+// Do counts the attempt, and records it after sending it to the next policy.
+func (s statsAttemptPolicy) Do(req *policy.Request) (*http.Response, error) {
+	var attempts *callAttempts
+	if req.OperationValue(&attempts) {
+		attempts.count++
+	}
+
+	resp, err := req.Next()
+
+	retry := attempts != nil && attempts.count > 1
+	throttled := err == nil && resp.StatusCode == http.StatusTooManyRequests
+	s.stats.recordAttempt(retry, throttled)
+
+	return resp, err
+}
+
+// statsOperation names the operation of the request to Azure DNS or Azure Private DNS after the method of the SDK client sending it,
+// e.g. "RecordSets.Get" for a GET request for a record set, or returns "Other" for requests to other resources.
+func statsOperation(req *http.Request) string {
+	verbs := map[string]string{
+		http.MethodGet:    "Get",
+		http.MethodPut:    "CreateOrUpdate",
+		http.MethodPatch:  "Update",
+		http.MethodDelete: "Delete",
+	}
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i, segment := range segments {
+		if !strings.EqualFold(segment, "dnsZones") && !strings.EqualFold(segment, "privateDnsZones") {
+			continue
+		}
+		verb, ok := verbs[req.Method]
+		switch len(segments[i+1:]) {
+		case 0:
+			if req.Method == http.MethodGet {
+				return "Zones.List"
+			}
+		case 1:
+			if ok {
+				return "Zones." + verb
+			}
+		case 2:
+			if req.Method == http.MethodGet {
+				return "RecordSets.List"
+			}
+		case 3:
+			if ok {
+				return "RecordSets." + verb
+			}
+		}
+		break
+	}
+	return "Other"
+}
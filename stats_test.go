@@ -0,0 +1,135 @@
+package azure
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_Stats(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		failures      int
+		wantCalls     map[string]int64
+		wantRetries   int64
+		wantThrottled int64
+		wantErrors    int64
+	}{
+		{
+			name:      "failures=0",
+			wantCalls: map[string]int64{"RecordSets.Delete": 1},
+		},
+		{
+			name:          "status=429,failures=2",
+			status:        http.StatusTooManyRequests,
+			failures:      2,
+			wantCalls:     map[string]int64{"RecordSets.Delete": 1},
+			wantRetries:   2,
+			wantThrottled: 2,
+		},
+		{
+			name:        "status=503,failures=4",
+			status:      http.StatusServiceUnavailable,
+			failures:    4,
+			wantCalls:   map[string]int64{"RecordSets.Delete": 1},
+			wantRetries: 3,
+			wantErrors:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := getFakeProvider()
+			failures := tt.failures
+			transport := provider.client.clientOptions.Transport
+			provider.client.clientOptions.Transport = transportFunc(func(req *http.Request) (*http.Response, error) {
+				if failures > 0 {
+					failures--
+					return &http.Response{
+						StatusCode: tt.status,
+						Status:     http.StatusText(tt.status),
+						Header:     http.Header{"Retry-After": []string{"0"}},
+						Body:       io.NopCloser(strings.NewReader("")),
+						Request:    req,
+					}, nil
+				}
+				return transport.Do(req)
+			})
+			provider.client.clientOptions.Retry = policy.RetryOptions{MaxRetries: 3, RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond}
+			provider.resetClient()
+
+			_, err := provider.DeleteRecords(context.TODO(), "example.com.", []libdns.Record{
+				libdns.RR{Name: "record-a", Type: "A"},
+			})
+			if (err != nil) != (tt.wantErrors > 0) {
+				t.Fatalf("got error: %v", err)
+			}
+
+			stats := provider.Stats()
+			if diff := cmp.Diff(stats.Calls, tt.wantCalls); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+			if stats.Retries != tt.wantRetries || stats.Throttled != tt.wantThrottled || stats.Errors != tt.wantErrors {
+				t.Errorf("got retries: %v, throttled: %v, errors: %v", stats.Retries, stats.Throttled, stats.Errors)
+			}
+			if (stats.LastError != nil) != (tt.wantErrors > 0) || stats.LastErrorAt.IsZero() != (tt.wantErrors == 0) {
+				t.Errorf("got last error: %v at %v", stats.LastError, stats.LastErrorAt)
+			}
+			if stats.AverageLatency <= 0 {
+				t.Errorf("got average latency: %v", stats.AverageLatency)
+			}
+		})
+	}
+
+	t.Run("provider=derived", func(t *testing.T) {
+		var paths []string
+		provider := getFakeProviderCapturingPaths(&paths)
+		ctx := WithResourceGroup(context.TODO(), "other-resource-group-name")
+		if _, err := provider.DeleteRecords(ctx, "example.com.", []libdns.Record{
+			libdns.RR{Name: "record-a", Type: "A"},
+		}); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if got := provider.Stats().Calls["RecordSets.Delete"]; got != 1 {
+			t.Errorf("got: %v, want: 1", got)
+		}
+	})
+}
+
+func Test_statsOperation(t *testing.T) {
+	zones := "https://management.azure.com/subscriptions/s/resourceGroups/g/providers/Microsoft.Network/dnszones"
+	tests := []struct {
+		method string
+		url    string
+		want   string
+	}{
+		{method: http.MethodGet, url: zones, want: "Zones.List"},
+		{method: http.MethodGet, url: "https://management.azure.com/subscriptions/s/providers/Microsoft.Network/dnszones", want: "Zones.List"},
+		{method: http.MethodGet, url: zones + "/example.com", want: "Zones.Get"},
+		{method: http.MethodGet, url: zones + "/example.com/recordsets", want: "RecordSets.List"},
+		{method: http.MethodGet, url: zones + "/example.com/A", want: "RecordSets.List"},
+		{method: http.MethodGet, url: zones + "/example.com/A/www", want: "RecordSets.Get"},
+		{method: http.MethodPut, url: zones + "/example.com/A/www", want: "RecordSets.CreateOrUpdate"},
+		{method: http.MethodPatch, url: zones + "/example.com/A/www", want: "RecordSets.Update"},
+		{method: http.MethodDelete, url: zones + "/example.com/A/www", want: "RecordSets.Delete"},
+		{method: http.MethodGet, url: "https://management.azure.com/subscriptions/s/resourceGroups/g/providers/Microsoft.Authorization/permissions", want: "Other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, nil)
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if got := statsOperation(req); got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
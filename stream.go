@@ -0,0 +1,73 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// RecordResult is the result of writing a record, streamed by WriteRecordsStream.
+type RecordResult struct {
+	// Record is the record as passed to WriteRecordsStream.
+	Record libdns.Record
+
+	// Err is the error that the write of the record set the record belongs to failed with, or nil if it succeeded.
+	Err error
+}
+
+// WriteRecordsStream writes the records to the zone with the operation in the background, as AppendRecords, SetRecords,
+// or DeleteRecords does, and streams the result of each record over the returned channel as soon as its record set is written,
+// so that the progress of large writes taking minutes can be shown. The channel is closed after the results of all the records.
+// Unlike the calls, the records are checked and replicated record set by record set. In the fail-fast batch mode,
+// the records after the first failure are not written and have the error of the failure as their result.
+// The channel is buffered for all the results, so the writes are not blocked by a caller that stops receiving.
+func (p *Provider) WriteRecordsStream(ctx context.Context, operation WriteOperation, zone string, records []libdns.Record) <-chan RecordResult {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.WriteRecordsStream(ctx, operation, zone, records)
+	}
+
+	results := make(chan RecordResult, len(records))
+
+	go func() {
+		defer close(results)
+
+		// Trace all the writes as one unit
+		ctx, _ := ensureCorrelationID(ctx)
+		recordGroups := groupRecordsByRecordSet(records, zone, p.recordSetName)
+		ctx = p.withProgress(ctx, len(recordGroups))
+
+		var failure error
+		for _, recordGroup := range recordGroups {
+			err := failure
+			if err == nil {
+				err = p.writeRecordsWith(ctx, operation, zone, recordGroup)
+				if err != nil && !p.isBestEffort() {
+					failure = err
+				}
+			}
+			for _, record := range recordGroup {
+				results <- RecordResult{Record: record, Err: err}
+			}
+		}
+	}()
+
+	return results
+}
+
+// writeRecordsWith writes the records to the zone with the operation.
+func (p *Provider) writeRecordsWith(ctx context.Context, operation WriteOperation, zone string, records []libdns.Record) error {
+	var err error
+	switch operation {
+	case WriteOperationAppend:
+		_, err = p.AppendRecords(ctx, zone, records)
+	case WriteOperationSet:
+		_, err = p.SetRecords(ctx, zone, records)
+	case WriteOperationDelete:
+		_, err = p.DeleteRecords(ctx, zone, records)
+	default:
+		err = fmt.Errorf("the write operation %v cannot be interpreted", operation)
+	}
+	return err
+}
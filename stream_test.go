@@ -0,0 +1,78 @@
+package azure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_WriteRecordsStream(t *testing.T) {
+	records := []libdns.Record{
+		libdns.TXT{Name: "record-a", Text: "NEW VALUE 1", TTL: time.Minute},
+		// The record set is owned by another owner, so writing to it fails if ownership is enforced
+		libdns.TXT{Name: "record-txt", Text: "TEST VALUE", TTL: time.Minute},
+		libdns.TXT{Name: "record-a", Text: "NEW VALUE 2", TTL: time.Minute},
+		libdns.TXT{Name: "record-b", Text: "NEW VALUE", TTL: time.Minute},
+	}
+	tests := []struct {
+		name       string
+		operation  WriteOperation
+		batchMode  BatchMode
+		ownerId    string
+		want       []string
+		wantWrites int
+	}{
+		{
+			name:       "operation=append,mode=fail_fast",
+			operation:  WriteOperationAppend,
+			ownerId:    "team-a",
+			want:       []string{"record-a ok", "record-a ok", "record-txt failed", "record-b failed"},
+			wantWrites: 1,
+		},
+		{
+			name:       "operation=append,mode=best_effort",
+			operation:  WriteOperationAppend,
+			batchMode:  BatchModeBestEffort,
+			ownerId:    "team-a",
+			want:       []string{"record-a ok", "record-a ok", "record-txt failed", "record-b ok"},
+			wantWrites: 2,
+		},
+		{
+			name:       "operation=set",
+			operation:  WriteOperationSet,
+			want:       []string{"record-a ok", "record-a ok", "record-txt ok", "record-b ok"},
+			wantWrites: 3,
+		},
+		{
+			name:       "operation=unknown",
+			operation:  "replace",
+			want:       []string{"record-a failed", "record-a failed", "record-txt failed", "record-b failed"},
+			wantWrites: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := newFakeZone(getOwnedTXTRecordSet("team-b"))
+			provider := getFakeProviderWithZone(zone)
+			provider.OwnerId = tt.ownerId
+			provider.BatchMode = tt.batchMode
+			var got []string
+			for result := range provider.WriteRecordsStream(context.TODO(), tt.operation, "example.com.", records) {
+				status := "ok"
+				if result.Err != nil {
+					status = "failed"
+				}
+				got = append(got, result.Record.RR().Name+" "+status)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+			if len(zone.written) != tt.wantWrites {
+				t.Errorf("got: %d writes, want: %d", len(zone.written), tt.wantWrites)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+)
+
+// TerraformFormat is what ExportTerraform renders.
+type TerraformFormat string
+
+const (
+	// TerraformFormatResources renders the azurerm_dns_*_record resource blocks.
+	TerraformFormatResources TerraformFormat = "resources"
+
+	// TerraformFormatImportBlocks renders the resource blocks with an import block for each, for Terraform 1.5 or later.
+	TerraformFormatImportBlocks TerraformFormat = "import-blocks"
+
+	// TerraformFormatImportCommands renders a terraform import command for each resource, for the resource blocks rendered before.
+	TerraformFormatImportCommands TerraformFormat = "import-commands"
+)
+
+// TerraformOptions are options to export the record sets of a zone to Terraform.
+type TerraformOptions struct {
+	// Format is what to render. Defaults to TerraformFormatResources.
+	Format TerraformFormat
+
+	// Zone Name is the expression of zone_name, e.g. "azurerm_dns_zone.example.name". Defaults to the name of the zone as a string.
+	ZoneName string
+
+	// Resource Group Name is the expression of resource_group_name, e.g. "azurerm_resource_group.example.name".
+	// Defaults to Resource Group Name of the provider as a string.
+	ResourceGroupName string
+}
+
+// terraformResourceTypes are the types of the azurerm resources of the record set types.
+var terraformResourceTypes = map[string]string{
+	"A":     "azurerm_dns_a_record",
+	"AAAA":  "azurerm_dns_aaaa_record",
+	"CAA":   "azurerm_dns_caa_record",
+	"CNAME": "azurerm_dns_cname_record",
+	"MX":    "azurerm_dns_mx_record",
+	"NS":    "azurerm_dns_ns_record",
+	"PTR":   "azurerm_dns_ptr_record",
+	"SRV":   "azurerm_dns_srv_record",
+	"TXT":   "azurerm_dns_txt_record",
+}
+
+// terraformLabelInvalid matches the characters not allowed in the names of Terraform resources.
+var terraformLabelInvalid = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// ExportTerraform renders the record sets of the zone as the azurerm_dns_*_record resources of the azurerm provider of Terraform,
+// or as the commands to import them, so that a zone managed through this provider can be brought under Terraform.
+// The record sets are rendered in order of their names and types, with the TTL, the values or the target resource of an alias,
+// and the metadata as tags. The SOA record set and the NS record set at the apex, which Azure DNS manages with the zone, are skipped.
+func (p *Provider) ExportTerraform(ctx context.Context, zone string, w io.Writer, options TerraformOptions) error {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.ExportTerraform(ctx, zone, w, options)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	recordSets, err := p.listRecordSets(ctx, zone)
+	if err != nil {
+		return wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+
+	return p.renderTerraform(zone, recordSets, w, options)
+}
+
+// listRecordSets lists all the record sets in the zone as they are on Azure DNS.
+func (p *Provider) listRecordSets(ctx context.Context, zone string) ([]*armdns.RecordSet, error) {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return nil, err
+	}
+
+	var allRecordSets []*armdns.RecordSet
+	err := p.retryOnAuthenticationError(func() error {
+		allRecordSets = nil
+		more, nextPage := p.newRecordSetPager(zone, "", "")
+		for more() {
+			recordSets, err := nextPage(ctx)
+			if err != nil {
+				return err
+			}
+			allRecordSets = append(allRecordSets, recordSets...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return allRecordSets, nil
+}
+
+// renderTerraform renders the record sets in the format of the options.
+func (p *Provider) renderTerraform(zone string, recordSets []*armdns.RecordSet, w io.Writer, options TerraformOptions) error {
+	format := options.Format
+	if format == "" {
+		format = TerraformFormatResources
+	}
+	if format != TerraformFormatResources && format != TerraformFormatImportBlocks && format != TerraformFormatImportCommands {
+		return fmt.Errorf("the Terraform format %v cannot be interpreted", format)
+	}
+	zoneName := options.ZoneName
+	if zoneName == "" {
+		zoneName = hclString(strings.TrimSuffix(zone, "."))
+	}
+	resourceGroupName := options.ResourceGroupName
+	if resourceGroupName == "" {
+		resourceGroupName = hclString(p.ResourceGroupName)
+	}
+
+	sort.SliceStable(recordSets, func(i, j int) bool {
+		a, b := recordSets[i], recordSets[j]
+		if !strings.EqualFold(stringValue(a.Name), stringValue(b.Name)) {
+			return strings.ToLower(stringValue(a.Name)) < strings.ToLower(stringValue(b.Name))
+		}
+		return recordSetTypeName(a) < recordSetTypeName(b)
+	})
+
+	labels := map[string]bool{}
+	var b strings.Builder
+	for _, recordSet := range recordSets {
+		name := stringValue(recordSet.Name)
+		typeName := recordSetTypeName(recordSet)
+		resourceType, ok := terraformResourceTypes[typeName]
+		if !ok || (typeName == "NS" && name == "@") {
+			continue
+		}
+		if recordSet.Properties == nil {
+			recordSet.Properties = &armdns.RecordSetProperties{}
+		}
+		label := terraformLabel(name, typeName, labels)
+		address := resourceType + "." + label
+		id := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/dnsZones/%s/%s/%s",
+			p.SubscriptionId, p.ResourceGroupName, strings.TrimSuffix(zone, "."), typeName, name)
+
+		if format == TerraformFormatImportCommands {
+			fmt.Fprintf(&b, "terraform import %s %s\n", address, shellQuote(id))
+			continue
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		if format == TerraformFormatImportBlocks {
+			fmt.Fprintf(&b, "import {\n  to = %s\n  id = %s\n}\n\n", address, hclString(id))
+		}
+		fmt.Fprintf(&b, "resource %q %q {\n", resourceType, label)
+		fmt.Fprintf(&b, "  name                = %s\n", hclString(name))
+		fmt.Fprintf(&b, "  zone_name           = %s\n", zoneName)
+		fmt.Fprintf(&b, "  resource_group_name = %s\n", resourceGroupName)
+		if recordSet.Properties.TTL != nil {
+			fmt.Fprintf(&b, "  ttl                 = %d\n", *recordSet.Properties.TTL)
+		}
+		renderTerraformValues(&b, typeName, recordSet.Properties)
+		if len(recordSet.Properties.Metadata) > 0 {
+			renderTerraformTags(&b, recordSet.Properties.Metadata)
+		}
+		b.WriteString("}\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// renderTerraformValues renders the values of the record set, or the target resource of an alias, as the arguments of the resource.
+func renderTerraformValues(b *strings.Builder, typeName string, properties *armdns.RecordSetProperties) {
+	if properties.TargetResource != nil && properties.TargetResource.ID != nil {
+		fmt.Fprintf(b, "  target_resource_id  = %s\n", hclString(*properties.TargetResource.ID))
+		return
+	}
+
+	switch typeName {
+	case "A":
+		var values []string
+		for _, record := range properties.ARecords {
+			values = append(values, stringValue(record.IPv4Address))
+		}
+		fmt.Fprintf(b, "  records             = %s\n", hclList(values))
+	case "AAAA":
+		var values []string
+		for _, record := range properties.AaaaRecords {
+			values = append(values, stringValue(record.IPv6Address))
+		}
+		fmt.Fprintf(b, "  records             = %s\n", hclList(values))
+	case "NS":
+		var values []string
+		for _, record := range properties.NsRecords {
+			values = append(values, stringValue(record.Nsdname))
+		}
+		fmt.Fprintf(b, "  records             = %s\n", hclList(values))
+	case "PTR":
+		var values []string
+		for _, record := range properties.PtrRecords {
+			values = append(values, stringValue(record.Ptrdname))
+		}
+		fmt.Fprintf(b, "  records             = %s\n", hclList(values))
+	case "CNAME":
+		if properties.CnameRecord != nil {
+			fmt.Fprintf(b, "  record              = %s\n", hclString(stringValue(properties.CnameRecord.Cname)))
+		}
+	case "CAA":
+		for _, record := range properties.CaaRecords {
+			fmt.Fprintf(b, "\n  record {\n    flags = %d\n    tag   = %s\n    value = %s\n  }\n",
+				int32Value(record.Flags), hclString(stringValue(record.Tag)), hclString(stringValue(record.Value)))
+		}
+	case "MX":
+		for _, record := range properties.MxRecords {
+			fmt.Fprintf(b, "\n  record {\n    preference = %d\n    exchange   = %s\n  }\n",
+				int32Value(record.Preference), hclString(stringValue(record.Exchange)))
+		}
+	case "SRV":
+		for _, record := range properties.SrvRecords {
+			fmt.Fprintf(b, "\n  record {\n    priority = %d\n    weight   = %d\n    port     = %d\n    target   = %s\n  }\n",
+				int32Value(record.Priority), int32Value(record.Weight), int32Value(record.Port), hclString(stringValue(record.Target)))
+		}
+	case "TXT":
+		for _, record := range properties.TxtRecords {
+			var value strings.Builder
+			for _, s := range record.Value {
+				value.WriteString(stringValue(s))
+			}
+			fmt.Fprintf(b, "\n  record {\n    value = %s\n  }\n", hclString(value.String()))
+		}
+	}
+}
+
+// renderTerraformTags renders the metadata of the record set as tags, in order of their keys.
+func renderTerraformTags(b *strings.Builder, metadata map[string]*string) {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	b.WriteString("\n  tags = {\n")
+	for _, key := range keys {
+		fmt.Fprintf(b, "    %s = %s\n", hclString(key), hclString(stringValue(metadata[key])))
+	}
+	b.WriteString("  }\n")
+}
+
+// recordSetTypeName returns the type of the record set without the resource provider, e.g. "A" for "Microsoft.Network/dnszones/A".
+func recordSetTypeName(recordSet *armdns.RecordSet) string {
+	typeName := stringValue(recordSet.Type)
+	return typeName[strings.LastIndex(typeName, "/")+1:]
+}
+
+// terraformLabel returns a unique name of the Terraform resource for the record set, e.g. "www_a" for "www" A and "apex_mx" for "@" MX.
+func terraformLabel(name string, typeName string, labels map[string]bool) string {
+	if name == "@" {
+		name = "apex"
+	}
+	name = strings.ReplaceAll(name, "*", "wildcard")
+	label := terraformLabelInvalid.ReplaceAllString(strings.ReplaceAll(name, ".", "_"), "_") + "_" + strings.ToLower(typeName)
+	if label[0] >= '0' && label[0] <= '9' || label[0] == '-' {
+		label = "_" + label
+	}
+	unique := label
+	for i := 2; labels[strings.ToLower(unique)]; i++ {
+		unique = fmt.Sprintf("%s_%d", label, i)
+	}
+	labels[strings.ToLower(unique)] = true
+	return unique
+}
+
+// hclString quotes the string as an HCL string literal, escaping the template sequences so that they are taken literally.
+func hclString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			b.WriteString(`\\`)
+		case c == '"':
+			b.WriteString(`\"`)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case (c == '$' || c == '%') && i+1 < len(s) && s[i+1] == '{':
+			b.WriteByte(c)
+			b.WriteByte(c)
+		case c < 0x20:
+			fmt.Fprintf(&b, `\u%04x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// hclList renders the strings as an HCL list of string literals.
+func hclList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, hclString(value))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// shellQuote quotes the string for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
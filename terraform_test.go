@@ -0,0 +1,171 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+)
+
+func getFakeProviderForTerraform() *Provider {
+	return getFakeProviderWithZone(newFakeZone(
+		&armdns.RecordSet{
+			Name:       to.Ptr("www"),
+			Type:       to.Ptr("Microsoft.Network/dnszones/TXT"),
+			Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](300), TxtRecords: []*armdns.TxtRecord{{Value: []*string{to.Ptr(`say "hi" ${var}`)}}}},
+		},
+		&armdns.RecordSet{
+			Name:       to.Ptr("@"),
+			Type:       to.Ptr("Microsoft.Network/dnszones/SOA"),
+			Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](3600), SoaRecord: &armdns.SoaRecord{Host: to.Ptr("ns1-01.azure-dns.com.")}},
+		},
+		&armdns.RecordSet{
+			Name:       to.Ptr("@"),
+			Type:       to.Ptr("Microsoft.Network/dnszones/NS"),
+			Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](172800), NsRecords: []*armdns.NsRecord{{Nsdname: to.Ptr("ns1-01.azure-dns.com.")}}},
+		},
+		&armdns.RecordSet{
+			Name: to.Ptr("www"),
+			Type: to.Ptr("Microsoft.Network/dnszones/A"),
+			Properties: &armdns.RecordSetProperties{
+				TTL:      to.Ptr[int64](300),
+				ARecords: []*armdns.ARecord{{IPv4Address: to.Ptr("192.0.2.1")}, {IPv4Address: to.Ptr("192.0.2.2")}},
+				Metadata: map[string]*string{"owner": to.Ptr("team-a")},
+			},
+		},
+		&armdns.RecordSet{
+			Name:       to.Ptr("@"),
+			Type:       to.Ptr("Microsoft.Network/dnszones/MX"),
+			Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](3600), MxRecords: []*armdns.MxRecord{{Preference: to.Ptr[int32](10), Exchange: to.Ptr("mail.example.com.")}}},
+		},
+		&armdns.RecordSet{
+			Name:       to.Ptr("*.app"),
+			Type:       to.Ptr("Microsoft.Network/dnszones/A"),
+			Properties: &armdns.RecordSetProperties{TTL: to.Ptr[int64](60), TargetResource: &armdns.SubResource{ID: to.Ptr("/subscriptions/x/resourceGroups/y/providers/Microsoft.Network/publicIPAddresses/z")}},
+		},
+	))
+}
+
+func Test_ExportTerraform(t *testing.T) {
+	tests := []struct {
+		name    string
+		options TerraformOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "format=resources",
+			options: TerraformOptions{ZoneName: "azurerm_dns_zone.example.name"},
+			want: `resource "azurerm_dns_a_record" "wildcard_app_a" {
+  name                = "*.app"
+  zone_name           = azurerm_dns_zone.example.name
+  resource_group_name = "fake-resource-group-name"
+  ttl                 = 60
+  target_resource_id  = "/subscriptions/x/resourceGroups/y/providers/Microsoft.Network/publicIPAddresses/z"
+}
+
+resource "azurerm_dns_mx_record" "apex_mx" {
+  name                = "@"
+  zone_name           = azurerm_dns_zone.example.name
+  resource_group_name = "fake-resource-group-name"
+  ttl                 = 3600
+
+  record {
+    preference = 10
+    exchange   = "mail.example.com."
+  }
+}
+
+resource "azurerm_dns_a_record" "www_a" {
+  name                = "www"
+  zone_name           = azurerm_dns_zone.example.name
+  resource_group_name = "fake-resource-group-name"
+  ttl                 = 300
+  records             = ["192.0.2.1", "192.0.2.2"]
+
+  tags = {
+    "owner" = "team-a"
+  }
+}
+
+resource "azurerm_dns_txt_record" "www_txt" {
+  name                = "www"
+  zone_name           = azurerm_dns_zone.example.name
+  resource_group_name = "fake-resource-group-name"
+  ttl                 = 300
+
+  record {
+    value = "say \"hi\" $${var}"
+  }
+}
+`,
+		},
+		{
+			name:    "format=import-commands",
+			options: TerraformOptions{Format: TerraformFormatImportCommands},
+			want: `terraform import azurerm_dns_a_record.wildcard_app_a '/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/dnsZones/example.com/A/*.app'
+terraform import azurerm_dns_mx_record.apex_mx '/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/dnsZones/example.com/MX/@'
+terraform import azurerm_dns_a_record.www_a '/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/dnsZones/example.com/A/www'
+terraform import azurerm_dns_txt_record.www_txt '/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/dnsZones/example.com/TXT/www'
+`,
+		},
+		{
+			name:    "format=invalid",
+			options: TerraformOptions{Format: "json"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := getFakeProviderForTerraform()
+			var b strings.Builder
+			err := provider.ExportTerraform(context.TODO(), "example.com.", &b, tt.options)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(b.String(), tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+
+	t.Run("format=import-blocks", func(t *testing.T) {
+		provider := getFakeProviderForTerraform()
+		var b strings.Builder
+		if err := provider.ExportTerraform(context.TODO(), "example.com.", &b, TerraformOptions{Format: TerraformFormatImportBlocks}); err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := `import {
+  to = azurerm_dns_mx_record.apex_mx
+  id = "/subscriptions/fake-subscription-id/resourceGroups/fake-resource-group-name/providers/Microsoft.Network/dnsZones/example.com/MX/@"
+}
+
+resource "azurerm_dns_mx_record" "apex_mx" {`
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("got: %s", b.String())
+		}
+	})
+}
+
+func Test_terraformLabel(t *testing.T) {
+	labels := map[string]bool{}
+	got := []string{
+		terraformLabel("www", "A", labels),
+		terraformLabel("WWW", "A", labels),
+		terraformLabel("_acme-challenge.www", "TXT", labels),
+		terraformLabel("1st", "CNAME", labels),
+	}
+	want := []string{"www_a", "WWW_a_2", "_acme-challenge_www_txt", "_1st_cname"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("diff: %s", diff)
+	}
+}
@@ -0,0 +1,102 @@
+package azure
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/libdns/libdns"
+)
+
+// maxTXTStringLength is the maximum length of a character string in a TXT record.
+const maxTXTStringLength = 255
+
+// normalizeTXTRecord converts a TXT record given as an opaque RR in the zone file syntax, e.g. with the data
+// `"v=DKIM1; k=rsa; " "p=MIGf..."`, to a TXT record with the unquoted and unescaped text that libdns expects,
+// so that it is written and compared in the same manner as the same text given as a TXT record.
+// The text of a TXT record is taken literally, quotes and backslashes included, as are the data of an RR that is not entirely quoted strings.
+func normalizeTXTRecord(record libdns.Record) libdns.Record {
+	rr, ok := record.(libdns.RR)
+	if !ok || rr.Type != "TXT" {
+		return record
+	}
+	text, ok := unquoteTXT(rr.Data)
+	if !ok {
+		return record
+	}
+	return libdns.TXT{Name: rr.Name, TTL: rr.TTL, Text: text}
+}
+
+// unquoteTXT concatenates the quoted character strings of TXT data in the zone file syntax, resolving the escapes
+// of RFC 1035, i.e. "\" followed by three decimal digits for a byte, or by any other character for the character itself.
+// It reports false if the data is not one or more quoted strings separated by whitespace.
+func unquoteTXT(data string) (string, bool) {
+	var text strings.Builder
+	rest := strings.TrimSpace(data)
+	if rest == "" {
+		return "", false
+	}
+	for rest != "" {
+		if rest[0] != '"' {
+			return "", false
+		}
+		i := 1
+		for ; i < len(rest) && rest[i] != '"'; i++ {
+			if rest[i] != '\\' {
+				text.WriteByte(rest[i])
+				continue
+			}
+			if i+3 < len(rest) && isDigit(rest[i+1]) && isDigit(rest[i+2]) && isDigit(rest[i+3]) {
+				value := int(rest[i+1]-'0')*100 + int(rest[i+2]-'0')*10 + int(rest[i+3]-'0')
+				if value > 255 {
+					return "", false
+				}
+				text.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+			if i+1 >= len(rest) {
+				return "", false
+			}
+			i++
+			text.WriteByte(rest[i])
+		}
+		if i >= len(rest) {
+			return "", false
+		}
+		rest = rest[i+1:]
+		trimmed := strings.TrimLeft(rest, " \t\r\n")
+		if trimmed != "" && len(trimmed) == len(rest) {
+			// The strings must be separated by whitespace
+			return "", false
+		}
+		rest = trimmed
+	}
+	return text.String(), true
+}
+
+// isDigit reports whether the byte is a decimal digit.
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+// splitTXT splits the text of a TXT record into the character strings of at most 255 bytes that Azure DNS stores,
+// without breaking a UTF-8 sequence, so that the text is read back byte-for-byte by concatenating the strings.
+func splitTXT(text string) []*string {
+	if len(text) <= maxTXTStringLength {
+		return []*string{to.Ptr(text)}
+	}
+	var values []*string
+	for len(text) > maxTXTStringLength {
+		n := maxTXTStringLength
+		for n > maxTXTStringLength-utf8.UTFMax && !utf8.RuneStart(text[n]) {
+			n--
+		}
+		values = append(values, to.Ptr(text[:n]))
+		text = text[n:]
+	}
+	if text != "" {
+		values = append(values, to.Ptr(text))
+	}
+	return values
+}
@@ -0,0 +1,111 @@
+package azure
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_unquoteTXT(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		want   string
+		wantOk bool
+	}{
+		{name: "unquoted", data: `v=spf1 include:example.com ~all`, wantOk: false},
+		{name: "quoted", data: `"v=spf1 include:example.com ~all"`, want: `v=spf1 include:example.com ~all`, wantOk: true},
+		{name: "strings", data: `"v=DKIM1; k=rsa; " "p=MIGfMA0"`, want: `v=DKIM1; k=rsa; p=MIGfMA0`, wantOk: true},
+		{name: "escapes", data: `"quotes \" backslashes \\000 del: \127"`, want: "quotes \" backslashes \\000 del: \x7F", wantOk: true},
+		{name: "unterminated", data: `"v=spf1`, wantOk: false},
+		{name: "trailing-text", data: `"v=spf1" ~all`, wantOk: false},
+		{name: "adjacent", data: `"a""b"`, wantOk: false},
+		{name: "inner-quotes", data: `say "hello"`, wantOk: false},
+		{name: "empty", data: ``, wantOk: false},
+		{name: "empty-string", data: `""`, want: ``, wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := unquoteTXT(tt.data)
+			if ok != tt.wantOk {
+				t.Fatalf("got: %v, want: %v", ok, tt.wantOk)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_splitTXT(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		wantLengths []int
+	}{
+		{name: "short", text: "TEST VALUE", wantLengths: []int{10}},
+		{name: "255", text: strings.Repeat("a", 255), wantLengths: []int{255}},
+		{name: "256", text: strings.Repeat("a", 256), wantLengths: []int{255, 1}},
+		{name: "utf-8", text: strings.Repeat("a", 254) + "é" + "b", wantLengths: []int{254, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lengths []int
+			var text strings.Builder
+			for _, value := range splitTXT(tt.text) {
+				if !utf8.ValidString(*value) {
+					t.Errorf("the string %q is not valid UTF-8", *value)
+				}
+				lengths = append(lengths, len(*value))
+				text.WriteString(*value)
+			}
+			if diff := cmp.Diff(lengths, tt.wantLengths); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+			if text.String() != tt.text {
+				t.Errorf("got: %q, want: %q", text.String(), tt.text)
+			}
+		})
+	}
+}
+
+func Test_TXTRoundTrip(t *testing.T) {
+	dkim := "v=DKIM1; k=rsa; p=" + strings.Repeat("MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQ", 10)
+	tests := []struct {
+		name   string
+		record libdns.Record
+		want   string
+	}{
+		{name: "spf", record: libdns.TXT{Name: "@", Text: "v=spf1 include:_spf.example.com ~all"}, want: "v=spf1 include:_spf.example.com ~all"},
+		{name: "dmarc", record: libdns.TXT{Name: "_dmarc", Text: "v=DMARC1; p=reject; rua=mailto:dmarc@example.com"}, want: "v=DMARC1; p=reject; rua=mailto:dmarc@example.com"},
+		{name: "dkim", record: libdns.TXT{Name: "selector._domainkey", Text: dkim}, want: dkim},
+		{name: "literal", record: libdns.TXT{Name: "literal", Text: `"quoted" \ backslash`}, want: `"quoted" \ backslash`},
+		{name: "rr=quoted", record: libdns.RR{Name: "selector._domainkey", Type: "TXT", Data: `"v=DKIM1; k=rsa; " "p=MIGfMA0"`}, want: "v=DKIM1; k=rsa; p=MIGfMA0"},
+		{name: "rr=unquoted", record: libdns.RR{Name: "@", Type: "TXT", Data: "v=spf1 -all"}, want: "v=spf1 -all"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recordSet, err := convertLibdnsRecordToAzureRecordSet(tt.record)
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			recordSet.Name = to.Ptr(tt.record.RR().Name)
+			recordSet.Type = to.Ptr("Microsoft.Network/dnszones/TXT")
+			records, err := convertAzureRecordSetsToLibdnsRecords([]*armdns.RecordSet{&recordSet})
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(records, []libdns.Record{libdns.TXT{Name: tt.record.RR().Name, Text: tt.want}}); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+			if got := recordData(tt.record); got != tt.want {
+				t.Errorf("got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/libdns/libdns"
+)
+
+// GetRecordsOfType lists the records in the zone that are of the Go type T, e.g. libdns.TXT or Alias,
+// so that callers do not have to filter the records with type switches. The record sets are filtered by Azure DNS
+// when T stands for a single record type, and the whole zone is listed otherwise, e.g. for libdns.RR,
+// which PTR and SOA records are returned as. libdns.Address lists both the A and AAAA records.
+func GetRecordsOfType[T libdns.Record](ctx context.Context, p *Provider, zone string) ([]T, error) {
+	var records []libdns.Record
+	for _, typeName := range recordTypesOf[T]() {
+		typedRecords, err := p.GetRecordsOfType(ctx, zone, typeName)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, typedRecords...)
+	}
+
+	var typedRecords []T
+	for _, record := range records {
+		if typedRecord, ok := record.(T); ok {
+			typedRecords = append(typedRecords, typedRecord)
+		}
+	}
+
+	return typedRecords, nil
+}
+
+// recordTypesOf returns the record types to list for the records of the Go type T,
+// or a single empty type to list all the record types.
+func recordTypesOf[T libdns.Record]() []string {
+	var zero T
+	switch any(zero).(type) {
+	case libdns.Address:
+		return []string{"A", "AAAA"}
+	case libdns.CAA:
+		return []string{"CAA"}
+	case libdns.CNAME:
+		return []string{"CNAME"}
+	case libdns.MX:
+		return []string{"MX"}
+	case libdns.NS:
+		return []string{"NS"}
+	case libdns.SRV:
+		return []string{"SRV"}
+	case libdns.TXT:
+		return []string{"TXT"}
+	default:
+		return []string{""}
+	}
+}
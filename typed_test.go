@@ -0,0 +1,62 @@
+package azure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_GetRecordsOfType(t *testing.T) {
+	t.Run("type=TXT", func(t *testing.T) {
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		// Listing the whole zone is not expected
+		fakeRecordSetsServer.NewListByDNSZonePager = nil
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		got, err := GetRecordsOfType[libdns.TXT](context.TODO(), &provider, "example.com.")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		var want []libdns.TXT
+		for _, record := range libdnsFakeRecords {
+			if txt, ok := record.(libdns.TXT); ok {
+				want = append(want, txt)
+			}
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("type=Address", func(t *testing.T) {
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		fakeRecordSetsServer.NewListByDNSZonePager = nil
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		got, err := GetRecordsOfType[libdns.Address](context.TODO(), &provider, "example.com.")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		var want []libdns.Address
+		for _, record := range libdnsFakeRecords {
+			if address, ok := record.(libdns.Address); ok {
+				want = append(want, address)
+			}
+		}
+		if len(want) < 2 {
+			t.Fatalf("the fake records have no A and AAAA records")
+		}
+		if diff := cmp.Diff(got, want, recordComparer); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("type=Record", func(t *testing.T) {
+		provider := getFakeProvider()
+		got, err := GetRecordsOfType[libdns.Record](context.TODO(), &provider, "example.com.")
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if len(got) != len(libdnsFakeRecords) {
+			t.Errorf("got: %d, want: %d", len(got), len(libdnsFakeRecords))
+		}
+	})
+}
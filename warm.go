@@ -0,0 +1,79 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+)
+
+// WarmZone prepares the provider for the calls for the zone ahead of them, e.g. at startup or before a burst of
+// certificate renewals, so that the first of them does not pay for setting up. It sets up the client, acquires an
+// access token, opens a connection to Azure Resource Manager, and looks up the zone, caching its details with
+// Zone Cache TTL set, along with the zones in the resource group with Route Records To Zones set, and whether it is
+// public or private with Detect Private Zones set. It then lists a single record set of the zone, so that a failure
+// to read its records shows up now rather than on the first call. Records are not cached, and are read on every call.
+func (p *Provider) WarmZone(ctx context.Context, zone string) error {
+	provider, ctx, zone := p.forZone(ctx, zone)
+	if provider != p {
+		return provider.WarmZone(ctx, zone)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	if err := p.warmZone(ctx, zone); err != nil {
+		return wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+
+	return nil
+}
+
+// warmZone looks up the zone, caching the details, and lists a single record set of the zone.
+func (p *Provider) warmZone(ctx context.Context, zone string) error {
+	start := time.Now()
+
+	zoneInfo, err := p.getZoneInfo(ctx, zone)
+	if err != nil {
+		return err
+	}
+	if p.ZoneCacheTTL > 0 {
+		p.zoneCache.mutex.Lock()
+		p.zoneCache.put(zoneInfo, time.Now())
+		p.zoneCache.mutex.Unlock()
+
+		if p.RouteRecordsToZones {
+			if _, err := p.listCachedZones(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := p.listFirstRecordSet(ctx, zone); err != nil {
+		return err
+	}
+
+	p.getLogger().Debug("warmed up the zone", "zone", zone, "duration", time.Since(start))
+	return nil
+}
+
+// listFirstRecordSet lists the first record set of the zone, discarding it.
+func (p *Provider) listFirstRecordSet(ctx context.Context, zone string) error {
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return err
+	}
+
+	return p.retryOnAuthenticationError(func() error {
+		pager := p.client.azureClient.NewListByDNSZonePager(
+			p.ResourceGroupName,
+			strings.TrimSuffix(zone, "."),
+			&armdns.RecordSetsClientListByDNSZoneOptions{Top: to.Ptr[int32](1)},
+		)
+		_, err := pager.NextPage(ctx)
+		return err
+	})
+}
@@ -0,0 +1,80 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_WarmZone(t *testing.T) {
+	tests := []struct {
+		name         string
+		zoneCacheTTL time.Duration
+		route        bool
+		want         []string
+		// wantAfter are the requests of GetZoneInfo after warming up
+		wantAfter []string
+	}{
+		{
+			name:      "cache=false",
+			want:      []string{"GET example.com", "GET example.com/recordsets $top=1"},
+			wantAfter: []string{"GET example.com"},
+		},
+		{
+			name:         "cache=true",
+			zoneCacheTTL: time.Minute,
+			want:         []string{"GET example.com", "GET example.com/recordsets $top=1"},
+		},
+		{
+			name:         "cache=true,route",
+			zoneCacheTTL: time.Minute,
+			route:        true,
+			want:         []string{"GET example.com", "GET dnsZones", "GET example.com/recordsets $top=1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			provider := getFakeProvider()
+			transport := provider.client.clientOptions.Transport
+			provider.client.clientOptions.Transport = transportFunc(func(req *http.Request) (*http.Response, error) {
+				_, path, _ := strings.Cut(req.URL.Path, "/providers/Microsoft.Network/")
+				request := req.Method + " " + strings.TrimPrefix(path, "dnsZones/")
+				if top := req.URL.Query().Get("$top"); top != "" {
+					request += " $top=" + top
+				}
+				got = append(got, request)
+				return transport.Do(req)
+			})
+			provider.resetClient()
+			provider.ZoneCacheTTL = tt.zoneCacheTTL
+			provider.RouteRecordsToZones = tt.route
+
+			if err := provider.WarmZone(context.TODO(), "example.com."); err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+
+			got = nil
+			if _, err := provider.GetZoneInfo(context.TODO(), "example.com."); err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got, tt.wantAfter); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+
+	t.Run("zone=none", func(t *testing.T) {
+		provider := getFakeProvider()
+		if err := provider.WarmZone(context.TODO(), "none.example.com."); err == nil || !isNotFoundError(err) {
+			t.Errorf("got: %v", err)
+		}
+	})
+}
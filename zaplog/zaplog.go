@@ -0,0 +1,148 @@
+// Package zaplog adapts a zap.Logger, such as the logger of Caddy, to the *slog.Logger that the provider logs to,
+// so that the logs of the provider flow into the logs of an application using zap without bridging code.
+//
+//	provider.Logger = zaplog.New(ctx.Logger())
+package zaplog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// New returns a *slog.Logger writing to the zap logger.
+func New(logger *zap.Logger) *slog.Logger {
+	return slog.New(NewHandler(logger))
+}
+
+// Handler is a slog.Handler writing the records to a zap logger. The levels of slog are mapped to the closest levels of zap,
+// the groups to namespaces, and the attributes to fields of the same types. The caller of a record is the caller
+// of the slog logger, which zap includes if the logger is built with zap.AddCaller.
+type Handler struct {
+	logger *zap.Logger
+}
+
+// NewHandler returns a handler writing to the zap logger.
+func NewHandler(logger *zap.Logger) *Handler {
+	return &Handler{logger: logger}
+}
+
+// Enabled reports whether the zap logger writes entries at the level.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.logger.Core().Enabled(zapLevel(level))
+}
+
+// Handle writes the record to the zap logger with the attributes of the record as fields.
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	ce := h.logger.Check(zapLevel(record.Level), record.Message)
+	if ce == nil {
+		return nil
+	}
+	if !record.Time.IsZero() {
+		ce.Time = record.Time
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+	}
+	fields := make([]zap.Field, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendField(fields, attr)
+		return true
+	})
+	ce.Write(fields...)
+	return nil
+}
+
+// WithAttrs returns a handler writing the attributes as fields with every record.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []zap.Field
+	for _, attr := range attrs {
+		fields = appendField(fields, attr)
+	}
+	return &Handler{logger: h.logger.With(fields...)}
+}
+
+// WithGroup returns a handler writing the attributes that follow in a namespace with the name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{logger: h.logger.With(zap.Namespace(name))}
+}
+
+// zapLevel returns the level of zap for the level of slog, rounding down the levels between the named ones.
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+// appendField appends the attribute as a field, following the rules of slog.Handler:
+// empty attributes are ignored, and the attributes of a group with an empty key are inlined.
+func appendField(fields []zap.Field, attr slog.Attr) []zap.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	value := attr.Value
+	switch value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(attr.Key, value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(attr.Key, value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(attr.Key, value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(attr.Key, value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(attr.Key, value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(attr.Key, value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(attr.Key, value.Time()))
+	case slog.KindGroup:
+		attrs := value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+		if attr.Key == "" {
+			for _, attr := range attrs {
+				fields = appendField(fields, attr)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(attr.Key, groupMarshaler(attrs)))
+	default:
+		if err, ok := value.Any().(error); ok {
+			return append(fields, zap.NamedError(attr.Key, err))
+		}
+		return append(fields, zap.Any(attr.Key, value.Any()))
+	}
+}
+
+// groupMarshaler encodes the attributes of a group as an object.
+type groupMarshaler []slog.Attr
+
+// MarshalLogObject adds the attributes of the group to the encoder.
+func (g groupMarshaler) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	var fields []zap.Field
+	for _, attr := range g {
+		fields = appendField(fields, attr)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	return nil
+}
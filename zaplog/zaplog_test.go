@@ -0,0 +1,100 @@
+package zaplog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_New(t *testing.T) {
+	tests := []struct {
+		name       string
+		log        func(logger *slog.Logger)
+		wantLevel  zapcore.Level
+		wantFields map[string]interface{}
+	}{
+		{
+			name: "level=debug",
+			log: func(logger *slog.Logger) {
+				logger.Debug("message", "name", "www", "ttl", 300)
+			},
+			wantLevel:  zapcore.DebugLevel,
+			wantFields: map[string]interface{}{"name": "www", "ttl": int64(300)},
+		},
+		{
+			name: "level=warn",
+			log: func(logger *slog.Logger) {
+				logger.Warn("message", "error", errors.New("failed"), "duration", time.Second, "ok", false)
+			},
+			wantLevel:  zapcore.WarnLevel,
+			wantFields: map[string]interface{}{"error": "failed", "duration": time.Second, "ok": false},
+		},
+		{
+			name: "level=error+1",
+			log: func(logger *slog.Logger) {
+				logger.Log(context.TODO(), slog.LevelError+1, "message")
+			},
+			wantLevel:  zapcore.ErrorLevel,
+			wantFields: map[string]interface{}{},
+		},
+		{
+			name: "attrs",
+			log: func(logger *slog.Logger) {
+				logger.With("zone", "example.com.").Info("message", slog.Group("", "inlined", "value"), slog.Group("empty"))
+			},
+			wantLevel:  zapcore.InfoLevel,
+			wantFields: map[string]interface{}{"zone": "example.com.", "inlined": "value"},
+		},
+		{
+			name: "groups",
+			log: func(logger *slog.Logger) {
+				logger.WithGroup("request").Info("message", "method", "GET", slog.Group("response", "status", 200))
+			},
+			wantLevel: zapcore.InfoLevel,
+			wantFields: map[string]interface{}{
+				"request": map[string]interface{}{
+					"method":   "GET",
+					"response": map[string]interface{}{"status": int64(200)},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			tt.log(New(zap.New(core)))
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("got %v entries, want 1", len(entries))
+			}
+			if entries[0].Level != tt.wantLevel || entries[0].Message != "message" {
+				t.Errorf("got: %v %v", entries[0].Level, entries[0].Message)
+			}
+			if entries[0].Caller.File == "" {
+				t.Errorf("the caller is not set")
+			}
+			if diff := cmp.Diff(entries[0].ContextMap(), tt.wantFields); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+
+	t.Run("level=disabled", func(t *testing.T) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		logger := New(zap.New(core))
+		if logger.Enabled(context.TODO(), slog.LevelDebug) {
+			t.Errorf("the debug level is enabled")
+		}
+		logger.Debug("message")
+		if logs.Len() != 0 {
+			t.Errorf("got: %v", logs.All())
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package azure
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// zoneCache caches the details of the zones looked up on Azure DNS until they expire.
+type zoneCache struct {
+	zones        map[string]cachedZoneInfo
+	listed       []ZoneInfo
+	listedAt     time.Time
+	visibilities map[string]cachedZoneVisibilities
+	mutex        sync.Mutex
+}
+
+// cachedZoneInfo is the details of a zone with the time they were looked up.
+type cachedZoneInfo struct {
+	zoneInfo ZoneInfo
+	cachedAt time.Time
+}
+
+// InvalidateZoneCache drops the cached details of the zones, so that they are looked up again on next use.
+func (p *Provider) InvalidateZoneCache() {
+	p.zoneCache.mutex.Lock()
+	defer p.zoneCache.mutex.Unlock()
+
+	p.zoneCache.zones = nil
+	p.zoneCache.listed = nil
+	p.zoneCache.listedAt = time.Time{}
+	p.zoneCache.visibilities = nil
+}
+
+// getCachedZoneInfo returns the details of the zone, looking them up if they are not cached or have expired.
+func (p *Provider) getCachedZoneInfo(ctx context.Context, zone string) (ZoneInfo, error) {
+	if p.ZoneCacheTTL <= 0 {
+		return p.getZoneInfo(ctx, zone)
+	}
+
+	p.zoneCache.mutex.Lock()
+	defer p.zoneCache.mutex.Unlock()
+
+	name := normalizeZoneName(zone)
+	if cached, ok := p.zoneCache.zones[name]; ok && time.Since(cached.cachedAt) < p.ZoneCacheTTL {
+		return cached.zoneInfo, nil
+	}
+
+	zoneInfo, err := p.getZoneInfo(ctx, zone)
+	if err != nil {
+		return ZoneInfo{}, err
+	}
+	p.zoneCache.put(zoneInfo, time.Now())
+
+	return zoneInfo, nil
+}
+
+// listCachedZones returns the details of the zones in the resource group of the provider,
+// listing them if they are not cached or have expired.
+func (p *Provider) listCachedZones(ctx context.Context) ([]ZoneInfo, error) {
+	options := ListZonesOptions{
+		ResourceGroupName: p.ResourceGroupName,
+	}
+	if p.ZoneCacheTTL <= 0 {
+		return p.ListZonesWithOptions(ctx, options)
+	}
+
+	p.zoneCache.mutex.Lock()
+	defer p.zoneCache.mutex.Unlock()
+
+	if !p.zoneCache.listedAt.IsZero() && time.Since(p.zoneCache.listedAt) < p.ZoneCacheTTL {
+		return p.zoneCache.listed, nil
+	}
+
+	zoneInfos, err := p.ListZonesWithOptions(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, zoneInfo := range zoneInfos {
+		p.zoneCache.put(zoneInfo, now)
+	}
+	p.zoneCache.listed = zoneInfos
+	p.zoneCache.listedAt = now
+
+	return zoneInfos, nil
+}
+
+// put caches the details of the zone.
+func (c *zoneCache) put(zoneInfo ZoneInfo, cachedAt time.Time) {
+	if c.zones == nil {
+		c.zones = map[string]cachedZoneInfo{}
+	}
+	c.zones[normalizeZoneName(zoneInfo.Name)] = cachedZoneInfo{
+		zoneInfo: zoneInfo,
+		cachedAt: cachedAt,
+	}
+}
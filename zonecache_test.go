@@ -0,0 +1,63 @@
+package azure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_getCachedZoneInfo(t *testing.T) {
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		invalidate bool
+		wantGets   int
+	}{
+		{name: "ttl=0", ttl: 0, wantGets: 3},
+		{name: "ttl=1h", ttl: time.Hour, wantGets: 1},
+		{name: "ttl=1h,invalidated", ttl: time.Hour, invalidate: true, wantGets: 3},
+		{name: "ttl=1ns", ttl: time.Nanosecond, wantGets: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := newFakeZone()
+			provider := getFakeProviderWithZone(zone)
+			provider.ZoneCacheTTL = tt.ttl
+			for i := 0; i < 3; i++ {
+				zoneInfo, err := provider.GetZoneInfo(context.TODO(), "example.com.")
+				if err != nil {
+					t.Fatalf("%s", err)
+				}
+				if diff := cmp.Diff(zoneInfo.NameServers, []string{"ns1-01.azure-dns.com.", "ns2-01.azure-dns.net."}); diff != "" {
+					t.Errorf("diff: %s", diff)
+				}
+				if tt.invalidate {
+					provider.InvalidateZoneCache()
+				}
+				time.Sleep(time.Millisecond)
+			}
+			if zone.zoneGets != tt.wantGets {
+				t.Errorf("got: %d, want: %d", zone.zoneGets, tt.wantGets)
+			}
+		})
+	}
+	t.Run("ttl=1h,listed", func(t *testing.T) {
+		zone := newFakeZone()
+		provider := getFakeProviderWithZone(zone)
+		provider.ZoneCacheTTL = time.Hour
+		if _, err := provider.listCachedZones(context.TODO()); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if _, err := provider.listCachedZones(context.TODO()); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if _, err := provider.GetZoneInfo(context.TODO(), "sub.example.com"); err != nil {
+			t.Fatalf("%s", err)
+		}
+		if zone.zoneLists != 1 || zone.zoneGets != 0 {
+			t.Errorf("got: %d lists and %d gets, want: 1 list and 0 gets", zone.zoneLists, zone.zoneGets)
+		}
+	})
+}
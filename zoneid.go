@@ -0,0 +1,92 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// forZone returns the provider for the location of the zone, with the context and the name of the zone to call it with.
+// If the zone is given by its resource ID, or has its resource ID in Zone Resource IDs, the provider is the one for
+// the subscription and the resource group of the resource ID, and the zone is its name. A resource ID given to the call
+// takes precedence over the location set by the context, which takes precedence over Zone Resource IDs.
+// The context returned is set to the location, so that the provider derived for it is not redirected again.
+// With Detect Private Zones, the provider is the one for the private DNS zones in the location if the zone is private.
+func (p *Provider) forZone(ctx context.Context, zone string) (*Provider, context.Context, string) {
+	provider, ctx, zone := p.forZoneLocation(ctx, zone)
+	if !provider.DetectPrivateZones || provider.private || zone == "" {
+		return provider, ctx, zone
+	}
+	return provider.forZoneVisibility(ctx, zone), ctx, zone
+}
+
+// forZoneLocation returns the provider for the location of the zone as forZone does, without detecting private zones.
+func (p *Provider) forZoneLocation(ctx context.Context, zone string) (*Provider, context.Context, string) {
+	location, name, ok := parseZoneResourceID(zone)
+	if !ok && !hasContextLocation(ctx) {
+		if resourceID := p.lookupZoneResourceID(zone); resourceID != "" {
+			location, name, ok = parseZoneResourceID(resourceID)
+		}
+	}
+	if !ok {
+		return p.forContext(ctx), ctx, zone
+	}
+
+	ctx = WithSubscription(WithResourceGroup(ctx, location.ResourceGroupName), location.SubscriptionId)
+	return p.forLocation(location), ctx, name
+}
+
+// lookupZoneResourceID returns the resource ID of the zone in Zone Resource IDs, or an empty string if it is not there.
+// The names of the zones are compared regardless of the case and the trailing dot.
+func (p *Provider) lookupZoneResourceID(zone string) string {
+	if zone == "" {
+		return ""
+	}
+	if resourceID, ok := p.ZoneResourceIDs[zone]; ok {
+		return resourceID
+	}
+	name := normalizeZoneName(zone)
+	for key, resourceID := range p.ZoneResourceIDs {
+		if normalizeZoneName(key) == name {
+			return resourceID
+		}
+	}
+	return ""
+}
+
+// validateZoneResourceIDs checks that the resource IDs in Zone Resource IDs can be interpreted.
+func (p *Provider) validateZoneResourceIDs() error {
+	for zone, resourceID := range p.ZoneResourceIDs {
+		if _, _, ok := parseZoneResourceID(resourceID); !ok {
+			return fmt.Errorf("the zone resource ID %v of the zone %v cannot be interpreted", resourceID, zone)
+		}
+	}
+	return nil
+}
+
+// hasContextLocation reports whether the context sets the subscription or the resource group.
+func hasContextLocation(ctx context.Context) bool {
+	subscriptionID, _ := ctx.Value(subscriptionKey{}).(string)
+	resourceGroupName, _ := ctx.Value(resourceGroupKey{}).(string)
+	return subscriptionID != "" || resourceGroupName != ""
+}
+
+// parseZoneResourceID extracts the location and the name of the zone, with a trailing dot, from the resource ID of a zone,
+// e.g. "/subscriptions/{id}/resourceGroups/{name}/providers/Microsoft.Network/dnszones/example.com".
+// It reports false if the string is not the resource ID of a DNS zone.
+func parseZoneResourceID(resourceID string) (ZoneLocation, string, bool) {
+	segments := strings.Split(strings.Trim(resourceID, "/"), "/")
+	if !strings.HasPrefix(resourceID, "/") || len(segments) != 8 {
+		return ZoneLocation{}, "", false
+	}
+	for i, want := range []string{"subscriptions", "", "resourceGroups", "", "providers", "Microsoft.Network", "dnszones", ""} {
+		if segments[i] == "" || (want != "" && !strings.EqualFold(segments[i], want)) {
+			return ZoneLocation{}, "", false
+		}
+	}
+	location := ZoneLocation{
+		SubscriptionId:    segments[1],
+		ResourceGroupName: segments[3],
+	}
+	return location, strings.TrimSuffix(segments[7], ".") + ".", true
+}
@@ -0,0 +1,121 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_parseZoneResourceID(t *testing.T) {
+	tests := []struct {
+		name         string
+		resourceID   string
+		wantLocation ZoneLocation
+		wantZone     string
+		wantOK       bool
+	}{
+		{
+			name:         "valid",
+			resourceID:   "/subscriptions/s/resourceGroups/g/providers/Microsoft.Network/dnszones/example.com",
+			wantLocation: ZoneLocation{SubscriptionId: "s", ResourceGroupName: "g"},
+			wantZone:     "example.com.",
+			wantOK:       true,
+		},
+		{
+			name:         "valid,case",
+			resourceID:   "/SUBSCRIPTIONS/s/resourcegroups/g/providers/microsoft.network/dnsZones/example.com.",
+			wantLocation: ZoneLocation{SubscriptionId: "s", ResourceGroupName: "g"},
+			wantZone:     "example.com.",
+			wantOK:       true,
+		},
+		{name: "name", resourceID: "example.com."},
+		{name: "relative", resourceID: "subscriptions/s/resourceGroups/g/providers/Microsoft.Network/dnszones/example.com"},
+		{name: "private zone", resourceID: "/subscriptions/s/resourceGroups/g/providers/Microsoft.Network/privateDnsZones/example.com"},
+		{name: "record set", resourceID: "/subscriptions/s/resourceGroups/g/providers/Microsoft.Network/dnszones/example.com/A/www"},
+		{name: "empty segment", resourceID: "/subscriptions//resourceGroups/g/providers/Microsoft.Network/dnszones/example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, zone, ok := parseZoneResourceID(tt.resourceID)
+			if ok != tt.wantOK || zone != tt.wantZone {
+				t.Errorf("got: %v %v, want: %v %v", zone, ok, tt.wantZone, tt.wantOK)
+			}
+			if diff := cmp.Diff(location, tt.wantLocation); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_ZoneResourceIDs(t *testing.T) {
+	otherZoneID := "/subscriptions/other-subscription-id/resourceGroups/other-resource-group-name/providers/Microsoft.Network/dnszones/example.com"
+	tests := []struct {
+		name            string
+		zoneResourceIDs map[string]string
+		ctx             context.Context
+		zone            string
+		wantPrefix      string
+	}{
+		{
+			name:       "zone=resource id",
+			zone:       otherZoneID,
+			wantPrefix: "/subscriptions/other-subscription-id/resourceGroups/other-resource-group-name/",
+		},
+		{
+			name:            "zone=name,map",
+			zoneResourceIDs: map[string]string{"Example.com": otherZoneID},
+			zone:            "example.com.",
+			wantPrefix:      "/subscriptions/other-subscription-id/resourceGroups/other-resource-group-name/",
+		},
+		{
+			name:            "zone=name,map,context",
+			zoneResourceIDs: map[string]string{"example.com.": otherZoneID},
+			ctx:             WithResourceGroup(context.TODO(), "context-resource-group-name"),
+			zone:            "example.com.",
+			wantPrefix:      "/subscriptions/fake-subscription-id/resourceGroups/context-resource-group-name/",
+		},
+		{
+			name:       "zone=resource id,context",
+			ctx:        WithResourceGroup(context.TODO(), "context-resource-group-name"),
+			zone:       otherZoneID,
+			wantPrefix: "/subscriptions/other-subscription-id/resourceGroups/other-resource-group-name/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			provider := getFakeProviderCapturingPaths(&paths)
+			provider.ZoneResourceIDs = tt.zoneResourceIDs
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.TODO()
+			}
+			if _, err := provider.SetRecords(ctx, tt.zone, []libdns.Record{
+				libdns.TXT{Name: "record-txt", Text: "NEW VALUE", TTL: time.Duration(30) * time.Second},
+			}); err != nil {
+				t.Fatalf("%s", err)
+			}
+			if len(paths) == 0 {
+				t.Fatalf("no requests are sent")
+			}
+			for _, path := range paths {
+				if !strings.HasPrefix(path, tt.wantPrefix) || !strings.Contains(path, "/example.com/") {
+					t.Errorf("got: %v, want prefix: %v", path, tt.wantPrefix)
+				}
+			}
+		})
+	}
+
+	t.Run("map=invalid", func(t *testing.T) {
+		provider := getFakeProvider()
+		provider.ZoneResourceIDs = map[string]string{"example.com.": "example.com"}
+		provider.resetClient()
+		if _, err := provider.GetRecords(context.TODO(), "example.com."); err == nil || !strings.Contains(err.Error(), "cannot be interpreted") {
+			t.Errorf("got: %v", err)
+		}
+	})
+}
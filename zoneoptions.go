@@ -0,0 +1,145 @@
+package azure
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/libdns/libdns"
+)
+
+// ZoneOptions are the settings of a zone overriding those of the provider, so that a single provider can manage
+// a tightly controlled zone and a permissive one side by side. The fields left unset take the settings of the provider.
+type ZoneOptions struct {
+	// Default TTL overrides the Default TTL of the provider for the zone.
+	DefaultTTL time.Duration `json:"default_ttl,omitempty"`
+
+	// Record Set Metadata is stamped on the record sets written to the zone along with the Record Set Metadata
+	// of the provider, overriding the values of the same keys.
+	RecordSetMetadata map[string]string `json:"record_set_metadata,omitempty"`
+
+	// Read Only overrides the Read Only of the provider for the zone if set,
+	// so that a zone can be made writable by a provider that is otherwise read-only, and vice versa.
+	ReadOnly *bool `json:"read_only,omitempty"`
+
+	// Write Concurrency overrides the Write Concurrency of the provider for the zone.
+	WriteConcurrency int `json:"write_concurrency,omitempty"`
+}
+
+// zoneSettings are the settings in effect for a zone.
+type zoneSettings struct {
+	defaultTTL        time.Duration
+	recordSetMetadata map[string]string
+	readOnly          bool
+	writeConcurrency  int
+}
+
+// zoneSettings returns the settings in effect for the zone, which are those of the provider overridden by Zone Options.
+func (p *Provider) zoneSettings(zone string) zoneSettings {
+	settings := zoneSettings{
+		defaultTTL:        p.DefaultTTL,
+		recordSetMetadata: p.RecordSetMetadata,
+		readOnly:          p.ReadOnly,
+		writeConcurrency:  p.WriteConcurrency,
+	}
+	options, ok := p.lookupZoneOptions(zone)
+	if !ok {
+		return settings
+	}
+
+	if options.DefaultTTL != 0 {
+		settings.defaultTTL = options.DefaultTTL
+	}
+	if len(options.RecordSetMetadata) > 0 {
+		settings.recordSetMetadata = map[string]string{}
+		for key, value := range p.RecordSetMetadata {
+			settings.recordSetMetadata[key] = value
+		}
+		for key, value := range options.RecordSetMetadata {
+			settings.recordSetMetadata[key] = value
+		}
+	}
+	if options.ReadOnly != nil {
+		settings.readOnly = *options.ReadOnly
+	}
+	if options.WriteConcurrency != 0 {
+		settings.writeConcurrency = options.WriteConcurrency
+	}
+	return settings
+}
+
+// lookupZoneOptions returns the Zone Options of the zone, reporting false if it has none.
+// The names of the zones are compared regardless of the case and the trailing dot.
+func (p *Provider) lookupZoneOptions(zone string) (ZoneOptions, bool) {
+	if zone == "" || len(p.ZoneOptions) == 0 {
+		return ZoneOptions{}, false
+	}
+	if options, ok := p.ZoneOptions[zone]; ok {
+		return options, true
+	}
+	name := normalizeZoneName(zone)
+	for key, options := range p.ZoneOptions {
+		if normalizeZoneName(key) == name {
+			return options, true
+		}
+	}
+	return ZoneOptions{}, false
+}
+
+// validateZoneOptions checks that the settings of the provider and those in Zone Options can be interpreted.
+func (p *Provider) validateZoneOptions() error {
+	if p.DefaultTTL < 0 {
+		return fmt.Errorf("the default TTL %v cannot be interpreted", p.DefaultTTL)
+	}
+	if p.WriteConcurrency < 0 {
+		return fmt.Errorf("the write concurrency %v cannot be interpreted", p.WriteConcurrency)
+	}
+	for zone, options := range p.ZoneOptions {
+		if options.DefaultTTL < 0 {
+			return fmt.Errorf("the default TTL %v of the zone %v cannot be interpreted", options.DefaultTTL, zone)
+		}
+		if options.WriteConcurrency < 0 {
+			return fmt.Errorf("the write concurrency %v of the zone %v cannot be interpreted", options.WriteConcurrency, zone)
+		}
+	}
+	return nil
+}
+
+// checkReadOnly throws an error if the zone is read-only.
+func (p *Provider) checkReadOnly(zone string) error {
+	if p.zoneSettings(zone).readOnly {
+		return fmt.Errorf("the zone %v is read-only", zone)
+	}
+	return nil
+}
+
+// applyDefaultTTL returns the records, giving those without a TTL the default TTL of the zone, if any.
+func (p *Provider) applyDefaultTTL(zone string, records []libdns.Record) []libdns.Record {
+	ttl := p.zoneSettings(zone).defaultTTL
+	if ttl == 0 {
+		return records
+	}
+
+	appliedRecords := make([]libdns.Record, 0, len(records))
+	for _, record := range records {
+		if record.RR().TTL != 0 {
+			appliedRecords = append(appliedRecords, record)
+			continue
+		}
+		if alias, ok := record.(Alias); ok {
+			alias.TTL = ttl
+			appliedRecords = append(appliedRecords, alias)
+			continue
+		}
+		rr := normalizeTXTRecord(record).RR()
+		appliedRecords = append(appliedRecords, newLibdnsRecord(rr.Name, ttl, rr.Type, rr.Data))
+	}
+	return appliedRecords
+}
+
+// stampRecordSetMetadata stamps the record set metadata of the zone, if any, on the properties of a record set to be written.
+func (p *Provider) stampRecordSetMetadata(zone string, properties *armdns.RecordSetProperties) {
+	for key, value := range p.zoneSettings(zone).recordSetMetadata {
+		setMetadata(properties, key, value)
+	}
+}
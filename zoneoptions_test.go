@@ -0,0 +1,207 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_zoneSettings(t *testing.T) {
+	provider := &Provider{
+		DefaultTTL:        time.Hour,
+		RecordSetMetadata: map[string]string{"team": "platform", "env": "prod"},
+		ReadOnly:          true,
+		WriteConcurrency:  1,
+		ZoneOptions: map[string]ZoneOptions{
+			"DEV.example.com": {
+				DefaultTTL:        time.Minute,
+				RecordSetMetadata: map[string]string{"env": "dev"},
+				ReadOnly:          to.Ptr(false),
+				WriteConcurrency:  8,
+			},
+			"staging.example.com.": {},
+		},
+	}
+	tests := []struct {
+		name string
+		zone string
+		want zoneSettings
+	}{
+		{
+			name: "zone=none",
+			zone: "example.com.",
+			want: zoneSettings{defaultTTL: time.Hour, recordSetMetadata: map[string]string{"team": "platform", "env": "prod"}, readOnly: true, writeConcurrency: 1},
+		},
+		{
+			name: "zone=overridden",
+			zone: "dev.example.com.",
+			want: zoneSettings{defaultTTL: time.Minute, recordSetMetadata: map[string]string{"team": "platform", "env": "dev"}, readOnly: false, writeConcurrency: 8},
+		},
+		{
+			name: "zone=inherited",
+			zone: "staging.example.com.",
+			want: zoneSettings{defaultTTL: time.Hour, recordSetMetadata: map[string]string{"team": "platform", "env": "prod"}, readOnly: true, writeConcurrency: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := provider.zoneSettings(tt.zone)
+			if diff := cmp.Diff(got, tt.want, cmp.AllowUnexported(zoneSettings{})); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_validateZoneOptions(t *testing.T) {
+	provider := &Provider{ZoneOptions: map[string]ZoneOptions{"example.com.": {WriteConcurrency: -1}}}
+	if err := provider.validateZoneOptions(); err == nil || err.Error() != "the write concurrency -1 of the zone example.com. cannot be interpreted" {
+		t.Errorf("got: %v", err)
+	}
+}
+
+func Test_ZoneOptions(t *testing.T) {
+	t.Run("read_only", func(t *testing.T) {
+		zone := newFakeZone()
+		provider := getFakeProviderWithZone(zone)
+		provider.ZoneOptions = map[string]ZoneOptions{"example.com.": {ReadOnly: to.Ptr(true)}}
+		_, err := provider.AppendRecords(context.TODO(), "example.com.", []libdns.Record{libdns.TXT{Name: "record-txt", Text: "TEST VALUE"}})
+		if err == nil || !strings.Contains(err.Error(), "the zone example.com. is read-only") {
+			t.Errorf("got: %v", err)
+		}
+		if len(zone.calls) > 0 {
+			t.Errorf("got calls: %v", zone.calls)
+		}
+	})
+
+	t.Run("default_ttl,metadata", func(t *testing.T) {
+		zone := newFakeZone()
+		provider := getFakeProviderWithZone(zone)
+		provider.RecordSetMetadata = map[string]string{"team": "platform"}
+		provider.ZoneOptions = map[string]ZoneOptions{"example.com": {DefaultTTL: 5 * time.Minute, RecordSetMetadata: map[string]string{"env": "dev"}}}
+		records, err := provider.SetRecords(context.TODO(), "example.com.", []libdns.Record{
+			libdns.TXT{Name: "record-txt", Text: "TEST VALUE"},
+			libdns.TXT{Name: "record-ttl", TTL: time.Hour, Text: "TEST VALUE"},
+		})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		wantRecords := []libdns.Record{
+			libdns.TXT{Name: "record-txt", TTL: 5 * time.Minute, Text: "TEST VALUE"},
+			libdns.TXT{Name: "record-ttl", TTL: time.Hour, Text: "TEST VALUE"},
+		}
+		if diff := cmp.Diff(records, wantRecords); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		properties := zone.get("record-txt", "TXT").Properties
+		if got := *properties.TTL; got != 300 {
+			t.Errorf("got TTL: %v", got)
+		}
+		gotMetadata := map[string]string{}
+		for key, value := range properties.Metadata {
+			gotMetadata[key] = *value
+		}
+		if diff := cmp.Diff(gotMetadata, map[string]string{"team": "platform", "env": "dev"}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+
+	t.Run("write_concurrency", func(t *testing.T) {
+		var mutex sync.Mutex
+		var inFlight, maxInFlight int
+		provider := getFakeProvider()
+		transport := provider.client.clientOptions.Transport
+		provider.client.clientOptions.Transport = transportFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodPut {
+				return transport.Do(req)
+			}
+			mutex.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			mutex.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+			resp, err := transport.Do(req)
+
+			mutex.Lock()
+			inFlight--
+			mutex.Unlock()
+			return resp, err
+		})
+		provider.resetClient()
+		provider.ZoneOptions = map[string]ZoneOptions{"example.com.": {WriteConcurrency: 3}}
+		var hooksInFlight, maxHooksInFlight atomic.Int32
+		provider.BeforeWrite = func(ctx context.Context, write *PlannedWrite) error {
+			n := hooksInFlight.Add(1)
+			if n > maxHooksInFlight.Load() {
+				maxHooksInFlight.Store(n)
+			}
+			time.Sleep(time.Millisecond)
+			hooksInFlight.Add(-1)
+			return nil
+		}
+
+		var records []libdns.Record
+		var want []libdns.RR
+		for _, name := range []string{"record-1", "record-2", "record-3", "record-4", "record-5", "record-6"} {
+			records = append(records, libdns.TXT{Name: name, TTL: time.Hour, Text: "TEST VALUE"})
+			want = append(want, libdns.RR{Name: name, TTL: time.Hour, Type: "TXT", Data: "TEST VALUE"})
+		}
+		updatedRecords, err := provider.SetRecords(context.TODO(), "example.com.", records)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		var got []libdns.RR
+		for _, record := range updatedRecords {
+			got = append(got, record.RR())
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if maxInFlight < 2 || maxInFlight > 3 {
+			t.Errorf("got max in flight: %v", maxInFlight)
+		}
+		if n := maxHooksInFlight.Load(); n != 1 {
+			t.Errorf("got max Before Write in flight: %v", n)
+		}
+	})
+
+	t.Run("write_concurrency,mode=fail_fast", func(t *testing.T) {
+		provider := getFakeProvider()
+		transport := provider.client.clientOptions.Transport
+		provider.client.clientOptions.Transport = transportFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method == http.MethodPut && strings.HasSuffix(req.URL.Path, "/record-2") {
+				// Fail after the other record sets have been sent
+				time.Sleep(20 * time.Millisecond)
+				return &http.Response{StatusCode: http.StatusBadRequest, Body: http.NoBody, Request: req}, nil
+			}
+			return transport.Do(req)
+		})
+		provider.resetClient()
+		provider.WriteConcurrency = 3
+
+		var records []libdns.Record
+		for _, name := range []string{"record-1", "record-2", "record-3"} {
+			records = append(records, libdns.TXT{Name: name, TTL: time.Hour, Text: "TEST VALUE"})
+		}
+		updatedRecords, err := provider.SetRecords(context.TODO(), "example.com.", records)
+		if err == nil {
+			t.Fatalf("got: nil error")
+		}
+		var got []string
+		for _, record := range updatedRecords {
+			got = append(got, record.RR().Name)
+		}
+		if diff := cmp.Diff(got, []string{"record-1", "record-3"}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
@@ -0,0 +1,286 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/libdns/libdns"
+)
+
+// ZoneIterator iterates over the zones matching the options, fetching them from Azure page by page.
+// It is not safe for concurrent use.
+type ZoneIterator struct {
+	provider *Provider
+	options  ListZonesOptions
+
+	more     func() bool
+	nextPage func(ctx context.Context) ([]*armdns.Zone, error)
+	fetched  bool
+
+	zones   []ZoneInfo
+	current ZoneInfo
+	count   int
+	err     error
+}
+
+// Next advances the iterator to the next zone, fetching the next page if necessary.
+// It returns false when there are no more zones or an error occurs; call Err to distinguish them.
+func (it *ZoneIterator) Next(ctx context.Context) bool {
+	if it.err != nil || (it.options.MaxResults > 0 && it.count >= it.options.MaxResults) {
+		return false
+	}
+
+	for len(it.zones) == 0 {
+		if it.fetched && !it.more() {
+			return false
+		}
+		if err := it.fetchPage(ctx); err != nil {
+			it.err = err
+			return false
+		}
+	}
+
+	it.current, it.zones = it.zones[0], it.zones[1:]
+	it.count++
+	return true
+}
+
+// Zone returns the current zone of the iterator.
+func (it *ZoneIterator) Zone() ZoneInfo {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ZoneIterator) Err() error {
+	return it.err
+}
+
+// fetchPage fetches the next page of zones and keeps the ones matching the options.
+// If the first page fails due to an authentication error, the client is rebuilt and the page is fetched once again.
+func (it *ZoneIterator) fetchPage(ctx context.Context) error {
+	p := it.provider
+	p.client.mutex.Lock()
+	defer p.client.mutex.Unlock()
+
+	if err := p.setupClient(); err != nil {
+		return err
+	}
+
+	fetch := func() error {
+		if !it.fetched {
+			it.newPager()
+		}
+		zones, err := it.nextPage(ctx)
+		if err != nil {
+			return err
+		}
+		it.fetched = true
+		for _, zone := range zones {
+			zoneInfo := convertAzureZoneToZoneInfo(*zone)
+			if matchZone(zoneInfo, it.options) {
+				it.zones = append(it.zones, zoneInfo)
+			}
+		}
+		return nil
+	}
+
+	if !it.fetched {
+		return p.retryOnAuthenticationError(fetch)
+	}
+	return fetch()
+}
+
+// newPager creates the pager of the zones with the current client.
+func (it *ZoneIterator) newPager() {
+	var top *int32
+	if it.options.PageSize > 0 {
+		top = &it.options.PageSize
+	}
+
+	zonesClient := it.provider.client.zonesClient
+	if it.options.ResourceGroupName != "" {
+		pager := zonesClient.NewListByResourceGroupPager(it.options.ResourceGroupName, &armdns.ZonesClientListByResourceGroupOptions{
+			Top: top,
+		})
+		it.more = pager.More
+		it.nextPage = func(ctx context.Context) ([]*armdns.Zone, error) {
+			page, err := pager.NextPage(ctx)
+			return page.Value, err
+		}
+		return
+	}
+
+	pager := zonesClient.NewListPager(&armdns.ZonesClientListOptions{
+		Top: top,
+	})
+	it.more = pager.More
+	it.nextPage = func(ctx context.Context) ([]*armdns.Zone, error) {
+		page, err := pager.NextPage(ctx)
+		return page.Value, err
+	}
+}
+
+// listZones lists the zones matching the options on Azure DNS.
+func (p *Provider) listZones(ctx context.Context, options ListZonesOptions) ([]ZoneInfo, error) {
+	var zoneInfos []ZoneInfo
+
+	it := p.IterateZones(options)
+	for it.Next(ctx) {
+		zoneInfos = append(zoneInfos, it.Zone())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return zoneInfos, nil
+}
+
+// matchZone reports whether the zone matches all the conditions of the options.
+func matchZone(zoneInfo ZoneInfo, options ListZonesOptions) bool {
+	for key, value := range options.Tags {
+		tagValue, ok := zoneInfo.Tags[key]
+		if !ok || (value != "" && tagValue != value) {
+			return false
+		}
+	}
+
+	if options.NameSuffix != "" {
+		suffix := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(options.NameSuffix, "."), ".") + ".")
+		name := strings.ToLower(zoneInfo.Name)
+		if name != suffix && !strings.HasSuffix(name, "."+suffix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// needsRouting reports whether the records should be routed to the zones inferred from their names
+// rather than written to the zone, which is when the zone is empty or any fully-qualified name is to be routed.
+func (p *Provider) needsRouting(zone string, records []libdns.Record) bool {
+	if zone == "" {
+		return true
+	}
+	if !p.RouteRecordsToZones {
+		return false
+	}
+	for _, record := range records {
+		if strings.HasSuffix(record.RR().Name, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeToZones groups the records by the zones in the resource group of the provider that their fully-qualified names
+// belong to, and writes each group with write. The zone of a record is the longest matching suffix of its name.
+// Records with relative names, and fully-qualified names matching no zone in the resource group but within the zone,
+// belong to the zone. Records whose zone cannot be inferred fail the call before anything is written.
+// The names of the returned records are relative to the zone if they belong to it, or fully qualified otherwise.
+func (p *Provider) writeToZones(ctx context.Context, zone string, records []libdns.Record, write func(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error)) ([]libdns.Record, error) {
+	records, err := decodeGenericRecords(records)
+	if err != nil {
+		return nil, err
+	}
+	zoneInfos, err := p.listCachedZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var zoneNames []string
+	for _, zoneInfo := range zoneInfos {
+		zoneNames = append(zoneNames, zoneInfo.Name)
+	}
+
+	var recordZones []string
+	recordsByZone := map[string][]libdns.Record{}
+	for _, record := range records {
+		rr := record.RR()
+		recordZone, ok := inferZone(rr.Name, zoneNames)
+		if !ok && zone != "" && (!strings.HasSuffix(rr.Name, ".") || isWithinZone(rr.Name, zone)) {
+			recordZone, ok = zone, true
+		}
+		if !ok {
+			return nil, fmt.Errorf("the zone of the record %v %v cannot be inferred", rr.Name, rr.Type)
+		}
+		if equalZoneNames(recordZone, zone) {
+			recordZone = zone
+		}
+		if _, ok := recordsByZone[recordZone]; !ok {
+			recordZones = append(recordZones, recordZone)
+		}
+		// Names are made relative so that the records are not routed again by write
+		recordsByZone[recordZone] = append(recordsByZone[recordZone], normalizeRecord(record, recordZone))
+	}
+
+	// Reject the records before writing to any zone
+	for _, recordZone := range recordZones {
+		if err := p.checkWritable(recordZone, recordsByZone[recordZone]); err != nil {
+			return nil, err
+		}
+	}
+
+	total := 0
+	for _, recordZone := range recordZones {
+		total += len(groupRecordsByRecordSet(recordsByZone[recordZone], recordZone, p.recordSetName))
+	}
+	ctx = p.withProgress(ctx, total)
+
+	var writtenRecords []libdns.Record
+	var failures []RecordSetFailure
+	for _, recordZone := range recordZones {
+		zoneRecords, err := write(ctx, recordZone, recordsByZone[recordZone])
+		for _, record := range zoneRecords {
+			if recordZone != zone {
+				rr := record.RR()
+				record = newLibdnsRecord(libdns.AbsoluteName(rr.Name, recordZone), rr.TTL, rr.Type, rr.Data)
+			}
+			writtenRecords = append(writtenRecords, record)
+		}
+		if err != nil {
+			if !p.isBestEffort() {
+				return writtenRecords, err
+			}
+			failures = appendFailures(failures, recordZone, err)
+		}
+	}
+
+	if len(failures) > 0 {
+		_, correlationID := ensureCorrelationID(ctx)
+		return writtenRecords, wrapCorrelationID(&BatchError{Failures: failures}, correlationID)
+	}
+	return writtenRecords, nil
+}
+
+// isWithinZone reports whether the fully-qualified name is the zone or a name under it.
+func isWithinZone(name string, zone string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+	return name == zone || strings.HasSuffix(name, "."+zone)
+}
+
+// equalZoneNames reports whether the names of the zones are the same regardless of the case and the trailing dot.
+func equalZoneNames(a string, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
+
+// inferZone returns the zone that the fully-qualified name belongs to, which is the longest of the zones matching its suffix.
+// Relative names, which have no trailing dot, do not belong to any zone.
+func inferZone(name string, zones []string) (string, bool) {
+	if !strings.HasSuffix(name, ".") {
+		return "", false
+	}
+
+	var inferredZone string
+	for _, zone := range zones {
+		if !isWithinZone(name, zone) {
+			continue
+		}
+		if len(zone) > len(inferredZone) {
+			inferredZone = zone
+		}
+	}
+
+	return inferredZone, inferredZone != ""
+}
@@ -0,0 +1,42 @@
+//go:build go1.23
+
+package azure
+
+import (
+	"context"
+	"iter"
+)
+
+// Zones returns an iterator over the zones in the resource group of the provider, yielding them with a nil error,
+// so that Go 1.23 and later can range over them while they are fetched page by page. If fetching a page fails,
+// the error is yielded with an empty ZoneInfo as the last element.
+func (p *Provider) Zones(ctx context.Context) iter.Seq2[ZoneInfo, error] {
+	if provider := p.forContext(ctx); provider != p {
+		return provider.Zones(ctx)
+	}
+
+	return p.ZonesWithOptions(ctx, ListZonesOptions{
+		ResourceGroupName: p.ResourceGroupName,
+	})
+}
+
+// ZonesWithOptions returns an iterator over the zones matching the options in the same manner as Zones.
+func (p *Provider) ZonesWithOptions(ctx context.Context, options ListZonesOptions) iter.Seq2[ZoneInfo, error] {
+	if provider := p.forContext(ctx); provider != p {
+		return provider.ZonesWithOptions(ctx, options)
+	}
+
+	return func(yield func(ZoneInfo, error) bool) {
+		ctx, correlationID := ensureCorrelationID(ctx)
+
+		it := p.IterateZones(options)
+		for it.Next(ctx) {
+			if !yield(it.Zone(), nil) {
+				return
+			}
+		}
+		if err := it.Err(); err != nil {
+			yield(ZoneInfo{}, wrapCorrelationID(enrichAuthorizationError(err, p.generateListScope(options)), correlationID))
+		}
+	}
+}
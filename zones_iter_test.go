@@ -0,0 +1,67 @@
+//go:build go1.23
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns/fake"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Zones(t *testing.T) {
+	t.Run("zones=all", func(t *testing.T) {
+		provider := getFakeProvider()
+		var got []string
+		for zone, err := range provider.Zones(context.TODO()) {
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			got = append(got, zone.Name)
+		}
+		want := []string{"example.com.", "sub.example.com."}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("zones=break", func(t *testing.T) {
+		provider := getFakeProvider()
+		var got []string
+		for zone, err := range provider.ZonesWithOptions(context.TODO(), ListZonesOptions{}) {
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			got = append(got, zone.Name)
+			break
+		}
+		if diff := cmp.Diff(got, []string{"example.com."}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("zones=error", func(t *testing.T) {
+		fakeZonesServer := getFakeZonesServer()
+		fakeZonesServer.NewListByResourceGroupPager = func(resourceGroupName string, options *armdns.ZonesClientListByResourceGroupOptions) (resp azfake.PagerResponder[armdns.ZonesClientListByResourceGroupResponse]) {
+			resp.AddResponseError(http.StatusInternalServerError, "InternalServerError")
+			return
+		}
+		provider := getFakeProviderWithServerFactory(fake.ServerFactory{
+			RecordSetsServer: getFakeRecordSetsServer(),
+			ZonesServer:      fakeZonesServer,
+		})
+		var errs []error
+		for _, err := range provider.Zones(WithCorrelationID(context.TODO(), "given-correlation-id")) {
+			errs = append(errs, err)
+		}
+		if len(errs) != 1 || errs[0] == nil {
+			t.Fatalf("got: %v", errs)
+		}
+		if !strings.Contains(errs[0].Error(), "given-correlation-id") {
+			t.Errorf("the correlation ID is not included in the error: %v", errs[0])
+		}
+	})
+}
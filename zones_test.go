@@ -0,0 +1,232 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	azfake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns/fake"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_ZoneIterator(t *testing.T) {
+	t.Run("maxresults=2", func(t *testing.T) {
+		var gotTop []int32
+		fakeZonesServer := getFakeZonesServer()
+		newListPager := fakeZonesServer.NewListPager
+		fakeZonesServer.NewListPager = func(options *armdns.ZonesClientListOptions) (resp azfake.PagerResponder[armdns.ZonesClientListResponse]) {
+			if options != nil && options.Top != nil {
+				gotTop = append(gotTop, *options.Top)
+			}
+			return newListPager(options)
+		}
+		provider := getFakeProviderWithServerFactory(fake.ServerFactory{
+			RecordSetsServer: getFakeRecordSetsServer(),
+			ZonesServer:      fakeZonesServer,
+		})
+
+		var got []string
+		it := provider.IterateZones(ListZonesOptions{PageSize: 2, MaxResults: 2})
+		for it.Next(context.TODO()) {
+			got = append(got, it.Zone().Name)
+		}
+		if err := it.Err(); err != nil {
+			t.Errorf("%s", err)
+		}
+		want := []string{"example.com.", "sub.example.com."}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		if diff := cmp.Diff(gotTop, []int32{2}); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("maxresults=0", func(t *testing.T) {
+		provider := getFakeProvider()
+		var got []string
+		it := provider.IterateZones(ListZonesOptions{NameSuffix: "myexample.com."})
+		for it.Next(context.TODO()) {
+			got = append(got, it.Zone().Name)
+		}
+		if err := it.Err(); err != nil {
+			t.Errorf("%s", err)
+		}
+		want := []string{"myexample.com."}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+}
+
+func Test_listZones(t *testing.T) {
+	tests := map[string]struct {
+		options ListZonesOptions
+		want    []string
+	}{
+		"filter=none": {
+			options: ListZonesOptions{},
+			want:    []string{"example.com.", "sub.example.com.", "myexample.com."},
+		},
+		"filter=resourcegroup": {
+			options: ListZonesOptions{ResourceGroupName: "fake-resource-group-name"},
+			want:    []string{"example.com.", "sub.example.com."},
+		},
+		"filter=tagkey": {
+			options: ListZonesOptions{Tags: map[string]string{"env": ""}},
+			want:    []string{"example.com.", "sub.example.com."},
+		},
+		"filter=tagvalue": {
+			options: ListZonesOptions{Tags: map[string]string{"env": "prod"}},
+			want:    []string{"sub.example.com."},
+		},
+		"filter=namesuffix": {
+			options: ListZonesOptions{NameSuffix: "Example.com"},
+			want:    []string{"example.com.", "sub.example.com."},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			provider := getFakeProvider()
+			zoneInfos, err := provider.listZones(context.TODO(), tt.options)
+			if err != nil {
+				t.Errorf("%s", err)
+			}
+			var got []string
+			for _, zoneInfo := range zoneInfos {
+				got = append(got, zoneInfo.Name)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_inferZone(t *testing.T) {
+	zones := []string{"example.com.", "sub.example.com.", "myexample.com.", "dev.eu.example.com."}
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{name: "www.example.com.", want: "example.com.", ok: true},
+		{name: "example.com.", want: "example.com.", ok: true},
+		{name: "www.sub.example.com.", want: "sub.example.com.", ok: true},
+		{name: "WWW.Sub.Example.COM.", want: "sub.example.com.", ok: true},
+		{name: "www.myexample.com.", want: "myexample.com.", ok: true},
+		{name: "_sip._tcp.app.dev.eu.example.com.", want: "dev.eu.example.com.", ok: true},
+		{name: "dev.eu.example.com.", want: "dev.eu.example.com.", ok: true},
+		{name: "eu.example.com.", want: "example.com.", ok: true},
+		{name: "www.adev.eu.example.com.", want: "example.com.", ok: true},
+		{name: "www.example.net.", want: "", ok: false},
+		{name: "www", want: "", ok: false},
+		{name: "www.example.com", want: "", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run("name="+tt.name, func(t *testing.T) {
+			got, ok := inferZone(tt.name, zones)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+			if ok != tt.ok {
+				t.Errorf("got: %v, want: %v", ok, tt.ok)
+			}
+		})
+	}
+}
+
+func Test_writeToZones(t *testing.T) {
+	t.Run("zone=inferred", func(t *testing.T) {
+		var got []string
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+		fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			got = append(got, relativeRecordSetName+" "+zoneName)
+			return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+		}
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		records, err := provider.SetRecords(context.TODO(), "", []libdns.Record{
+			libdns.TXT{Name: "_acme-challenge.www.example.com.", Text: "TEST VALUE", TTL: time.Minute},
+			libdns.TXT{Name: "_acme-challenge.www.sub.example.com.", Text: "TEST VALUE", TTL: time.Minute},
+			libdns.TXT{Name: "sub.example.com.", Text: "TEST VALUE", TTL: time.Minute},
+		})
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		want := []string{"_acme-challenge.www example.com", "_acme-challenge.www sub.example.com", "@ sub.example.com"}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+		var gotNames []string
+		for _, record := range records {
+			gotNames = append(gotNames, record.RR().Name)
+		}
+		wantNames := []string{"_acme-challenge.www.example.com.", "_acme-challenge.www.sub.example.com.", "sub.example.com."}
+		if diff := cmp.Diff(gotNames, wantNames); diff != "" {
+			t.Errorf("diff: %s", diff)
+		}
+	})
+	t.Run("zone=routed", func(t *testing.T) {
+		for _, routeRecordsToZones := range []bool{true, false} {
+			t.Run(fmt.Sprintf("route=%v", routeRecordsToZones), func(t *testing.T) {
+				var got []string
+				fakeRecordSetsServer := getFakeRecordSetsServer()
+				createOrUpdate := fakeRecordSetsServer.CreateOrUpdate
+				fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+					got = append(got, relativeRecordSetName+" "+zoneName)
+					return createOrUpdate(ctx, resourceGroupName, zoneName, relativeRecordSetName, recordType, parameters, options)
+				}
+				provider := getFakeProviderWithServer(fakeRecordSetsServer)
+				provider.RouteRecordsToZones = routeRecordsToZones
+				records, err := provider.SetRecords(context.TODO(), "example.com.", []libdns.Record{
+					libdns.TXT{Name: "www", Text: "TEST VALUE", TTL: time.Minute},
+					libdns.TXT{Name: "www.sub.example.com.", Text: "TEST VALUE", TTL: time.Minute},
+					libdns.TXT{Name: "api.example.com.", Text: "TEST VALUE", TTL: time.Minute},
+				})
+				if err != nil {
+					t.Fatalf("%s", err)
+				}
+				want := []string{"www example.com", "api example.com", "www sub.example.com"}
+				wantNames := []string{"www", "api", "www.sub.example.com."}
+				if !routeRecordsToZones {
+					want = []string{"www example.com", "www.sub example.com", "api example.com"}
+					wantNames = []string{"www", "www.sub", "api"}
+				}
+				if diff := cmp.Diff(got, want); diff != "" {
+					t.Errorf("diff: %s", diff)
+				}
+				var gotNames []string
+				for _, record := range records {
+					gotNames = append(gotNames, record.RR().Name)
+				}
+				if diff := cmp.Diff(gotNames, wantNames); diff != "" {
+					t.Errorf("diff: %s", diff)
+				}
+			})
+		}
+	})
+	t.Run("zone=unknown", func(t *testing.T) {
+		var got []string
+		fakeRecordSetsServer := getFakeRecordSetsServer()
+		fakeRecordSetsServer.CreateOrUpdate = func(ctx context.Context, resourceGroupName string, zoneName string, relativeRecordSetName string, recordType armdns.RecordType, parameters armdns.RecordSet, options *armdns.RecordSetsClientCreateOrUpdateOptions) (resp azfake.Responder[armdns.RecordSetsClientCreateOrUpdateResponse], errResp azfake.ErrorResponder) {
+			got = append(got, relativeRecordSetName)
+			return
+		}
+		provider := getFakeProviderWithServer(fakeRecordSetsServer)
+		_, err := provider.AppendRecords(context.TODO(), "", []libdns.Record{
+			libdns.TXT{Name: "www.example.com.", Text: "TEST VALUE", TTL: time.Minute},
+			libdns.TXT{Name: "www.example.net.", Text: "TEST VALUE", TTL: time.Minute},
+		})
+		if err == nil || err.Error() != "the zone of the record www.example.net. TXT cannot be inferred" {
+			t.Errorf("got: %v", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("the records are written before the zones of all records are inferred: %v", got)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package azure
+
+import (
+	"context"
+	"time"
+)
+
+// ZoneVisibility is whether a zone is a public DNS zone, served on the internet, or a private DNS zone, served within
+// the virtual networks linked to it.
+type ZoneVisibility string
+
+const (
+	// ZoneVisibilityPublic is a public DNS zone of Azure DNS.
+	ZoneVisibilityPublic ZoneVisibility = "public"
+
+	// ZoneVisibilityPrivate is a private DNS zone of Azure Private DNS.
+	ZoneVisibilityPrivate ZoneVisibility = "private"
+)
+
+// cachedZoneVisibilities is the visibilities with which a zone exists with the time they were detected.
+type cachedZoneVisibilities struct {
+	visibilities []ZoneVisibility
+	cachedAt     time.Time
+}
+
+// ZoneVisibilities returns the visibilities with which the zone exists in the resource group of the provider:
+// ZoneVisibilityPublic if it is a public DNS zone, ZoneVisibilityPrivate if it is a private DNS zone, both if it is
+// both, or none if it exists with neither. The zone may be given by its resource ID, as for the other calls.
+func (p *Provider) ZoneVisibilities(ctx context.Context, zone string) ([]ZoneVisibility, error) {
+	provider, ctx, zone := p.forZoneLocation(ctx, zone)
+	if provider != p {
+		return provider.ZoneVisibilities(ctx, zone)
+	}
+
+	ctx, correlationID := ensureCorrelationID(ctx)
+
+	visibilities, err := p.getZoneVisibilities(ctx, zone)
+	if err != nil {
+		return nil, wrapCorrelationID(enrichAuthorizationError(err, p.generateZoneID(zone)), correlationID)
+	}
+
+	return visibilities, nil
+}
+
+// getZoneVisibilities looks up the zone as a public and as a private DNS zone in the resource group of the provider.
+func (p *Provider) getZoneVisibilities(ctx context.Context, zone string) ([]ZoneVisibility, error) {
+	privateProvider := p.forPrivateLocation(ZoneLocation{
+		SubscriptionId:    p.SubscriptionId,
+		ResourceGroupName: p.ResourceGroupName,
+	})
+
+	visibilities := []ZoneVisibility{}
+	for _, provider := range []*Provider{p, privateProvider} {
+		if _, err := provider.getZoneInfo(ctx, zone); err != nil {
+			if isNotFoundError(err) {
+				continue
+			}
+			return nil, err
+		}
+		if provider.private {
+			visibilities = append(visibilities, ZoneVisibilityPrivate)
+		} else {
+			visibilities = append(visibilities, ZoneVisibilityPublic)
+		}
+	}
+	return visibilities, nil
+}
+
+// getCachedZoneVisibilities returns the visibilities of the zone, detecting them if they are not cached or have expired.
+func (p *Provider) getCachedZoneVisibilities(ctx context.Context, zone string) ([]ZoneVisibility, error) {
+	if p.ZoneCacheTTL <= 0 {
+		return p.getZoneVisibilities(ctx, zone)
+	}
+
+	p.zoneCache.mutex.Lock()
+	defer p.zoneCache.mutex.Unlock()
+
+	name := normalizeZoneName(zone)
+	if cached, ok := p.zoneCache.visibilities[name]; ok && time.Since(cached.cachedAt) < p.ZoneCacheTTL {
+		return cached.visibilities, nil
+	}
+
+	visibilities, err := p.getZoneVisibilities(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	if p.zoneCache.visibilities == nil {
+		p.zoneCache.visibilities = map[string]cachedZoneVisibilities{}
+	}
+	p.zoneCache.visibilities[name] = cachedZoneVisibilities{
+		visibilities: visibilities,
+		cachedAt:     time.Now(),
+	}
+
+	return visibilities, nil
+}
+
+// forZoneVisibility returns p, or the provider for the private DNS zones in its location if the calls for the zone
+// are to be routed to the private zone: if the zone exists only as a private zone, or as both and Preferred Zone Visibility
+// is ZoneVisibilityPrivate. If the visibilities cannot be detected, the calls are made to the public zone, where they
+// fail if it does not exist.
+func (p *Provider) forZoneVisibility(ctx context.Context, zone string) *Provider {
+	visibilities, err := p.getCachedZoneVisibilities(ctx, zone)
+	if err != nil {
+		p.getLogger().Warn("failed to detect whether the zone is public or private; using the public zone", "zone", zone, "error", err)
+		return p
+	}
+	if chooseZoneVisibility(visibilities, p.PreferredZoneVisibility) != ZoneVisibilityPrivate {
+		return p
+	}
+	return p.forPrivateLocation(ZoneLocation{
+		SubscriptionId:    p.SubscriptionId,
+		ResourceGroupName: p.ResourceGroupName,
+	})
+}
+
+// chooseZoneVisibility returns the preferred visibility if the zone exists with it, or else the only visibility
+// with which it exists. The preferred visibility defaults to public, which is also chosen for a zone that exists with neither.
+func chooseZoneVisibility(visibilities []ZoneVisibility, preferred ZoneVisibility) ZoneVisibility {
+	if preferred == "" {
+		preferred = ZoneVisibilityPublic
+	}
+	for _, visibility := range visibilities {
+		if visibility == preferred {
+			return preferred
+		}
+	}
+	if len(visibilities) == 0 {
+		return ZoneVisibilityPublic
+	}
+	return visibilities[0]
+}
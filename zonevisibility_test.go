@@ -0,0 +1,114 @@
+package azure
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libdns/libdns"
+)
+
+func Test_ZoneVisibilities(t *testing.T) {
+	tests := []struct {
+		zone string
+		want []ZoneVisibility
+	}{
+		{zone: "example.com.", want: []ZoneVisibility{ZoneVisibilityPublic, ZoneVisibilityPrivate}},
+		{zone: "private.example.com.", want: []ZoneVisibility{ZoneVisibilityPrivate}},
+		{zone: "none.example.com.", want: []ZoneVisibility{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.zone, func(t *testing.T) {
+			provider, privateZones := getFakeProviderWithPrivateZones(t, newFakeZone(), "fake-resource-group-name")
+			privateZones.addZone("fake-resource-group-name", "private.example.com")
+			got, err := provider.ZoneVisibilities(context.TODO(), tt.zone)
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("diff: %s", diff)
+			}
+		})
+	}
+}
+
+func Test_chooseZoneVisibility(t *testing.T) {
+	tests := []struct {
+		name         string
+		visibilities []ZoneVisibility
+		preferred    ZoneVisibility
+		want         ZoneVisibility
+	}{
+		{name: "none", want: ZoneVisibilityPublic},
+		{name: "none,preferred=private", preferred: ZoneVisibilityPrivate, want: ZoneVisibilityPublic},
+		{name: "private", visibilities: []ZoneVisibility{ZoneVisibilityPrivate}, want: ZoneVisibilityPrivate},
+		{name: "both", visibilities: []ZoneVisibility{ZoneVisibilityPublic, ZoneVisibilityPrivate}, want: ZoneVisibilityPublic},
+		{name: "both,preferred=private", visibilities: []ZoneVisibility{ZoneVisibilityPublic, ZoneVisibilityPrivate}, preferred: ZoneVisibilityPrivate, want: ZoneVisibilityPrivate},
+		{name: "public,preferred=private", visibilities: []ZoneVisibility{ZoneVisibilityPublic}, preferred: ZoneVisibilityPrivate, want: ZoneVisibilityPublic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chooseZoneVisibility(tt.visibilities, tt.preferred); got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_DetectPrivateZones(t *testing.T) {
+	tests := []struct {
+		name      string
+		detect    bool
+		preferred ZoneVisibility
+		zone      string
+		// wantPrivate reports whether the record is written to the private zone instead of the public one
+		wantPrivate bool
+	}{
+		{name: "detect=false,private", zone: "private.example.com."},
+		{name: "detect=true,private", detect: true, zone: "private.example.com.", wantPrivate: true},
+		{name: "detect=true,both", detect: true, zone: "example.com."},
+		{name: "detect=true,both,preferred=private", detect: true, preferred: ZoneVisibilityPrivate, zone: "example.com.", wantPrivate: true},
+		{name: "detect=true,none", detect: true, zone: "none.example.com."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := newFakeZone()
+			provider, privateZones := getFakeProviderWithPrivateZones(t, zone, "fake-resource-group-name")
+			privateZones.addZone("fake-resource-group-name", "private.example.com")
+			provider.DetectPrivateZones = tt.detect
+			provider.PreferredZoneVisibility = tt.preferred
+			provider.ZoneCacheTTL = time.Minute
+
+			records, err := provider.AppendRecords(context.TODO(), tt.zone, []libdns.Record{
+				libdns.TXT{Name: "www", Text: "TOKEN", TTL: time.Minute},
+			})
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if len(records) != 1 {
+				t.Errorf("got: %v", records)
+			}
+			privateRecordSet := "/providers/Microsoft.Network/privateDnsZones/" + strings.TrimSuffix(tt.zone, ".") + "/TXT/www"
+			var gotPrivate bool
+			for path := range privateZones.recordSets {
+				gotPrivate = gotPrivate || strings.HasSuffix(path, privateRecordSet)
+			}
+			gotPublic := zone.get("www", "TXT") != nil
+			if gotPrivate != tt.wantPrivate || gotPublic == tt.wantPrivate {
+				t.Errorf("got private: %v, public: %v, want private: %v", gotPrivate, gotPublic, tt.wantPrivate)
+			}
+
+			if tt.wantPrivate {
+				got, err := provider.GetRecords(context.TODO(), tt.zone)
+				if err != nil {
+					t.Fatalf("%s", err)
+				}
+				if diff := cmp.Diff(got, []libdns.Record{libdns.TXT{Name: "www", Text: "TOKEN", TTL: time.Minute}}); diff != "" {
+					t.Errorf("diff: %s", diff)
+				}
+			}
+		})
+	}
+}